@@ -0,0 +1,344 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/checkpoint"
+	"github.com/google/pullsheet/pkg/leaderboard"
+	"github.com/google/pullsheet/pkg/output"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// backfillCmd represents the subcommand for `pullsheet backfill`
+var backfillCmd = &cobra.Command{
+	Use:           "backfill",
+	Short:         "Collect a long history in resumable slices, then export it",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBackfill(rootOpts)
+	},
+}
+
+type backfillOptions struct {
+	checkpointDir string
+	outputDir     string
+	sliceMonths   int
+	rateFloor     int
+}
+
+var backfillOpts = &backfillOptions{}
+
+func init() {
+	backfillCmd.Flags().StringVar(
+		&backfillOpts.checkpointDir,
+		"checkpoint-dir",
+		"",
+		"Directory to checkpoint completed slices in. Re-running with the same directory resumes.")
+
+	backfillCmd.Flags().StringVar(
+		&backfillOpts.outputDir,
+		"output-dir",
+		"",
+		"Directory to export the assembled results to, as with pullsheet export")
+
+	backfillCmd.Flags().IntVar(
+		&backfillOpts.sliceMonths,
+		"slice-months",
+		1,
+		"Length of each slice, in months")
+
+	backfillCmd.Flags().IntVar(
+		&backfillOpts.rateFloor,
+		"rate-floor",
+		500,
+		"Pause until the rate limit resets when fewer API requests than this remain")
+
+	rootCmd.AddCommand(backfillCmd)
+}
+
+// backfillQuery identifies what a checkpoint directory was created for
+type backfillQuery struct {
+	Repos       []string
+	Users       []string
+	Branches    []string
+	SliceMonths int
+}
+
+// slice is a portion of the requested window
+type slice struct {
+	since time.Time
+	until time.Time
+}
+
+func (s slice) name() string {
+	return fmt.Sprintf("slice-%s-%s.json", s.since.Format(dateForm), s.until.Format(dateForm))
+}
+
+// slices splits a window into consecutive periods of months, oldest first
+func slices(since time.Time, until time.Time, months int) []slice {
+	ss := []slice{}
+	for start := since; start.Before(until); start = start.AddDate(0, months, 0) {
+		end := start.AddDate(0, months, 0)
+		if end.After(until) {
+			end = until
+		}
+		ss = append(ss, slice{since: start, until: end})
+	}
+	return ss
+}
+
+func runBackfill(rootOpts *rootOptions) error {
+	if backfillOpts.checkpointDir == "" || backfillOpts.outputDir == "" {
+		return fmt.Errorf("--checkpoint-dir and --output-dir are required")
+	}
+	if backfillOpts.sliceMonths < 1 {
+		return fmt.Errorf("--slice-months must be at least 1")
+	}
+
+	if err := os.MkdirAll(backfillOpts.checkpointDir, 0o755); err != nil {
+		return err
+	}
+
+	if err := checkQuery(rootOpts); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := loadIdentities(ctx, c, rootOpts); err != nil {
+		return err
+	}
+
+	ss := slices(rootOpts.sinceParsed, rootOpts.untilParsed, backfillOpts.sliceMonths)
+	results := []leaderboard.Data{}
+
+	// For estimating how long the remaining slices will take
+	var spent time.Duration
+	var used, fetched int
+
+	for i, s := range ss {
+		path := filepath.Join(backfillOpts.checkpointDir, s.name())
+
+		d := leaderboard.Data{}
+		ok, err := checkpoint.Load(path, &d)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			logrus.Infof("slice %d/%d (%s) already complete", i+1, len(ss), s.name())
+			results = append(results, d)
+			continue
+		}
+
+		before, err := c.WaitForQuota(ctx, backfillOpts.rateFloor)
+		if err != nil {
+			return err
+		}
+
+		start := time.Now()
+		opts := *rootOpts
+		opts.sinceParsed = s.since
+		opts.untilParsed = s.until
+
+		d, err = leaderboardData(ctx, c, &opts, prsPlan(rootOpts))
+		if err != nil {
+			return errors.Wrapf(err, "slice %s", s.name())
+		}
+
+		if err := checkpoint.Save(path, d); err != nil {
+			return errors.Wrap(err, "checkpoint")
+		}
+		results = append(results, d)
+
+		after, err := c.Quota(ctx)
+		if err != nil {
+			return err
+		}
+
+		spent += time.Since(start)
+		fetched++
+		// A reset mid-slice makes the difference meaningless
+		if after.Reset.Equal(before.Reset) {
+			used += before.Remaining - after.Remaining
+		}
+
+		logrus.Infof("%d/%d slices complete, est. %s remaining at current quota", i+1, len(ss), estimate(len(ss)-i-1, fetched, spent, used, after).Round(time.Minute))
+	}
+
+	dir, err := output.Create(backfillOpts.outputDir)
+	if err != nil {
+		return err
+	}
+
+	dir.SetSources(prsPlan(rootOpts).Sources())
+	if err := writeExport(dir, rootOpts, mergeData(results)); err != nil {
+		logrus.Errorf("backfill export failed, partial output left in %s", dir.Staging())
+		return err
+	}
+
+	return dir.Commit()
+}
+
+// checkQuery ensures a checkpoint directory is only resumed with the query it was created for
+func checkQuery(rootOpts *rootOptions) error {
+	q := backfillQuery{
+		Repos:       rootOpts.repos,
+		Users:       rootOpts.users,
+		Branches:    rootOpts.branches,
+		SliceMonths: backfillOpts.sliceMonths,
+	}
+
+	path := filepath.Join(backfillOpts.checkpointDir, "query.json")
+	saved := backfillQuery{}
+	ok, err := checkpoint.Load(path, &saved)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return checkpoint.Save(path, q)
+	}
+
+	if !reflect.DeepEqual(saved, q) {
+		return fmt.Errorf("%s was created for a different query: %+v", backfillOpts.checkpointDir, saved)
+	}
+	return nil
+}
+
+// estimate returns how long the remaining slices should take, including waits for the rate limit to reset
+func estimate(remaining int, fetched int, spent time.Duration, used int, quota *github.Rate) time.Duration {
+	if fetched == 0 || remaining == 0 {
+		return 0
+	}
+
+	t := spent / time.Duration(fetched) * time.Duration(remaining)
+
+	needed := float64(used) / float64(fetched) * float64(remaining)
+	if short := needed - float64(quota.Remaining-backfillOpts.rateFloor); short > 0 && quota.Limit > 0 {
+		resets := math.Ceil(short / float64(quota.Limit))
+		t += time.Duration(resets) * time.Hour
+	}
+
+	return t
+}
+
+// mergeData combines slices, dropping items seen in an earlier slice
+func mergeData(ds []leaderboard.Data) leaderboard.Data {
+	m := leaderboard.Data{}
+	seen := map[string]bool{}
+	first := func(key string) bool {
+		if seen[key] {
+			return false
+		}
+		seen[key] = true
+		return true
+	}
+
+	for _, d := range ds {
+		for _, pr := range d.PRs {
+			if first("pr " + pr.URL) {
+				m.PRs = append(m.PRs, pr)
+			}
+		}
+		for _, r := range d.Reviews {
+			if first("review " + r.URL + " " + r.Reviewer) {
+				m.Reviews = append(m.Reviews, r)
+			}
+		}
+		for _, i := range d.Issues {
+			if first("issue " + i.URL) {
+				m.Issues = append(m.Issues, i)
+			}
+		}
+		// Comments are counted per slice, so each slice's counts are distinct contributions
+		m.Comments = append(m.Comments, d.Comments...)
+
+		if d.Triage != nil {
+			m.Triage = append(m.Triage, d.Triage...)
+		}
+		if d.Ownership != nil {
+			m.Ownership = mergeOwnership(m.Ownership, d.Ownership)
+		}
+	}
+
+	return m
+}
+
+// mergeOwnership sums CODEOWNERS coverage per rule. An owner is only idle if they were idle in every slice the rule applied to.
+func mergeOwnership(into []*repo.OwnershipSummary, from []*repo.OwnershipSummary) []*repo.OwnershipSummary {
+	key := func(o *repo.OwnershipSummary) string {
+		return fmt.Sprintf("%s:%d:%s", o.Project, o.Line, o.Pattern)
+	}
+
+	existing := map[string]*repo.OwnershipSummary{}
+	for _, o := range into {
+		existing[key(o)] = o
+	}
+
+	for _, o := range from {
+		e := existing[key(o)]
+		if e == nil {
+			c := *o
+			existing[key(o)] = &c
+			into = append(into, &c)
+			continue
+		}
+
+		if o.PRs == 0 {
+			continue
+		}
+
+		if e.PRs > 0 {
+			idle := map[string]bool{}
+			for _, u := range strings.Fields(o.IdleOwners) {
+				idle[u] = true
+			}
+			still := []string{}
+			for _, u := range strings.Fields(e.IdleOwners) {
+				if idle[u] {
+					still = append(still, u)
+				}
+			}
+			e.IdleOwners = strings.Join(still, " ")
+		} else {
+			e.IdleOwners = o.IdleOwners
+		}
+
+		e.PRs += o.PRs
+		e.OwnerReviewed += o.OwnerReviewed
+	}
+
+	return into
+}
@@ -0,0 +1,420 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/output"
+)
+
+// cacheCmd groups the subcommands which manage the cache of GitHub data
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of GitHub data",
+}
+
+// cachePurgeCmd represents the subcommand for `pullsheet cache purge`
+var cachePurgeCmd = &cobra.Command{
+	Use:           "purge",
+	Short:         "Remove cached GitHub data, so that it is fetched again",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCachePurge()
+	},
+}
+
+// cacheStatsCmd represents the subcommand for `pullsheet cache stats`
+var cacheStatsCmd = &cobra.Command{
+	Use:           "stats",
+	Short:         "Describe the size and age of the cache of GitHub data",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheStats()
+	},
+}
+
+// cacheExportCmd represents the subcommand for `pullsheet cache export`
+var cacheExportCmd = &cobra.Command{
+	Use:           "export",
+	Short:         "Write every cached entry to a portable archive",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheExport()
+	},
+}
+
+// cacheImportCmd represents the subcommand for `pullsheet cache import`
+var cacheImportCmd = &cobra.Command{
+	Use:           "import <archive>",
+	Short:         "Load the entries of an exported archive into the cache, keeping whichever copy is newer",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Args:          cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCacheImport(args[0])
+	},
+}
+
+type cacheExportOptions struct {
+	out string
+}
+
+var cacheExportOpts = &cacheExportOptions{}
+
+type cacheStatsOptions struct {
+	format string
+}
+
+var cacheStatsOpts = &cacheStatsOptions{}
+
+type cachePurgeOptions struct {
+	repos     []string
+	olderThan time.Duration
+	kinds     []string
+	all       bool
+	yes       bool
+}
+
+var cachePurgeOpts = &cachePurgeOptions{}
+
+func init() {
+	cachePurgeCmd.Flags().StringSliceVar(
+		&cachePurgeOpts.repos,
+		"repo",
+		[]string{},
+		"Only remove entries for these org/project repositories")
+
+	cachePurgeCmd.Flags().DurationVar(
+		&cachePurgeOpts.olderThan,
+		"older-than",
+		0,
+		"Only remove entries cached longer ago than this, such as 720h")
+
+	cachePurgeCmd.Flags().StringSliceVar(
+		&cachePurgeOpts.kinds,
+		"kind",
+		[]string{},
+		fmt.Sprintf("Only remove entries of these kinds: %s", strings.Join(cacheKinds(), ", ")))
+
+	cachePurgeCmd.Flags().BoolVar(
+		&cachePurgeOpts.all,
+		"all",
+		false,
+		"Remove every entry, after confirming")
+
+	cachePurgeCmd.Flags().BoolVar(
+		&cachePurgeOpts.yes,
+		"yes",
+		false,
+		"Don't ask for confirmation of --all")
+
+	cacheStatsCmd.Flags().StringVar(
+		&cacheStatsOpts.format,
+		"format",
+		"table",
+		"Output format: table or json")
+
+	cacheExportCmd.Flags().StringVar(
+		&cacheExportOpts.out,
+		"out",
+		"",
+		"File to write the gzipped tar archive to, ex: cache.tar.gz")
+
+	cacheCmd.AddCommand(cachePurgeCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheExportCmd)
+	cacheCmd.AddCommand(cacheImportCmd)
+	rootCmd.AddCommand(cacheCmd)
+}
+
+// cacheKinds returns the kinds of cache entry, sorted
+func cacheKinds() []string {
+	ks := []string{}
+	for k := range ghcache.Kinds {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func runCachePurge() error {
+	selected := len(cachePurgeOpts.repos) > 0 || cachePurgeOpts.olderThan > 0 || len(cachePurgeOpts.kinds) > 0
+	if cachePurgeOpts.all && selected {
+		return fmt.Errorf("--all can't be used with --repo, --older-than, or --kind")
+	}
+	if !cachePurgeOpts.all && !selected {
+		return fmt.Errorf("choose what to purge with --repo, --older-than, or --kind, or purge everything with --all")
+	}
+
+	for _, k := range cachePurgeOpts.kinds {
+		if _, ok := ghcache.Kinds[k]; !ok {
+			return fmt.Errorf("unknown --kind %q, choose from: %s", k, strings.Join(cacheKinds(), ", "))
+		}
+	}
+	for _, r := range cachePurgeOpts.repos {
+		if !strings.Contains(r, "/") || strings.Count(r, "/") > 1 {
+			return fmt.Errorf("--repo %q should be org/project", r)
+		}
+	}
+
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	es, err := c.Entries()
+	if err != nil {
+		return errors.Wrap(err, "cache entries")
+	}
+
+	purge := []string{}
+	for _, e := range es {
+		if cachePurgeOpts.all || purgeSelected(e, time.Now()) {
+			purge = append(purge, e.Key)
+		}
+	}
+
+	if cachePurgeOpts.all && len(purge) > 0 && !cachePurgeOpts.yes {
+		fmt.Printf("Remove all %d entries from the cache in %s? [y/N] ", len(purge), c)
+		answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+		if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+			return fmt.Errorf("purge cancelled")
+		}
+	}
+
+	if err := c.Purge(purge); err != nil {
+		return errors.Wrap(err, "purge")
+	}
+	for _, k := range purge {
+		logrus.Debugf("removed %s", k)
+	}
+
+	logrus.Infof("removed %d of %d entries from %s", len(purge), len(es), c)
+	return nil
+}
+
+// purgeSelected returns whether an entry matches every selector given
+func purgeSelected(e cache.Entry, now time.Time) bool {
+	if cachePurgeOpts.olderThan > 0 && !e.Created.Before(now.Add(-cachePurgeOpts.olderThan)) {
+		return false
+	}
+
+	if len(cachePurgeOpts.kinds) > 0 {
+		found := false
+		for _, k := range cachePurgeOpts.kinds {
+			found = found || ghcache.KeyKind(e.Key) == k
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(cachePurgeOpts.repos) > 0 {
+		found := false
+		for _, r := range cachePurgeOpts.repos {
+			parts := strings.Split(r, "/")
+			found = found || ghcache.KeyRepo(e.Key, parts[0], parts[1])
+		}
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// cacheStats is the output of `pullsheet cache stats`
+type cacheStats struct {
+	// Cache names the cache, such as the directory or file it is kept in
+	Cache string `json:"cache"`
+	ghcache.Summary
+	// Process counts the lookups made by this process, which only happen when stats are collected alongside other work
+	Process ghcache.CounterSnapshot `json:"process"`
+}
+
+func runCacheStats() error {
+	if cacheStatsOpts.format != "table" && cacheStatsOpts.format != "json" {
+		return fmt.Errorf("unknown format %q, choose table or json", cacheStatsOpts.format)
+	}
+
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	es, err := c.Entries()
+	if err != nil {
+		return errors.Wrap(err, "cache entries")
+	}
+
+	st := cacheStats{
+		Cache:   c.String(),
+		Summary: ghcache.Summarize(es, time.Now()),
+		Process: ghcache.RunCounters.Snapshot(),
+	}
+
+	if cacheStatsOpts.format == "json" {
+		b, err := json.MarshalIndent(st, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+
+	return writeCacheStats(os.Stdout, st)
+}
+
+// writeCacheStats renders cache stats as plain text tables
+func writeCacheStats(w io.Writer, st cacheStats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Cache in %s\n", st.Cache)
+
+	sections := []struct {
+		title  string
+		groups []ghcache.Group
+	}{
+		{"Kind", st.Prefixes},
+		{"Repository", st.Repos},
+		{"Age", st.Ages},
+		{"", []ghcache.Group{st.Total}},
+	}
+
+	for _, s := range sections {
+		fmt.Fprintln(tw)
+		if s.title != "" {
+			fmt.Fprintf(tw, "%s\tEntries\tSize\tOldest\tNewest\n", s.title)
+		}
+		for _, g := range s.groups {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s\t%s\n", g.Name, g.Entries, humanBytes(g.Bytes), cacheTime(g.Oldest), cacheTime(g.Newest))
+		}
+	}
+
+	if hits, misses := st.Process.Totals(); hits+misses > 0 {
+		fmt.Fprintf(tw, "\nThis run\tHits\tMisses\n")
+		prefixes := map[string]bool{}
+		for p := range st.Process.Hits {
+			prefixes[p] = true
+		}
+		for p := range st.Process.Misses {
+			prefixes[p] = true
+		}
+		names := []string{}
+		for p := range prefixes {
+			names = append(names, p)
+		}
+		sort.Strings(names)
+		for _, p := range names {
+			fmt.Fprintf(tw, "%s\t%d\t%d\n", p, st.Process.Hits[p], st.Process.Misses[p])
+		}
+	}
+
+	return tw.Flush()
+}
+
+// cacheTime formats when an entry was cached, or "-" if there were none
+func cacheTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format("2006-01-02 15:04")
+}
+
+// humanBytes abbreviates a size in bytes, ex: 12.3 MB
+func humanBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
+
+// openCache returns the cache selected by the root flags
+func openCache() (cache.Cacher, error) {
+	return client.NewCache(client.Config{PersistBackend: rootOpts.cacheBackend, CacheAddr: rootOpts.cacheAddr})
+}
+
+func runCacheExport() error {
+	if cacheExportOpts.out == "" {
+		return fmt.Errorf("--out is required")
+	}
+
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	f, err := output.CreateFile(cacheExportOpts.out)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := cache.Export(f, c)
+	if err != nil {
+		return errors.Wrap(err, "export")
+	}
+	if err := f.Commit(); err != nil {
+		return err
+	}
+
+	logrus.Infof("exported %d entries from %s to %s", n, c, cacheExportOpts.out)
+	return nil
+}
+
+func runCacheImport(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	c, err := openCache()
+	if err != nil {
+		return err
+	}
+
+	loaded, skipped, err := cache.Import(f, c)
+	if err != nil {
+		return errors.Wrapf(err, "import %s", path)
+	}
+
+	logrus.Infof("imported %d entries into %s, skipping %d already cached more recently", loaded, c, skipped)
+	return nil
+}
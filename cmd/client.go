@@ -0,0 +1,211 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// teamCacheAge is how long team memberships are cached
+const teamCacheAge = 24 * time.Hour
+
+// newClient returns a GitHub client, and resolves the root options which need one to interpret
+func newClient(ctx context.Context, rootOpts *rootOptions) (*client.Client, error) {
+	c, err := client.New(ctx, client.Config{
+		GitHubTokenPath:  rootOpts.tokenPath,
+		BaseURL:          rootOpts.githubURL,
+		UploadURL:        rootOpts.githubUpload,
+		PersistBackend:   rootOpts.cacheBackend,
+		CacheAddr:        rootOpts.cacheAddr,
+		MinRateRemaining: rootOpts.minRate,
+		MaxRetries:       &rootOpts.maxRetries,
+		CallTimeout:      rootOpts.callTimeout,
+		Proxy:            rootOpts.proxy,
+		CABundle:         rootOpts.caBundle,
+		DialTimeout:      rootOpts.dialTimeout,
+		ResponseTimeout:  rootOpts.respTimeout,
+		LogRequests:      rootOpts.logRequests,
+		CacheTTL:         rootOpts.cacheTTL,
+		NoCache:          rootOpts.noCache,
+		Offline:          rootOpts.offline,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Checked before org/* is expanded, which would fail confusingly with bad credentials
+	if rootOpts.verifyAuth {
+		if err := verifyAuth(ctx, c, rootOpts.repos); err != nil {
+			return nil, err
+		}
+	}
+
+	listed := rootOpts.repos
+	filter := repo.RepoFilter{
+		IncludeArchived: rootOpts.includeArchived,
+		Topics:          rootOpts.repoTopics,
+		Languages:       rootOpts.repoLanguages,
+		Visibility:      rootOpts.repoVisibility,
+	}
+	rootOpts.repos, err = expandOrgs(ctx, c, rootOpts.repos, filter)
+	if err != nil {
+		return nil, err
+	}
+	rootOpts.repos = excludeRepos(rootOpts.repos, listed, rootOpts.excludeRepos)
+
+	// initCommand credited the users named outright to their aliases, but team members are only known now
+	rootOpts.users, err = expandTeams(ctx, c, rootOpts.users, rootOpts.repoOpts.Canonical)
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// expandOrgs replaces org/* entries in a list of repositories with every active repository in the organization which passes the filter
+func expandOrgs(ctx context.Context, c *client.Client, repos []string, filter repo.RepoFilter) ([]string, error) {
+	expanded := []string{}
+	seen := map[string]bool{}
+	add := func(r string) {
+		if !seen[strings.ToLower(r)] {
+			seen[strings.ToLower(r)] = true
+			expanded = append(expanded, r)
+		}
+	}
+
+	for _, r := range repos {
+		if !strings.HasSuffix(r, "/*") {
+			add(r)
+			continue
+		}
+
+		org := strings.TrimSuffix(r, "/*")
+		names, err := repo.ListRepoNames(ctx, c, org, filter)
+		if err != nil {
+			return nil, err
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%s has no active repositories matching the --repo-* filters", r)
+		}
+
+		logrus.Infof("%s expanded to %d repositories: %s", r, len(names), strings.Join(names, ", "))
+		for _, n := range names {
+			add(n)
+		}
+	}
+
+	return expanded, nil
+}
+
+// excludeRepos returns repos without those matching the exclusion patterns. Excluding a repository which was
+// listed by name, rather than by org/*, is likely a mistake, so it is warned about.
+func excludeRepos(repos []string, listed []string, patterns []string) []string {
+	if len(patterns) == 0 {
+		return repos
+	}
+
+	named := map[string]bool{}
+	for _, r := range listed {
+		named[strings.ToLower(r)] = true
+	}
+
+	kept := []string{}
+	for _, r := range repos {
+		if !repo.RepoExcluded(r, patterns) {
+			kept = append(kept, r)
+			continue
+		}
+
+		if named[strings.ToLower(r)] {
+			logrus.Warningf("%s was listed in --repos, but is excluded by --exclude-repos", r)
+		} else {
+			logrus.Infof("excluding %s", r)
+		}
+	}
+	return kept
+}
+
+// expandTeams replaces @org/team-slug entries in a list of users with the team's members, so that everything
+// downstream sees plain logins. Each member is replaced with the login canonical credits their activity to.
+func expandTeams(ctx context.Context, c *client.Client, users []string, canonical func(string) string) ([]string, error) {
+	expanded := []string{}
+	seen := map[string]bool{}
+	add := func(login string) {
+		if !seen[strings.ToLower(login)] {
+			seen[strings.ToLower(login)] = true
+			expanded = append(expanded, login)
+		}
+	}
+
+	for _, u := range users {
+		if !strings.HasPrefix(u, "@") {
+			add(u)
+			continue
+		}
+
+		parts := strings.Split(strings.TrimPrefix(u, "@"), "/")
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("%q is not a team: use @org/team-slug, with the slug of the team itself rather than a path to it", u)
+		}
+
+		members, err := ghcache.TeamMembersBySlug(ctx, c.Cache, c.GitHubClient, time.Now().Add(-teamCacheAge), parts[0], parts[1])
+		if errors.Is(err, ghcache.ErrNotFound) {
+			return nil, fmt.Errorf("team %s does not exist, or the token cannot see it", u)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("team %s: %v", u, err)
+		}
+		if len(members) == 0 {
+			return nil, fmt.Errorf("team %s has no members", u)
+		}
+
+		logins := []string{}
+		for _, m := range members {
+			add(canonical(m.GetLogin()))
+			logins = append(logins, m.GetLogin())
+		}
+		logrus.Infof("%s expanded to %d users: %s", u, len(logins), strings.Join(logins, ", "))
+	}
+
+	return expanded, nil
+}
+
+// verifyAuth checks the client's credentials, and that they can see the repositories named outright
+func verifyAuth(ctx context.Context, c *client.Client, repos []string) error {
+	named := []string{}
+	for _, r := range repos {
+		if strings.HasSuffix(r, "/*") {
+			continue
+		}
+		org, project := repo.ParseURL(r)
+		named = append(named, org+"/"+project)
+	}
+
+	a, err := c.VerifyAuth(ctx, named)
+	if a != nil {
+		logrus.Infof("GitHub token %s", a)
+	}
+	return err
+}
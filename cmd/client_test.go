@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+func TestExpandTeamsCanonical(t *testing.T) {
+	p, err := cache.New(cache.Config{Backend: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	members := `[{"login": "alice-old"}, {"login": "carol"}, {"login": "Bob"}]`
+	if err := p.SetValue(ghcache.TeamMembersPrefix+"-org-team", &cache.Value{Data: []byte(members)}); err != nil {
+		t.Fatal(err)
+	}
+
+	ro := &repo.Options{Aliases: map[string]string{}}
+	if err := ro.AddAlias("alice-old=alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A nil GitHub client fails the test with a panic if the cache is missed
+	c := &client.Client{Cache: p}
+	got, err := expandTeams(context.Background(), c, []string{"alice", "bob", "@org/team"}, ro.Canonical)
+	if err != nil {
+		t.Fatalf("expandTeams() returned error: %v", err)
+	}
+	if want := []string{"alice", "bob", "carol"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("expandTeams() = %v, want %v", got, want)
+	}
+}
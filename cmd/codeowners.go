@@ -0,0 +1,63 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/summary"
+)
+
+// codeownersCmd represents the subcommand for `pullsheet codeowners`
+var codeownersCmd = &cobra.Command{
+	Use:           "codeowners",
+	Short:         "Generate data around CODEOWNERS review coverage",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCodeowners(rootOpts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(codeownersCmd)
+}
+
+func runCodeowners(rootOpts *rootOptions) error {
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	prs, err := summary.Pulls(ctx, c, rootOpts.repoOpts, rootOpts.repos, nil, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, rootOpts.sinceParsed, rootOpts.untilParsed)
+	if err != nil {
+		return err
+	}
+
+	reviews, err := summary.Reviews(ctx, c, rootOpts.repoOpts, rootOpts.repos, nil, rootOpts.sinceParsed, rootOpts.untilParsed)
+	if err != nil {
+		return err
+	}
+
+	data, err := summary.Ownership(ctx, c, rootOpts.repos, rootOpts.sinceParsed, prs, reviews)
+	if err != nil {
+		return err
+	}
+
+	return deliver(ctx, rootOpts, "codeowners", &data)
+}
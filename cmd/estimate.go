@@ -0,0 +1,103 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// prsOpts are the options of `pullsheet prs`
+var prsOpts struct {
+	dryRun bool
+}
+
+// estimatePRs lists each repository's PRs and prints the requests fetching the rest would take, without writing
+// any output
+func estimatePRs(ctx context.Context, c *client.Client, rootOpts *rootOptions) error {
+	plan := prsPlan(rootOpts)
+
+	es := []repo.Estimate{}
+	for _, r := range rootOpts.repos {
+		org, project := repo.ParseURL(r)
+		e, err := repo.EstimatePulls(ctx, c, rootOpts.repoOpts, org, project, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, plan.Files, plan.Reviews)
+		if err != nil {
+			return fmt.Errorf("estimate %s: %w", r, err)
+		}
+		es = append(es, e)
+	}
+
+	// Listing was done for real, so is already reflected in what remains
+	remaining := -1
+	rate, err := c.Quota(ctx)
+	if err != nil {
+		logrus.Warningf("unable to get the rate limit: %v", err)
+	} else {
+		remaining = rate.Remaining
+	}
+
+	return writeEstimates(os.Stdout, es, remaining)
+}
+
+// writeEstimates renders estimates as a plain text table, followed by how they compare to the remaining quota
+func writeEstimates(w io.Writer, es []repo.Estimate, remaining int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Repository\tPRs\tDetails\tFiles\tReviews\tCached\tRequests\n")
+
+	total := repo.Estimate{Repo: "Total"}
+	row := func(e repo.Estimate) {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%s\t%d\n", e.Repo, e.PRs, e.DetailCalls, e.FileCalls, e.ReviewCalls, hitRate(e), e.Requests())
+	}
+	for _, e := range es {
+		row(e)
+		total.PRs += e.PRs
+		total.DetailCalls += e.DetailCalls
+		total.FileCalls += e.FileCalls
+		total.ReviewCalls += e.ReviewCalls
+		total.Lookups += e.Lookups
+		total.Hits += e.Hits
+	}
+	fmt.Fprintln(tw)
+	row(total)
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if remaining < 0 {
+		return nil
+	}
+	fmt.Fprintf(w, "\nAbout %d requests needed, %d remaining in the current rate limit window\n", total.Requests(), remaining)
+	if total.Requests() > remaining {
+		fmt.Fprintf(w, "That is more than remain, so the run would wait for the rate limit to reset\n")
+	}
+	return nil
+}
+
+// hitRate renders the share of an estimate's cache lookups which were hits
+func hitRate(e repo.Estimate) string {
+	if e.Lookups == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d%%", e.Hits*100/e.Lookups)
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/leaderboard"
+	"github.com/google/pullsheet/pkg/output"
+	"github.com/google/pullsheet/pkg/site"
+)
+
+// exportSiteCmd represents the subcommand for `pullsheet export-site`
+var exportSiteCmd = &cobra.Command{
+	Use:           "export-site",
+	Short:         "Write a self-contained static site of everything the server would show",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExportSite(rootOpts)
+	},
+}
+
+var exportSiteDir string
+
+func init() {
+	exportSiteCmd.Flags().StringVar(
+		&exportSiteDir,
+		"output-dir",
+		"",
+		"Directory to write the site to. Any previous contents are replaced once every page has been written.")
+
+	rootCmd.AddCommand(exportSiteCmd)
+}
+
+func runExportSite(rootOpts *rootOptions) error {
+	if exportSiteDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := loadIdentities(ctx, c, rootOpts); err != nil {
+		return err
+	}
+
+	data, err := leaderboardData(ctx, c, rootOpts, prsPlan(rootOpts))
+	if err != nil {
+		return err
+	}
+
+	dir, err := output.Create(exportSiteDir)
+	if err != nil {
+		return err
+	}
+
+	dir.SetSources(prsPlan(rootOpts).Sources())
+
+	if err := writeSite(dir, rootOpts, data); err != nil {
+		logrus.Errorf("export-site failed, partial output left in %s", dir.Staging())
+		return err
+	}
+
+	return dir.Commit()
+}
+
+// writeSite writes the static pages and downloads, refusing to finish if any page links to a missing file
+func writeSite(dir *output.Dir, rootOpts *rootOptions, d leaderboard.Data) error {
+	title := leaderboardTitle(rootOpts)
+	links := []site.Link{}
+
+	// Leaderboard entries link to the activity pages written beside it
+	rootOpts.boardOpts.UserLinks = leaderboard.UserPage
+	html, err := rootOpts.boardOpts.RenderStatic(title, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, d)
+	if err != nil {
+		return errors.Wrap(err, "leaderboard")
+	}
+	if err := dir.Write("leaderboard.html", []byte(html)); err != nil {
+		return err
+	}
+
+	pages, err := userPages(rootOpts, d)
+	if err != nil {
+		return err
+	}
+	for name, page := range pages {
+		if err := dir.Write(name, []byte(page)); err != nil {
+			return err
+		}
+	}
+	links = append(links, site.Link{Href: "leaderboard.html", Description: "Leaderboard"})
+
+	if err := writeCSVs(dir, d); err != nil {
+		return err
+	}
+	for name := range exportCSVs(&d) {
+		links = append(links, site.Link{Href: name, Description: strings.TrimSuffix(name, ".csv") + " (CSV)"})
+	}
+
+	js, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "data")
+	}
+	if err := dir.Write("data.json", js); err != nil {
+		return err
+	}
+	links = append(links, site.Link{Href: "data.json", Description: "All collected data (JSON)"})
+
+	if err := writeWarnings(dir); err != nil {
+		return err
+	}
+	links = append(links, site.Link{Href: "warnings.csv", Description: "Items skipped or adjusted while collecting data (CSV)"})
+
+	index, err := site.Index(title, rootOpts.sinceParsed.Format(dateForm), rootOpts.untilParsed.Format(dateForm), links)
+	if err != nil {
+		return errors.Wrap(err, "index")
+	}
+	if err := dir.Write(site.IndexName, []byte(index)); err != nil {
+		return err
+	}
+
+	broken, err := site.BrokenLinks(dir.Staging())
+	if err != nil {
+		return errors.Wrap(err, "checking links")
+	}
+	if len(broken) > 0 {
+		return fmt.Errorf("broken links:\n%s", strings.Join(broken, "\n"))
+	}
+
+	return nil
+}
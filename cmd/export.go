@@ -0,0 +1,224 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/leaderboard"
+	"github.com/google/pullsheet/pkg/output"
+	"github.com/google/pullsheet/pkg/sheet"
+)
+
+// exportCmd represents the subcommand for `pullsheet export`
+var exportCmd = &cobra.Command{
+	Use:           "export",
+	Short:         "Write every CSV and the leaderboard to a directory",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExport(rootOpts)
+	},
+}
+
+// verifyOutputCmd represents the subcommand for `pullsheet verify-output`
+var verifyOutputCmd = &cobra.Command{
+	Use:           "verify-output <dir>",
+	Short:         "Verify an export directory is complete and matches its manifest",
+	Args:          cobra.ExactArgs(1),
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runVerifyOutput(args[0])
+	},
+}
+
+type exportOptions struct {
+	outputDir string
+}
+
+var exportOpts = &exportOptions{}
+
+func init() {
+	exportCmd.Flags().StringVar(
+		&exportOpts.outputDir,
+		"output-dir",
+		"",
+		"Directory to write artifacts to. It is replaced only once every artifact has been written.")
+
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(verifyOutputCmd)
+}
+
+func runExport(rootOpts *rootOptions) error {
+	if exportOpts.outputDir == "" {
+		return fmt.Errorf("--output-dir is required")
+	}
+
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	if err := loadIdentities(ctx, c, rootOpts); err != nil {
+		return err
+	}
+
+	// prs.csv includes every column
+	data, err := leaderboardData(ctx, c, rootOpts, prsPlan(rootOpts))
+	if err != nil {
+		return err
+	}
+
+	dir, err := output.Create(exportOpts.outputDir)
+	if err != nil {
+		return err
+	}
+
+	dir.SetSources(prsPlan(rootOpts).Sources())
+
+	if err := writeExport(dir, rootOpts, data); err != nil {
+		logrus.Errorf("export failed, partial output left in %s", dir.Staging())
+		return err
+	}
+
+	return dir.Commit()
+}
+
+// writeExport writes each artifact into an output directory
+func writeExport(dir *output.Dir, rootOpts *rootOptions, d leaderboard.Data) error {
+	if err := writeCSVs(dir, d); err != nil {
+		return err
+	}
+
+	if err := writeWorkbook(dir, d); err != nil {
+		return err
+	}
+
+	// Leaderboard entries link to the activity pages written beside it
+	rootOpts.boardOpts.UserLinks = leaderboard.UserPage
+	html, err := rootOpts.boardOpts.Render(leaderboardTitle(rootOpts), rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, d)
+	if err != nil {
+		return errors.Wrap(err, "leaderboard")
+	}
+
+	if err := dir.Write("leaderboard.html", []byte(html)); err != nil {
+		return err
+	}
+
+	pages, err := userPages(rootOpts, d)
+	if err != nil {
+		return err
+	}
+	for name, page := range pages {
+		if err := dir.Write(name, []byte(page)); err != nil {
+			return err
+		}
+	}
+
+	return writeWarnings(dir)
+}
+
+// exportCSVs returns the CSV artifacts for the collected data, by file name
+func exportCSVs(d *leaderboard.Data) map[string]interface{} {
+	csvs := map[string]interface{}{
+		"prs.csv":            &d.PRs,
+		"reviews.csv":        &d.Reviews,
+		"issues.csv":         &d.Issues,
+		"issue-comments.csv": &d.Comments,
+	}
+	if d.Triage != nil {
+		csvs["triage.csv"] = &d.Triage
+	}
+	if d.Ownership != nil {
+		csvs["codeowners.csv"] = &d.Ownership
+	}
+	return csvs
+}
+
+// writeCSVs writes a CSV artifact for each kind of collected data
+func writeCSVs(dir *output.Dir, d leaderboard.Data) error {
+	for name, v := range exportCSVs(&d) {
+		out, err := gocsv.MarshalString(v)
+		if err != nil {
+			return errors.Wrap(err, name)
+		}
+		if err := dir.Write(name, []byte(out)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeWorkbook writes a single XLSX workbook with a sheet for each CSV
+func writeWorkbook(dir *output.Dir, d leaderboard.Data) error {
+	csvs := exportCSVs(&d)
+	names := []string{}
+	for name := range csvs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sheets := []sheet.Sheet{}
+	for _, name := range names {
+		sheets = append(sheets, sheet.Sheet{Name: strings.TrimSuffix(name, ".csv"), Rows: csvs[name]})
+	}
+
+	var b bytes.Buffer
+	if err := sheet.WriteXLSX(&b, sheets); err != nil {
+		return errors.Wrap(err, "workbook")
+	}
+	return dir.Write("pullsheet.xlsx", b.Bytes())
+}
+
+// writeWarnings writes every item skipped or adjusted along the way. It should be written last so that nothing is missed.
+func writeWarnings(dir *output.Dir) error {
+	warnings := digest.Default.Entries()
+	out, err := gocsv.MarshalString(&warnings)
+	if err != nil {
+		return errors.Wrap(err, "warnings")
+	}
+
+	return dir.Write("warnings.csv", []byte(out))
+}
+
+func runVerifyOutput(path string) error {
+	problems, err := output.Verify(path)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("%s failed verification with %d problems", path, len(problems))
+	}
+
+	logrus.Infof("%s is complete", path)
+	return nil
+}
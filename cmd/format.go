@@ -0,0 +1,350 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/gocarina/gocsv"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/output"
+	"github.com/google/pullsheet/pkg/repo"
+	"github.com/google/pullsheet/pkg/sheet"
+	"github.com/google/pullsheet/pkg/sqlite"
+)
+
+// formats are the output formats of the data commands
+var formats = []string{"csv", "json", "ndjson", "markdown", "xlsx"}
+
+// validFormat returns an error if a format is unknown, or can't be written to the terminal
+func validFormat(format string, out string) error {
+	if format == "xlsx" && out == "" {
+		return fmt.Errorf("--format=xlsx requires --out")
+	}
+
+	for _, f := range formats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown format %q, choose from: %s", format, strings.Join(formats, ", "))
+}
+
+// outputFile is where output is written. A file takes effect only once committed, so a failed run leaves the previous
+// one in place.
+type outputFile interface {
+	io.Writer
+	// Commit finishes writing the output
+	Commit() error
+	// Close discards the output unless it was committed
+	Close() error
+}
+
+// outputWriter returns where output should be written: the --out file if set, otherwise stdout
+func outputWriter(rootOpts *rootOptions) (outputFile, error) {
+	if rootOpts.out == "" {
+		return stdout{os.Stdout}, nil
+	}
+	return output.CreateFile(rootOpts.out)
+}
+
+type stdout struct {
+	io.Writer
+}
+
+func (stdout) Commit() error { return nil }
+func (stdout) Close() error  { return nil }
+
+// writeOutputTo passes the output to write, committing what it wrote unless it fails. Rows written before an
+// interruption are complete, so are kept.
+func writeOutputTo(rootOpts *rootOptions, write func(io.Writer) error) error {
+	w, err := outputWriter(rootOpts)
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+
+	err = write(w)
+	if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+		return err
+	}
+	if cerr := w.Commit(); cerr != nil {
+		return cerr
+	}
+	return err
+}
+
+// deliver sends a command's results wherever the output flags ask: a SQLite database, a Google Sheet, or a file or stdout
+func deliver(ctx context.Context, rootOpts *rootOptions, name string, v interface{}) error {
+	if rootOpts.sqlite != "" {
+		if _, ok := sqlite.Tables[name]; !ok {
+			return fmt.Errorf("%s results can't be written to SQLite", name)
+		}
+		return sqlite.Write(rootOpts.sqlite, name, v)
+	}
+
+	if rootOpts.googleSheet != "" {
+		v, err := selectFields(v, rootOpts.fields)
+		if err != nil {
+			return err
+		}
+
+		g, err := sheet.NewGoogle(ctx, rootOpts.googleCreds)
+		if err != nil {
+			return errors.Wrap(err, "google credentials")
+		}
+		return g.Write(ctx, rootOpts.googleSheet, name, v, rootOpts.appendSheet)
+	}
+
+	out, err := marshal(rootOpts, v)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("%d bytes of %s output", len(out), name)
+	return writeOutput(rootOpts, out)
+}
+
+// writeOutput writes rendered output to stdout, or to the --out file if set
+func writeOutput(rootOpts *rootOptions, out string) error {
+	return writeOutputTo(rootOpts, func(w io.Writer) error {
+		_, err := io.WriteString(w, out)
+		return err
+	})
+}
+
+// marshal renders a pointer to a slice of summaries in the requested output format
+func marshal(rootOpts *rootOptions, v interface{}) (string, error) {
+	// Markdown has its own default columns, so picks fields itself
+	if rootOpts.format == "markdown" {
+		return markdown(v, rootOpts.fields)
+	}
+
+	name := strings.TrimSuffix(reflect.TypeOf(v).Elem().Elem().Elem().Name(), "Summary")
+	v, err := selectFields(v, rootOpts.fields)
+	if err != nil {
+		return "", err
+	}
+
+	switch rootOpts.format {
+	case "json":
+		// Scripts can rely on an array, even when nothing was found
+		if rv := reflect.ValueOf(v).Elem(); rv.Kind() == reflect.Slice && rv.Len() == 0 {
+			return "[]\n", nil
+		}
+
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		return string(b) + "\n", nil
+	case "ndjson":
+		var sb strings.Builder
+		enc := json.NewEncoder(&sb)
+		rv := reflect.ValueOf(v).Elem()
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return "", err
+			}
+		}
+		return sb.String(), nil
+	case "xlsx":
+		var b bytes.Buffer
+		if err := sheet.WriteXLSX(&b, []sheet.Sheet{{Name: name, Rows: v}}); err != nil {
+			return "", err
+		}
+		return b.String(), nil
+	default:
+		return gocsv.MarshalString(v)
+	}
+}
+
+// fieldSet is a subset of the fields of a summary type, in the order they should be output
+type fieldSet struct {
+	// t is a struct type holding just the selected fields, with their original tags
+	t       reflect.Type
+	indexes []int
+}
+
+// newFieldSet returns the named fields of a summary struct type, or an error listing the valid names
+func newFieldSet(t reflect.Type, names []string) (*fieldSet, error) {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	byName := map[string]int{}
+	valid := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+		byName[t.Field(i).Name] = i
+		valid = append(valid, t.Field(i).Name)
+	}
+
+	fs := &fieldSet{}
+	sfs := []reflect.StructField{}
+	seen := map[string]bool{}
+	for _, n := range names {
+		i, ok := byName[n]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q, choose from: %s", n, strings.Join(valid, ", "))
+		}
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+
+		f := t.Field(i)
+		sfs = append(sfs, reflect.StructField{Name: f.Name, Type: f.Type, Tag: f.Tag})
+		fs.indexes = append(fs.indexes, i)
+	}
+
+	fs.t = reflect.StructOf(sfs)
+	return fs, nil
+}
+
+// project returns a copy of a summary, or a pointer to one, holding only the selected fields
+func (fs *fieldSet) project(v interface{}) interface{} {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	out := reflect.New(fs.t).Elem()
+	for i, idx := range fs.indexes {
+		out.Field(i).Set(rv.Field(idx))
+	}
+	return out.Interface()
+}
+
+// selectFields returns a pointer to a slice of summaries narrowed to the named fields, or v itself if none are named
+func selectFields(v interface{}, names []string) (interface{}, error) {
+	if len(names) == 0 {
+		return v, nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	fs, err := newFieldSet(rv.Type().Elem(), names)
+	if err != nil {
+		return nil, err
+	}
+
+	out := reflect.MakeSlice(reflect.SliceOf(fs.t), 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		if rv.Index(i).Kind() == reflect.Ptr && rv.Index(i).IsNil() {
+			continue
+		}
+		out = reflect.Append(out, reflect.ValueOf(fs.project(rv.Index(i).Interface())))
+	}
+
+	p := reflect.New(out.Type())
+	p.Elem().Set(out)
+	return p.Interface(), nil
+}
+
+// ndjsonEncoder returns a function writing a summary of the same type as sample to w as a line of JSON,
+// narrowed to the named fields if any are
+func ndjsonEncoder(w io.Writer, sample interface{}, names []string) (func(interface{}) error, error) {
+	enc := json.NewEncoder(w)
+	if len(names) == 0 {
+		return enc.Encode, nil
+	}
+
+	fs, err := newFieldSet(reflect.TypeOf(sample), names)
+	if err != nil {
+		return nil, err
+	}
+	return func(v interface{}) error {
+		return enc.Encode(fs.project(v))
+	}, nil
+}
+
+// markdownOmitted are left out of Markdown tables unless asked for, as they are too long to read in a table
+var markdownOmitted = map[string]bool{"Description": true, "Files": true}
+
+// markdownMaxCell is the longest a cell may be before it is truncated
+const markdownMaxCell = 80
+
+// markdown renders a pointer to a slice of summaries as a GitHub-flavored Markdown table
+func markdown(v interface{}, columns []string) (string, error) {
+	rv := reflect.ValueOf(v).Elem()
+	t := rv.Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	fields := map[string]int{}
+	names := []string{}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath != "" {
+			continue
+		}
+		fields[t.Field(i).Name] = i
+		names = append(names, t.Field(i).Name)
+	}
+
+	if len(columns) == 0 {
+		for _, n := range names {
+			if !markdownOmitted[n] {
+				columns = append(columns, n)
+			}
+		}
+	}
+
+	for _, c := range columns {
+		if _, ok := fields[c]; !ok {
+			return "", fmt.Errorf("unknown field %q, choose from: %s", c, strings.Join(names, ", "))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString("| " + strings.Join(columns, " | ") + " |\n")
+	sb.WriteString(strings.Repeat("|---", len(columns)) + "|\n")
+
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		cells := []string{}
+		for _, c := range columns {
+			cell := ""
+			if f := reflect.Indirect(row.Field(fields[c])); f.IsValid() {
+				cell = fmt.Sprint(f.Interface())
+			}
+			if c == "URL" && cell != "" {
+				cells = append(cells, fmt.Sprintf("[#%s](%s)", path.Base(cell), cell))
+				continue
+			}
+			cells = append(cells, markdownCell(cell))
+		}
+		sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+
+	return sb.String(), nil
+}
+
+// markdownCell escapes a value for a Markdown table cell, keeping it on one line
+func markdownCell(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if r := []rune(s); len(r) > markdownMaxCell {
+		s = string(r[:markdownMaxCell-3]) + "..."
+	}
+	return strings.ReplaceAll(s, "|", `\|`)
+}
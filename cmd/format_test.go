@@ -0,0 +1,74 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+func TestWriteOutputToFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "succeeded", want: "a,b\nc,"},
+		// A failed run leaves the previous output in place, rather than half a CSV
+		{name: "failed", err: errors.New("connection reset"), want: "previous\n"},
+		// The rows written before an interruption are complete, so are kept
+		{name: "interrupted", err: repo.ErrInterrupted, want: "a,b\nc,"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			out := filepath.Join(dir, "prs.csv")
+			if err := ioutil.WriteFile(out, []byte("previous\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := writeOutputTo(&rootOptions{out: out}, func(w io.Writer) error {
+				fmt.Fprint(w, "a,b\nc,")
+				return tc.err
+			})
+			if !errors.Is(err, tc.err) {
+				t.Errorf("writeOutputTo() = %v, want %v", err, tc.err)
+			}
+
+			got, err := ioutil.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("--out contains %q, want %q", got, tc.want)
+			}
+
+			entries, err := ioutil.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(entries) != 1 {
+				t.Errorf("%s has %d entries, want only --out", dir, len(entries))
+			}
+		})
+	}
+}
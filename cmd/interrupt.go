@@ -0,0 +1,58 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+)
+
+// interruptedExitCode is the exit code of a run interrupted part way, whose output holds only what was fetched first
+const interruptedExitCode = 3
+
+// interruptContext returns a context which is cancelled by the first SIGINT or SIGTERM, so that what has been fetched
+// so far can still be written. A second signal exits at once. stop releases the signals.
+func interruptContext() (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	stopped := make(chan struct{})
+
+	go func() {
+		select {
+		case <-sigs:
+		case <-stopped:
+			return
+		}
+		logrus.Warningf("Interrupted, writing what has been fetched so far. Interrupt again to exit at once.")
+		cancel()
+
+		select {
+		case <-sigs:
+			os.Exit(interruptedExitCode)
+		case <-stopped:
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sigs)
+		close(stopped)
+		cancel()
+	}
+}
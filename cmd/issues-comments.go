@@ -16,13 +16,9 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/gocarina/gocsv"
 	"github.com/google/pullsheet/pkg/summary"
 	"github.com/spf13/cobra"
-
-	"github.com/google/pullsheet/pkg/client"
 )
 
 // issuesCommentsCmd represents the subcommand for `pullsheet issue-comments`
@@ -42,21 +38,22 @@ func init() {
 
 func runIssueComments(rootOpts *rootOptions) error {
 	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
-	data, err := summary.Comments(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	data, err := summary.Comments(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.maxComments)
 	if err != nil {
 		return err
 	}
 
-	out, err := gocsv.MarshalString(&data)
-	if err != nil {
-		return err
+	if rootOpts.repoOpts.OwnedBy != "" {
+		_, data, err = summary.InheritOwnership(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.sinceParsed, rootOpts.untilParsed, nil, nil, data)
+		if err != nil {
+			return err
+		}
 	}
 
-	fmt.Print(out)
-	return nil
+	return deliver(ctx, rootOpts, "comments", &data)
 }
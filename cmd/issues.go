@@ -17,13 +17,16 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
+	"strings"
 
-	"github.com/gocarina/gocsv"
 	"github.com/google/pullsheet/pkg/summary"
-	"github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/google/pullsheet/pkg/checkpoint"
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/repo"
 )
 
 // issuesCmd represents the subcommand for `pullsheet issues`
@@ -37,29 +40,149 @@ var issuesCmd = &cobra.Command{
 	},
 }
 
+type issuesOptions struct {
+	milestone string
+	assignee  string
+	state     string
+	reason    string
+}
+
+var issuesOpts = &issuesOptions{}
+
 func init() {
+	issuesCmd.Flags().StringVar(
+		&issuesOpts.milestone,
+		"milestone",
+		"",
+		"Only include issues in the milestone with this title, ignoring case")
+
+	issuesCmd.Flags().StringVar(
+		&issuesOpts.assignee,
+		"assignee",
+		"",
+		"Only include issues assigned to this login, filtered by GitHub. \"none\" matches unassigned issues.")
+
+	issuesCmd.Flags().StringVar(
+		&issuesOpts.state,
+		"issue-state",
+		summary.IssuesClosed,
+		"Which issues to include: those opened or closed within the time range, or both")
+
+	issuesCmd.Flags().StringVar(
+		&issuesOpts.reason,
+		"closed-reason",
+		"all",
+		"Only include closed issues closed for this reason: completed, not_planned, or all")
+
+	addStateFileFlag(issuesCmd)
 	rootCmd.AddCommand(issuesCmd)
 }
 
 func runIssues(rootOpts *rootOptions) error {
-	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
-	if err != nil {
+	valid := false
+	for _, s := range summary.IssueStates {
+		valid = valid || issuesOpts.state == s
+	}
+	if !valid {
+		return fmt.Errorf("unknown --issue-state %q, choose from: %s", issuesOpts.state, strings.Join(summary.IssueStates, ", "))
+	}
+	if issuesOpts.state == summary.IssuesBoth && rootOpts.sqlite != "" {
+		return fmt.Errorf("--issue-state=%s can't be used with --sqlite, which keeps one row per issue", summary.IssuesBoth)
+	}
+
+	switch issuesOpts.reason {
+	case "all":
+		rootOpts.repoOpts.ClosedReason = ""
+	case repo.CompletedReason, repo.NotPlannedReason:
+		rootOpts.repoOpts.ClosedReason = issuesOpts.reason
+	default:
+		return fmt.Errorf("unknown --closed-reason %q, choose from: %s, %s, all", issuesOpts.reason, repo.CompletedReason, repo.NotPlannedReason)
+	}
+
+	if err := checkStateFile(rootOpts); err != nil {
 		return err
 	}
 
-	data, err := summary.Issues(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	rootOpts.repoOpts.Milestone = issuesOpts.milestone
+	rootOpts.repoOpts.Assignee = issuesOpts.assignee
+
+	ctx, stop := interruptContext()
+	defer stop()
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
-	out, err := gocsv.MarshalString(&data)
+	if rootOpts.stateFile != "" {
+		return incrementalIssues(ctx, c, rootOpts)
+	}
+
+	// Each line is written as soon as its issue is fetched, so an interrupted run leaves only complete lines behind
+	if rootOpts.format == "ndjson" && rootOpts.sqlite == "" {
+		return writeOutputTo(rootOpts, func(w io.Writer) error {
+			encode, err := ndjsonEncoder(w, repo.IssueSummary{}, rootOpts.fields)
+			if err != nil {
+				return err
+			}
+
+			return summary.IssuesTo(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, issuesOpts.state, func(s *repo.IssueSummary) error {
+				return encode(s)
+			})
+		})
+	}
+
+	data, err := summary.Issues(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, issuesOpts.state)
+	if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+		return err
+	}
+
+	// ctx is cancelled once interrupted, but what was fetched is still written
+	if derr := deliver(context.Background(), rootOpts, "issues", &data); derr != nil {
+		return derr
+	}
+	return err
+}
+
+// incrementalIssues fetches only the issues newer than those in the state file, and merges them with the previous output
+func incrementalIssues(ctx context.Context, c *client.Client, rootOpts *rootOptions) error {
+	st, err := loadState(rootOpts.stateFile)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("%d bytes of issue output", len(out))
-	fmt.Print(out)
+	// Each state counts different dates, so is tracked separately
+	kind := "issues/" + issuesOpts.state
+	data := []*repo.IssueSummary{}
+	// An interrupted repository's rows are written, but not recorded, so the next run fetches it again, mostly from cache
+	var interrupted error
+	complete := 0
+	for _, r := range rootOpts.repos {
+		since := st.since(kind, r, rootOpts.sinceParsed)
+		rs, err := summary.Issues(ctx, c, rootOpts.repoOpts, []string{r}, rootOpts.users, since, rootOpts.untilParsed, issuesOpts.state)
+		if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+			return err
+		}
+		data = append(data, rs...)
+		if err != nil {
+			interrupted = err
+			break
+		}
+		complete = len(data)
+	}
+
+	prev := []*repo.IssueSummary{}
+	if err := previousRows(rootOpts, &prev); err != nil {
+		return err
+	}
+	recorded := data[:complete]
+	st.record(kind, &recorded)
+	mergeByURL(&data, &prev)
 
-	return nil
+	if err := deliver(context.Background(), rootOpts, "issues", &data); err != nil {
+		return err
+	}
+	if err := checkpoint.Save(rootOpts.stateFile, st); err != nil {
+		return errors.Wrap(err, "state file")
+	}
+	return interrupted
 }
@@ -17,6 +17,8 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/google/pullsheet/pkg/summary"
@@ -26,6 +28,8 @@ import (
 
 	"github.com/google/pullsheet/pkg/client"
 	"github.com/google/pullsheet/pkg/leaderboard"
+	"github.com/google/pullsheet/pkg/output"
+	"github.com/google/pullsheet/pkg/repo"
 )
 
 // leaderBoardCmd represents the subcommand for `pullsheet leaderboard`
@@ -39,49 +43,174 @@ var leaderBoardCmd = &cobra.Command{
 	},
 }
 
+type leaderboardOptions struct {
+	dataOut   string
+	userPages string
+}
+
+var leaderboardOpts = &leaderboardOptions{}
+
 func init() {
+	leaderBoardCmd.Flags().StringVar(
+		&leaderboardOpts.dataOut,
+		"data-out",
+		"",
+		"Also write the leaderboard's charts and tables as JSON to this path",
+	)
+
+	leaderBoardCmd.Flags().StringVar(
+		&leaderboardOpts.userPages,
+		"user-pages",
+		"",
+		"Also write a page of each user's activity to this directory, linking leaderboard entries to them. Save the leaderboard there too for the links to work.",
+	)
+
 	rootCmd.AddCommand(leaderBoardCmd)
 }
 
 func runLeaderBoard(rootOpts *rootOptions) error {
 	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
-	prs, err := summary.Pulls(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.sinceParsed, rootOpts.untilParsed)
-	if err != nil {
+	if err := loadIdentities(ctx, c, rootOpts); err != nil {
 		return err
 	}
 
-	reviews, err := summary.Reviews(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	data, err := leaderboardData(ctx, c, rootOpts, leaderboardPlan(rootOpts))
 	if err != nil {
 		return err
 	}
 
-	issues, err := summary.Issues(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	if leaderboardOpts.userPages != "" {
+		rootOpts.boardOpts.UserLinks = leaderboard.UserPage
+		if err := writeUserPages(leaderboardOpts.userPages, rootOpts, data); err != nil {
+			return err
+		}
+	}
+
+	out, err := rootOpts.boardOpts.Render(leaderboardTitle(rootOpts), rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, data)
 	if err != nil {
 		return err
 	}
 
-	comments, err := summary.Comments(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	if leaderboardOpts.dataOut != "" {
+		js, err := rootOpts.boardOpts.RenderJSON(leaderboardTitle(rootOpts), rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, data)
+		if err != nil {
+			return err
+		}
+		if err := output.WriteFile(leaderboardOpts.dataOut, js); err != nil {
+			return fmt.Errorf("data out: %w", err)
+		}
+		logrus.Infof("wrote leaderboard data to %s", leaderboardOpts.dataOut)
+	}
+
+	logrus.Infof("%d bytes of leaderboard output", len(out))
+	fmt.Print(out)
+
+	return nil
+}
+
+// userPages returns the activity page of each user the leaderboard names, by file name
+func userPages(rootOpts *rootOptions, d leaderboard.Data) (map[string]string, error) {
+	pages := map[string]string{}
+	for _, login := range rootOpts.boardOpts.UserLogins(d) {
+		html, err := rootOpts.boardOpts.RenderUser(leaderboardTitle(rootOpts), rootOpts.sinceParsed, rootOpts.untilParsed, login, d)
+		if err != nil {
+			return nil, fmt.Errorf("user page for %s: %w", login, err)
+		}
+		pages[leaderboard.UserPage(login)] = html
+	}
+	return pages, nil
+}
+
+// writeUserPages writes the activity page of each user the leaderboard names to dir
+func writeUserPages(dir string, rootOpts *rootOptions, d leaderboard.Data) error {
+	pages, err := userPages(rootOpts, d)
 	if err != nil {
 		return err
 	}
 
-	title := rootOpts.title
-	if title == "" {
-		title = strings.Join(rootOpts.repos, ", ")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("user pages: %w", err)
+	}
+	for name, html := range pages {
+		if err := output.WriteFile(filepath.Join(dir, name), []byte(html)); err != nil {
+			return fmt.Errorf("user pages: %w", err)
+		}
 	}
+	logrus.Infof("wrote %d user pages to %s", len(pages), dir)
+	return nil
+}
+
+// leaderboardPlan returns what must be fetched to render a leaderboard
+func leaderboardPlan(rootOpts *rootOptions) summary.FetchPlan {
+	// Charts only need PR deltas, unless CODEOWNERS coverage is matched against file paths, or hot paths are charted
+	return summary.FetchPlan{Files: rootOpts.fullFiles || rootOpts.codeowners || rootOpts.boardOpts.NeedsFiles()}
+}
+
+// leaderboardData collects the data needed to render a leaderboard
+func leaderboardData(ctx context.Context, c *client.Client, rootOpts *rootOptions, plan summary.FetchPlan) (leaderboard.Data, error) {
+	d := leaderboard.Data{}
+	var err error
 
-	out, err := leaderboard.Render(title, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, prs, reviews, issues, comments)
+	d.PRs, err = summary.PullsWithPlan(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, rootOpts.sinceParsed, rootOpts.untilParsed, plan)
 	if err != nil {
-		return err
+		return d, err
 	}
 
-	logrus.Infof("%d bytes of issue-comments output", len(out))
-	fmt.Print(out)
+	if rootOpts.newContributors {
+		d.NewContributors, err = repo.NewContributors(ctx, c, rootOpts.repoOpts, d.PRs, rootOpts.sinceParsed)
+		if err != nil {
+			return d, err
+		}
+	}
 
-	return nil
+	d.Reviews, err = summary.Reviews(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	if err != nil {
+		return d, err
+	}
+
+	d.Issues, err = summary.Issues(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, summary.IssuesClosed)
+	if err != nil {
+		return d, err
+	}
+
+	d.Comments, err = summary.Comments(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.maxComments)
+	if err != nil {
+		return d, err
+	}
+
+	if rootOpts.repoOpts.OwnedBy != "" {
+		d.Reviews, d.Comments, err = summary.InheritOwnership(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.sinceParsed, rootOpts.untilParsed, d.PRs, d.Reviews, d.Comments)
+		if err != nil {
+			return d, err
+		}
+	}
+
+	if rootOpts.issueEvents {
+		d.Triage, err = summary.Triage(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.selfTriage, rootOpts.collapse)
+		if err != nil {
+			return d, err
+		}
+	}
+
+	if rootOpts.codeowners {
+		d.Ownership, err = summary.Ownership(ctx, c, rootOpts.repos, rootOpts.sinceParsed, d.PRs, d.Reviews)
+		if err != nil {
+			return d, err
+		}
+	}
+
+	return d, nil
+}
+
+// leaderboardTitle returns the title for leaderboard pages
+func leaderboardTitle(rootOpts *rootOptions) string {
+	if rootOpts.title != "" {
+		return rootOpts.title
+	}
+	return strings.Join(rootOpts.repos, ", ")
 }
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var (
+	// loginRe matches a GitHub login, or an @org/team-slug to expand
+	loginRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9-]*|@[A-Za-z0-9][A-Za-z0-9-]*/[A-Za-z0-9._-]+)$`)
+	// repoRe matches an org/name repository or its URL, or org/* for all of an organization's
+	repoRe = regexp.MustCompile(`^(https?://[^/\s]+/)?[A-Za-z0-9][A-Za-z0-9-]*/([A-Za-z0-9._-]+|\*)$`)
+)
+
+// expandUserFiles replaces @file entries in a list of users with the logins listed in the file.
+// Entries naming a file that doesn't exist are left alone, as they may be an @org/team-slug.
+func expandUserFiles(users []string) ([]string, error) {
+	return expandListFiles(users, func(e string) (string, bool) {
+		// Mentions pasted from GitHub start with @, but teams must keep theirs
+		if !strings.Contains(e, "/") {
+			e = strings.TrimPrefix(e, "@")
+		}
+		return e, loginRe.MatchString(e)
+	})
+}
+
+// expandRepoFiles replaces @file entries in a list of repositories with the repositories listed in the file
+func expandRepoFiles(repos []string) ([]string, error) {
+	return expandListFiles(repos, func(e string) (string, bool) {
+		return e, repoRe.MatchString(e)
+	})
+}
+
+// expandListFiles replaces @file entries with the entries in the file, one per line. Blank lines and # comments are ignored,
+// and each entry is normalized and checked by valid.
+func expandListFiles(list []string, valid func(string) (string, bool)) ([]string, error) {
+	expanded := []string{}
+	for _, e := range list {
+		path := strings.TrimPrefix(e, "@")
+		if !strings.HasPrefix(e, "@") {
+			expanded = append(expanded, e)
+			continue
+		}
+
+		if _, err := os.Stat(path); err != nil {
+			if os.IsNotExist(err) && strings.Count(path, "/") == 1 {
+				expanded = append(expanded, e)
+				continue
+			}
+			return nil, fmt.Errorf("list file: %v", err)
+		}
+
+		entries, err := readListFile(path, valid)
+		if err != nil {
+			return nil, err
+		}
+		expanded = append(expanded, entries...)
+	}
+	return expanded, nil
+}
+
+// readListFile returns the entries of a list file
+func readListFile(path string, valid func(string) (string, bool)) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("list file: %v", err)
+	}
+	defer f.Close()
+
+	entries := []string{}
+	s := bufio.NewScanner(f)
+	for n := 1; s.Scan(); n++ {
+		line := s.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		e, ok := valid(line)
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: malformed entry %q", path, n, line)
+		}
+		entries = append(entries, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// setupProgress displays a progress line on stderr, updated in place, if it is a terminal or --progress is set
+func setupProgress() {
+	if !rootOpts.progress && !isTerminal(os.Stderr) {
+		return
+	}
+
+	var mu sync.Mutex
+	repo.Progress = func(e repo.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fmt.Fprintf(os.Stderr, "\r\x1b[K%s", repo.RunProgress.String())
+		// The line is finished when the last repository is
+		if e.Phase == "repo" && e.Items == 0 && e.Done == e.Total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
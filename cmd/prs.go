@@ -16,14 +16,15 @@ package cmd
 
 import (
 	"context"
-	"fmt"
+	"io"
 
-	"github.com/gocarina/gocsv"
 	"github.com/google/pullsheet/pkg/summary"
-	"github.com/sirupsen/logrus"
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/google/pullsheet/pkg/checkpoint"
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/repo"
 )
 
 // prsCmd represents the subcommand for `pullsheet prs`
@@ -38,28 +39,106 @@ var prsCmd = &cobra.Command{
 }
 
 func init() {
+	addStateFileFlag(prsCmd)
+	prsCmd.Flags().BoolVar(&prsOpts.dryRun, "dry-run", false, "List PRs only, and print an estimate of the requests fetching the rest would take instead of any output")
 	rootCmd.AddCommand(prsCmd)
 }
 
 func runPRs(rootOpts *rootOptions) error {
-	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
-	if err != nil {
+	if err := checkStateFile(rootOpts); err != nil {
 		return err
 	}
 
-	data, err := summary.Pulls(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.sinceParsed, rootOpts.untilParsed)
+	ctx, stop := interruptContext()
+	defer stop()
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
-	out, err := gocsv.MarshalString(&data)
+	if prsOpts.dryRun {
+		return estimatePRs(ctx, c, rootOpts)
+	}
+
+	if err := loadIdentities(ctx, c, rootOpts); err != nil {
+		return err
+	}
+
+	if rootOpts.stateFile != "" {
+		return incrementalPRs(ctx, c, rootOpts)
+	}
+
+	// Each line is written as soon as its PR is summarized, so an interrupted run leaves only complete lines behind
+	if rootOpts.format == "ndjson" && rootOpts.sqlite == "" {
+		return writeOutputTo(rootOpts, func(w io.Writer) error {
+			encode, err := ndjsonEncoder(w, repo.PRSummary{}, rootOpts.fields)
+			if err != nil {
+				return err
+			}
+
+			return summary.PullsTo(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, rootOpts.sinceParsed, rootOpts.untilParsed, prsPlan(rootOpts), func(s *repo.PRSummary) error {
+				return encode(s)
+			})
+		})
+	}
+
+	data, err := summary.PullsWithPlan(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, rootOpts.sinceParsed, rootOpts.untilParsed, prsPlan(rootOpts))
+	if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+		return err
+	}
+
+	// ctx is cancelled once interrupted, but what was fetched is still written
+	if derr := deliver(context.Background(), rootOpts, "prs", &data); derr != nil {
+		return derr
+	}
+	return err
+}
+
+// incrementalPRs fetches only the PRs merged since those in the state file, and merges them with the previous output
+func incrementalPRs(ctx context.Context, c *client.Client, rootOpts *rootOptions) error {
+	st, err := loadState(rootOpts.stateFile)
 	if err != nil {
 		return err
 	}
 
-	logrus.Infof("%d bytes of prs output", len(out))
-	fmt.Print(out)
+	data := []*repo.PRSummary{}
+	// An interrupted repository's rows are written, but not recorded, so the next run fetches it again, mostly from cache
+	var interrupted error
+	complete := 0
+	for _, r := range rootOpts.repos {
+		since := st.since("prs", r, rootOpts.sinceParsed)
+		rs, err := summary.PullsWithPlan(ctx, c, rootOpts.repoOpts, []string{r}, rootOpts.users, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, since, rootOpts.untilParsed, prsPlan(rootOpts))
+		if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+			return err
+		}
+		data = append(data, rs...)
+		if err != nil {
+			interrupted = err
+			break
+		}
+		complete = len(data)
+	}
+
+	prev := []*repo.PRSummary{}
+	if err := previousRows(rootOpts, &prev); err != nil {
+		return err
+	}
+	recorded := data[:complete]
+	st.record("prs", &recorded)
+	mergeByURL(&data, &prev)
+
+	if err := deliver(context.Background(), rootOpts, "prs", &data); err != nil {
+		return err
+	}
+	if err := checkpoint.Save(rootOpts.stateFile, st); err != nil {
+		return errors.Wrap(err, "state file")
+	}
+	return interrupted
+}
 
-	return nil
+// prsPlan returns what must be fetched for every PR column, less reviews if they were skipped
+func prsPlan(rootOpts *rootOptions) summary.FetchPlan {
+	plan := summary.FullPlan
+	plan.Reviews = !rootOpts.skipReviews
+	return plan
 }
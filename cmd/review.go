@@ -16,14 +16,9 @@ package cmd
 
 import (
 	"context"
-	"fmt"
 
-	"github.com/gocarina/gocsv"
 	"github.com/google/pullsheet/pkg/summary"
-	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-
-	"github.com/google/pullsheet/pkg/client"
 )
 
 // reviewsCmd represents the subcommand for `pullsheet reviews`
@@ -43,23 +38,22 @@ func init() {
 
 func runReviews(rootOpts *rootOptions) error {
 	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
-	data, err := summary.Reviews(ctx, c, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
+	data, err := summary.Reviews(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed)
 	if err != nil {
 		return err
 	}
 
-	out, err := gocsv.MarshalString(&data)
-	if err != nil {
-		return err
+	if rootOpts.repoOpts.OwnedBy != "" {
+		data, _, err = summary.InheritOwnership(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.sinceParsed, rootOpts.untilParsed, nil, data, nil)
+		if err != nil {
+			return err
+		}
 	}
 
-	logrus.Infof("%d bytes of reviews output", len(out))
-	fmt.Print(out)
-
-	return nil
+	return deliver(ctx, rootOpts, "reviews", &data)
 }
@@ -15,7 +15,10 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
 	"time"
 
@@ -24,6 +27,16 @@ import (
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/leaderboard"
+	"github.com/google/pullsheet/pkg/membership"
+	"github.com/google/pullsheet/pkg/repo"
+	"github.com/google/pullsheet/pkg/schema"
+	"github.com/google/pullsheet/pkg/summary"
 )
 
 const dateForm = "2006-01-02"
@@ -38,16 +51,104 @@ pullsheet generates a CSV (comma separated values) & HTML output about GitHub ac
 }
 
 type rootOptions struct {
-	repos       []string
-	users       []string
-	since       string
-	until       string
-	sinceParsed time.Time
-	untilParsed time.Time
-	title       string
-	tokenPath   string
-	logLevel    string
-	branches    []string
+	repos           []string
+	users           []string
+	since           string
+	until           string
+	sinceParsed     time.Time
+	untilParsed     time.Time
+	title           string
+	tokenPath       string
+	githubURL       string
+	githubUpload    string
+	logLevel        string
+	branches        []string
+	labels          []string
+	excludeRepos    []string
+	repoTopics      []string
+	repoLanguages   []string
+	repoVisibility  string
+	includeArchived bool
+	excludeUsers    []string
+	userAliases     []string
+	aliasFile       string
+	excludeLabels   []string
+	associations    []string
+	botPatterns     []string
+	countExts       []string
+	includeBots     bool
+	useMailmap      bool
+	ignorePaths     string
+	truncPaths      string
+	trackerKey      string
+	issueEvents     bool
+	selfTriage      bool
+	collapse        bool
+	maxComments     int
+	codeowners      bool
+	minPerRepo      int
+	minReviews      int
+	top             int
+	minCount        int
+	charts          []string
+	pathDepth       int
+	trendUsers      bool
+	newContributors bool
+	assumeNewAfter  string
+	ownedBy         string
+	ownedFrac       float64
+	memberFile      string
+	memberChart     bool
+	completedOnly   bool
+	quickCloses     time.Duration
+	noSelfMerged    bool
+	stateFile       string
+	cacheTTL        time.Duration
+	cacheBackend    string
+	cacheAddr       string
+	minRate         int
+	maxRetries      int
+	callTimeout     time.Duration
+	proxy           string
+	caBundle        string
+	dialTimeout     time.Duration
+	respTimeout     time.Duration
+	logRequests     bool
+	verifyAuth      bool
+	noCache         bool
+	offline         bool
+	concurrency     int
+	repoConc        int
+	failFast        bool
+	useSearch       bool
+	graphql         bool
+	perPage         int
+	progress        bool
+	fullFiles       bool
+	skipReviews     bool
+	drafts          bool
+	minDelta        int
+	noReverts       bool
+	needApproval    bool
+	noPicks         bool
+	maxDelta        int
+	sizeLimits      []int
+	maxFilesListed  int
+	locale          string
+	gitattrs        bool
+	impactFile      string
+	templateDir     string
+	format          string
+	fields          []string
+	out             string
+	sqlite          string
+	googleSheet     string
+	googleCreds     string
+	appendSheet     bool
+
+	// repoOpts and boardOpts are built from the flags above by initCommand. boardOpts.Repo is repoOpts.
+	repoOpts  *repo.Options
+	boardOpts *leaderboard.Options
 }
 
 var rootOpts = &rootOptions{}
@@ -55,30 +156,114 @@ var rootOpts = &rootOptions{}
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+
+	if s := repo.RunStats.String(); s != "" {
+		logrus.Infof("%s", s)
+	}
+	if s := ghcache.RunCounters.String(); s != "" {
+		logrus.Infof("%s", s)
+	}
+
+	// Non-fatal warnings are summarized once, rather than scrolling by per item
+	if s := digest.Default.String(); s != "" {
+		fmt.Fprint(os.Stderr, s)
+	}
+
+	if errors.Is(err, repo.ErrInterrupted) {
+		logrus.Warningf("The run was interrupted, so its output holds only what was fetched first. Rerunning with --state-file continues from there.")
+		os.Exit(interruptedExitCode)
+	}
+	if err != nil {
 		logrus.Fatal(err)
 	}
 }
 
 func init() {
+	defaults := leaderboard.DefaultOptions()
 	rootCmd.PersistentFlags().StringSliceVar(
 		&rootOpts.repos,
 		"repos",
 		[]string{},
-		"comma-delimited list of repositories. ex: kubernetes/minikube, google/pullsheet",
+		"comma-delimited list of repositories, or org/* for all of an organization's, or @file to read them from a file. ex: kubernetes/minikube, google/pullsheet",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.excludeRepos,
+		"exclude-repos",
+		[]string{},
+		"comma-delimited list of repositories to leave out, which may be glob patterns. ex: org/mirror-*",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.repoTopics,
+		"repo-topic",
+		[]string{},
+		"only expand org/* to repositories with at least one of these topics",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.repoLanguages,
+		"repo-language",
+		[]string{},
+		"only expand org/* to repositories whose primary language is one of these",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.repoVisibility,
+		"repo-visibility",
+		"all",
+		fmt.Sprintf("only expand org/* to repositories with this visibility: %s", strings.Join(repo.RepoVisibilities, ", ")),
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.includeArchived,
+		"include-archived",
+		false,
+		"expand org/* to archived repositories too",
 	)
 
 	rootCmd.PersistentFlags().StringSliceVar(
 		&rootOpts.branches,
 		"branches",
 		[]string{},
-		"comma-delimited list of branches ex: master,main,head",
+		"comma-delimited list of branches, glob patterns, or regular expressions prefixed with ~. ex: main,release-*,~^v\\d+$",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.excludeUsers,
+		"exclude-users",
+		[]string{},
+		"comma-delimited list of logins to leave out of every report, even if listed in --users",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.userAliases,
+		"user-alias",
+		nil,
+		"old=new logins to credit activity by old to new, such as after a rename (repeatable)",
+	)
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.aliasFile,
+		"user-aliases",
+		"",
+		"YAML file mapping old logins to new ones, as with --user-alias",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.labels,
+		"labels",
+		[]string{},
+		"comma-delimited list of labels, one of which PRs must have, ignoring case ex: release-blocker",
+	)
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.excludeLabels,
+		"exclude-labels",
+		[]string{},
+		"comma-delimited list of labels PRs must not have, ignoring case",
 	)
 	rootCmd.PersistentFlags().StringSliceVar(
 		&rootOpts.users,
 		"users",
 		[]string{},
-		"comma-delimiited list of users",
+		"comma-delimited list of users, or @file to read them from a file",
 	)
 
 	rootCmd.PersistentFlags().StringVar(
@@ -109,6 +294,527 @@ func init() {
 		"GitHub token path",
 	)
 
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.githubURL,
+		"github-url",
+		"",
+		"API address of a GitHub Enterprise Server, ex: https://github.example.com/api/v3/. Defaults to $GITHUB_API_URL, or github.com.",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.githubUpload,
+		"github-upload-url",
+		"",
+		"Upload API address of a GitHub Enterprise Server, if it isn't on the same host as --github-url",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.useMailmap,
+		"use-mailmap",
+		false,
+		"Credit the GitHub logins each repository's .mailmap maps to one another to a single login",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.ignorePaths,
+		"ignore-path-regex",
+		repo.DefaultIgnorePathPattern,
+		"regular expression matching changed paths to leave out of PR deltas and types",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.truncPaths,
+		"truncate-path-regex",
+		repo.DefaultTruncatePathPattern,
+		"regular expression matching changed paths whose additions count for at most 10 lines",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.trackerKey,
+		"tracker-key-regex",
+		repo.DefaultTrackerKeyPattern,
+		"regular expression matching issue-tracker keys in PR titles and bodies",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.issueEvents,
+		"issue-events",
+		false,
+		"Fetch issue label and milestone events to measure triage activity",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.selfTriage,
+		"include-self-triage",
+		false,
+		"Count triage actions on issues the actor opened themselves",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.collapse,
+		"collapse-toggles",
+		false,
+		"Ignore labels which were removed by the same actor within a minute of being added",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.maxComments,
+		"max-comments-per-issue",
+		0,
+		"Stop fetching comments for an issue after this many, flagging it as truncated (0 for unlimited)",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.codeowners,
+		"codeowners",
+		false,
+		"Report how well each repository's CODEOWNERS rules are covered by reviews",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.minPerRepo,
+		"min-per-repo",
+		0,
+		"Minimum delta a user must merge into a repository for it to count toward their breadth",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.top,
+		"top",
+		defaults.TopX,
+		"How many users each leaderboard chart shows, adding up the rest as everyone else, or 0 for all of them",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.minCount,
+		"min-count",
+		0,
+		"Leave users with a lower count than this out of leaderboard charts, adding them up as everyone else",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.charts,
+		"charts",
+		nil,
+		"Comma-separated chart IDs to show in leaderboards, in this order (default: all)",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.pathDepth,
+		"path-depth",
+		defaults.PathDepth,
+		"How many leading path components name a directory in the Top Directories leaderboard chart",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.minReviews,
+		"min-reviews",
+		5,
+		"Minimum reviews a user must have submitted to appear in the review latency chart",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.trendUsers,
+		"trend-users",
+		false,
+		"Add a line for each of the top 5 users to leaderboard trend charts",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.newContributors,
+		"new-contributors",
+		false,
+		"Add a leaderboard section for authors with no PR merged into the queried repositories before --since, at a cached search per author",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.assumeNewAfter,
+		"assume-new-after",
+		"",
+		"Only look for PRs merged after this date (YYYY-MM-DD) when finding new contributors, rather than over each repository's whole history",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.ownedBy,
+		"owned-by",
+		"",
+		"Only count changes to files owned by this CODEOWNERS owner (ex: @org/team)",
+	)
+
+	rootCmd.PersistentFlags().Float64Var(
+		&rootOpts.ownedFrac,
+		"owned-fraction",
+		0,
+		"Minimum fraction of a PR's changed lines which must be owned for --owned-by to include it (0 for any)",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.memberFile,
+		"membership-history",
+		"",
+		"YAML file of per-user organization join/leave dates, used to fill in MemberAtTime",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.completedOnly,
+		"completed-closures-only",
+		false,
+		"Only credit leaderboard issue closers for issues closed as completed, not as not planned",
+	)
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.quickCloses,
+		"exclude-quick-closes",
+		0,
+		"Leave issues closed by bots, or by their author within this long, out of time-to-close charts, ex: 10m",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.noSelfMerged,
+		"exclude-self-merged",
+		false,
+		"Leave PRs merged by their own author out of every leaderboard chart but Self-Mergers",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.memberChart,
+		"member-charts",
+		false,
+		"Add leaderboard charts split by organization membership (requires --membership-history)",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.fullFiles,
+		"full-files",
+		false,
+		"Always fetch the changed files of each PR, even when the output only needs its delta",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.drafts,
+		"include-drafts",
+		false,
+		"Include PRs, and reviews of PRs, which were still drafts when closed",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.countExts,
+		"count-extensions",
+		[]string{},
+		"Only count changes to files with these extensions, such as .go,.c,.h, toward PR deltas",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.minDelta,
+		"min-delta",
+		0,
+		"Skip PRs with fewer lines added and deleted than this, after truncation",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.maxDelta,
+		"max-delta",
+		0,
+		"Skip PRs with more lines added and deleted than this, after truncation, or 0 for no limit",
+	)
+
+	rootCmd.PersistentFlags().IntSliceVar(
+		&rootOpts.sizeLimits,
+		"size-buckets",
+		defaults.Repo.SizeLimits,
+		"Largest delta of the XS, S, M, and L size buckets, after truncation; larger PRs are XL",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.maxFilesListed,
+		"max-files-listed",
+		100,
+		"List at most this many paths in a PR's Files column, followed by a count of the rest, or 0 for no limit. Every file still counts toward the delta.",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.associations,
+		"author-association",
+		[]string{},
+		fmt.Sprintf("Only include PRs whose authors have one of these associations with the repository: %s", strings.Join(repo.ValidAssociations, ", ")),
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.botPatterns,
+		"bot-regex",
+		repo.DefaultBotPatterns,
+		"regular expressions matching the logins of bots GitHub doesn't mark as bots",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.includeBots,
+		"include-bots",
+		false,
+		"Count the activity of bots, such as dependabot, as if they were people",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.needApproval,
+		"require-approval",
+		false,
+		"Skip PRs merged without an approving review from someone other than the author",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.noReverts,
+		"exclude-reverts",
+		false,
+		"Skip PRs titled as reverts of another, rather than counting them with a Kind of revert",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.noPicks,
+		"exclude-cherry-picks",
+		false,
+		"Skip PRs titled as cherry-picks, rather than counting them with a Kind of cherry-pick",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.skipReviews,
+		"skip-reviews",
+		false,
+		"Don't fetch the reviews of each PR, leaving the Reviewers and Approvers columns empty",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.locale,
+		"locale",
+		"en",
+		fmt.Sprintf("language to render leaderboards in, one of: %s", strings.Join(leaderboard.Locales(), ", ")),
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.gitattrs,
+		"respect-gitattributes",
+		false,
+		"Exclude files marked linguist-generated or linguist-vendored in each repository's .gitattributes from PR deltas",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.impactFile,
+		"impact-config",
+		"",
+		"YAML file of metric weights for a composite impact score table in leaderboards",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.templateDir,
+		"template-dir",
+		"",
+		"Directory of Go templates overriding the leaderboard page, which is leaderboard.html; also used by the server",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.format,
+		"format",
+		"csv",
+		fmt.Sprintf("output format of the data commands, one of: %s", strings.Join(formats, ", ")),
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.fields,
+		"fields",
+		[]string{},
+		"comma-delimited list of fields to output, in order, ex: URL,Date,User,Delta. Defaults to all of them",
+	)
+
+	rootCmd.PersistentFlags().StringSliceVar(
+		&rootOpts.fields,
+		"columns",
+		[]string{},
+		"comma-delimited list of fields to output",
+	)
+	if err := rootCmd.PersistentFlags().MarkDeprecated("columns", "use --fields instead"); err != nil {
+		logrus.Fatal(err)
+	}
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.out,
+		"out",
+		"",
+		"file to write output to instead of stdout. A .xlsx extension selects --format=xlsx",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.sqlite,
+		"sqlite",
+		"",
+		"SQLite database to upsert prs, issues, reviews, or issue-comments results into, instead of printing them",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.googleSheet,
+		"google-sheet",
+		"",
+		"ID of a Google Sheets spreadsheet to write results to, in a tab named for the command, instead of printing them",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.googleCreds,
+		"google-credentials",
+		"",
+		"service account JSON key file to authenticate to Google Sheets with",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.appendSheet,
+		"append",
+		false,
+		"append rows below those already in the --google-sheet tab, rather than replacing them",
+	)
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.cacheTTL,
+		"cache-ttl",
+		0,
+		"Refetch cached GitHub data older than this, ex: 24h. By default, cached data is only refetched if it predates the last change GitHub reports.",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.cacheBackend,
+		"cache-backend",
+		"",
+		fmt.Sprintf("Where to cache GitHub data: %s. The default is $PERSIST_BACKEND, or disk. $PERSIST_PATH sets where disk and bolt keep it.", strings.Join(cache.Backends, ", ")),
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.cacheAddr,
+		"cache-addr",
+		"",
+		"host:port of the redis server used by --cache-backend=redis. Its password is read from $REDIS_PASSWORD.",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.minRate,
+		"min-rate-remaining",
+		0,
+		"Pause until the GitHub rate limit resets when fewer API requests than this remain, rather than running out",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.maxRetries,
+		"max-retries",
+		client.DefaultRetries,
+		"How many times to resend a GitHub API request which fails for reasons that may pass, such as a server error. 0 fails at once.",
+	)
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.callTimeout,
+		"call-timeout",
+		0,
+		"How long each attempt at a GitHub API request may take before it is abandoned and retried, ex: 30s. By default there is no limit.",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.proxy,
+		"proxy",
+		"",
+		"URL of a proxy to send GitHub requests through, ex: http://proxy.example.com:3128. Defaults to $HTTPS_PROXY.",
+	)
+
+	rootCmd.PersistentFlags().StringVar(
+		&rootOpts.caBundle,
+		"ca-bundle",
+		"",
+		"PEM file of certificates to trust besides the system's, such as a proxy's",
+	)
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.dialTimeout,
+		"dial-timeout",
+		0,
+		"How long connecting to GitHub, or the proxy, may take, ex: 10s",
+	)
+
+	rootCmd.PersistentFlags().DurationVar(
+		&rootOpts.respTimeout,
+		"response-timeout",
+		0,
+		"How long GitHub may take to start responding to a request, ex: 60s. By default there is no limit.",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.verifyAuth,
+		"verify-auth",
+		false,
+		"Check the GitHub token, and that it can see each repository in --repos, before fetching anything, logging who it authenticates as, its scopes, and the rate limit",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.logRequests,
+		"log-requests",
+		false,
+		"Log the method, URL, status, and duration of every GitHub request",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.noCache,
+		"no-cache",
+		false,
+		"Ignore cached GitHub data, fetching everything again. Results are still cached for later runs.",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.concurrency,
+		"concurrency",
+		repo.Concurrency,
+		"How many pull requests, issues, or file lists to fetch from GitHub at once",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.repoConc,
+		"repo-concurrency",
+		summary.RepoConcurrency,
+		"How many repositories to fetch at once. Results are still written in the order repositories are given.",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.failFast,
+		"fail-fast",
+		false,
+		"Stop at the first repository which fails, rather than finishing the others and reporting every failure",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.useSearch,
+		"use-search",
+		false,
+		"Find merged pull requests with GitHub's search, rather than listing every pull request closed since --since. Much faster for old, busy repositories.",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.graphql,
+		"graphql",
+		false,
+		"Fetch pull request details, files, and reviews with GraphQL, 50 pull requests per query, rather than several REST calls for each",
+	)
+
+	rootCmd.PersistentFlags().IntVar(
+		&rootOpts.perPage,
+		"per-page",
+		100,
+		"How many pull requests or issues each page of a listing holds, from 1 to 100",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.progress,
+		"progress",
+		false,
+		"Show a progress line on stderr, updated in place, even if it isn't a terminal",
+	)
+
+	rootCmd.PersistentFlags().BoolVar(
+		&rootOpts.offline,
+		"offline",
+		false,
+		"Only use cached GitHub data, however old, without contacting GitHub. Data which isn't cached is an error.",
+	)
+
 	rootCmd.PersistentFlags().StringVar(
 		&rootOpts.logLevel,
 		"log-level",
@@ -144,10 +850,21 @@ func initRootOpts() error {
 	rootOpts.title = viper.GetString("title")
 	rootOpts.tokenPath = viper.GetString("token-path")
 
-	return nil
+	if rootOpts.githubURL == "" {
+		rootOpts.githubURL = os.Getenv("GITHUB_API_URL")
+	}
+	repo.WebURL = client.WebURL(rootOpts.githubURL)
+
+	var err error
+	rootOpts.repos, err = expandRepoFiles(rootOpts.repos)
+	if err != nil {
+		return err
+	}
+	rootOpts.users, err = expandUserFiles(rootOpts.users)
+	return err
 }
 
-func initCommand(*cobra.Command, []string) error {
+func initCommand(cmd *cobra.Command, _ []string) error {
 	if err := setupGlobalLogger(rootOpts.logLevel); err != nil {
 		return err
 	}
@@ -155,8 +872,231 @@ func initCommand(*cobra.Command, []string) error {
 		return err
 	}
 
+	// Catch output fields added without documentation before anything is written
+	if err := schema.Validate(); err != nil {
+		return err
+	}
+
+	setupProgress()
+	lo := leaderboard.DefaultOptions()
+	ro := lo.Repo
+	lo.MinPerRepo = rootOpts.minPerRepo
+	lo.MinReviews = rootOpts.minReviews
+	if rootOpts.top < 0 || rootOpts.minCount < 0 {
+		return fmt.Errorf("--top and --min-count can't be negative")
+	}
+	lo.TopX = rootOpts.top
+	lo.MinCount = rootOpts.minCount
+	if len(rootOpts.charts) > 0 {
+		if err := leaderboard.ValidCharts(rootOpts.charts); err != nil {
+			return fmt.Errorf("--charts: %w", err)
+		}
+		lo.Charts = rootOpts.charts
+	}
+	if rootOpts.pathDepth < 1 {
+		return fmt.Errorf("--path-depth must be at least 1")
+	}
+	lo.PathDepth = rootOpts.pathDepth
+	lo.TrendUsers = rootOpts.trendUsers
+	if rootOpts.assumeNewAfter != "" {
+		t, err := time.Parse(dateForm, rootOpts.assumeNewAfter)
+		if err != nil {
+			return fmt.Errorf("--assume-new-after: %w", err)
+		}
+		ro.AssumeNewAfter = t
+	}
+	ro.OwnedBy = rootOpts.ownedBy
+	ro.OwnedFraction = rootOpts.ownedFrac
+	ro.RespectGitattributes = rootOpts.gitattrs
+	ro.IncludeDrafts = rootOpts.drafts
+	ro.UseSearch = rootOpts.useSearch
+
+	if rootOpts.maxRetries < 0 || rootOpts.callTimeout < 0 {
+		return fmt.Errorf("--max-retries and --call-timeout can't be negative")
+	}
+
+	if rootOpts.dialTimeout < 0 || rootOpts.respTimeout < 0 {
+		return fmt.Errorf("--dial-timeout and --response-timeout can't be negative")
+	}
+
+	if rootOpts.offline && (rootOpts.noCache || rootOpts.cacheTTL > 0) {
+		return fmt.Errorf("--offline can't be used with --no-cache or --cache-ttl, as only cached data is used")
+	}
+	ghcache.Offline = rootOpts.offline
+
+	if rootOpts.verifyAuth && rootOpts.offline {
+		return fmt.Errorf("--verify-auth can't be used with --offline, which doesn't contact GitHub")
+	}
+
+	// GraphQL results reach the rest of the run through the cache
+	if rootOpts.graphql && (rootOpts.noCache || rootOpts.offline) {
+		return fmt.Errorf("--graphql can't be used with --no-cache or --offline, as its results are read back from the cache")
+	}
+	ro.UseGraphQL = rootOpts.graphql
+
+	if rootOpts.perPage < 1 || rootOpts.perPage > 100 {
+		return fmt.Errorf("--per-page must be from 1 to 100")
+	}
+	repo.PerPage = rootOpts.perPage
+
+	if rootOpts.concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	repo.Concurrency = rootOpts.concurrency
+
+	if rootOpts.repoConc < 1 {
+		return fmt.Errorf("--repo-concurrency must be at least 1")
+	}
+	summary.RepoConcurrency = rootOpts.repoConc
+	summary.FailFast = rootOpts.failFast
+
+	if rootOpts.minDelta < 0 || rootOpts.maxDelta < 0 {
+		return fmt.Errorf("--min-delta and --max-delta can't be negative")
+	}
+	if rootOpts.maxDelta > 0 && rootOpts.minDelta > rootOpts.maxDelta {
+		return fmt.Errorf("--min-delta of %d is over --max-delta of %d", rootOpts.minDelta, rootOpts.maxDelta)
+	}
+	ro.MinDelta = rootOpts.minDelta
+	if err := repo.ValidSizeLimits(rootOpts.sizeLimits); err != nil {
+		return fmt.Errorf("--size-buckets: %w", err)
+	}
+	ro.SizeLimits = rootOpts.sizeLimits
+	for _, e := range rootOpts.countExts {
+		ro.CountExtensions["."+strings.TrimPrefix(strings.ToLower(strings.TrimSpace(e)), ".")] = true
+	}
+	ro.ExcludeReverts = rootOpts.noReverts
+	ro.RequireApproval = rootOpts.needApproval
+
+	for _, a := range rootOpts.associations {
+		a = strings.ToUpper(strings.TrimSpace(a))
+		valid := false
+		for _, v := range repo.ValidAssociations {
+			valid = valid || a == v
+		}
+		if !valid {
+			return fmt.Errorf("unknown author association %q, choose from: %s", a, strings.Join(repo.ValidAssociations, ", "))
+		}
+		ro.Associations[a] = true
+	}
+	ro.ExcludeCherryPicks = rootOpts.noPicks
+	ro.MaxDelta = rootOpts.maxDelta
+
+	if rootOpts.maxFilesListed < 0 {
+		return fmt.Errorf("--max-files-listed can't be negative")
+	}
+	ro.MaxFilesListed = rootOpts.maxFilesListed
+	for _, u := range rootOpts.excludeUsers {
+		ro.ExcludeUsers[strings.ToLower(u)] = true
+	}
+
+	if rootOpts.aliasFile != "" {
+		if err := ro.LoadAliases(rootOpts.aliasFile); err != nil {
+			return errors.Wrap(err, "user aliases")
+		}
+	}
+	for _, a := range rootOpts.userAliases {
+		if err := ro.AddAlias(a); err != nil {
+			return fmt.Errorf("--user-alias: %w", err)
+		}
+	}
+	for i, u := range rootOpts.users {
+		rootOpts.users[i] = ro.Canonical(u)
+	}
+	lo.MemberCharts = rootOpts.memberChart
+	lo.CompletedOnly = rootOpts.completedOnly
+	if rootOpts.quickCloses < 0 {
+		return fmt.Errorf("--exclude-quick-closes can't be negative")
+	}
+	lo.QuickCloses = rootOpts.quickCloses
+	lo.ExcludeSelfMerged = rootOpts.noSelfMerged
+
+	if strings.HasSuffix(strings.ToLower(rootOpts.out), ".xlsx") && !cmd.Flags().Changed("format") {
+		rootOpts.format = "xlsx"
+	}
+
+	if err := repo.ValidRepoPatterns(rootOpts.excludeRepos); err != nil {
+		return err
+	}
+
+	if err := repo.ValidBranchPatterns(rootOpts.branches); err != nil {
+		return err
+	}
+
+	valid := false
+	for _, v := range repo.RepoVisibilities {
+		valid = valid || rootOpts.repoVisibility == v
+	}
+	if !valid {
+		return fmt.Errorf("unknown --repo-visibility %q, choose from: %s", rootOpts.repoVisibility, strings.Join(repo.RepoVisibilities, ", "))
+	}
+
+	if err := validFormat(rootOpts.format, rootOpts.out); err != nil {
+		return err
+	}
+
+	if rootOpts.sqlite != "" && len(rootOpts.fields) > 0 {
+		return fmt.Errorf("--fields can't be used with --sqlite, which stores every field")
+	}
+
+	if rootOpts.googleSheet != "" && rootOpts.googleCreds == "" {
+		return fmt.Errorf("--google-sheet requires --google-credentials")
+	}
+
+	if err := leaderboard.ValidLocale(rootOpts.locale); err != nil {
+		return err
+	}
+	lo.Locale = rootOpts.locale
+
 	var err error
 
+	if rootOpts.memberFile != "" {
+		ro.Membership, err = membership.Load(rootOpts.memberFile)
+		if err != nil {
+			return errors.Wrap(err, "membership history")
+		}
+	} else if rootOpts.memberChart {
+		return fmt.Errorf("--member-charts requires --membership-history")
+	}
+
+	if rootOpts.impactFile != "" {
+		lo.Impact, err = leaderboard.LoadImpact(rootOpts.impactFile)
+		if err != nil {
+			return errors.Wrap(err, "impact config")
+		}
+	}
+
+	lo.Templates, err = leaderboard.LoadTemplates(rootOpts.templateDir)
+	if err != nil {
+		return errors.Wrap(err, "leaderboard template")
+	}
+
+	ro.IncludeBots = rootOpts.includeBots
+	ro.BotRes = nil
+	for _, p := range rootOpts.botPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return errors.Wrapf(err, "bot regex %q", p)
+		}
+		ro.BotRes = append(ro.BotRes, re)
+	}
+
+	ro.IgnorePathRe, err = regexp.Compile(rootOpts.ignorePaths)
+	if err != nil {
+		return errors.Wrap(err, "ignore path regex")
+	}
+
+	ro.TruncatePathRe, err = regexp.Compile(rootOpts.truncPaths)
+	if err != nil {
+		return errors.Wrap(err, "truncate path regex")
+	}
+
+	ro.TrackerKeyRe, err = regexp.Compile(rootOpts.trackerKey)
+	if err != nil {
+		return errors.Wrap(err, "tracker key regex")
+	}
+	rootOpts.repoOpts = ro
+	rootOpts.boardOpts = lo
+
 	t, err := tparse.ParseNow(dateForm, rootOpts.since)
 	if err == nil {
 		rootOpts.sinceParsed = t
@@ -185,6 +1125,26 @@ func initCommand(*cobra.Command, []string) error {
 	return nil
 }
 
+// loadIdentities credits the logins each repository's .mailmap maps between GitHub noreply addresses to one login, if
+// requested
+func loadIdentities(ctx context.Context, c *client.Client, rootOpts *rootOptions) error {
+	if !rootOpts.useMailmap {
+		return nil
+	}
+
+	m, err := summary.Mailmap(ctx, c, rootOpts.repos, rootOpts.sinceParsed)
+	if err != nil {
+		return err
+	}
+
+	n := rootOpts.repoOpts.AddMailmapAliases(m)
+	logrus.Infof("loaded %d .mailmap identity mappings, adding %d login aliases", m.Len(), n)
+	for i, u := range rootOpts.users {
+		rootOpts.users[i] = rootOpts.repoOpts.Canonical(u)
+	}
+	return nil
+}
+
 // SetupGlobalLogger uses to provided log level string and applies it globally.
 func setupGlobalLogger(level string) error {
 	logrus.SetFormatter(&logrus.TextFormatter{
@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/schema"
+)
+
+// schemaCmd represents the subcommand for `pullsheet schema`
+var schemaCmd = &cobra.Command{
+	Use:           "schema",
+	Short:         "Describe the columns of each output",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchema()
+	},
+}
+
+type schemaOptions struct {
+	format string
+}
+
+var schemaOpts = &schemaOptions{}
+
+func init() {
+	schemaCmd.Flags().StringVar(
+		&schemaOpts.format,
+		"format",
+		"markdown",
+		"Output format: json or markdown")
+
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchema() error {
+	d := schema.Describe()
+
+	switch schemaOpts.format {
+	case "json":
+		b, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(b))
+	case "markdown":
+		fmt.Print(schema.Markdown(d))
+	default:
+		return fmt.Errorf("unknown format %q, choose json or markdown", schemaOpts.format)
+	}
+
+	return nil
+}
@@ -18,11 +18,11 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 
 	"github.com/spf13/cobra"
 
-	"github.com/google/pullsheet/pkg/client"
 	"github.com/google/pullsheet/pkg/server"
 	"github.com/google/pullsheet/pkg/server/job"
 )
@@ -52,11 +52,17 @@ func init() {
 
 func runServer(rootOpts *rootOptions) error {
 	ctx := context.Background()
-	c, err := client.New(ctx, client.Config{GitHubTokenPath: rootOpts.tokenPath})
+	c, err := newClient(ctx, rootOpts)
 	if err != nil {
 		return err
 	}
 
+	// Leaderboard entries link to the activity pages served for the job at /
+	lo := *rootOpts.boardOpts
+	lo.UserLinks = func(login string) string {
+		return "/job/0/user/" + url.PathEscape(login)
+	}
+
 	// setup initial job
 	j := job.New(
 		&job.Opts{
@@ -65,10 +71,28 @@ func runServer(rootOpts *rootOptions) error {
 			Since: rootOpts.sinceParsed,
 			Until: rootOpts.untilParsed,
 			Title: rootOpts.title,
+
+			ExcludeRepos:  rootOpts.excludeRepos,
+			Labels:        rootOpts.labels,
+			ExcludeLabels: rootOpts.excludeLabels,
+
+			Leaderboard: &lo,
+
+			MaxCommentsPerIssue: rootOpts.maxComments,
+
+			IssueEvents:       rootOpts.issueEvents,
+			IncludeSelfTriage: rootOpts.selfTriage,
+			CollapseToggles:   rootOpts.collapse,
+			Codeowners:        rootOpts.codeowners,
+			FullFiles:         rootOpts.fullFiles,
+			NewContributors:   rootOpts.newContributors,
 		})
 
 	s := server.New(ctx, c, j)
 	http.HandleFunc("/", s.Root())
+	http.HandleFunc("/job/", s.JobData())
+	http.HandleFunc("/status", s.Status())
+	http.HandleFunc("/statusz", s.Statusz())
 	http.HandleFunc("/healthz", s.Healthz())
 	http.HandleFunc("/threadz", s.Threadz())
 
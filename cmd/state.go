@@ -0,0 +1,191 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/checkpoint"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// stateVersion is bumped whenever runState changes incompatibly
+const stateVersion = 1
+
+// runState records how far previous incremental runs got, so the next one only fetches what is new
+type runState struct {
+	Version int `json:"version"`
+	// Newest is the date of the newest item processed, by kind of result, then by org/name repository
+	Newest map[string]map[string]time.Time `json:"newest"`
+}
+
+// addStateFileFlag adds --state-file to a command which supports incremental runs
+func addStateFileFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVar(
+		&rootOpts.stateFile,
+		"state-file",
+		"",
+		"JSON file recording the newest item processed per repository. Runs using it only fetch newer items, and merge them into the previous --out file.")
+}
+
+// checkStateFile returns an error if the output options can't be merged with a previous run's
+func checkStateFile(rootOpts *rootOptions) error {
+	if rootOpts.stateFile == "" || rootOpts.sqlite != "" {
+		return nil
+	}
+	if rootOpts.out == "" || (rootOpts.format != "csv" && rootOpts.format != "json") {
+		return fmt.Errorf("--state-file requires --sqlite, or --out with --format=csv or json, to merge results into")
+	}
+	if len(rootOpts.fields) > 0 {
+		return fmt.Errorf("--state-file can't be used with --fields, as results are merged on every field")
+	}
+	return nil
+}
+
+// loadState returns the state of previous runs, which is empty if there were none
+func loadState(path string) (*runState, error) {
+	st := &runState{Version: stateVersion, Newest: map[string]map[string]time.Time{}}
+	ok, err := checkpoint.Load(path, st)
+	if err != nil {
+		return nil, errors.Wrap(err, "state file")
+	}
+	if ok && st.Version != stateVersion {
+		return nil, fmt.Errorf("state file %s is version %d, expected %d: delete it to start over", path, st.Version, stateVersion)
+	}
+	if st.Newest == nil {
+		st.Newest = map[string]map[string]time.Time{}
+	}
+	return st, nil
+}
+
+// since returns where a repository's results of a kind should start: since, or the date of the newest item
+// processed by a previous run, if later. That whole day is fetched again, as dates don't record the time.
+func (st *runState) since(kind string, r string, since time.Time) time.Time {
+	if t := st.Newest[kind][strings.ToLower(r)]; t.After(since) {
+		return t
+	}
+	return since
+}
+
+// record notes the newest date of each repository's results, a pointer to a slice of summaries
+func (st *runState) record(kind string, rows interface{}) {
+	if st.Newest[kind] == nil {
+		st.Newest[kind] = map[string]time.Time{}
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(rows))
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		t, err := time.Parse(dateForm, row.FieldByName("Date").String())
+		if err != nil {
+			continue
+		}
+
+		org, project := repo.ParseURL(row.FieldByName("URL").String())
+		r := strings.ToLower(org + "/" + project)
+		if t.After(st.Newest[kind][r]) {
+			st.Newest[kind][r] = t
+		}
+	}
+}
+
+// previousRows reads the results of a previous run from the --out file, if there is one, into v, a pointer to a slice of summaries
+func previousRows(rootOpts *rootOptions, v interface{}) error {
+	if rootOpts.sqlite != "" {
+		// Rows are upserted, so there is nothing to merge
+		return nil
+	}
+
+	b, err := ioutil.ReadFile(rootOpts.out)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if rootOpts.format == "json" {
+		err = json.Unmarshal(b, v)
+	} else {
+		err = unmarshalCSV(b, v)
+	}
+	if err != nil {
+		return fmt.Errorf("previous results in %s: %v", rootOpts.out, err)
+	}
+	return nil
+}
+
+// unmarshalCSV is gocsv.UnmarshalBytes, except that empty cells leave pointer fields nil rather than pointing to a zero value
+func unmarshalCSV(b []byte, v interface{}) error {
+	if err := gocsv.UnmarshalBytes(b, v); err != nil {
+		return err
+	}
+
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil || len(records) == 0 {
+		return err
+	}
+
+	rv := reflect.ValueOf(v).Elem()
+	for i := 0; i < rv.Len() && i+1 < len(records); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		for c, name := range records[0] {
+			f := row.FieldByName(name)
+			if f.IsValid() && f.Kind() == reflect.Ptr && c < len(records[i+1]) && records[i+1][c] == "" {
+				f.Set(reflect.Zero(f.Type()))
+			}
+		}
+	}
+	return nil
+}
+
+// mergeByURL appends the rows of prev which aren't among those of cur to cur. Both are pointers to slices of summaries.
+func mergeByURL(cur interface{}, prev interface{}) {
+	cv := reflect.ValueOf(cur).Elem()
+	pv := reflect.ValueOf(prev).Elem()
+
+	seen := map[string]bool{}
+	for i := 0; i < cv.Len(); i++ {
+		seen[rowKey(cv.Index(i))] = true
+	}
+
+	for i := 0; i < pv.Len(); i++ {
+		if !seen[rowKey(pv.Index(i))] {
+			cv.Set(reflect.Append(cv, pv.Index(i)))
+		}
+	}
+}
+
+// rowKey identifies a summary by its URL, and its Event if it has one, as an issue may be both opened and closed
+func rowKey(v reflect.Value) string {
+	row := reflect.Indirect(v)
+	key := row.FieldByName("URL").String()
+	if e := row.FieldByName("Event"); e.IsValid() {
+		key += " " + e.String()
+	}
+	return key
+}
@@ -0,0 +1,59 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/repo"
+	"github.com/google/pullsheet/pkg/summary"
+)
+
+// ticketsCmd represents the subcommand for `pullsheet tickets`
+var ticketsCmd = &cobra.Command{
+	Use:           "tickets",
+	Short:         "Generate data around issue-tracker keys referenced by pull requests",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTickets(rootOpts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(ticketsCmd)
+}
+
+func runTickets(rootOpts *rootOptions) error {
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	// Tickets are found in titles and bodies, so file lists are only needed for exact deltas
+	prs, err := summary.PullsWithPlan(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.branches, rootOpts.labels, rootOpts.excludeLabels, rootOpts.sinceParsed, rootOpts.untilParsed, summary.FetchPlan{Files: rootOpts.fullFiles})
+	if err != nil {
+		return err
+	}
+
+	data, untracked := repo.TicketSummaries(prs)
+	logrus.Infof("%.1f%% of %d merged PRs reference no ticket", untracked*100, len(prs))
+
+	return deliver(ctx, rootOpts, "tickets", &data)
+}
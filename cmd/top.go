@@ -0,0 +1,121 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/leaderboard"
+)
+
+// topCmd represents the subcommand for `pullsheet top`
+var topCmd = &cobra.Command{
+	Use:           "top",
+	Short:         "Display leaderboard data in the terminal",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTop(rootOpts)
+	},
+}
+
+type topOptions struct {
+	watch time.Duration
+	width int
+}
+
+var topOpts = &topOptions{}
+
+func init() {
+	topCmd.Flags().DurationVar(
+		&topOpts.watch,
+		"watch",
+		0,
+		"Refresh the display at this interval (ex: 10m), reusing cached data")
+
+	topCmd.Flags().IntVar(
+		&topOpts.width,
+		"width",
+		0,
+		"Maximum line width (defaults to $COLUMNS on a terminal, unlimited otherwise)")
+
+	rootCmd.AddCommand(topCmd)
+}
+
+func runTop(rootOpts *rootOptions) error {
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	tty := isTerminal(os.Stdout)
+	opts := leaderboard.TextOptions{Width: topOpts.width, Color: tty}
+	if opts.Width == 0 && tty {
+		opts.Width = terminalWidth()
+	}
+
+	for {
+		if err := renderTop(ctx, c, rootOpts, opts, tty); err != nil {
+			return err
+		}
+
+		if topOpts.watch == 0 {
+			return nil
+		}
+
+		logrus.Infof("refreshing in %s", topOpts.watch)
+		time.Sleep(topOpts.watch)
+	}
+}
+
+func renderTop(ctx context.Context, c *client.Client, rootOpts *rootOptions, opts leaderboard.TextOptions, tty bool) error {
+	data, err := leaderboardData(ctx, c, rootOpts, leaderboardPlan(rootOpts))
+	if err != nil {
+		return err
+	}
+
+	if tty && topOpts.watch > 0 {
+		// Clear the screen so that each refresh replaces the last
+		fmt.Print("\x1b[H\x1b[2J")
+	}
+
+	return rootOpts.boardOpts.RenderText(os.Stdout, leaderboardTitle(rootOpts), rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.users, data, opts)
+}
+
+// isTerminal returns whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// terminalWidth returns the width of the terminal, as advertised by the shell
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		return n
+	}
+	return 80
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/google/pullsheet/pkg/summary"
+)
+
+// triageCmd represents the subcommand for `pullsheet triage`
+var triageCmd = &cobra.Command{
+	Use:           "triage",
+	Short:         "Generate data around issue labeling and milestones",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTriage(rootOpts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(triageCmd)
+}
+
+func runTriage(rootOpts *rootOptions) error {
+	ctx := context.Background()
+	c, err := newClient(ctx, rootOpts)
+	if err != nil {
+		return err
+	}
+
+	data, err := summary.Triage(ctx, c, rootOpts.repoOpts, rootOpts.repos, rootOpts.users, rootOpts.sinceParsed, rootOpts.untilParsed, rootOpts.selfTriage, rootOpts.collapse)
+	if err != nil {
+		return err
+	}
+
+	return deliver(ctx, rootOpts, "triage", &data)
+}
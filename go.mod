@@ -5,13 +5,17 @@ go 1.16
 require (
 	github.com/blevesearch/segment v0.9.0
 	github.com/etdub/goparsetime v0.0.0-20160315173935-ea17b0ac3318 // indirect
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/gocarina/gocsv v0.0.0-20201208093247-67c824bc04d4
 	github.com/google/go-github/v33 v33.0.0
 	github.com/google/triage-party v0.0.0-20210325043323-fc6840b93022
 	github.com/karrick/tparse v2.4.2+incompatible
+	github.com/mattn/go-sqlite3 v1.14.6
 	github.com/pkg/errors v0.9.1
 	github.com/sirupsen/logrus v1.8.1
 	github.com/spf13/cobra v1.1.3
 	github.com/spf13/viper v1.7.1
+	go.etcd.io/bbolt v1.3.5
 	golang.org/x/oauth2 v0.0.0-20210323180902-22b0adad7558
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -0,0 +1,173 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+)
+
+const (
+	// archiveVersion is bumped whenever the archive format changes incompatibly
+	archiveVersion = 3
+	// archiveSchema names the version of go-github whose types the blobs hold, as they are encoded with them
+	archiveSchema = "go-github/v33"
+
+	manifestName = "manifest.json"
+	// entriesDir holds a file per entry, so that neither Export nor Import hold more than one in memory
+	entriesDir = "entries/"
+)
+
+// manifest describes an archive, and is its first file
+type manifest struct {
+	Version int       `json:"version"`
+	Schema  string    `json:"schema"`
+	Created time.Time `json:"created"`
+}
+
+// archiveEntry is a single cache entry, and the contents of a file in entriesDir. It holds either a blob or a value.
+type archiveEntry struct {
+	Key   string        `json:"key"`
+	Blob  *persist.Blob `json:"blob,omitempty"`
+	Value *Value        `json:"value,omitempty"`
+}
+
+// Export writes every entry of a cache to w as a gzipped tar archive, returning how many were written
+func Export(w io.Writer, c Cacher) (int, error) {
+	es, err := c.Entries()
+	if err != nil {
+		return 0, fmt.Errorf("entries: %v", err)
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	m, err := json.MarshalIndent(manifest{Version: archiveVersion, Schema: archiveSchema, Created: time.Now()}, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	if err := writeArchiveFile(tw, manifestName, m); err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for i, e := range es {
+		// A key holding both a blob and a value is listed once for each, so is only written the first time
+		if i > 0 && es[i-1].Key == e.Key {
+			continue
+		}
+		for _, ae := range []archiveEntry{{Key: e.Key, Blob: c.Get(e.Key, time.Time{})}, {Key: e.Key, Value: c.GetValue(e.Key, time.Time{})}} {
+			if ae.Blob == nil && ae.Value == nil {
+				// Purged or expired since it was listed
+				continue
+			}
+			body, err := json.Marshal(ae)
+			if err != nil {
+				return n, fmt.Errorf("encode %s: %v", e.Key, err)
+			}
+			n++
+			if err := writeArchiveFile(tw, fmt.Sprintf("%s%08d.json", entriesDir, n), body); err != nil {
+				return n, err
+			}
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return n, err
+	}
+	return n, gz.Close()
+}
+
+// writeArchiveFile adds a file to an archive
+func writeArchiveFile(tw *tar.Writer, name string, body []byte) error {
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(body)), ModTime: time.Now()}); err != nil {
+		return err
+	}
+	_, err := tw.Write(body)
+	return err
+}
+
+// Import loads the entries of an archive written by Export into a cache. An entry already cached is only replaced
+// if the archive's copy is newer. It returns how many entries were loaded, and how many were skipped as older.
+func Import(r io.Reader, c Cacher) (int, int, error) {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return 0, 0, fmt.Errorf("not a pullsheet cache archive: %v", err)
+	}
+	tr := tar.NewReader(gz)
+
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != manifestName {
+		return 0, 0, fmt.Errorf("not a pullsheet cache archive: no %s", manifestName)
+	}
+
+	var m manifest
+	if err := json.NewDecoder(tr).Decode(&m); err != nil {
+		return 0, 0, fmt.Errorf("manifest: %v", err)
+	}
+	if m.Version != archiveVersion {
+		return 0, 0, fmt.Errorf("archive is format version %d, but this pullsheet reads version %d: export it again with a matching pullsheet", m.Version, archiveVersion)
+	}
+	if m.Schema != archiveSchema {
+		return 0, 0, fmt.Errorf("archive holds %s data, but this pullsheet uses %s: export it again with a matching pullsheet", m.Schema, archiveSchema)
+	}
+
+	loaded, skipped := 0, 0
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return loaded, skipped, nil
+		}
+		if err != nil {
+			return loaded, skipped, fmt.Errorf("read archive: %v", err)
+		}
+		if !strings.HasPrefix(hdr.Name, entriesDir) {
+			return loaded, skipped, fmt.Errorf("unexpected file %s in archive", hdr.Name)
+		}
+
+		var e archiveEntry
+		if err := json.NewDecoder(tr).Decode(&e); err != nil {
+			return loaded, skipped, fmt.Errorf("%s: %v", hdr.Name, err)
+		}
+		switch {
+		case e.Blob != nil:
+			if cur := c.Get(e.Key, time.Time{}); cur != nil && !e.Blob.Created.After(cur.Created) {
+				skipped++
+				continue
+			}
+			if err := c.Set(e.Key, e.Blob); err != nil {
+				return loaded, skipped, fmt.Errorf("set %s: %v", e.Key, err)
+			}
+		case e.Value != nil:
+			if cur := c.GetValue(e.Key, time.Time{}); cur != nil && !e.Value.Created.After(cur.Created) {
+				skipped++
+				continue
+			}
+			if err := c.SetValue(e.Key, e.Value); err != nil {
+				return loaded, skipped, fmt.Errorf("set %s: %v", e.Key, err)
+			}
+		default:
+			continue
+		}
+		loaded++
+	}
+}
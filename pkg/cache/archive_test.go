@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/persist"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	src := newMemory()
+	title := "Fix the <flaky> test"
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for _, k := range []string{"pr-org-project-1", "pr-org-project-2", "issue-org-project-3"} {
+		if err := src.Set(k, &persist.Blob{GHIssue: &github.Issue{Title: &title}, Created: old}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := src.SetValue("reviews-org-project-1", &Value{Data: []byte(`[{"id":1}]`), Created: old}); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := Export(&buf, src)
+	if err != nil || n != 4 {
+		t.Fatalf("Export() = %d, %v, want 4 entries", n, err)
+	}
+
+	// One file per entry, after the manifest
+	names := archiveNames(t, buf.Bytes())
+	if len(names) != 5 || names[0] != manifestName {
+		t.Errorf("archive files = %v, want the manifest and 4 entries", names)
+	}
+
+	dst := newMemory()
+	newer := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	if err := dst.Set("pr-org-project-1", &persist.Blob{Created: newer}); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, skipped, err := Import(bytes.NewReader(buf.Bytes()), dst)
+	if err != nil || loaded != 3 || skipped != 1 {
+		t.Fatalf("Import() = %d, %d, %v, want 3 loaded and 1 skipped", loaded, skipped, err)
+	}
+
+	b := dst.Get("issue-org-project-3", time.Time{})
+	if b == nil || b.GHIssue.GetTitle() != title || !b.Created.Equal(old) {
+		t.Errorf("imported blob = %+v, want the exported one", b)
+	}
+	if v := dst.GetValue("reviews-org-project-1", time.Time{}); v == nil || string(v.Data) != `[{"id":1}]` || !v.Created.Equal(old) {
+		t.Errorf("imported value = %+v, want the exported one", v)
+	}
+	if b := dst.Get("pr-org-project-1", time.Time{}); b == nil || !b.Created.Equal(newer) {
+		t.Errorf("newer cached blob was replaced by an older archived one")
+	}
+}
+
+func TestImportRejectsOtherVersions(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	if err := writeArchiveFile(tw, manifestName, []byte(`{"version": 1, "schema": "go-github/v33"}`)); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	_, _, err := Import(&buf, newMemory())
+	if err == nil || !strings.Contains(err.Error(), "format version 1") {
+		t.Errorf("Import() of a version 1 archive = %v, want a format version error", err)
+	}
+
+	if _, _, err := Import(strings.NewReader("not an archive"), newMemory()); err == nil {
+		t.Errorf("Import() of garbage returned no error")
+	}
+}
+
+// archiveNames returns the names of the files in a gzipped tar archive, in order
+func archiveNames(t *testing.T, b []byte) []string {
+	t.Helper()
+	gz, err := gzip.NewReader(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	names := []string{}
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return names
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+	}
+}
+
+func TestImportRejectsOtherSchemas(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	m := fmt.Sprintf(`{"version": %d, "schema": "go-github/v72"}`, archiveVersion)
+	if err := writeArchiveFile(tw, manifestName, []byte(m)); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	gz.Close()
+
+	_, _, err := Import(&buf, newMemory())
+	if err == nil || !strings.Contains(err.Error(), "go-github/v72") {
+		t.Errorf("Import() of a go-github/v72 archive = %v, want a schema error", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// boltBucket holds every blob, as encoded by encodeBlob
+var boltBucket = []byte("blobs")
+
+// boltValueBucket holds every value, as encoded by encodeValue
+var boltValueBucket = []byte("values")
+
+// boltDB keeps blobs in a single BoltDB file. Reads run in parallel, while writes are serialized by BoltDB.
+type boltDB struct {
+	path string
+	db   *bolt.DB
+}
+
+func newBolt(path string) *boltDB {
+	return &boltDB{path: path}
+}
+
+func (b *boltDB) String() string {
+	return b.path
+}
+
+func (b *boltDB) Initialize() error {
+	if err := os.MkdirAll(filepath.Dir(b.path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %v", err)
+	}
+
+	// Only one process may open the file, so wait a while for another to finish rather than hanging
+	db, err := bolt.Open(b.path, 0o644, &bolt.Options{Timeout: 30 * time.Second})
+	if err != nil {
+		return fmt.Errorf("open %s: %v", b.path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltBucket, boltValueBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("create bucket: %v", err)
+	}
+
+	b.db = db
+	return nil
+}
+
+func (b *boltDB) Set(key string, bl *persist.Blob) error {
+	val, err := encodeBlob(bl)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte(key), val)
+	})
+}
+
+func (b *boltDB) Get(key string, t time.Time) *persist.Blob {
+	var bl *persist.Blob
+	err := b.db.View(func(tx *bolt.Tx) error {
+		// The value is only valid within the transaction, so is decoded here
+		val := tx.Bucket(boltBucket).Get([]byte(key))
+		if val == nil {
+			return nil
+		}
+
+		var err error
+		bl, err = decodeBlob(val)
+		return err
+	})
+	if err != nil {
+		logrus.Warningf("unreadable cache entry for %v: %v", key, err)
+		return nil
+	}
+
+	if bl == nil || bl.Created.Before(t) {
+		return nil
+	}
+	return bl
+}
+
+func (b *boltDB) SetValue(key string, v *Value) error {
+	val, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+
+	return b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltValueBucket).Put([]byte(key), val)
+	})
+}
+
+func (b *boltDB) GetValue(key string, t time.Time) *Value {
+	var v *Value
+	err := b.db.View(func(tx *bolt.Tx) error {
+		val := tx.Bucket(boltValueBucket).Get([]byte(key))
+		if val == nil {
+			return nil
+		}
+
+		var err error
+		v, err = decodeValue(val)
+		return err
+	})
+	if err != nil {
+		logrus.Warningf("unreadable cache value for %v: %v", key, err)
+		return nil
+	}
+
+	if v == nil || v.Created.Before(t) {
+		return nil
+	}
+	return v
+}
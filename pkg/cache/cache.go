@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cache stores GitHub data between runs. Backends are chosen by name:
+// the triage-party persist backends, a BoltDB file, or memory alone.
+package cache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+)
+
+// Cacher stores blobs and values by key, and is what pkg/ghcache reads and writes through. Every backend behaves the
+// same way:
+//
+// - Keys are opaque strings, which may contain any character other than a NUL.
+// - Blobs and values are stored apart, so the same key may hold one of each.
+// - Set replaces any blob stored under the key, and sets its Created time to now if it is zero. SetValue does the same for values.
+// - Get returns nil if nothing is stored under the key, or if what is was created before t. Blobs are never expired otherwise.
+// - A blob returned by Get, or a value returned by GetValue, must not be modified, as it may be shared with other readers.
+// - Entries and Purge manage what is stored, and are an error for the persist database backends, which can't.
+// - All methods are safe for concurrent use, once Initialize has returned.
+type Cacher interface {
+	String() string
+	Initialize() error
+	Set(key string, b *persist.Blob) error
+	Get(key string, t time.Time) *persist.Blob
+	SetValue(key string, v *Value) error
+	GetValue(key string, t time.Time) *Value
+	// Entries describes every blob and value stored, sorted by key
+	Entries() ([]Entry, error)
+	// Purge removes the blobs and values stored under keys, skipping keys with none
+	Purge(keys []string) error
+}
+
+// Value is raw data, for what persist.Blob has no field for, such as timelines or reviews encoded as JSON
+type Value struct {
+	Created time.Time
+	Data    []byte
+}
+
+// Entry describes a stored blob or value
+type Entry struct {
+	Key string
+	// Size is how many bytes the blob or value takes up in the backend
+	Size int64
+	// Created is when the blob or value was stored
+	Created time.Time
+}
+
+// program names the cache, and so its directory on disk
+const program = "pullsheet"
+
+// Backends are the backends New accepts, besides the persist database backends: mysql, postgres, and cloudsql
+var Backends = []string{"disk", "bolt", "memory", "redis"}
+
+// Config selects and configures a backend
+type Config struct {
+	// Backend is one of Backends, or a persist database backend. The default is disk.
+	Backend string
+	// Path is where the disk or bolt backend keeps the cache, or the database for the persist database backends.
+	// The default for disk and bolt is in the user's cache directory.
+	Path string
+	// Addr is the host:port of the redis server
+	Addr string
+	// Password authenticates to the redis server, if it requires one
+	Password string
+	// TTL is how long the redis server keeps entries, or 0 to keep them until evicted
+	TTL time.Duration
+}
+
+// New returns an uninitialized cache using the configured backend
+func New(c Config) (Cacher, error) {
+	switch c.Backend {
+	case "bolt":
+		if c.Path == "" {
+			dir, err := DefaultDir()
+			if err != nil {
+				return nil, err
+			}
+			c.Path = filepath.Join(dir, "cache.bolt")
+		}
+		return newBolt(c.Path), nil
+	case "memory":
+		return newMemory(), nil
+	case "redis":
+		if c.Addr == "" {
+			return nil, fmt.Errorf("the redis backend requires an address")
+		}
+		return newRedis(c.Addr, c.Password, c.TTL), nil
+	}
+
+	p, err := persist.FromEnv(program, c.Backend, c.Path)
+	if err != nil {
+		return nil, fmt.Errorf("persist fromenv: %v", err)
+	}
+	if c.Backend == "" || c.Backend == "disk" {
+		return &disk{Cacher: p}, nil
+	}
+	return &database{Cacher: p, backend: c.Backend, values: newMemory()}, nil
+}
+
+// DefaultDir returns the directory in which caches are kept unless configured otherwise
+func DefaultDir() (string, error) {
+	root, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("cache dir: %v", err)
+	}
+	return filepath.Join(root, program), nil
+}
+
+// encodeBlob serializes a blob as the persist disk backend does, setting its Created time to now if it is zero
+func encodeBlob(b *persist.Blob) ([]byte, error) {
+	if b.Created.IsZero() {
+		b.Created = time.Now()
+	}
+
+	var bs bytes.Buffer
+	if err := gob.NewEncoder(&bs).Encode(b); err != nil {
+		return nil, fmt.Errorf("encode: %v", err)
+	}
+	return bs.Bytes(), nil
+}
+
+// decodeBlob deserializes a blob encoded by encodeBlob
+func decodeBlob(val []byte) (*persist.Blob, error) {
+	b := &persist.Blob{}
+	if err := gob.NewDecoder(bytes.NewReader(val)).Decode(b); err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+	return b, nil
+}
+
+// encodeValue serializes a value, setting its Created time to now if it is zero
+func encodeValue(v *Value) ([]byte, error) {
+	if v.Created.IsZero() {
+		v.Created = time.Now()
+	}
+
+	var bs bytes.Buffer
+	if err := gob.NewEncoder(&bs).Encode(v); err != nil {
+		return nil, fmt.Errorf("encode: %v", err)
+	}
+	return bs.Bytes(), nil
+}
+
+// decodeValue deserializes a value encoded by encodeValue
+func decodeValue(val []byte) (*Value, error) {
+	v := &Value{}
+	if err := gob.NewDecoder(bytes.NewReader(val)).Decode(v); err != nil {
+		return nil, fmt.Errorf("decode: %v", err)
+	}
+	return v, nil
+}
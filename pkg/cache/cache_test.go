@@ -0,0 +1,293 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/persist"
+	bolt "go.etcd.io/bbolt"
+)
+
+// backends returns an initialized cache of each backend which needs no server
+func backends(t *testing.T) map[string]Cacher {
+	t.Helper()
+	cs := map[string]Cacher{}
+	for _, c := range []Config{
+		{Backend: "memory"},
+		{Backend: "bolt", Path: filepath.Join(t.TempDir(), "cache.bolt")},
+	} {
+		p, err := New(c)
+		if err != nil {
+			t.Fatalf("New(%s) returned error: %v", c.Backend, err)
+		}
+		if err := p.Initialize(); err != nil {
+			t.Fatalf("%s Initialize() returned error: %v", c.Backend, err)
+		}
+		cs[c.Backend] = p
+	}
+	return cs
+}
+
+func TestBackendSetGet(t *testing.T) {
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			title := "first"
+			if err := c.Set("pr-org-project-1", &persist.Blob{GHIssue: &github.Issue{Title: &title}, Created: old}); err != nil {
+				t.Fatalf("Set() returned error: %v", err)
+			}
+
+			b := c.Get("pr-org-project-1", old)
+			if b == nil || b.GHIssue.GetTitle() != title || !b.Created.Equal(old) {
+				t.Errorf("Get() = %+v, want the blob created at %s", b, old)
+			}
+			if b := c.Get("pr-org-project-1", old.Add(time.Second)); b != nil {
+				t.Errorf("Get() of a blob created before t = %+v, want nil", b)
+			}
+			if b := c.Get("pr-org-project-2", time.Time{}); b != nil {
+				t.Errorf("Get() of a missing key = %+v, want nil", b)
+			}
+
+			// A zero Created time becomes now, and Set replaces what was stored
+			before := time.Now().Add(-time.Second)
+			title = "second"
+			if err := c.Set("pr-org-project-1", &persist.Blob{GHIssue: &github.Issue{Title: &title}}); err != nil {
+				t.Fatalf("Set() returned error: %v", err)
+			}
+			b = c.Get("pr-org-project-1", before)
+			if b == nil || b.GHIssue.GetTitle() != "second" {
+				t.Errorf("Get() after replacing = %+v, want the new blob created now", b)
+			}
+
+			// Keys are opaque
+			odd := "search/q=is:pr merged:>2021-03-01 ü\t"
+			if err := c.Set(odd, &persist.Blob{Created: old}); err != nil {
+				t.Fatalf("Set(%q) returned error: %v", odd, err)
+			}
+			if b := c.Get(odd, time.Time{}); b == nil {
+				t.Errorf("Get(%q) = nil, want the blob", odd)
+			}
+		})
+	}
+}
+
+func TestBackendSetGetValue(t *testing.T) {
+	cs := backends(t)
+	cs["disk"] = &disk{Cacher: dirCacher{dir: t.TempDir()}}
+	cs["mysql"] = &database{backend: "mysql", values: newMemory()}
+
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	for name, c := range cs {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"reviews-org-project-1", "contents-org-project:docs/OWNERS"} {
+				if err := c.SetValue(k, &Value{Data: []byte(`["a"]`), Created: old}); err != nil {
+					t.Fatalf("SetValue(%q) returned error: %v", k, err)
+				}
+				v := c.GetValue(k, old)
+				if v == nil || string(v.Data) != `["a"]` || !v.Created.Equal(old) {
+					t.Errorf("GetValue(%q) = %+v, want the value created at %s", k, v, old)
+				}
+				if v := c.GetValue(k, old.Add(time.Second)); v != nil {
+					t.Errorf("GetValue(%q) of a value created before t = %+v, want nil", k, v)
+				}
+			}
+			if v := c.GetValue("reviews-org-project-2", time.Time{}); v != nil {
+				t.Errorf("GetValue() of a missing key = %+v, want nil", v)
+			}
+
+			// Replacing sets the Created time to now
+			before := time.Now().Add(-time.Second)
+			if err := c.SetValue("reviews-org-project-1", &Value{Data: []byte(`[]`)}); err != nil {
+				t.Fatalf("SetValue() returned error: %v", err)
+			}
+			if v := c.GetValue("reviews-org-project-1", before); v == nil || string(v.Data) != `[]` {
+				t.Errorf("GetValue() after replacing = %+v, want the new value created now", v)
+			}
+		})
+	}
+}
+
+func TestBlobsAndValuesApart(t *testing.T) {
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			if err := c.SetValue("pr-org-project-1", &Value{Data: []byte("1")}); err != nil {
+				t.Fatal(err)
+			}
+			if b := c.Get("pr-org-project-1", time.Time{}); b != nil {
+				t.Errorf("Get() of a key holding a value = %+v, want nil", b)
+			}
+			if err := c.Set("pr-org-project-1", &persist.Blob{}); err != nil {
+				t.Fatal(err)
+			}
+			if v := c.GetValue("pr-org-project-1", time.Time{}); v == nil || string(v.Data) != "1" {
+				t.Errorf("GetValue() after setting a blob under its key = %+v, want the value", v)
+			}
+		})
+	}
+}
+
+func TestNewRequiresRedisAddr(t *testing.T) {
+	if _, err := New(Config{Backend: "redis"}); err == nil {
+		t.Errorf("New() of redis without an address returned no error")
+	}
+}
+
+func TestEntriesAndPurge(t *testing.T) {
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, c := range backends(t) {
+		t.Run(name, func(t *testing.T) {
+			for _, k := range []string{"pr-org-project-2", "issue-org-project-3", "pr-org-project-1"} {
+				if err := c.Set(k, &persist.Blob{Created: old}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := c.SetValue("reviews-org-project-1", &Value{Data: []byte("[]"), Created: old}); err != nil {
+				t.Fatal(err)
+			}
+
+			es, err := c.Entries()
+			if err != nil {
+				t.Fatalf("Entries() returned error: %v", err)
+			}
+			want := []string{"issue-org-project-3", "pr-org-project-1", "pr-org-project-2", "reviews-org-project-1"}
+			if got := entryKeys(es); !reflect.DeepEqual(got, want) {
+				t.Errorf("Entries() keys = %v, want %v", got, want)
+			}
+			for _, e := range es {
+				if e.Size <= 0 || !e.Created.Equal(old) {
+					t.Errorf("entry %s = %+v, want a size and created at %s", e.Key, e, old)
+				}
+			}
+
+			// Keys with nothing stored are skipped
+			if err := c.Purge([]string{"pr-org-project-1", "pr-org-project-9", "reviews-org-project-1"}); err != nil {
+				t.Fatalf("Purge() returned error: %v", err)
+			}
+			if b := c.Get("pr-org-project-1", time.Time{}); b != nil {
+				t.Errorf("Get() of a purged key = %+v, want nil", b)
+			}
+			if v := c.GetValue("reviews-org-project-1", time.Time{}); v != nil {
+				t.Errorf("GetValue() of a purged key = %+v, want nil", v)
+			}
+			es, err = c.Entries()
+			if err != nil {
+				t.Fatalf("Entries() returned error: %v", err)
+			}
+			if got, want := entryKeys(es), []string{"issue-org-project-3", "pr-org-project-2"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("Entries() keys after Purge() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+// dirCacher is a persist backend which only knows its directory
+type dirCacher struct {
+	persist.Cacher
+	dir string
+}
+
+func (d dirCacher) String() string {
+	return d.dir
+}
+
+func TestDiskEntriesAndPurge(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "pr"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, k := range []string{"pr/org-project-1", "issue-org-project-3"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.FromSlash(k)), []byte("blob"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	d := &disk{Cacher: dirCacher{dir: dir}}
+	if err := d.SetValue("reviews/org-project-1", &Value{Data: []byte("[]")}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Values are listed by their key, not the file holding them
+	es, err := d.Entries()
+	if err != nil {
+		t.Fatalf("Entries() returned error: %v", err)
+	}
+	if got, want := entryKeys(es), []string{"issue-org-project-3", "pr/org-project-1", "reviews/org-project-1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Entries() keys = %v, want %v", got, want)
+	}
+	if es[0].Size != 4 {
+		t.Errorf("entry size = %d, want 4", es[0].Size)
+	}
+
+	if err := d.Purge([]string{"pr/org-project-1", "reviews/org-project-1", "missing"}); err != nil {
+		t.Fatalf("Purge() returned error: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "pr", "org-project-1")); !os.IsNotExist(err) {
+		t.Errorf("purged file still exists: %v", err)
+	}
+	if v := d.GetValue("reviews/org-project-1", time.Time{}); v != nil {
+		t.Errorf("GetValue() of a purged key = %+v, want nil", v)
+	}
+
+	// A cache which hasn't been written to yet is empty, not an error
+	empty := &disk{Cacher: dirCacher{dir: filepath.Join(dir, "missing")}}
+	if es, err := empty.Entries(); err != nil || len(es) != 0 {
+		t.Errorf("Entries() of a missing directory = %v, %v, want none", es, err)
+	}
+}
+
+func TestDatabaseEntriesAndPurge(t *testing.T) {
+	d := &database{backend: "mysql"}
+	if _, err := d.Entries(); err == nil {
+		t.Errorf("Entries() of a database backend returned no error")
+	}
+	if err := d.Purge([]string{"key"}); err == nil {
+		t.Errorf("Purge() of a database backend returned no error")
+	}
+}
+
+// entryKeys returns the key of each entry, in order
+func entryKeys(es []Entry) []string {
+	keys := []string{}
+	for _, e := range es {
+		keys = append(keys, e.Key)
+	}
+	return keys
+}
+
+func TestBoltUndecodableEntry(t *testing.T) {
+	b := newBolt(filepath.Join(t.TempDir(), "cache.bolt"))
+	if err := b.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry written with types of another go-github version, or otherwise unreadable, is a miss, not a panic
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(boltBucket).Put([]byte("pr-org-project-1"), []byte("not a gob"))
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := b.Get("pr-org-project-1", time.Time{}); got != nil {
+		t.Errorf("Get() of an undecodable entry = %+v, want nil", got)
+	}
+}
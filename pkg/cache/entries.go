@@ -0,0 +1,314 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// disk is the persist disk backend, which keeps a file per key in the directory String returns. Values, which
+// persist.Blob can't hold, are kept as a file per key in diskValuesDir within it.
+type disk struct {
+	persist.Cacher
+}
+
+// diskValuesDir is the directory within the disk backend's which holds values
+const diskValuesDir = ".values"
+
+// database is a persist database backend, such as mysql, which can only get and set blobs. Values, which
+// persist.Blob can't hold, are kept in memory alone, so are fetched again by every run.
+type database struct {
+	persist.Cacher
+	backend string
+	values  *memory
+}
+
+func (d *database) SetValue(key string, v *Value) error {
+	return d.values.SetValue(key, v)
+}
+
+func (d *database) GetValue(key string, t time.Time) *Value {
+	return d.values.GetValue(key, t)
+}
+
+func (d *database) Entries() ([]Entry, error) {
+	return nil, fmt.Errorf("the %s cache backend can't list its entries", d.backend)
+}
+
+func (d *database) Purge([]string) error {
+	return fmt.Errorf("the %s cache backend can't purge entries", d.backend)
+}
+
+// valuePath returns the file holding the value for key
+func (d *disk) valuePath(key string) string {
+	return filepath.Join(d.String(), diskValuesDir, filepath.FromSlash(key))
+}
+
+// SetValue writes the value beside its file and moves it into place, so that a concurrent GetValue never reads a
+// partial one
+func (d *disk) SetValue(key string, v *Value) error {
+	val, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+
+	path := d.valuePath(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("mkdir: %v", err)
+	}
+	f, err := ioutil.TempFile(filepath.Dir(path), ".partial-")
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(val); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return nil
+}
+
+func (d *disk) GetValue(key string, t time.Time) *Value {
+	val, err := ioutil.ReadFile(d.valuePath(key))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Warningf("unreadable cache value for %v: %v", key, err)
+		}
+		return nil
+	}
+
+	v, err := decodeValue(val)
+	if err != nil {
+		logrus.Warningf("unreadable cache value for %v: %v", key, err)
+		return nil
+	}
+	if v.Created.Before(t) {
+		return nil
+	}
+	return v
+}
+
+// Entries uses the modification time of each file as when it was created, as the persist disk backend writes each
+// file once, and SetValue replaces rather than rewrites a value's
+func (d *disk) Entries() ([]Entry, error) {
+	values := filepath.Join(d.String(), diskValuesDir)
+	es, err := walkEntries(d.String(), values)
+	if err != nil {
+		return es, err
+	}
+	ves, err := walkEntries(values, "")
+	es = append(es, ves...)
+	sortEntries(es)
+	return es, err
+}
+
+// walkEntries describes each file within dir as an entry keyed by its path relative to dir, skipping the directory
+// skip. A missing dir has no entries.
+func walkEntries(dir string, skip string) ([]Entry, error) {
+	es := []Entry{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if path == dir && os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() && path == skip {
+			return filepath.SkipDir
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		es = append(es, Entry{Key: filepath.ToSlash(rel), Size: info.Size(), Created: info.ModTime()})
+		return nil
+	})
+	return es, err
+}
+
+func (d *disk) Purge(keys []string) error {
+	for _, k := range keys {
+		for _, path := range []string{filepath.Join(d.String(), filepath.FromSlash(k)), d.valuePath(k)} {
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Entries sizes each blob and value as it would be encoded by the other backends, as memory keeps them decoded
+func (m *memory) Entries() ([]Entry, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	es := []Entry{}
+	for k, b := range m.blobs {
+		val, err := encodeBlob(b)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, Entry{Key: k, Size: int64(len(val)), Created: b.Created})
+	}
+	for k, v := range m.values {
+		val, err := encodeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		es = append(es, Entry{Key: k, Size: int64(len(val)), Created: v.Created})
+	}
+	sortEntries(es)
+	return es, nil
+}
+
+func (m *memory) Purge(keys []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range keys {
+		delete(m.blobs, k)
+		delete(m.values, k)
+	}
+	return nil
+}
+
+func (b *boltDB) Entries() ([]Entry, error) {
+	es := []Entry{}
+	err := b.db.View(func(tx *bolt.Tx) error {
+		err := tx.Bucket(boltBucket).ForEach(func(k, val []byte) error {
+			bl, err := decodeBlob(val)
+			if err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+			es = append(es, Entry{Key: string(k), Size: int64(len(val)), Created: bl.Created})
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		return tx.Bucket(boltValueBucket).ForEach(func(k, val []byte) error {
+			v, err := decodeValue(val)
+			if err != nil {
+				return fmt.Errorf("%s: %v", k, err)
+			}
+			es = append(es, Entry{Key: string(k), Size: int64(len(val)), Created: v.Created})
+			return nil
+		})
+	})
+	sortEntries(es)
+	return es, err
+}
+
+func (b *boltDB) Purge(keys []string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{boltBucket, boltValueBucket} {
+			bucket := tx.Bucket(name)
+			for _, k := range keys {
+				if err := bucket.Delete([]byte(k)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	})
+}
+
+// Entries reads every blob and value from the server, as their creation time is only known once decoded. Unlike Get
+// and Set, it fails if the server can't be reached, rather than describe what is cached in memory alone.
+func (r *redisCache) Entries() ([]Entry, error) {
+	if r.isDown() {
+		return nil, fmt.Errorf("the redis server at %s can't be reached", r.addr)
+	}
+
+	es := []Entry{}
+	it := r.client.Scan(0, "", 1000).Iterator()
+	for it.Next() {
+		val, err := r.client.Get(it.Val()).Bytes()
+		if err == redis.Nil {
+			// Expired or purged since the scan
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if key := strings.TrimPrefix(it.Val(), redisValuePrefix); key != it.Val() {
+			v, err := decodeValue(val)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			es = append(es, Entry{Key: key, Size: int64(len(val)), Created: v.Created})
+			continue
+		}
+
+		b, err := decodeBlob(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", it.Val(), err)
+		}
+		es = append(es, Entry{Key: it.Val(), Size: int64(len(val)), Created: b.Created})
+	}
+	if err := it.Err(); err != nil && err != redis.Nil {
+		return nil, err
+	}
+	sortEntries(es)
+	return es, nil
+}
+
+// Purge fails if the server can't be reached, as only what is cached in memory could be removed
+func (r *redisCache) Purge(keys []string) error {
+	if err := r.mem.Purge(keys); err != nil {
+		return err
+	}
+	if r.isDown() {
+		return fmt.Errorf("the redis server at %s can't be reached", r.addr)
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	del := make([]string, 0, 2*len(keys))
+	for _, k := range keys {
+		del = append(del, k, redisValuePrefix+k)
+	}
+	return r.client.Del(del...).Err()
+}
+
+// sortEntries sorts entries by key
+func sortEntries(es []Entry) {
+	sort.Slice(es, func(i, j int) bool { return es[i].Key < es[j].Key })
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+)
+
+// memory keeps blobs and values for the life of the process, without touching the filesystem
+type memory struct {
+	mu     sync.RWMutex
+	blobs  map[string]*persist.Blob
+	values map[string]*Value
+}
+
+func newMemory() *memory {
+	return &memory{blobs: map[string]*persist.Blob{}, values: map[string]*Value{}}
+}
+
+func (m *memory) String() string {
+	return "memory"
+}
+
+func (m *memory) Initialize() error {
+	return nil
+}
+
+func (m *memory) Set(key string, b *persist.Blob) error {
+	if b.Created.IsZero() {
+		b.Created = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.blobs[key] = b
+	return nil
+}
+
+func (m *memory) Get(key string, t time.Time) *persist.Blob {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	b := m.blobs[key]
+	if b == nil || b.Created.Before(t) {
+		return nil
+	}
+	return b
+}
+
+func (m *memory) SetValue(key string, v *Value) error {
+	if v.Created.IsZero() {
+		v.Created = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values[key] = v
+	return nil
+}
+
+func (m *memory) GetValue(key string, t time.Time) *Value {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v := m.values[key]
+	if v == nil || v.Created.Before(t) {
+		return nil
+	}
+	return v
+}
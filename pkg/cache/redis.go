@@ -0,0 +1,172 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/sirupsen/logrus"
+)
+
+// redisCache keeps blobs on a redis server, so that they can be shared between machines. Blobs are also kept in
+// memory, which is all that is used once the server can't be reached, so that a run is slowed but not stopped.
+type redisCache struct {
+	addr     string
+	password string
+	ttl      time.Duration
+	client   *redis.Client
+	mem      *memory
+
+	mu   sync.Mutex
+	down bool
+}
+
+// redisValuePrefix is prepended to the key of each value, so that values are stored apart from blobs. Keys can't
+// contain a NUL, so no blob key can start with it.
+const redisValuePrefix = "\x00value\x00"
+
+func newRedis(addr string, password string, ttl time.Duration) *redisCache {
+	return &redisCache{addr: addr, password: password, ttl: ttl, mem: newMemory()}
+}
+
+func (r *redisCache) String() string {
+	return "redis://" + r.addr
+}
+
+func (r *redisCache) Initialize() error {
+	r.client = redis.NewClient(&redis.Options{Addr: r.addr, Password: r.password})
+	if err := r.client.Ping().Err(); err != nil {
+		r.fail(err)
+	}
+	return nil
+}
+
+// fail stops using the redis server, warning the first time
+func (r *redisCache) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.down {
+		logrus.Warningf("redis cache at %s failed, caching in memory instead: %v", r.addr, err)
+	}
+	r.down = true
+}
+
+func (r *redisCache) isDown() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.down
+}
+
+func (r *redisCache) Set(key string, b *persist.Blob) error {
+	if err := r.mem.Set(key, b); err != nil {
+		return err
+	}
+	if r.isDown() {
+		return nil
+	}
+
+	val, err := encodeBlob(b)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.Set(key, val, r.ttl).Err(); err != nil {
+		r.fail(err)
+	}
+	return nil
+}
+
+func (r *redisCache) Get(key string, t time.Time) *persist.Blob {
+	if b := r.mem.Get(key, t); b != nil {
+		return b
+	}
+	if r.isDown() {
+		return nil
+	}
+
+	val, err := r.client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+
+	b, err := decodeBlob(val)
+	if err != nil {
+		logrus.Warningf("unreadable cache entry for %v: %v", key, err)
+		return nil
+	}
+
+	if b.Created.Before(t) {
+		return nil
+	}
+	r.mem.Set(key, b)
+	return b
+}
+
+func (r *redisCache) SetValue(key string, v *Value) error {
+	if err := r.mem.SetValue(key, v); err != nil {
+		return err
+	}
+	if r.isDown() {
+		return nil
+	}
+
+	val, err := encodeValue(v)
+	if err != nil {
+		return err
+	}
+
+	if err := r.client.Set(redisValuePrefix+key, val, r.ttl).Err(); err != nil {
+		r.fail(err)
+	}
+	return nil
+}
+
+func (r *redisCache) GetValue(key string, t time.Time) *Value {
+	if v := r.mem.GetValue(key, t); v != nil {
+		return v
+	}
+	if r.isDown() {
+		return nil
+	}
+
+	val, err := r.client.Get(redisValuePrefix + key).Bytes()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+
+	v, err := decodeValue(val)
+	if err != nil {
+		logrus.Warningf("unreadable cache value for %v: %v", key, err)
+		return nil
+	}
+
+	if v.Created.Before(t) {
+		return nil
+	}
+	r.mem.SetValue(key, v)
+	return v
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+)
+
+func TestRedisUnreachable(t *testing.T) {
+	// Nothing listens on port 1, so the server is down from the start
+	r := newRedis("127.0.0.1:1", "", 0)
+	if err := r.Initialize(); err != nil {
+		t.Fatalf("Initialize() returned error: %v", err)
+	}
+	if !r.isDown() {
+		t.Fatalf("isDown() = false, want true")
+	}
+
+	// Blobs are still cached in memory, so the run is slowed but not stopped
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := r.Set("pr-org-project-1", &persist.Blob{Created: old}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+	if b := r.Get("pr-org-project-1", old); b == nil {
+		t.Errorf("Get() = nil, want the blob cached in memory")
+	}
+
+	if _, err := r.Entries(); err == nil {
+		t.Errorf("Entries() of an unreachable server returned no error")
+	}
+	if err := r.Purge([]string{"pr-org-project-1"}); err == nil {
+		t.Errorf("Purge() of an unreachable server returned no error")
+	}
+}
+
+func TestRedisRoundTrip(t *testing.T) {
+	addr := os.Getenv("REDIS_ADDR")
+	if addr == "" {
+		t.Skip("set REDIS_ADDR to the host:port of a scratch redis server to test against it")
+	}
+
+	w := newRedis(addr, os.Getenv("REDIS_PASSWORD"), time.Minute)
+	if err := w.Initialize(); err != nil || w.isDown() {
+		t.Fatalf("Initialize() = %v, down = %v", err, w.isDown())
+	}
+	key := "pullsheet-test-" + time.Now().Format(time.RFC3339Nano)
+	defer w.Purge([]string{key})
+
+	old := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	if err := w.Set(key, &persist.Blob{Created: old}); err != nil {
+		t.Fatalf("Set() returned error: %v", err)
+	}
+
+	// A second client, such as another CI runner, reads it from the server rather than memory
+	r := newRedis(addr, os.Getenv("REDIS_PASSWORD"), time.Minute)
+	if err := r.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if b := r.Get(key, old); b == nil || !b.Created.Equal(old) {
+		t.Errorf("Get() = %+v, want the blob created at %s", b, old)
+	}
+	if b := r.Get(key, old.Add(time.Second)); b != nil {
+		t.Errorf("Get() of a blob created before t = %+v, want nil", b)
+	}
+}
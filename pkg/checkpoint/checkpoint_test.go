@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package checkpoint
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+type progress struct {
+	Done  []string `json:"done"`
+	Count int      `json:"count"`
+}
+
+func TestSaveLoad(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "progress.json")
+
+	want := progress{Done: []string{"2021-01", "2021-02"}, Count: 42}
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	// Overwrites the previous checkpoint
+	want.Count = 43
+	if err := Save(path, want); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got := progress{}
+	ok, err := Load(path, &got)
+	if err != nil || !ok {
+		t.Fatalf("Load() = %v, %v, want true, nil", ok, err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Load() read %+v, want %+v", got, want)
+	}
+
+	// No temporary files are left behind
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%s has %d entries, want only the checkpoint", dir, len(entries))
+	}
+}
+
+func TestLoadMissing(t *testing.T) {
+	ok, err := Load(filepath.Join(t.TempDir(), "none.json"), &progress{})
+	if ok || err != nil {
+		t.Errorf("Load() = %v, %v for a missing checkpoint, want false, nil", ok, err)
+	}
+}
+
+func TestLoadCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.json")
+	if err := ioutil.WriteFile(path, []byte(`{"done": [`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if ok, err := Load(path, &progress{}); ok || err == nil {
+		t.Errorf("Load() = %v, %v for a truncated checkpoint, want an error", ok, err)
+	}
+}
+
+func TestSaveUnwritable(t *testing.T) {
+	if err := Save(filepath.Join(t.TempDir(), "missing", "progress.json"), progress{}); err == nil {
+		t.Errorf("Save() into a missing directory succeeded")
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Auth is what GitHub says about the credentials a client uses
+type Auth struct {
+	Login string `json:"login"`
+	// Classic is whether the token is a classic personal access or OAuth token, the only kinds which report scopes
+	Classic bool     `json:"classic"`
+	Scopes  []string `json:"scopes"`
+	// Rate is the core API rate limit when the credentials were checked
+	Rate    github.Rate `json:"rate"`
+	Checked time.Time   `json:"checked"`
+}
+
+// String describes the credentials on one line
+func (a *Auth) String() string {
+	scopes := "not reported, as it isn't a classic token"
+	if a.Classic {
+		scopes = strings.Join(a.Scopes, ", ")
+		if scopes == "" {
+			scopes = "none"
+		}
+	}
+	return fmt.Sprintf("authenticated as %s, scopes: %s, %d of %d requests remaining until %s",
+		a.Login, scopes, a.Rate.Remaining, a.Rate.Limit, a.Rate.Reset.Format("15:04"))
+}
+
+// hasScope returns whether a classic token has a scope, or any token, as others don't report theirs
+func (a *Auth) hasScope(scope string) bool {
+	if !a.Classic {
+		return true
+	}
+	for _, s := range a.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifyAuth checks that GitHub accepts the client's credentials, recording what it says about them in c.Auth,
+// and that they can see each org/project repository, with an error saying what to do if not
+func (c *Client) VerifyAuth(ctx context.Context, repos []string) (*Auth, error) {
+	u, resp, err := c.GitHubClient.Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return nil, fmt.Errorf("GitHub rejected the token, which may have expired or been revoked: create a new one at %s/settings/tokens: %w", WebURL(c.GitHubClient.BaseURL.String()), err)
+		}
+		return nil, fmt.Errorf("checking the token: %w", err)
+	}
+
+	a := &Auth{Login: u.GetLogin(), Checked: time.Now()}
+	if _, ok := resp.Header[http.CanonicalHeaderKey("X-OAuth-Scopes")]; ok {
+		a.Classic = true
+		for _, s := range strings.Split(resp.Header.Get("X-OAuth-Scopes"), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				a.Scopes = append(a.Scopes, s)
+			}
+		}
+	}
+
+	r, err := c.Quota(ctx)
+	if err != nil {
+		return nil, err
+	}
+	a.Rate = *r
+	c.Auth = a
+
+	unseen := []string{}
+	for _, rp := range repos {
+		parts := strings.Split(rp, "/")
+		if len(parts) != 2 || parts[1] == "*" {
+			continue
+		}
+		_, _, err := c.GitHubClient.Repositories.Get(ctx, parts[0], parts[1])
+		if IsNotFound(err) {
+			unseen = append(unseen, rp)
+			continue
+		}
+		if err != nil {
+			return a, fmt.Errorf("checking %s: %w", rp, err)
+		}
+	}
+
+	if len(unseen) > 0 {
+		hint := "check the names, and that the token has been granted access to them"
+		if !a.hasScope("repo") {
+			hint = fmt.Sprintf("if they are private, the token needs the repo scope, but has only: %s", strings.Join(a.Scopes, ", "))
+		}
+		return a, fmt.Errorf("%s can't see %s: %s", a.Login, strings.Join(unseen, ", "), hint)
+	}
+	return a, nil
+}
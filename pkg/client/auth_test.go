@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// authServer returns a GitHub API which answers with scopes, if set, and can see the org/visible repository alone
+func authServer(t *testing.T, status int, scopes *string) *Client {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v3/user":
+			if scopes != nil {
+				w.Header().Set("X-OAuth-Scopes", *scopes)
+			}
+			w.WriteHeader(status)
+			w.Write([]byte(`{"login": "octocat"}`))
+		case "/api/v3/rate_limit":
+			w.Write([]byte(`{"resources": {"core": {"limit": 5000, "remaining": 4321, "reset": 1614556800}}}`))
+		case "/api/v3/repos/org/visible":
+			w.Write([]byte(`{"full_name": "org/visible"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "Not Found"}`))
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	gc, err := githubClient(srv.Client(), Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{GitHubClient: gc}
+}
+
+func TestVerifyAuth(t *testing.T) {
+	classic := "read:org, repo"
+	noRepo := "read:org"
+	empty := ""
+
+	tests := []struct {
+		name        string
+		status      int
+		scopes      *string
+		repos       []string
+		wantClassic bool
+		wantScopes  []string
+		wantErr     string
+	}{
+		{name: "classic", status: http.StatusOK, scopes: &classic, repos: []string{"org/visible", "org/*"}, wantClassic: true, wantScopes: []string{"read:org", "repo"}},
+		{name: "fine-grained", status: http.StatusOK, repos: []string{"org/visible"}},
+		{name: "no scopes", status: http.StatusOK, scopes: &empty, wantClassic: true},
+		{name: "unseen", status: http.StatusOK, scopes: &classic, repos: []string{"org/visible", "org/hidden"}, wantClassic: true, wantScopes: []string{"read:org", "repo"}, wantErr: "octocat can't see org/hidden: check the names"},
+		{name: "unseen without repo scope", status: http.StatusOK, scopes: &noRepo, repos: []string{"org/hidden"}, wantClassic: true, wantScopes: []string{"read:org"}, wantErr: "the token needs the repo scope"},
+		{name: "unseen fine-grained", status: http.StatusOK, repos: []string{"org/hidden"}, wantErr: "check the names"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c := authServer(t, tc.status, tc.scopes)
+			a, err := c.VerifyAuth(context.Background(), tc.repos)
+			if tc.wantErr == "" && err != nil {
+				t.Fatalf("VerifyAuth() returned error: %v", err)
+			}
+			if tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)) {
+				t.Fatalf("VerifyAuth() error = %v, want one containing %q", err, tc.wantErr)
+			}
+
+			if a.Login != "octocat" || a.Classic != tc.wantClassic || !reflect.DeepEqual(a.Scopes, tc.wantScopes) {
+				t.Errorf("VerifyAuth() = %+v, want octocat, classic %v, scopes %v", a, tc.wantClassic, tc.wantScopes)
+			}
+			if a.Rate.Remaining != 4321 || c.Auth != a {
+				t.Errorf("VerifyAuth() rate = %d, recorded = %v, want 4321 recorded on the client", a.Rate.Remaining, c.Auth == a)
+			}
+		})
+	}
+}
+
+func TestVerifyAuthRejected(t *testing.T) {
+	c := authServer(t, http.StatusUnauthorized, nil)
+	_, err := c.VerifyAuth(context.Background(), nil)
+	if err == nil || !strings.Contains(err.Error(), "GitHub rejected the token") {
+		t.Errorf("VerifyAuth() error = %v, want a rejected token", err)
+	}
+	if c.Auth != nil {
+		t.Errorf("Auth = %+v, want nil after a rejected token", c.Auth)
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+// policyCache applies a freshness policy to every read of a cache, whatever timestamp the reader asks for.
+// Writes pass straight through, so that later runs can use them.
+type policyCache struct {
+	cache.Cacher
+	// ttl is how old an entry may be before it is refetched, or 0 for no limit
+	ttl time.Duration
+	// bypass ignores every entry, as if the cache were empty
+	bypass bool
+}
+
+// Get returns the entry for key if it was created after both t and the TTL
+func (c *policyCache) Get(key string, t time.Time) *persist.Blob {
+	if c.bypass {
+		return nil
+	}
+	return c.Cacher.Get(key, c.cutoff(t))
+}
+
+// GetValue returns the value for key if it was created after both t and the TTL
+func (c *policyCache) GetValue(key string, t time.Time) *cache.Value {
+	if c.bypass {
+		return nil
+	}
+	return c.Cacher.GetValue(key, c.cutoff(t))
+}
+
+// cutoff returns the later of t and the TTL
+func (c *policyCache) cutoff(t time.Time) time.Time {
+	if c.ttl > 0 {
+		if cutoff := time.Now().Add(-c.ttl); cutoff.After(t) {
+			return cutoff
+		}
+	}
+	return t
+}
+
+// NewCache returns the initialized cache backend a client with this configuration reads and writes, without its
+// freshness policy. It needs no GitHub token, so suits commands which only manage the cache.
+func NewCache(c Config) (cache.Cacher, error) {
+	if c.PersistBackend == "" {
+		c.PersistBackend = os.Getenv("PERSIST_BACKEND")
+	}
+
+	if c.PersistPath == "" {
+		c.PersistPath = os.Getenv("PERSIST_PATH")
+	}
+
+	if c.CachePassword == "" {
+		c.CachePassword = os.Getenv("REDIS_PASSWORD")
+	}
+
+	// Entries older than the TTL are refetched anyway, so the redis server needn't keep them
+	p, err := cache.New(cache.Config{
+		Backend:  c.PersistBackend,
+		Path:     c.PersistPath,
+		Addr:     c.CacheAddr,
+		Password: c.CachePassword,
+		TTL:      c.CacheTTL,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.Initialize(); err != nil {
+		return nil, fmt.Errorf("cache init: %v", err)
+	}
+	return p, nil
+}
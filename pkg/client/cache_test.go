@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+func TestPolicyCache(t *testing.T) {
+	now := time.Now()
+	week := now.Add(-7 * 24 * time.Hour)
+	hour := now.Add(-time.Hour)
+
+	tests := []struct {
+		name   string
+		ttl    time.Duration
+		bypass bool
+		since  time.Time
+		want   map[string]bool
+	}{
+		{name: "no policy", want: map[string]bool{"week": true, "hour": true}},
+		{name: "ttl", ttl: 24 * time.Hour, want: map[string]bool{"hour": true}},
+		{name: "reader asks for newer than the ttl", ttl: 30 * 24 * time.Hour, since: hour.Add(-time.Minute), want: map[string]bool{"hour": true}},
+		{name: "bypass", bypass: true, want: map[string]bool{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := cache.New(cache.Config{Backend: "memory"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c := &policyCache{Cacher: p, ttl: tc.ttl, bypass: tc.bypass}
+
+			// Writes pass through, whatever the policy
+			for k, created := range map[string]time.Time{"week": week, "hour": hour} {
+				if err := c.Set(k, &persist.Blob{Created: created}); err != nil {
+					t.Fatal(err)
+				}
+				if p.Get(k, time.Time{}) == nil {
+					t.Errorf("Set(%s) didn't reach the backend", k)
+				}
+			}
+
+			for _, k := range []string{"week", "hour"} {
+				if got := c.Get(k, tc.since) != nil; got != tc.want[k] {
+					t.Errorf("Get(%s) found = %v, want %v", k, got, tc.want[k])
+				}
+			}
+		})
+	}
+}
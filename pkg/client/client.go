@@ -17,63 +17,186 @@ package client
 import (
 	"context"
 	"fmt"
-	"io/ioutil"
+	"net/http"
+	"net/url"
 	"os"
-	"strings"
+	"time"
 
 	"github.com/google/go-github/v33/github"
-	"golang.org/x/oauth2"
+	"github.com/sirupsen/logrus"
 
-	"github.com/google/triage-party/pkg/persist"
+	"github.com/google/pullsheet/pkg/cache"
 )
 
 type Client struct {
-	Cache        persist.Cacher
+	Cache        cache.Cacher
 	GitHubClient *github.Client
+	// Budget is the core API rate limit, as of the latest response
+	Budget *Budget
+	// Auth is what GitHub said about the client's credentials, once VerifyAuth has checked them
+	Auth *Auth
 }
 
+// DefaultWebURL is the web address of github.com, whose API is used unless a GitHub Enterprise Server's is configured
+const DefaultWebURL = "https://github.com"
+
 type Config struct {
+	// GitHubTokenPath is a file holding a GitHub token, or a directory of them, used if GitHubToken is empty
 	GitHubTokenPath string
-	GitHubToken     string
-	PersistBackend  string
-	PersistPath     string
+	// GitHubToken is a GitHub token, or several separated by commas, which are rotated through as each one's rate
+	// limit runs out. The default is $GITHUB_TOKEN, or $GH_TOKEN.
+	GitHubToken string
+	// BaseURL is the API address of a GitHub Enterprise Server, such as https://github.example.com/api/v3/, or just
+	// the server's address. The default is $GITHUB_API_URL, or github.com's API.
+	BaseURL string
+	// UploadURL is the upload API address of a GitHub Enterprise Server. The default is the server's address.
+	UploadURL string
+	// PersistBackend names the cache backend, as listed in cache.Backends. The default is disk.
+	PersistBackend string
+	// PersistPath is where the disk or bolt backend keeps the cache, or the database for the others
+	PersistPath string
+	// CacheAddr is the host:port of the redis backend's server
+	CacheAddr string
+	// CachePassword authenticates to the redis backend's server. The default is $REDIS_PASSWORD.
+	CachePassword string
+
+	// CacheTTL refetches cached entries older than this, however recent the data they hold, if set
+	CacheTTL time.Duration
+	// NoCache refetches everything, but still caches the results for later runs
+	NoCache bool
+
+	// MinRateRemaining pauses requests until the rate limit resets when fewer than this remain, if set
+	MinRateRemaining int
+
+	// MaxRetries is how many times a failed request is resent, or DefaultRetries if nil
+	MaxRetries *int
+	// CallTimeout is how long each attempt at a request may take, or 0 for no limit
+	CallTimeout time.Duration
+
+	// Proxy is the URL of a proxy to send requests through. The default is $HTTPS_PROXY, less $NO_PROXY.
+	Proxy string
+	// CABundle is a PEM file of certificates to trust besides the system's, such as those of a proxy
+	CABundle string
+	// DialTimeout is how long connecting may take, and ResponseTimeout how long a response's headers may take to
+	// arrive once a request is sent, or 0 for the defaults
+	DialTimeout     time.Duration
+	ResponseTimeout time.Duration
+	// LogRequests logs the method, URL, status, and duration of every request sent
+	LogRequests bool
+	// Transport sends requests instead of one built from the settings above, if set. Authentication, retries, and
+	// request logging still wrap it.
+	Transport http.RoundTripper
+
+	// Offline refuses every request to GitHub, so that only cached data is used. No token is needed.
+	Offline bool
 }
 
-func New(ctx context.Context, c Config) (*Client, error) {
-	if c.PersistBackend == "" {
-		c.PersistBackend = os.Getenv("PERSIST_BACKEND")
+// offlineTransport fails every request, so that nothing reaches GitHub while offline
+type offlineTransport struct{}
+
+func (offlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return nil, fmt.Errorf("offline, not requesting %s", req.URL.Path)
+}
+
+// WebURL returns the web address of the GitHub instance whose API is at apiURL, for links: github.com's if apiURL is
+// empty or github.com's API, or otherwise apiURL's scheme and host, as a GitHub Enterprise Server serves both.
+func WebURL(apiURL string) string {
+	u, err := url.Parse(apiURL)
+	if err != nil || u.Host == "" || u.Host == "api.github.com" {
+		return DefaultWebURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// apiURL returns the configured API address: BaseURL, $GITHUB_API_URL, or empty for github.com's
+func apiURL(c Config) string {
+	if c.BaseURL == "" {
+		return os.Getenv("GITHUB_API_URL")
 	}
+	return c.BaseURL
+}
 
-	if c.PersistPath == "" {
-		c.PersistPath = os.Getenv("PERSIST_PATH")
+// githubClient returns a client of the configured GitHub instance, making requests with hc
+func githubClient(hc *http.Client, c Config) (*github.Client, error) {
+	c.BaseURL = apiURL(c)
+	if WebURL(c.BaseURL) == DefaultWebURL {
+		return github.NewClient(hc), nil
 	}
 
-	if c.GitHubToken == "" {
-		c.GitHubToken = os.Getenv("GITHUB_TOKEN")
+	// go-github appends api/uploads/ to it, so it can't default to an API address ending in api/v3/
+	if c.UploadURL == "" {
+		c.UploadURL = WebURL(c.BaseURL)
+	}
+	gc, err := github.NewEnterpriseClient(c.BaseURL, c.UploadURL, hc)
+	if err != nil {
+		return nil, fmt.Errorf("github enterprise client: %w", err)
 	}
+	logrus.Infof("Using the GitHub API at %s", gc.BaseURL)
+	return gc, nil
+}
 
-	if c.GitHubToken == "" {
-		bs, err := ioutil.ReadFile(c.GitHubTokenPath)
+func New(ctx context.Context, c Config) (*Client, error) {
+	if c.Offline {
+		p, err := NewCache(c)
+		if err != nil {
+			return nil, err
+		}
+		gc, err := githubClient(&http.Client{Transport: offlineTransport{}}, c)
 		if err != nil {
 			return nil, err
 		}
-		c.GitHubToken = strings.TrimSpace(string(bs))
+		return &Client{
+			Cache:        p,
+			GitHubClient: gc,
+			Budget:       &Budget{},
+		}, nil
+	}
+
+	ts, src, err := tokens(c)
+	if err != nil {
+		return nil, err
+	}
+	logrus.Infof("Using GitHub credentials from %s", src)
+
+	retries := DefaultRetries
+	if c.MaxRetries != nil {
+		retries = *c.MaxRetries
+	}
+
+	if len(ts) > 1 {
+		logrus.Infof("Using %d GitHub tokens, switching when one's rate limit runs out", len(ts))
+	}
+
+	rt, err := httpTransport(c)
+	if err != nil {
+		return nil, err
 	}
 
-	tc := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: c.GitHubToken}))
-	gc := github.NewClient(tc)
+	budget := &Budget{floor: c.MinRateRemaining}
+	tc := &http.Client{Transport: &retryTransport{
+		base:     newTokenTransport(rt, ts),
+		attempts: retries + 1,
+		backoff:  defaultBackoff,
+		budget:   budget,
+		timeout:  c.CallTimeout,
+	}}
+	gc, err := githubClient(tc, c)
+	if err != nil {
+		return nil, err
+	}
 
-	p, err := persist.FromEnv("pullsheet", c.PersistBackend, c.PersistPath)
+	p, err := NewCache(c)
 	if err != nil {
-		return nil, fmt.Errorf("persist fromenv: %v", err)
+		return nil, err
 	}
 
-	if err := p.Initialize(); err != nil {
-		return nil, fmt.Errorf("persist init: %v", err)
+	if c.CacheTTL > 0 || c.NoCache {
+		p = &policyCache{Cacher: p, ttl: c.CacheTTL, bypass: c.NoCache}
 	}
 
 	return &Client{
 		Cache:        p,
 		GitHubClient: gc,
+		Budget:       budget,
 	}, nil
 }
@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+)
+
+func TestWebURL(t *testing.T) {
+	tests := []struct {
+		api  string
+		want string
+	}{
+		{api: "", want: DefaultWebURL},
+		{api: "https://api.github.com/", want: DefaultWebURL},
+		{api: "https://github.example.com/api/v3/", want: "https://github.example.com"},
+		{api: "http://github.example.com:8080", want: "http://github.example.com:8080"},
+		{api: "github.example.com", want: DefaultWebURL},
+	}
+
+	for _, tc := range tests {
+		if got := WebURL(tc.api); got != tc.want {
+			t.Errorf("WebURL(%q) = %q, want %q", tc.api, got, tc.want)
+		}
+	}
+}
+
+func TestGithubClient(t *testing.T) {
+	old, ok := os.LookupEnv("GITHUB_API_URL")
+	defer func() {
+		if ok {
+			os.Setenv("GITHUB_API_URL", old)
+		} else {
+			os.Unsetenv("GITHUB_API_URL")
+		}
+	}()
+	os.Unsetenv("GITHUB_API_URL")
+
+	tests := []struct {
+		name    string
+		c       Config
+		env     string
+		want    string
+		wantUpl string
+	}{
+		{name: "github.com", want: "https://api.github.com/", wantUpl: "https://uploads.github.com/"},
+		{name: "server address", c: Config{BaseURL: "https://github.example.com"}, want: "https://github.example.com/api/v3/", wantUpl: "https://github.example.com/api/uploads/"},
+		{name: "upload address", c: Config{BaseURL: "https://github.example.com/api/v3/", UploadURL: "https://uploads.example.com/"}, want: "https://github.example.com/api/v3/", wantUpl: "https://uploads.example.com/api/uploads/"},
+		{name: "environment", env: "https://github.example.com/api/v3/", want: "https://github.example.com/api/v3/", wantUpl: "https://github.example.com/api/uploads/"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			os.Setenv("GITHUB_API_URL", tc.env)
+			gc, err := githubClient(http.DefaultClient, tc.c)
+			if err != nil {
+				t.Fatalf("githubClient() returned error: %v", err)
+			}
+			if got := gc.BaseURL.String(); got != tc.want {
+				t.Errorf("BaseURL = %s, want %s", got, tc.want)
+			}
+			if got := gc.UploadURL.String(); got != tc.wantUpl {
+				t.Errorf("UploadURL = %s, want %s", got, tc.wantUpl)
+			}
+		})
+	}
+}
+
+func TestEnterpriseRequests(t *testing.T) {
+	paths := []string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Write([]byte(`{"full_name": "org/project"}`))
+	}))
+	defer srv.Close()
+
+	gc, err := githubClient(srv.Client(), Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, err := gc.Repositories.Get(context.Background(), "org", "project")
+	if err != nil {
+		t.Fatalf("Get() returned error: %v", err)
+	}
+	if r.GetFullName() != "org/project" || len(paths) != 1 || paths[0] != "/api/v3/repos/org/project" {
+		t.Errorf("requested %v for %s, want /api/v3/repos/org/project", paths, r.GetFullName())
+	}
+}
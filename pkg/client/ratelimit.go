@@ -0,0 +1,152 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+)
+
+// Quota returns the current core API rate limit
+func (c *Client) Quota(ctx context.Context) (*github.Rate, error) {
+	rl, _, err := c.GitHubClient.RateLimits(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("rate limits: %v", err)
+	}
+	if rl.GetCore() == nil {
+		return nil, fmt.Errorf("no core rate limit returned")
+	}
+	return rl.GetCore(), nil
+}
+
+// WaitForQuota blocks until at least floor core API requests remain, sleeping until the limit resets if needed
+func (c *Client) WaitForQuota(ctx context.Context, floor int) (*github.Rate, error) {
+	for {
+		r, err := c.Quota(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if r.Remaining >= floor {
+			return r, nil
+		}
+
+		// A little slack, as GitHub's clock and ours may disagree
+		wait := time.Until(r.Reset.Time) + 5*time.Second
+		logrus.Infof("%d API requests remaining, below %d: pausing %s until the limit resets", r.Remaining, floor, wait.Round(time.Second))
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// Budget tracks the core API rate limit from the headers of every response, and pauses requests when it runs low
+type Budget struct {
+	mu        sync.Mutex
+	limit     int
+	remaining int
+	reset     time.Time
+	// floor is how few requests may remain before requests pause until the limit resets, or 0 to never pause
+	floor int
+	// waiting is when the limit resets, while requests are paused for it
+	waiting time.Time
+}
+
+// BudgetStatus is the state of a Budget at a point in time. Limit is 0 until a response has been seen.
+type BudgetStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+	// WaitingUntil is when requests paused for the rate limit resume, or zero if they aren't paused
+	WaitingUntil time.Time
+}
+
+// Status returns the current state of the budget
+func (b *Budget) Status() BudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return BudgetStatus{Limit: b.limit, Remaining: b.remaining, Reset: b.reset, WaitingUntil: b.waiting}
+}
+
+// update records the rate limit headers of a response
+func (b *Budget) update(h http.Header) {
+	// Other resources, such as search, have limits of their own
+	if r := h.Get("X-RateLimit-Resource"); r != "" && r != "core" {
+		return
+	}
+
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.limit = limit
+	b.remaining = remaining
+	b.reset = time.Unix(reset, 0)
+}
+
+// wait blocks until the limit resets, if fewer requests than the floor remain
+func (b *Budget) wait(ctx context.Context) error {
+	b.mu.Lock()
+	if b.floor == 0 || b.limit == 0 || b.remaining >= b.floor || time.Now().After(b.reset) {
+		b.mu.Unlock()
+		return nil
+	}
+
+	reset := b.reset
+	if !b.waiting.Equal(reset) {
+		b.waiting = reset
+		logrus.Warningf("%d API requests remaining, below --min-rate-remaining of %d: pausing until the limit resets at %s, in %s",
+			b.remaining, b.floor, reset.Format("15:04"), time.Until(reset).Round(time.Second))
+	}
+	b.mu.Unlock()
+
+	// A little slack, as GitHub's clock and ours may disagree
+	if err := sleep(ctx, time.Until(reset)+time.Second); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.waiting.Equal(reset) {
+		b.waiting = time.Time{}
+		// The next response will say, but the limit has been reset
+		if !b.reset.After(reset) {
+			b.remaining = b.limit
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// quotaServer serves a core rate limit with the given requests remaining, resetting in an hour
+func quotaServer(t *testing.T, remaining int) *Client {
+	t.Helper()
+	reset := time.Now().Add(time.Hour).Unix()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"resources": {"core": {"limit": 5000, "remaining": %d, "reset": %d}}}`, remaining, reset)
+	}))
+	t.Cleanup(srv.Close)
+
+	gc, err := githubClient(srv.Client(), Config{BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Client{GitHubClient: gc}
+}
+
+func TestWaitForQuota(t *testing.T) {
+	r, err := quotaServer(t, 4321).WaitForQuota(context.Background(), 1000)
+	if err != nil {
+		t.Fatalf("WaitForQuota() returned error: %v", err)
+	}
+	if r.Remaining != 4321 || r.Limit != 5000 {
+		t.Errorf("WaitForQuota() = %d of %d remaining, want 4321 of 5000", r.Remaining, r.Limit)
+	}
+}
+
+func TestWaitForQuotaPauses(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Below the floor, it waits for the reset an hour away, until cancelled
+	start := time.Now()
+	_, err := quotaServer(t, 10).WaitForQuota(ctx, 1000)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForQuota() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("WaitForQuota() took %s to notice cancellation", elapsed)
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRetries is how many times a failed request is resent before its failure is returned
+	DefaultRetries = 4
+	// defaultBackoff is how long to wait before the first retry of a failed request, doubling for each retry after
+	defaultBackoff = time.Second
+)
+
+// retryTransport resends GitHub API requests which fail for reasons that pass: rate limits, server errors, and
+// dropped connections. Rate limited requests wait as long as GitHub asks, others back off exponentially.
+type retryTransport struct {
+	base     http.RoundTripper
+	attempts int
+	backoff  time.Duration
+	budget   *Budget
+	// timeout is how long each attempt may take, or 0 for no limit
+	timeout time.Duration
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// A request body can only be read once, so can't be resent unless it can be recreated
+	resendable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+
+	backoff := t.backoff
+	for attempt := 1; ; attempt++ {
+		r := req
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r = req.Clone(req.Context())
+			r.Body = body
+		}
+
+		if err := t.budget.wait(req.Context()); err != nil {
+			return nil, err
+		}
+
+		resp, err := t.roundTrip(r)
+		if resp != nil {
+			t.budget.update(resp.Header)
+		}
+
+		wait, retry := retryWait(req.Context(), resp, err, backoff)
+		if !retry || attempt >= t.attempts || !resendable {
+			return resp, err
+		}
+
+		if resp != nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+			logrus.Warningf("%s %s: %s, retrying in %s (attempt %d of %d)", req.Method, req.URL.Path, resp.Status, wait.Round(time.Second), attempt, t.attempts)
+		} else {
+			logrus.Warningf("%s %s: %v, retrying in %s (attempt %d of %d)", req.Method, req.URL.Path, err, wait.Round(time.Second), attempt, t.attempts)
+		}
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+		backoff *= 2
+	}
+}
+
+// roundTrip sends a single attempt at a request, within the per-attempt timeout.
+// The timeout applies to this attempt alone, not the request's context, which later requests may share.
+func (t *retryTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	if t.timeout == 0 {
+		return t.base.RoundTrip(req)
+	}
+
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// The body is read after RoundTrip returns, so the timeout lasts until it is closed
+	resp.Body = &cancelBody{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelBody cancels a context once the body it wraps is closed
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryWait returns how long to wait before resending a request, and whether it should be resent at all.
+// Failures which may pass are retried: rate limits, server errors, and network errors such as timeouts.
+// Others are permanent, as asking again won't change the answer: a missing repository, a lack of permission, or
+// an invalid request.
+func retryWait(ctx context.Context, resp *http.Response, err error, backoff time.Duration) (time.Duration, bool) {
+	if err != nil {
+		// Cancellation is the caller's decision, not a failure
+		return backoff, ctx.Err() == nil
+	}
+
+	if wait, ok := rateLimitWait(resp); ok {
+		return wait, true
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode >= 500:
+		return backoff, true
+	default:
+		return 0, false
+	}
+}
+
+// IsNotFound returns whether err is, or wraps, GitHub's answer that something doesn't exist
+func IsNotFound(err error) bool {
+	var er *github.ErrorResponse
+	if !errors.As(err, &er) || er.Response == nil {
+		return false
+	}
+	return er.Response.StatusCode == http.StatusNotFound || er.Response.StatusCode == http.StatusGone
+}
+
+// rateLimitWait returns how long GitHub asks to wait before resending a rate limited request, if it was rate limited.
+// Secondary rate limits send Retry-After, while the primary rate limit sends the time it resets.
+func rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+
+	remaining := resp.Header.Get("X-RateLimit-Remaining")
+	limit := resp.Header.Get("X-RateLimit-Limit")
+
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			wait := time.Duration(secs) * time.Second
+			logrus.Warningf("GitHub secondary rate limit hit, waiting %s (%s of %s requests remaining)", wait, remaining, limit)
+			return wait, true
+		}
+	}
+
+	if remaining == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			// A second of slack, as clocks differ
+			wait := time.Until(time.Unix(reset, 0)) + time.Second
+			if wait < 0 {
+				wait = 0
+			}
+			logrus.Warningf("GitHub rate limit of %s requests used up, waiting %s until it resets at %s", limit, wait.Round(time.Second), time.Unix(reset, 0).Format("15:04:05"))
+			return wait, true
+		}
+	}
+
+	return 0, false
+}
+
+// sleep waits for d, or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
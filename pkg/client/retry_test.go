@@ -0,0 +1,279 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// roundTripFunc sends requests by calling itself
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// response returns a response with a status and headers, as name and value pairs
+func response(status int, headers ...string) *http.Response {
+	h := http.Header{}
+	for i := 0; i+1 < len(headers); i += 2 {
+		h.Set(headers[i], headers[i+1])
+	}
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header:     h,
+		Body:       ioutil.NopCloser(strings.NewReader("")),
+	}
+}
+
+// scripted returns a transport answering with each of rs in turn, and the requests it was sent
+func scripted(rs ...*http.Response) (http.RoundTripper, *[]*http.Request) {
+	reqs := []*http.Request{}
+	return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		reqs = append(reqs, req)
+		if len(reqs) > len(rs) {
+			return response(http.StatusOK), nil
+		}
+		return rs[len(reqs)-1], nil
+	}), &reqs
+}
+
+func get(t *testing.T, rt http.RoundTripper) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest("GET", "https://api.github.com/repos/org/project", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() returned error: %v", err)
+	}
+	return resp
+}
+
+func TestRateLimitWait(t *testing.T) {
+	reset := strconv.FormatInt(time.Now().Add(time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name   string
+		resp   *http.Response
+		min    time.Duration
+		max    time.Duration
+		wantOK bool
+	}{
+		{name: "ok", resp: response(http.StatusOK)},
+		{name: "forbidden", resp: response(http.StatusForbidden, "X-RateLimit-Remaining", "10")},
+		{name: "server error", resp: response(http.StatusInternalServerError, "Retry-After", "5")},
+		{name: "secondary", resp: response(http.StatusForbidden, "Retry-After", "30"), min: 30 * time.Second, max: 30 * time.Second, wantOK: true},
+		{name: "too many requests", resp: response(http.StatusTooManyRequests, "Retry-After", "2"), min: 2 * time.Second, max: 2 * time.Second, wantOK: true},
+		{name: "primary", resp: response(http.StatusForbidden, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", reset), min: 55 * time.Second, max: 62 * time.Second, wantOK: true},
+		{name: "primary already reset", resp: response(http.StatusForbidden, "X-RateLimit-Remaining", "0", "X-RateLimit-Reset", "1"), wantOK: true},
+		{name: "unparseable retry-after", resp: response(http.StatusForbidden, "Retry-After", "soon")},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			wait, ok := rateLimitWait(tc.resp)
+			if ok != tc.wantOK {
+				t.Fatalf("rateLimitWait() ok = %v, want %v", ok, tc.wantOK)
+			}
+			if wait < tc.min || wait > tc.max {
+				t.Errorf("rateLimitWait() = %s, want between %s and %s", wait, tc.min, tc.max)
+			}
+		})
+	}
+}
+
+func TestRetryTransportWaitsOutRateLimits(t *testing.T) {
+	base, reqs := scripted(
+		response(http.StatusForbidden, "Retry-After", "0"),
+		response(http.StatusTooManyRequests, "Retry-After", "0"),
+		response(http.StatusOK),
+	)
+	rt := &retryTransport{base: base, attempts: DefaultRetries + 1, backoff: time.Millisecond, budget: &Budget{}}
+
+	if resp := get(t, rt); resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if len(*reqs) != 3 {
+		t.Errorf("sent %d requests, want 3", len(*reqs))
+	}
+}
+
+func TestRetryWaitCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, retry := retryWait(ctx, nil, context.Canceled, time.Second); retry {
+		t.Errorf("retryWait() retries a cancelled request")
+	}
+	if wait, retry := retryWait(context.Background(), nil, context.DeadlineExceeded, time.Second); !retry || wait != time.Second {
+		t.Errorf("retryWait() of a network error = %s, %v, want the backoff and a retry", wait, retry)
+	}
+}
+
+func TestRetryTransportPermanentErrors(t *testing.T) {
+	for _, status := range []int{http.StatusNotFound, http.StatusUnauthorized, http.StatusUnprocessableEntity, http.StatusForbidden} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			base, reqs := scripted(response(status))
+			rt := &retryTransport{base: base, attempts: DefaultRetries + 1, backoff: time.Millisecond, budget: &Budget{}}
+
+			if resp := get(t, rt); resp.StatusCode != status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, status)
+			}
+			if len(*reqs) != 1 {
+				t.Errorf("sent %d requests, want 1", len(*reqs))
+			}
+		})
+	}
+}
+
+func TestIsNotFound(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: &github.ErrorResponse{Response: response(http.StatusNotFound)}, want: true},
+		{err: fmt.Errorf("get: %w", &github.ErrorResponse{Response: response(http.StatusGone)}), want: true},
+		{err: &github.ErrorResponse{Response: response(http.StatusForbidden)}},
+		{err: &github.ErrorResponse{}},
+		{err: errors.New("not found")},
+		{err: nil},
+	}
+
+	for _, tc := range tests {
+		if got := IsNotFound(tc.err); got != tc.want {
+			t.Errorf("IsNotFound(%v) = %v, want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestRetryTransportAttempts(t *testing.T) {
+	tests := []struct {
+		retries  int
+		failures int
+		want     int
+		status   int
+	}{
+		{retries: 0, failures: 1, want: 1, status: http.StatusBadGateway},
+		{retries: 2, failures: 1, want: 2, status: http.StatusOK},
+		{retries: 2, failures: 2, want: 3, status: http.StatusOK},
+		{retries: 2, failures: 5, want: 3, status: http.StatusBadGateway},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d retries %d failures", tc.retries, tc.failures), func(t *testing.T) {
+			rs := []*http.Response{}
+			for i := 0; i < tc.failures; i++ {
+				rs = append(rs, response(http.StatusBadGateway))
+			}
+			base, reqs := scripted(rs...)
+			rt := &retryTransport{base: base, attempts: tc.retries + 1, backoff: time.Millisecond, budget: &Budget{}}
+
+			if resp := get(t, rt); resp.StatusCode != tc.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.status)
+			}
+			if len(*reqs) != tc.want {
+				t.Errorf("sent %d requests, want %d", len(*reqs), tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransportRequestBody(t *testing.T) {
+	tests := []struct {
+		name       string
+		resendable bool
+		want       []string
+		status     int
+	}{
+		{name: "recreated", resendable: true, want: []string{`{"body":"x"}`, `{"body":"x"}`}, status: http.StatusOK},
+		{name: "read once", resendable: false, want: []string{`{"body":"x"}`}, status: http.StatusBadGateway},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			bodies := []string{}
+			base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+				b, err := ioutil.ReadAll(req.Body)
+				if err != nil {
+					return nil, err
+				}
+				bodies = append(bodies, string(b))
+				if len(bodies) == 1 {
+					return response(http.StatusBadGateway), nil
+				}
+				return response(http.StatusOK), nil
+			})
+			rt := &retryTransport{base: base, attempts: DefaultRetries + 1, backoff: time.Millisecond, budget: &Budget{}}
+
+			req, err := http.NewRequest("POST", "https://api.github.com/graphql", strings.NewReader(`{"body":"x"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !tc.resendable {
+				req.Body = ioutil.NopCloser(req.Body)
+				req.GetBody = nil
+			}
+
+			resp, err := rt.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() returned error: %v", err)
+			}
+			if resp.StatusCode != tc.status {
+				t.Errorf("status = %d, want %d", resp.StatusCode, tc.status)
+			}
+			if !reflect.DeepEqual(bodies, tc.want) {
+				t.Errorf("bodies sent = %q, want %q", bodies, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryTransportCallTimeout(t *testing.T) {
+	calls := 0
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			// The first attempt hangs until its timeout
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return response(http.StatusOK), nil
+	})
+	rt := &retryTransport{base: base, attempts: 2, backoff: time.Millisecond, budget: &Budget{}, timeout: 10 * time.Millisecond}
+
+	resp := get(t, rt)
+	if resp.StatusCode != http.StatusOK || calls != 2 {
+		t.Errorf("status = %d after %d attempts, want %d after 2", resp.StatusCode, calls, http.StatusOK)
+	}
+
+	// The timeout lasts until the body is closed, then its context is released
+	if err := resp.Body.Close(); err != nil {
+		t.Errorf("Close() returned error: %v", err)
+	}
+}
@@ -0,0 +1,302 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// tokenTransport authenticates requests with one of several GitHub tokens, moving on to the next once the active
+// token's core rate limit runs out, so that their limits add up. A request refused because the limit ran out is
+// resent with the next token. Responses report the remaining requests of every token together, so that go-github,
+// which refuses to send requests while the limit is used up, and the Budget see the pool rather than one token.
+type tokenTransport struct {
+	base   http.RoundTripper
+	tokens []string
+
+	mu     sync.Mutex
+	active int
+	// remaining and resets are each token's core rate limit, as of its latest response. remaining is -1 until then.
+	remaining []int
+	resets    []time.Time
+}
+
+func newTokenTransport(base http.RoundTripper, tokens []string) *tokenTransport {
+	t := &tokenTransport{
+		base:      base,
+		tokens:    tokens,
+		remaining: make([]int, len(tokens)),
+		resets:    make([]time.Time, len(tokens)),
+	}
+	for i := range t.remaining {
+		t.remaining[i] = -1
+	}
+	return t
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		i := t.pick()
+		r := req.Clone(req.Context())
+		if attempt > 1 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			r.Body = body
+		}
+		r.Header.Set("Authorization", "Bearer "+t.tokens[i])
+
+		resp, err := t.base.RoundTrip(r)
+		if err != nil {
+			return nil, err
+		}
+		t.record(i, resp.Header)
+
+		refused := (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("X-RateLimit-Remaining") == "0"
+		resendable := req.Body == nil || req.GetBody != nil
+		if !refused || !resendable || !t.available() {
+			if len(t.tokens) > 1 {
+				t.pool(resp.Header)
+			}
+			return resp, nil
+		}
+
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// usable returns whether the i'th token has requests to spare. The lock must be held.
+func (t *tokenTransport) usable(i int, now time.Time) bool {
+	return t.remaining[i] != 0 || now.After(t.resets[i])
+}
+
+// available returns whether any token has requests to spare
+func (t *tokenTransport) available() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for i := range t.tokens {
+		if t.usable(i, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// pick returns the index of the token to use, moving on from the active one if its limit has run out. If every
+// token's has, it is the one which resets first.
+func (t *tokenTransport) pick() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for n := 0; n < len(t.tokens); n++ {
+		i := (t.active + n) % len(t.tokens)
+		if !t.usable(i, now) {
+			continue
+		}
+		if i != t.active {
+			logrus.Infof("GitHub token %d of %d has %d requests remaining, switching to token %d", t.active+1, len(t.tokens), t.remaining[t.active], i+1)
+			t.active = i
+		}
+		return i
+	}
+
+	first := 0
+	for i := range t.tokens {
+		if t.resets[i].Before(t.resets[first]) {
+			first = i
+		}
+	}
+	return first
+}
+
+// record notes the rate limit headers of a response to a request made with the i'th token
+func (t *tokenTransport) record(i int, h http.Header) {
+	// Other resources, such as search, have limits of their own
+	if r := h.Get("X-RateLimit-Resource"); r != "" && r != "core" {
+		return
+	}
+	remaining, err := strconv.Atoi(h.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	reset, err := strconv.ParseInt(h.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.remaining[i] = remaining
+	t.resets[i] = time.Unix(reset, 0)
+}
+
+// pool rewrites the core rate limit headers of a response to count the remaining requests of every token. Tokens
+// without a response yet are counted as having the whole limit. Once every token is used up, the reset is the first.
+func (t *tokenTransport) pool(h http.Header) {
+	if r := h.Get("X-RateLimit-Resource"); r != "" && r != "core" {
+		return
+	}
+	limit, err := strconv.Atoi(h.Get("X-RateLimit-Limit"))
+	if err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	remaining := 0
+	var first time.Time
+	for i := range t.tokens {
+		switch {
+		case t.remaining[i] < 0, now.After(t.resets[i]):
+			remaining += limit
+		default:
+			remaining += t.remaining[i]
+		}
+		if first.IsZero() || t.resets[i].Before(first) {
+			first = t.resets[i]
+		}
+	}
+
+	h.Set("X-RateLimit-Limit", strconv.Itoa(limit*len(t.tokens)))
+	h.Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+	if remaining == 0 {
+		h.Set("X-RateLimit-Reset", strconv.FormatInt(first.Unix(), 10))
+	}
+}
+
+// splitTokens returns the tokens in s, separated by commas or whitespace
+func splitTokens(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// tokens returns the configured GitHub tokens, and where they were found: GitHubToken, $GITHUB_TOKEN, or $GH_TOKEN,
+// which may list several separated by commas, GitHubTokenPath, a file or a directory of files, or else the gh CLI's
+// login to the GitHub instance
+func tokens(c Config) ([]string, string, error) {
+	for _, src := range []struct{ name, val string }{
+		{"the configured token", c.GitHubToken},
+		{"$GITHUB_TOKEN", os.Getenv("GITHUB_TOKEN")},
+		{"$GH_TOKEN", os.Getenv("GH_TOKEN")},
+	} {
+		if ts := splitTokens(src.val); len(ts) > 0 {
+			return ts, src.name, nil
+		}
+	}
+
+	if c.GitHubTokenPath != "" {
+		ts, err := fileTokens(c.GitHubTokenPath)
+		return ts, c.GitHubTokenPath, err
+	}
+
+	host := strings.TrimPrefix(strings.TrimPrefix(WebURL(apiURL(c)), "https://"), "http://")
+	t, src, err := ghToken(host)
+	if err != nil {
+		logrus.Debugf("gh CLI credentials: %v", err)
+		return nil, "", fmt.Errorf("no GitHub token: pass --token-path, set GITHUB_TOKEN or GH_TOKEN, or log in to %s with `gh auth login`. Tried $GITHUB_TOKEN, $GH_TOKEN, %s, and `gh auth token`", host, ghHostsPath())
+	}
+	return []string{t}, src, nil
+}
+
+// fileTokens returns the tokens in a file, or in each file of a directory
+func fileTokens(path string) ([]string, error) {
+	paths := []string{path}
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		fis, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		paths = []string{}
+		for _, fi := range fis {
+			if !fi.IsDir() && !strings.HasPrefix(fi.Name(), ".") {
+				paths = append(paths, filepath.Join(path, fi.Name()))
+			}
+		}
+	}
+
+	ts := []string{}
+	for _, p := range paths {
+		bs, err := ioutil.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		ts = append(ts, splitTokens(string(bs))...)
+	}
+	if len(ts) == 0 {
+		return nil, fmt.Errorf("no GitHub token in %s", path)
+	}
+	return ts, nil
+}
+
+// ghHostsPath returns where the gh CLI keeps its logins: hosts.yml in $GH_CONFIG_DIR, $XDG_CONFIG_HOME/gh, or
+// ~/.config/gh
+func ghHostsPath() string {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "gh")
+		} else {
+			home, _ := os.UserHomeDir()
+			dir = filepath.Join(home, ".config", "gh")
+		}
+	}
+	return filepath.Join(dir, "hosts.yml")
+}
+
+// ghToken returns the gh CLI's token for a host, and where it was found: its hosts.yml, or, as newer versions keep
+// the token in the system keyring, `gh auth token`
+func ghToken(host string) (string, string, error) {
+	path := ghHostsPath()
+	if bs, err := ioutil.ReadFile(path); err == nil {
+		hosts := map[string]struct {
+			OAuthToken string `yaml:"oauth_token"`
+		}{}
+		if err := yaml.Unmarshal(bs, &hosts); err != nil {
+			logrus.Warningf("unable to parse %s: %v", path, err)
+		} else if t := hosts[host].OAuthToken; t != "" {
+			return t, path, nil
+		}
+	}
+
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err != nil {
+		return "", "", fmt.Errorf("gh auth token: %w", err)
+	}
+	t := strings.TrimSpace(string(out))
+	if t == "" {
+		return "", "", fmt.Errorf("gh auth token: no token for %s", host)
+	}
+	return t, "`gh auth token`", nil
+}
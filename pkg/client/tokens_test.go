@@ -0,0 +1,193 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// limited returns a response carrying a token's core rate limit
+func limited(status, remaining int, reset time.Time) *http.Response {
+	return response(status,
+		"X-RateLimit-Limit", "5000",
+		"X-RateLimit-Remaining", strconv.Itoa(remaining),
+		"X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+}
+
+func TestTokenTransportRotates(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	base, reqs := scripted(
+		limited(http.StatusOK, 1, reset),
+		limited(http.StatusForbidden, 0, reset),
+		limited(http.StatusOK, 4999, reset),
+		limited(http.StatusOK, 4998, reset),
+	)
+	rt := newTokenTransport(base, []string{"one", "two"})
+
+	for i := 0; i < 3; i++ {
+		if resp := get(t, rt); resp.StatusCode != http.StatusOK {
+			t.Fatalf("request %d status = %d, want %d", i, resp.StatusCode, http.StatusOK)
+		}
+	}
+
+	// The refused request is resent with the next token, which is used from then on
+	got := []string{}
+	for _, r := range *reqs {
+		got = append(got, r.Header.Get("Authorization"))
+	}
+	want := []string{"Bearer one", "Bearer one", "Bearer two", "Bearer two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Authorization headers = %v, want %v", got, want)
+	}
+}
+
+func TestTokenTransportAllUsedUp(t *testing.T) {
+	first := time.Now().Add(10 * time.Minute)
+	base, reqs := scripted(
+		limited(http.StatusForbidden, 0, time.Now().Add(time.Hour)),
+		limited(http.StatusForbidden, 0, first),
+	)
+	rt := newTokenTransport(base, []string{"one", "two"})
+
+	// With every token used up, the refusal is returned rather than resent again
+	resp := get(t, rt)
+	if resp.StatusCode != http.StatusForbidden || len(*reqs) != 2 {
+		t.Fatalf("status = %d after %d requests, want %d after 2", resp.StatusCode, len(*reqs), http.StatusForbidden)
+	}
+
+	// Its headers report the pool, which resets when the first token does
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %s, want 0", got)
+	}
+	if got, want := resp.Header.Get("X-RateLimit-Reset"), strconv.FormatInt(first.Unix(), 10); got != want {
+		t.Errorf("X-RateLimit-Reset = %s, want %s", got, want)
+	}
+	if got := rt.pick(); got != 1 {
+		t.Errorf("pick() = %d, want the token which resets first", got)
+	}
+}
+
+func TestTokenTransportPool(t *testing.T) {
+	reset := time.Now().Add(time.Hour)
+	rt := newTokenTransport(nil, []string{"one", "two", "three"})
+	rt.record(0, limited(http.StatusOK, 100, reset).Header)
+	// A token whose limit has reset counts in full
+	rt.record(1, limited(http.StatusOK, 0, time.Now().Add(-time.Minute)).Header)
+
+	h := limited(http.StatusOK, 100, reset).Header
+	rt.pool(h)
+	if got := h.Get("X-RateLimit-Limit"); got != "15000" {
+		t.Errorf("X-RateLimit-Limit = %s, want 15000", got)
+	}
+	if got := h.Get("X-RateLimit-Remaining"); got != "10100" {
+		t.Errorf("X-RateLimit-Remaining = %s, want 10100", got)
+	}
+
+	// Other resources are left alone
+	search := response(http.StatusOK, "X-RateLimit-Resource", "search", "X-RateLimit-Limit", "30", "X-RateLimit-Remaining", "29").Header
+	rt.pool(search)
+	if got := search.Get("X-RateLimit-Remaining"); got != "29" {
+		t.Errorf("search X-RateLimit-Remaining = %s, want 29", got)
+	}
+}
+
+func TestTokenTransportSingleToken(t *testing.T) {
+	base, _ := scripted(limited(http.StatusOK, 42, time.Now().Add(time.Hour)))
+	rt := newTokenTransport(base, []string{"one"})
+
+	if got := get(t, rt).Header.Get("X-RateLimit-Remaining"); got != "42" {
+		t.Errorf("X-RateLimit-Remaining = %s, want the token's own 42", got)
+	}
+}
+
+func TestSplitTokens(t *testing.T) {
+	got := splitTokens(" one,two\nthree ,, four\t")
+	want := []string{"one", "two", "three", "four"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("splitTokens() = %v, want %v", got, want)
+	}
+}
+
+func TestFileTokens(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"a":       "one\n",
+		"b":       "two,three\n",
+		".hidden": "four\n",
+	} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := fileTokens(dir)
+	if err != nil {
+		t.Fatalf("fileTokens(dir) returned error: %v", err)
+	}
+	if want := []string{"one", "two", "three"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("fileTokens(dir) = %v, want %v", got, want)
+	}
+
+	got, err = fileTokens(filepath.Join(dir, "b"))
+	if err != nil || !reflect.DeepEqual(got, []string{"two", "three"}) {
+		t.Errorf("fileTokens(file) = %v, %v, want [two three]", got, err)
+	}
+
+	empty := filepath.Join(t.TempDir(), "empty")
+	if err := ioutil.WriteFile(empty, []byte("\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fileTokens(empty); err == nil {
+		t.Errorf("fileTokens() of an empty file returned no error")
+	}
+}
+
+func TestTokensPrecedence(t *testing.T) {
+	for _, name := range []string{"GITHUB_TOKEN", "GH_TOKEN"} {
+		old, ok := os.LookupEnv(name)
+		defer func(name string) {
+			if ok {
+				os.Setenv(name, old)
+			} else {
+				os.Unsetenv(name)
+			}
+		}(name)
+	}
+	os.Setenv("GITHUB_TOKEN", "")
+	os.Setenv("GH_TOKEN", "gh1, gh2")
+
+	ts, src, err := tokens(Config{})
+	if err != nil || src != "$GH_TOKEN" || !reflect.DeepEqual(ts, []string{"gh1", "gh2"}) {
+		t.Errorf("tokens() = %v, %s, %v, want $GH_TOKEN's", ts, src, err)
+	}
+
+	os.Setenv("GITHUB_TOKEN", "env")
+	ts, src, err = tokens(Config{})
+	if err != nil || src != "$GITHUB_TOKEN" || !reflect.DeepEqual(ts, []string{"env"}) {
+		t.Errorf("tokens() = %v, %s, %v, want $GITHUB_TOKEN's", ts, src, err)
+	}
+
+	ts, src, err = tokens(Config{GitHubToken: "configured"})
+	if err != nil || src != "the configured token" || !reflect.DeepEqual(ts, []string{"configured"}) {
+		t.Errorf("tokens() = %v, %s, %v, want the configured token", ts, src, err)
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// httpTransport returns the transport requests are sent with: c.Transport if set, or otherwise one honoring the
+// configured proxy, extra certificates, and timeouts, logging each request if asked to
+func httpTransport(c Config) (http.RoundTripper, error) {
+	rt := c.Transport
+	if rt == nil {
+		t := http.DefaultTransport.(*http.Transport).Clone()
+
+		if c.Proxy != "" {
+			u, err := url.Parse(c.Proxy)
+			if err != nil {
+				return nil, fmt.Errorf("proxy: %w", err)
+			}
+			t.Proxy = http.ProxyURL(u)
+		}
+
+		if c.CABundle != "" {
+			pool, err := certPool(c.CABundle)
+			if err != nil {
+				return nil, err
+			}
+			t.TLSClientConfig = &tls.Config{RootCAs: pool}
+		}
+
+		if c.DialTimeout > 0 {
+			d := &net.Dialer{Timeout: c.DialTimeout, KeepAlive: 30 * time.Second}
+			t.DialContext = d.DialContext
+			t.TLSHandshakeTimeout = c.DialTimeout
+		}
+		t.ResponseHeaderTimeout = c.ResponseTimeout
+		rt = t
+	}
+
+	if c.LogRequests {
+		rt = &logTransport{base: rt}
+	}
+	return rt, nil
+}
+
+// certPool returns the system's certificates, plus those in a PEM file
+func certPool(path string) (*x509.CertPool, error) {
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+
+	bs, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ca bundle: %w", err)
+	}
+	if !pool.AppendCertsFromPEM(bs) {
+		return nil, fmt.Errorf("ca bundle: no certificates found in %s", path)
+	}
+	return pool, nil
+}
+
+// logTransport logs the method, URL, status, and duration of every request
+type logTransport struct {
+	base http.RoundTripper
+}
+
+func (t *logTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	took := time.Since(start).Round(time.Millisecond)
+
+	if err != nil {
+		logrus.Infof("%s %s: %v after %s", req.Method, req.URL, err, took)
+		return nil, err
+	}
+	logrus.Infof("%s %s: %s in %s", req.Method, req.URL, resp.Status, took)
+	return resp, nil
+}
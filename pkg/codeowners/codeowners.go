@@ -0,0 +1,196 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Paths are where GitHub looks for a CODEOWNERS file, in order of precedence
+var Paths = []string{".github/CODEOWNERS", "CODEOWNERS", "docs/CODEOWNERS"}
+
+// Rule is a single CODEOWNERS line
+type Rule struct {
+	Line    int
+	Pattern string
+	// Owners may be empty, which explicitly leaves matching paths unowned
+	Owners []string
+	re     *regexp.Regexp
+}
+
+// Match returns whether a repository-relative path matches the rule
+func (r *Rule) Match(path string) bool {
+	return r.re.MatchString(strings.TrimPrefix(path, "/"))
+}
+
+// Ruleset is a parsed CODEOWNERS file
+type Ruleset struct {
+	Rules []*Rule
+}
+
+// Owner returns the rule governing a path, or nil if none match. As with GitHub, the last matching rule wins.
+func (rs *Ruleset) Owner(path string) *Rule {
+	for i := len(rs.Rules) - 1; i >= 0; i-- {
+		if rs.Rules[i].Match(path) {
+			return rs.Rules[i]
+		}
+	}
+	return nil
+}
+
+// Parse parses a CODEOWNERS file, returning the rules that could be read and an error per malformed line
+func Parse(r io.Reader) (*Ruleset, []error) {
+	rs := &Ruleset{}
+	errs := []error{}
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		fields := splitFields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		re, err := compile(fields[0])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: pattern %q: %v", n, fields[0], err))
+			continue
+		}
+
+		rs.Rules = append(rs.Rules, &Rule{
+			Line:    n,
+			Pattern: fields[0],
+			Owners:  fields[1:],
+			re:      re,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return rs, errs
+}
+
+// splitFields splits a line on unescaped whitespace, dropping comments
+func splitFields(line string) []string {
+	fields := []string{}
+	var cur strings.Builder
+	escaped := false
+
+	flush := func() {
+		if cur.Len() > 0 {
+			fields = append(fields, cur.String())
+			cur.Reset()
+		}
+	}
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			// Keep the escape so that the pattern compiler treats the character literally
+			cur.WriteRune('\\')
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case r == '#':
+			flush()
+			return fields
+		case r == ' ' || r == '\t':
+			flush()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+
+	return fields
+}
+
+// compile converts a gitignore-style CODEOWNERS pattern to a regular expression
+func compile(pattern string) (*regexp.Regexp, error) {
+	p := pattern
+	dirOnly := strings.HasSuffix(p, "/")
+	p = strings.TrimSuffix(p, "/")
+
+	// A slash at the start or in the middle anchors the pattern to the repository root
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	if p == "" {
+		return nil, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+	if !anchored {
+		sb.WriteString("(?:.*/)?")
+	}
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(p[i])))
+			}
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				i++
+				if i+1 < len(p) && p[i+1] == '/' {
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				} else {
+					sb.WriteString(".*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated character class")
+			}
+			sb.WriteString(p[i : i+end+1])
+			i += end
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	switch {
+	case dirOnly:
+		// Directories match everything beneath them
+		sb.WriteString("/.*")
+	case strings.HasSuffix(p, "/*"):
+		// As documented by GitHub, "docs/*" does not match files in subdirectories of docs
+	default:
+		// A pattern may name a file, or a directory containing files
+		sb.WriteString("(?:/.*)?")
+	}
+	sb.WriteString("$")
+
+	return regexp.Compile(sb.String())
+}
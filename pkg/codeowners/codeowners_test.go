@@ -0,0 +1,116 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package codeowners
+
+import (
+	"strings"
+	"testing"
+)
+
+const testFile = `# Default owners
+*       @org/everyone
+
+# Anchored to the root
+/build/ @org/infra
+docs/*  @org/docs
+
+# Unanchored
+*.go    @org/gophers
+vendor  @org/deps
+
+**/testdata/** @org/testers
+apps/**/config.yaml @org/config
+
+# Explicitly unowned
+/generated/
+
+escaped\ space.txt @org/spaces # trailing comment
+`
+
+func TestOwner(t *testing.T) {
+	rs, errs := Parse(strings.NewReader(testFile))
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors: %v", errs)
+	}
+
+	tests := []struct {
+		path string
+		// want is the owners of the governing rule, joined by commas, or "-" for no rule
+		want string
+	}{
+		{"README.md", "@org/everyone"},
+		// Last match wins: *.go comes after *
+		{"main.go", "@org/gophers"},
+		{"pkg/repo/pr.go", "@org/gophers"},
+		// Anchored directory
+		{"build/Makefile", "@org/infra"},
+		{"build/ci/run.sh", "@org/infra"},
+		{"src/build/Makefile", "@org/everyone"},
+		// docs/* matches direct children only, but like any slashed pattern is anchored
+		{"docs/index.md", "@org/docs"},
+		{"docs/api/index.md", "@org/everyone"},
+		{"src/docs/index.md", "@org/everyone"},
+		// Unanchored names match at any depth, as files or directories
+		{"vendor/lib/lib.c", "@org/deps"},
+		{"third_party/vendor/x.c", "@org/deps"},
+		// ** spans directories
+		{"testdata/a.json", "@org/testers"},
+		{"pkg/repo/testdata/a.json", "@org/testers"},
+		{"apps/config.yaml", "@org/config"},
+		{"apps/web/prod/config.yaml", "@org/config"},
+		{"apps/web/config.yml", "@org/everyone"},
+		// A rule without owners governs, leaving paths unowned
+		{"generated/types.ts", ""},
+		{"/generated/types.ts", ""},
+		{"escaped space.txt", "@org/spaces"},
+	}
+
+	for _, tc := range tests {
+		got := "-"
+		if r := rs.Owner(tc.path); r != nil {
+			got = strings.Join(r.Owners, ",")
+		}
+		if got != tc.want {
+			t.Errorf("Owner(%q) = %q, want %q", tc.path, got, tc.want)
+		}
+	}
+}
+
+func TestOwnerNoMatch(t *testing.T) {
+	rs, _ := Parse(strings.NewReader("/docs/ @org/docs\n"))
+	if r := rs.Owner("src/main.go"); r != nil {
+		t.Errorf("Owner() = line %d, want no rule", r.Line)
+	}
+}
+
+func TestParseSkipsCommentsAndBlankLines(t *testing.T) {
+	rs, errs := Parse(strings.NewReader("# comment\n\n   \n\t# indented comment\n*.md @org/docs\n"))
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors: %v", errs)
+	}
+	if len(rs.Rules) != 1 || rs.Rules[0].Line != 5 || rs.Rules[0].Pattern != "*.md" {
+		t.Errorf("Parse() rules = %+v, want *.md on line 5", rs.Rules)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	rs, errs := Parse(strings.NewReader("*.go @org/go\n[abc @org/bad\n/ @org/root\n*.md @org/docs\n"))
+	if len(errs) != 2 {
+		t.Errorf("Parse() returned %d errors, want 2: %v", len(errs), errs)
+	}
+	if len(rs.Rules) != 2 {
+		t.Errorf("Parse() returned %d rules, want the 2 valid ones", len(rs.Rules))
+	}
+}
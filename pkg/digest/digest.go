@@ -0,0 +1,152 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package digest collects non-fatal warnings during a run, so that they can
+// be summarized at the end rather than logged one item at a time.
+package digest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Warning categories
+const (
+	FetchFailed = "fetch failed"
+	Truncated   = "truncated"
+	SuspectDate = "suspect date"
+	CacheWrite  = "cache write failed"
+	ParseError  = "parse error"
+	RepoSkipped = "repository skipped"
+)
+
+// maxExamples is how many example items are kept per group
+const maxExamples = 3
+
+// Entry is a single non-fatal warning
+type Entry struct {
+	Category string `json:"category" desc:"Kind of problem, such as fetch failed or truncated"`
+	Repo     string `json:"repo" desc:"Repository the item belongs to (org/project), if known"`
+	Item     string `json:"item" desc:"URL, path, or cache key of the affected item"`
+	Message  string `json:"message" desc:"Details of the problem"`
+}
+
+// Group summarizes the warnings for a category and repository
+type Group struct {
+	Category string
+	Repo     string
+	Count    int
+	Examples []string
+}
+
+// Digest is a concurrency-safe collection of warnings
+type Digest struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// New returns an empty digest
+func New() *Digest {
+	return &Digest{}
+}
+
+// Default is the digest used by the package-level functions
+var Default = New()
+
+// Add records a warning, logging it only at debug level
+func (d *Digest) Add(category string, repo string, item string, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	logrus.Debugf("%s: %s %s: %s", category, repo, item, msg)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = append(d.entries, Entry{Category: category, Repo: repo, Item: item, Message: msg})
+}
+
+// Entries returns a copy of every warning recorded
+func (d *Digest) Entries() []Entry {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]Entry{}, d.entries...)
+}
+
+// Len returns the number of warnings recorded
+func (d *Digest) Len() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.entries)
+}
+
+// Reset discards every warning recorded
+func (d *Digest) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = nil
+}
+
+// Groups returns the warnings grouped by category and repository, most frequent first
+func (d *Digest) Groups() []Group {
+	idx := map[string]int{}
+	gs := []Group{}
+
+	for _, e := range d.Entries() {
+		key := e.Category + "\x00" + e.Repo
+		i, ok := idx[key]
+		if !ok {
+			i = len(gs)
+			idx[key] = i
+			gs = append(gs, Group{Category: e.Category, Repo: e.Repo})
+		}
+
+		gs[i].Count++
+		if len(gs[i].Examples) < maxExamples {
+			gs[i].Examples = append(gs[i].Examples, e.Item)
+		}
+	}
+
+	sort.SliceStable(gs, func(i, j int) bool {
+		if gs[i].Count != gs[j].Count {
+			return gs[i].Count > gs[j].Count
+		}
+		if gs[i].Category != gs[j].Category {
+			return gs[i].Category < gs[j].Category
+		}
+		return gs[i].Repo < gs[j].Repo
+	})
+	return gs
+}
+
+// String returns a compact table of the grouped warnings, or an empty string if there were none
+func (d *Digest) String() string {
+	gs := d.Groups()
+	if len(gs) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d warnings:\n", d.Len())
+	for _, g := range gs {
+		fmt.Fprintf(&sb, "  %-20s %-30s %5d  %s\n", g.Category, g.Repo, g.Count, strings.Join(g.Examples, ", "))
+	}
+	return sb.String()
+}
+
+// Add records a warning in the default digest
+func Add(category string, repo string, item string, format string, args ...interface{}) {
+	Default.Add(category, repo, item, format, args...)
+}
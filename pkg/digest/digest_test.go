@@ -0,0 +1,86 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package digest
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestGroups(t *testing.T) {
+	d := New()
+	d.Add(Truncated, "org/a", "a/1", "too long")
+	for i, item := range []string{"b/1", "b/2", "b/3", "b/4"} {
+		d.Add(FetchFailed, "org/b", item, "attempt %d", i)
+	}
+	d.Add(FetchFailed, "org/a", "a/2", "gone")
+	d.Add(CacheWrite, "org/a", "a/3", "full")
+
+	want := []Group{
+		// Only the first few items are kept as examples
+		{Category: FetchFailed, Repo: "org/b", Count: 4, Examples: []string{"b/1", "b/2", "b/3"}},
+		// Ties are ordered by category, then repository
+		{Category: CacheWrite, Repo: "org/a", Count: 1, Examples: []string{"a/3"}},
+		{Category: FetchFailed, Repo: "org/a", Count: 1, Examples: []string{"a/2"}},
+		{Category: Truncated, Repo: "org/a", Count: 1, Examples: []string{"a/1"}},
+	}
+	if got := d.Groups(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Groups() = %+v, want %+v", got, want)
+	}
+
+	if got := d.Entries()[1].Message; got != "attempt 0" {
+		t.Errorf("Entries()[1].Message = %q, want %q", got, "attempt 0")
+	}
+}
+
+func TestString(t *testing.T) {
+	d := New()
+	if got := d.String(); got != "" {
+		t.Errorf("String() = %q for an empty digest, want none", got)
+	}
+
+	d.Add(ParseError, "org/a", "CODEOWNERS", "line 3")
+	d.Add(ParseError, "org/a", ".mailmap", "line 7")
+	got := d.String()
+	for _, want := range []string{"2 warnings:", ParseError, "org/a", "CODEOWNERS, .mailmap"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("String() = %q, want it to contain %q", got, want)
+		}
+	}
+
+	d.Reset()
+	if d.Len() != 0 || d.String() != "" {
+		t.Errorf("Reset() left %d warnings", d.Len())
+	}
+}
+
+func TestConcurrentAdd(t *testing.T) {
+	d := New()
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.Add(FetchFailed, "org/a", "item", "failed")
+		}()
+	}
+	wg.Wait()
+
+	if d.Len() != 50 {
+		t.Errorf("Len() = %d, want 50", d.Len())
+	}
+}
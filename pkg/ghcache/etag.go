@@ -0,0 +1,164 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/sirupsen/logrus"
+)
+
+// listPage is a page of list results, cached with the ETag GitHub sent for it
+type listPage struct {
+	ETag     string          `json:"etag"`
+	Body     json.RawMessage `json:"body"`
+	NextPage int             `json:"next_page"`
+}
+
+// PullRequestsList lists a page of pull requests, as c.PullRequests.List does. A page listed before is requested
+// with its ETag, and reused if GitHub reports it unchanged, which costs no rate limit.
+func PullRequestsList(ctx context.Context, p cache.Cacher, c *github.Client, org string, project string, opts *github.PullRequestListOptions) ([]*github.PullRequest, *github.Response, error) {
+	q := url.Values{}
+	setQuery(q, "state", opts.State)
+	setQuery(q, "head", opts.Head)
+	setQuery(q, "base", opts.Base)
+	setQuery(q, "sort", opts.Sort)
+	setQuery(q, "direction", opts.Direction)
+
+	prs := []*github.PullRequest{}
+	resp, err := conditionalList(ctx, p, c, PullRequestListPrefix, org, project, "pulls", "", q, opts.ListOptions, &prs)
+	return prs, resp, err
+}
+
+// IssuesListByRepo lists a page of issues, as c.Issues.ListByRepo does, reusing unchanged pages as PullRequestsList does
+func IssuesListByRepo(ctx context.Context, p cache.Cacher, c *github.Client, org string, project string, opts *github.IssueListByRepoOptions) ([]*github.Issue, *github.Response, error) {
+	q := url.Values{}
+	setQuery(q, "milestone", opts.Milestone)
+	setQuery(q, "state", opts.State)
+	setQuery(q, "assignee", opts.Assignee)
+	setQuery(q, "creator", opts.Creator)
+	setQuery(q, "mentioned", opts.Mentioned)
+	setQuery(q, "labels", strings.Join(opts.Labels, ","))
+	setQuery(q, "sort", opts.Sort)
+	setQuery(q, "direction", opts.Direction)
+	if !opts.Since.IsZero() {
+		q.Set("since", opts.Since.Format(time.RFC3339))
+	}
+
+	is := []*github.Issue{}
+	resp, err := conditionalList(ctx, p, c, IssueListPrefix, org, project, "issues", "", q, opts.ListOptions, &is)
+	return is, resp, err
+}
+
+// RepositoriesListByOrg lists a page of an organization's repositories, as c.Repositories.ListByOrg does, reusing
+// unchanged pages as PullRequestsList does
+func RepositoriesListByOrg(ctx context.Context, p cache.Cacher, c *github.Client, org string, opts *github.RepositoryListByOrgOptions) ([]*github.Repository, *github.Response, error) {
+	q := url.Values{}
+	setQuery(q, "type", opts.Type)
+	setQuery(q, "sort", opts.Sort)
+	setQuery(q, "direction", opts.Direction)
+
+	rs := []*github.Repository{}
+	// Topics are still a preview
+	resp, err := conditionalList(ctx, p, c, RepositoryListPrefix, org, "", "repos", "application/vnd.github.mercy-preview+json", q, opts.ListOptions, &rs)
+	return rs, resp, err
+}
+
+func setQuery(q url.Values, name string, value string) {
+	if value != "" {
+		q.Set(name, value)
+	}
+}
+
+// conditionalList fetches a page of a repository's list endpoint into v, or an organization's if project is empty,
+// sending the ETag of the cached page if there is one. Every page is cached, so that it can be replayed Offline.
+func conditionalList(ctx context.Context, p cache.Cacher, c *github.Client, prefix string, org string, project string, endpoint string, accept string, q url.Values, lo github.ListOptions, v interface{}) (*github.Response, error) {
+	scope, path := org+"-"+project, fmt.Sprintf("repos/%s/%s/%s", org, project, endpoint)
+	if project == "" {
+		scope, path = org, fmt.Sprintf("orgs/%s/%s", org, endpoint)
+	}
+
+	// Pages are keyed by number, and a hash of the rest of the query, so that different filters don't share pages
+	h := fnv.New32a()
+	h.Write([]byte(endpoint + "?" + q.Encode()))
+	key := fmt.Sprintf("%s-%s-%d-q%08x", prefix, scope, lo.Page, h.Sum32())
+
+	if lo.PerPage > 0 {
+		q.Set("per_page", strconv.Itoa(lo.PerPage))
+	}
+	if lo.Page > 0 {
+		q.Set("page", strconv.Itoa(lo.Page))
+	}
+
+	// The ETag decides whether the page is still current, so its age doesn't matter
+	var cached *listPage
+	if val := p.GetValue(key, time.Time{}); val != nil {
+		lp := &listPage{}
+		if err := loadJSON(val, lp); err == nil {
+			cached = lp
+		}
+	}
+
+	if Offline {
+		RunCounters.record(key, cached != nil)
+		if cached == nil {
+			return nil, offlineMiss(key)
+		}
+		resp := &github.Response{Response: &http.Response{StatusCode: http.StatusNotModified}, NextPage: cached.NextPage}
+		return resp, json.Unmarshal(cached.Body, v)
+	}
+
+	req, err := c.NewRequest("GET", path+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	var raw json.RawMessage
+	resp, err := c.Do(ctx, req, &raw)
+	if cached != nil && cached.ETag != "" && resp != nil && resp.StatusCode == http.StatusNotModified {
+		RunCounters.record(key, true)
+		logrus.Debugf("%s unchanged since cached", key)
+		resp.NextPage = cached.NextPage
+		return resp, json.Unmarshal(cached.Body, v)
+	}
+	RunCounters.record(key, false)
+	if err != nil {
+		return resp, err
+	}
+
+	if err := json.Unmarshal(raw, v); err != nil {
+		return resp, fmt.Errorf("decode: %w", err)
+	}
+
+	storeJSON(p, org, project, key, &listPage{ETag: resp.Header.Get("ETag"), Body: raw, NextPage: resp.NextPage})
+	return resp, nil
+}
@@ -16,56 +16,78 @@ package ghcache
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/google/go-github/v33/github"
 	"github.com/google/triage-party/pkg/persist"
 	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/digest"
 )
 
 const (
 	keyTime = "2006-01-02T150405"
 )
 
-type blob struct {
-	PullRequest         github.PullRequest
-	CommitFiles         []github.CommitFile
-	PullRequestComments []github.PullRequestComment
-	IssueComments       []github.IssueComment
-	Issue               github.Issue
+// ErrNotFound is wrapped by errors for things GitHub says do not exist
+var ErrNotFound = errors.New("not found")
+
+// ErrOffline is wrapped by errors for data which isn't cached, while Offline
+var ErrOffline = errors.New("not cached, and offline")
+
+// Offline serves everything from the cache, however old, so that reports can be reproduced without GitHub.
+// Data which isn't cached is an error wrapping ErrOffline, rather than a request.
+var Offline bool
+
+// stale returns whether a cached item, last updated when GitHub says, predates t. Both times are GitHub's, so
+// this holds even when the cache entry was written by a machine whose clock disagrees.
+func stale(updated time.Time, t time.Time) bool {
+	return !Offline && updated.Before(t)
 }
 
-func PullRequestsGet(ctx context.Context, p persist.Cacher, c *github.Client, t time.Time, org string, project string, num int) (*github.PullRequest, error) {
-	key := fmt.Sprintf("pr-%s-%s-%d", org, project, num)
-	val := p.Get(key, t)
+// offlineMiss returns the error for a key which isn't cached, while Offline
+func offlineMiss(key string) error {
+	return fmt.Errorf("%s: %w", key, ErrOffline)
+}
 
-	if val != nil {
+func PullRequestsGet(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) (*github.PullRequest, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", PullRequestPrefix, org, project, num)
+	val := get(p, key, t)
+
+	if val != nil && !stale(val.GHPullRequest.GetUpdatedAt(), t) {
 		return val.GHPullRequest, nil
 	}
 
-	if val == nil {
-		logrus.Debugf("cache miss for %v", key)
-		pr, _, err := c.PullRequests.Get(ctx, org, project, num)
-		if err != nil {
-			return nil, fmt.Errorf("get: %v", err)
-		}
-		return pr, p.Set(key, &persist.Blob{GHPullRequest: pr})
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
 	}
 
-	logrus.Debugf("cache hit: %v", key)
-	return val.GHPullRequest, nil
+	pr, _, err := c.PullRequests.Get(ctx, org, project, num)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+	store(p, org, project, key, &persist.Blob{GHPullRequest: pr})
+	return pr, nil
 }
 
-func PullRequestsListFiles(ctx context.Context, p persist.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.CommitFile, error) {
-	key := fmt.Sprintf("pr-listfiles-%s-%s-%d", org, project, num)
-	val := p.Get(key, t)
+func PullRequestsListFiles(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.CommitFile, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", PullRequestFilesPrefix, org, project, num)
+	val := get(p, key, t)
 
 	if val != nil {
 		return val.GHCommitFiles, nil
 	}
 
-	logrus.Debugf("cache miss for %v: %s", key)
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
 
 	opts := &github.ListOptions{PerPage: 100}
 	fs := []*github.CommitFile{}
@@ -73,7 +95,7 @@ func PullRequestsListFiles(ctx context.Context, p persist.Cacher, c *github.Clie
 	for {
 		fsp, resp, err := c.PullRequests.ListFiles(ctx, org, project, num, opts)
 		if err != nil {
-			return nil, fmt.Errorf("get: %v", err)
+			return nil, fmt.Errorf("get: %w", err)
 		}
 		fs = append(fs, fsp...)
 
@@ -84,19 +106,23 @@ func PullRequestsListFiles(ctx context.Context, p persist.Cacher, c *github.Clie
 		opts.Page = resp.NextPage
 	}
 
-	return fs, p.Set(key, &persist.Blob{GHCommitFiles: fs})
+	store(p, org, project, key, &persist.Blob{GHCommitFiles: fs})
+	return fs, nil
 
 }
 
-func PullRequestsListComments(ctx context.Context, p persist.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.PullRequestComment, error) {
-	key := fmt.Sprintf("pr-comments-%s-%s-%d", org, project, num)
-	val := p.Get(key, t)
+func PullRequestsListComments(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.PullRequestComment, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", PullRequestCommentsPrefix, org, project, num)
+	val := get(p, key, t)
 
 	if val != nil {
 		return val.GHPullRequestComments, nil
 	}
 
-	logrus.Debugf("cache miss for %v: %s", key)
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
 
 	cs := []*github.PullRequestComment{}
 	opts := &github.PullRequestListCommentsOptions{
@@ -106,7 +132,7 @@ func PullRequestsListComments(ctx context.Context, p persist.Cacher, c *github.C
 	for {
 		csp, resp, err := c.PullRequests.ListComments(ctx, org, project, num, opts)
 		if err != nil {
-			return nil, fmt.Errorf("get: %v", err)
+			return nil, fmt.Errorf("get: %w", err)
 		}
 
 		cs = append(cs, csp...)
@@ -117,35 +143,88 @@ func PullRequestsListComments(ctx context.Context, p persist.Cacher, c *github.C
 		opts.ListOptions.Page = resp.NextPage
 	}
 
-	return cs, p.Set(key, &persist.Blob{GHPullRequestComments: cs})
+	store(p, org, project, key, &persist.Blob{GHPullRequestComments: cs})
+	return cs, nil
 }
 
-func IssuesGet(ctx context.Context, p persist.Cacher, c *github.Client, t time.Time, org string, project string, num int) (*github.Issue, error) {
-	key := fmt.Sprintf("issue-%s-%s-%d", org, project, num)
-	val := p.Get(key, t)
+func IssuesGet(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) (*github.Issue, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", IssuePrefix, org, project, num)
+	val := get(p, key, t)
 
-	if val != nil {
+	if val != nil && !stale(val.GHIssue.GetUpdatedAt(), t) {
 		return val.GHIssue, nil
 	}
 
-	logrus.Debugf("cache miss for %v: %s", key)
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
 
-	i, _, err := c.Issues.Get(ctx, org, project, num)
+	// Fetched raw, as this version of go-github doesn't decode state_reason
+	req, err := c.NewRequest("GET", fmt.Sprintf("repos/%s/%s/issues/%d", org, project, num), nil)
 	if err != nil {
-		return nil, fmt.Errorf("get: %v", err)
+		return nil, fmt.Errorf("get: %w", err)
 	}
+	req.Header.Set("Accept", "application/vnd.github.squirrel-girl-preview")
 
-	return i, p.Set(key, &persist.Blob{GHIssue: i})
+	var raw json.RawMessage
+	if _, err := c.Do(ctx, req, &raw); err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+
+	i := &github.Issue{}
+	if err := json.Unmarshal(raw, i); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	reason := struct {
+		StateReason string `json:"state_reason"`
+	}{}
+	if err := json.Unmarshal(raw, &reason); err != nil {
+		return nil, fmt.Errorf("decode: %w", err)
+	}
+
+	store(p, org, project, key, &persist.Blob{GHIssue: i})
+	storeJSON(p, org, project, stateReasonKey(org, project, num), reason.StateReason)
+	return i, nil
+}
+
+func stateReasonKey(org string, project string, num int) string {
+	return fmt.Sprintf("%s-%s-%s-%d", IssueStateReasonPrefix, org, project, num)
+}
+
+// IssueStateReason returns why an issue fetched by IssuesGet was closed, such as completed or not_planned.
+// It is empty if the issue is open, or was cached before reasons were.
+func IssueStateReason(p cache.Cacher, t time.Time, org string, project string, num int) string {
+	val := getValue(p, stateReasonKey(org, project, num), t)
+	if val == nil {
+		return ""
+	}
+
+	reason := ""
+	if err := loadJSON(val, &reason); err != nil {
+		return ""
+	}
+	return reason
 }
 
-func IssuesListComments(ctx context.Context, p persist.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.IssueComment, error) {
-	key := fmt.Sprintf("issue-comments-%s-%s-%d", org, project, num)
-	val := p.Get(key, t)
+// IssuesListComments returns the comments on an issue. If limit is positive, pagination stops once more than limit comments are found.
+func IssuesListComments(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int, limit int) ([]*github.IssueComment, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", IssueCommentsPrefix, org, project, num)
+	if limit > 0 {
+		// Partial results must not be mistaken for the full list
+		key = fmt.Sprintf("%s-max%d", key, limit)
+	}
+	val := get(p, key, t)
 
 	if val != nil {
 		return val.GHIssueComments, nil
 	}
 
+	if Offline {
+		return nil, offlineMiss(key)
+	}
+
 	opts := &github.IssueListCommentsOptions{
 		ListOptions: github.ListOptions{PerPage: 100},
 	}
@@ -154,7 +233,7 @@ func IssuesListComments(ctx context.Context, p persist.Cacher, c *github.Client,
 	for {
 		csp, resp, err := c.Issues.ListComments(ctx, org, project, num, opts)
 		if err != nil {
-			return nil, fmt.Errorf("get: %v", err)
+			return nil, fmt.Errorf("get: %w", err)
 		}
 
 		cs = append(cs, csp...)
@@ -163,8 +242,208 @@ func IssuesListComments(ctx context.Context, p persist.Cacher, c *github.Client,
 			break
 		}
 
+		if limit > 0 && len(cs) > limit {
+			logrus.Debugf("%s/%s #%d has more than %d comments, not fetching more", org, project, num, limit)
+			break
+		}
+
 		opts.ListOptions.Page = resp.NextPage
 	}
 
-	return cs, p.Set(key, &persist.Blob{GHIssueComments: cs})
+	store(p, org, project, key, &persist.Blob{GHIssueComments: cs})
+	return cs, nil
+}
+
+// fileContents is how RepositoriesGetContents caches a file. Found is false for a file which does not exist.
+type fileContents struct {
+	Found   bool   `json:"found"`
+	Content string `json:"content"`
+}
+
+// RepositoriesGetContents returns the contents of a file on the default branch, or "" if it does not exist.
+func RepositoriesGetContents(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, path string) (string, error) {
+	key := ContentsPrefix + "-" + contentsRepo(org, project) + path
+	val := getValue(p, key, t)
+
+	if val != nil {
+		fc := fileContents{}
+		if err := loadJSON(val, &fc); err == nil {
+			return fc.Content, nil
+		}
+		logrus.Warningf("unreadable cache entry for %v, refetching", key)
+	}
+
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return "", offlineMiss(key)
+	}
+
+	fc, _, resp, err := c.Repositories.GetContents(ctx, org, project, path, nil)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			storeJSON(p, org, project, key, fileContents{})
+			return "", nil
+		}
+		return "", fmt.Errorf("get: %w", err)
+	}
+
+	content, err := fc.GetContent()
+	if err != nil {
+		return "", fmt.Errorf("decode: %w", err)
+	}
+
+	storeJSON(p, org, project, key, fileContents{Found: true, Content: content})
+	return content, nil
+}
+
+func IssuesListTimeline(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.Timeline, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", IssueTimelinePrefix, org, project, num)
+	val := getValue(p, key, t)
+
+	if val != nil {
+		es := []*github.Timeline{}
+		if err := loadJSON(val, &es); err == nil {
+			return es, nil
+		}
+		logrus.Warningf("unreadable cache entry for %v, refetching", key)
+	}
+
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	es := []*github.Timeline{}
+	for {
+		esp, resp, err := c.Issues.ListIssueTimeline(ctx, org, project, num, opts)
+		if err != nil {
+			return nil, fmt.Errorf("get: %w", err)
+		}
+
+		es = append(es, esp...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	storeJSON(p, org, project, key, es)
+	return es, nil
+}
+
+// PullRequestsListReviews returns the reviews of a pull request
+func PullRequestsListReviews(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, project string, num int) ([]*github.PullRequestReview, error) {
+	key := fmt.Sprintf("%s-%s-%s-%d", PullRequestReviewsPrefix, org, project, num)
+	val := getValue(p, key, t)
+
+	if val != nil {
+		rs := []*github.PullRequestReview{}
+		if err := loadJSON(val, &rs); err == nil {
+			return rs, nil
+		}
+		logrus.Warningf("unreadable cache entry for %v, refetching", key)
+	}
+
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
+
+	opts := &github.ListOptions{PerPage: 100}
+	rs := []*github.PullRequestReview{}
+	for {
+		rsp, resp, err := c.PullRequests.ListReviews(ctx, org, project, num, opts)
+		if err != nil {
+			return nil, fmt.Errorf("get: %w", err)
+		}
+
+		rs = append(rs, rsp...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	storeJSON(p, org, project, key, rs)
+	return rs, nil
+}
+
+// TeamMembersBySlug returns the members of an organization's team, including members of its child teams.
+// A team that does not exist is an error wrapping ErrNotFound.
+func TeamMembersBySlug(ctx context.Context, p cache.Cacher, c *github.Client, t time.Time, org string, slug string) ([]*github.User, error) {
+	key := fmt.Sprintf("%s-%s-%s", TeamMembersPrefix, org, slug)
+	val := getValue(p, key, t)
+
+	if val != nil {
+		us := []*github.User{}
+		if err := loadJSON(val, &us); err == nil {
+			return us, nil
+		}
+		logrus.Warningf("unreadable cache entry for %v, refetching", key)
+	}
+
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return nil, offlineMiss(key)
+	}
+
+	opts := &github.TeamListTeamMembersOptions{ListOptions: github.ListOptions{PerPage: 100}}
+	us := []*github.User{}
+	for {
+		usp, resp, err := c.Teams.ListTeamMembersBySlug(ctx, org, slug, opts)
+		if err != nil {
+			if resp != nil && resp.StatusCode == http.StatusNotFound {
+				return nil, fmt.Errorf("team %s/%s: %w", org, slug, ErrNotFound)
+			}
+			return nil, fmt.Errorf("get: %w", err)
+		}
+
+		us = append(us, usp...)
+
+		if resp.NextPage == 0 {
+			break
+		}
+
+		opts.Page = resp.NextPage
+	}
+
+	storeJSON(p, org, slug, key, us)
+	return us, nil
+}
+
+// Cached returns whether the entry for a numbered item, such as a PR's files, is cached as of t, without fetching
+// it or counting the lookup
+func Cached(p cache.Cacher, t time.Time, prefix string, org string, project string, num int) bool {
+	key := fmt.Sprintf("%s-%s-%s-%d", prefix, org, project, num)
+	return p.Get(key, t) != nil || p.GetValue(key, t) != nil
+}
+
+// storeJSON caches a value which persist.Blob has no field for, such as timelines or reviews, as JSON
+func storeJSON(p cache.Cacher, org string, project string, key string, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		digest.Add(digest.CacheWrite, org+"/"+project, key, "%v", err)
+		return
+	}
+
+	if err := p.SetValue(key, &cache.Value{Data: b}); err != nil {
+		digest.Add(digest.CacheWrite, org+"/"+project, key, "%v", err)
+	}
+}
+
+// loadJSON decodes a value cached by storeJSON
+func loadJSON(val *cache.Value, v interface{}) error {
+	return json.Unmarshal(val.Data, v)
+}
+
+// store caches a blob. Failures are recorded in the digest rather than failing the request, as the data is still usable.
+func store(p cache.Cacher, org string, project string, key string, blob *persist.Blob) {
+	if err := p.Set(key, blob); err != nil {
+		digest.Add(digest.CacheWrite, org+"/"+project, key, "%v", err)
+	}
 }
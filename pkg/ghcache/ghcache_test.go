@@ -0,0 +1,192 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/persist"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+func newMemoryCache(t *testing.T) cache.Cacher {
+	t.Helper()
+	p, err := cache.New(cache.Config{Backend: "memory"})
+	if err != nil {
+		t.Fatalf("new cache: %v", err)
+	}
+	if err := p.Initialize(); err != nil {
+		t.Fatalf("initialize: %v", err)
+	}
+	return p
+}
+
+func TestRepositoriesGetContentsCached(t *testing.T) {
+	p := newMemoryCache(t)
+	storeJSON(p, "org", "project", ContentsPrefix+"-org-project:.mailmap", fileContents{Found: true, Content: "A <a@example.com>\n"})
+	storeJSON(p, "org", "project", ContentsPrefix+"-org-project:CODEOWNERS", fileContents{})
+
+	// A nil client fails the test with a panic if the cache is missed
+	got, err := RepositoriesGetContents(context.Background(), p, nil, time.Time{}, "org", "project", ".mailmap")
+	if err != nil || got != "A <a@example.com>\n" {
+		t.Errorf("RepositoriesGetContents(.mailmap) = %q, %v, want the cached contents", got, err)
+	}
+
+	got, err = RepositoriesGetContents(context.Background(), p, nil, time.Time{}, "org", "project", "CODEOWNERS")
+	if err != nil || got != "" {
+		t.Errorf("RepositoriesGetContents(CODEOWNERS) = %q, %v, want \"\" for a missing file", got, err)
+	}
+}
+
+func TestLoadJSON(t *testing.T) {
+	p := newMemoryCache(t)
+	storeJSON(p, "org", "project", "key", fileContents{Found: true, Content: "x"})
+
+	fc := fileContents{}
+	if err := loadJSON(p.GetValue("key", time.Time{}), &fc); err != nil || fc.Content != "x" {
+		t.Errorf("loadJSON() = %+v, %v, want the stored value", fc, err)
+	}
+	if b := p.Get("key", time.Time{}); b != nil {
+		t.Errorf("Get() = %+v, want no blob for a JSON value", b)
+	}
+
+	if err := loadJSON(&cache.Value{Data: []byte("{")}, &fc); err == nil {
+		t.Errorf("loadJSON() of a truncated value returned no error")
+	}
+}
+
+func TestRepositoriesGetContentsOffline(t *testing.T) {
+	p := newMemoryCache(t)
+	Offline = true
+	defer func() { Offline = false }()
+	if _, err := RepositoriesGetContents(context.Background(), p, nil, time.Time{}, "org", "project", "missing"); err == nil {
+		t.Errorf("RepositoriesGetContents() offline with nothing cached returned no error")
+	}
+}
+
+// testGitHub returns a client of a GitHub API served by h
+func testGitHub(t *testing.T, h http.HandlerFunc) *github.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+
+	c := github.NewClient(srv.Client())
+	u, err := url.Parse(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.BaseURL = u
+	return c
+}
+
+func TestRefreshedWhenUpdated(t *testing.T) {
+	v1 := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	v2 := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	requests := 0
+	c := testGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"number": 1, "title": "reopened", "updated_at": %q}`, v2.Format(time.RFC3339))
+	})
+
+	t.Run("PullRequestsGet", func(t *testing.T) {
+		p := newMemoryCache(t)
+		// Written by a machine whose clock runs ahead, so the entry's Created time is no guide
+		future := time.Now().Add(24 * time.Hour)
+		if err := p.Set(PullRequestPrefix+"-org-project-1", &persist.Blob{GHPullRequest: &github.PullRequest{Title: github.String("first"), UpdatedAt: &v1}, Created: future}); err != nil {
+			t.Fatal(err)
+		}
+
+		requests = 0
+		pr, err := PullRequestsGet(context.Background(), p, c, v1, "org", "project", 1)
+		if err != nil || pr.GetTitle() != "first" || requests != 0 {
+			t.Errorf("PullRequestsGet() unchanged = %q, %v after %d requests, want the cached PR", pr.GetTitle(), err, requests)
+		}
+
+		pr, err = PullRequestsGet(context.Background(), p, c, v2, "org", "project", 1)
+		if err != nil || pr.GetTitle() != "reopened" || requests != 1 {
+			t.Errorf("PullRequestsGet() updated = %q, %v after %d requests, want the refetched PR", pr.GetTitle(), err, requests)
+		}
+
+		// The refetched PR replaces the cached one
+		pr, err = PullRequestsGet(context.Background(), p, c, v2, "org", "project", 1)
+		if err != nil || pr.GetTitle() != "reopened" || requests != 1 {
+			t.Errorf("PullRequestsGet() again = %q, %v after %d requests, want the newly cached PR", pr.GetTitle(), err, requests)
+		}
+	})
+
+	t.Run("IssuesGet", func(t *testing.T) {
+		p := newMemoryCache(t)
+		future := time.Now().Add(24 * time.Hour)
+		if err := p.Set(IssuePrefix+"-org-project-1", &persist.Blob{GHIssue: &github.Issue{Title: github.String("first"), UpdatedAt: &v1}, Created: future}); err != nil {
+			t.Fatal(err)
+		}
+
+		requests = 0
+		i, err := IssuesGet(context.Background(), p, c, v1, "org", "project", 1)
+		if err != nil || i.GetTitle() != "first" || requests != 0 {
+			t.Errorf("IssuesGet() unchanged = %q, %v after %d requests, want the cached issue", i.GetTitle(), err, requests)
+		}
+
+		i, err = IssuesGet(context.Background(), p, c, v2, "org", "project", 1)
+		if err != nil || i.GetTitle() != "reopened" || requests != 1 {
+			t.Errorf("IssuesGet() updated = %q, %v after %d requests, want the refetched issue", i.GetTitle(), err, requests)
+		}
+	})
+}
+
+func TestStaleOffline(t *testing.T) {
+	v1 := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	if !stale(v1, v1.Add(time.Second)) || stale(v1, v1) || stale(v1, time.Time{}) {
+		t.Errorf("stale() doesn't compare the update time with t")
+	}
+
+	// Offline, whatever is cached is all there is
+	Offline = true
+	defer func() { Offline = false }()
+	if stale(v1, v1.Add(time.Second)) {
+		t.Errorf("stale() = true while offline, want false")
+	}
+}
+
+func TestIssuesListTimelineCached(t *testing.T) {
+	requests := 0
+	c := testGitHub(t, func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`[{"event": "labeled", "label": {"name": "bug"}, "actor": {"login": "alice"}}, {"event": "milestoned", "milestone": {"title": "v1"}}]`))
+	})
+	p := newMemoryCache(t)
+
+	for i := 0; i < 2; i++ {
+		es, err := IssuesListTimeline(context.Background(), p, c, time.Time{}, "org", "project", 1)
+		if err != nil {
+			t.Fatalf("IssuesListTimeline() returned error: %v", err)
+		}
+		if len(es) != 2 || es[0].GetLabel().GetName() != "bug" || es[0].GetActor().GetLogin() != "alice" || es[1].GetMilestone().GetTitle() != "v1" {
+			t.Errorf("IssuesListTimeline() call %d = %+v, want the labeled and milestoned events", i+1, es)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1 with the second call cached", requests)
+	}
+}
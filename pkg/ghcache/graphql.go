@@ -0,0 +1,304 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/google/triage-party/pkg/persist"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+// GraphQLBatch is how many PRs are fetched per GraphQL query
+const GraphQLBatch = 50
+
+// graphQLPage is how many files, reviews, or labels of a PR a query returns. Those with more are left to REST.
+const graphQLPage = 100
+
+// gqlFields are the fields of a PR fetched by GraphQL, enough to fill in what PullRequestsGet, PullRequestsListFiles,
+// and PullRequestsListReviews return for the rest of the pipeline
+const gqlFields = `number title body url state isDraft createdAt updatedAt closedAt mergedAt merged
+additions deletions changedFiles authorAssociation baseRefName
+author { login }
+mergedBy { login }
+mergeCommit { oid }
+labels(first: 100) { totalCount nodes { name } }
+files(first: 100) { totalCount nodes { path additions deletions changeType } }
+reviews(first: 100) { totalCount nodes { databaseId state body submittedAt url author { login } } }`
+
+type gqlLogin struct {
+	Login string `json:"login"`
+}
+
+type gqlPullRequest struct {
+	Number            int        `json:"number"`
+	Title             string     `json:"title"`
+	Body              string     `json:"body"`
+	URL               string     `json:"url"`
+	State             string     `json:"state"`
+	IsDraft           bool       `json:"isDraft"`
+	CreatedAt         time.Time  `json:"createdAt"`
+	UpdatedAt         time.Time  `json:"updatedAt"`
+	ClosedAt          *time.Time `json:"closedAt"`
+	MergedAt          *time.Time `json:"mergedAt"`
+	Merged            bool       `json:"merged"`
+	Additions         int        `json:"additions"`
+	Deletions         int        `json:"deletions"`
+	ChangedFiles      int        `json:"changedFiles"`
+	AuthorAssociation string     `json:"authorAssociation"`
+	BaseRefName       string     `json:"baseRefName"`
+	Author            *gqlLogin  `json:"author"`
+	MergedBy          *gqlLogin  `json:"mergedBy"`
+	MergeCommit       *struct {
+		OID string `json:"oid"`
+	} `json:"mergeCommit"`
+	Labels struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Name string `json:"name"`
+		} `json:"nodes"`
+	} `json:"labels"`
+	Files struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			Path       string `json:"path"`
+			Additions  int    `json:"additions"`
+			Deletions  int    `json:"deletions"`
+			ChangeType string `json:"changeType"`
+		} `json:"nodes"`
+	} `json:"files"`
+	Reviews struct {
+		TotalCount int `json:"totalCount"`
+		Nodes      []struct {
+			DatabaseID  int64      `json:"databaseId"`
+			State       string     `json:"state"`
+			Body        string     `json:"body"`
+			SubmittedAt *time.Time `json:"submittedAt"`
+			URL         string     `json:"url"`
+			Author      *gqlLogin  `json:"author"`
+		} `json:"nodes"`
+	} `json:"reviews"`
+}
+
+type gqlResponse struct {
+	Data struct {
+		RateLimit struct {
+			Cost      int       `json:"cost"`
+			Remaining int       `json:"remaining"`
+			ResetAt   time.Time `json:"resetAt"`
+		} `json:"rateLimit"`
+		// Repository holds each PR under its alias, or null if it couldn't be fetched
+		Repository map[string]*gqlPullRequest `json:"repository"`
+	} `json:"data"`
+	Errors []struct {
+		Message string        `json:"message"`
+		Path    []interface{} `json:"path"`
+	} `json:"errors"`
+}
+
+// gqlChangeTypes maps GraphQL's change types to the file statuses REST reports
+var gqlChangeTypes = map[string]string{
+	"ADDED":    "added",
+	"DELETED":  "removed",
+	"MODIFIED": "modified",
+	"RENAMED":  "renamed",
+	"COPIED":   "copied",
+	"CHANGED":  "changed",
+}
+
+// PullRequestsGetBatch fetches the PRs which aren't cached as of their UpdatedAt with GraphQL, GraphQLBatch per
+// query, and caches their details, files, and reviews under the keys PullRequestsGet, PullRequestsListFiles, and
+// PullRequestsListReviews read. Files and reviews are only cached if all of them were returned. Queries pause while
+// GraphQL's point budget is too low for another.
+func PullRequestsGetBatch(ctx context.Context, p cache.Cacher, c *github.Client, org string, project string, prs []*github.PullRequest) error {
+	todo := []*github.PullRequest{}
+	for _, pr := range prs {
+		val := p.Get(fmt.Sprintf("%s-%s-%s-%d", PullRequestPrefix, org, project, pr.GetNumber()), pr.GetUpdatedAt())
+		if val == nil || stale(val.GHPullRequest.GetUpdatedAt(), pr.GetUpdatedAt()) {
+			todo = append(todo, pr)
+		}
+	}
+	if len(todo) == 0 || Offline {
+		return nil
+	}
+
+	logrus.Infof("Fetching %d of %d PRs in %s/%s with GraphQL", len(todo), len(prs), org, project)
+	for start := 0; start < len(todo); start += GraphQLBatch {
+		end := start + GraphQLBatch
+		if end > len(todo) {
+			end = len(todo)
+		}
+
+		nums := []int{}
+		for _, pr := range todo[start:end] {
+			nums = append(nums, pr.GetNumber())
+		}
+
+		r, err := graphQLPulls(ctx, c, org, project, nums)
+		if err != nil {
+			return fmt.Errorf("graphql: %w", err)
+		}
+
+		for _, e := range r.Errors {
+			logrus.Warningf("graphql %v: %s, leaving it to REST", e.Path, e.Message)
+		}
+		for _, gpr := range r.Data.Repository {
+			if gpr != nil {
+				storeGraphQLPull(p, org, project, gpr)
+			}
+		}
+
+		rl := r.Data.RateLimit
+		logrus.Debugf("graphql query cost %d points, %d remaining", rl.Cost, rl.Remaining)
+		if end < len(todo) && rl.Remaining < rl.Cost {
+			// A little slack, as GitHub's clock and ours may disagree
+			wait := time.Until(rl.ResetAt) + 5*time.Second
+			logrus.Infof("%d GraphQL points remaining, below the %d a query costs: pausing %s until the limit resets", rl.Remaining, rl.Cost, wait.Round(time.Second))
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+		}
+	}
+
+	return nil
+}
+
+// graphQLPulls queries the given PRs of a repository, each under the alias pr<number>
+func graphQLPulls(ctx context.Context, c *github.Client, org string, project string, nums []int) (*gqlResponse, error) {
+	var q strings.Builder
+	fmt.Fprintf(&q, "query($owner: String!, $name: String!) {\nrateLimit { cost remaining resetAt }\nrepository(owner: $owner, name: $name) {\n")
+	for _, n := range nums {
+		fmt.Fprintf(&q, "pr%d: pullRequest(number: %d) { %s }\n", n, n, gqlFields)
+	}
+	q.WriteString("}\n}")
+
+	body := map[string]interface{}{
+		"query":     q.String(),
+		"variables": map[string]string{"owner": org, "name": project},
+	}
+
+	req, err := c.NewRequest("POST", graphQLURL(c), body)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &gqlResponse{}
+	if _, err := c.Do(ctx, req, r); err != nil {
+		return nil, err
+	}
+	if r.Data.Repository == nil && len(r.Errors) > 0 {
+		return nil, fmt.Errorf("%s", r.Errors[0].Message)
+	}
+	return r, nil
+}
+
+// graphQLURL returns the GraphQL endpoint of the API c talks to. GitHub Enterprise serves it beside /api/v3.
+func graphQLURL(c *github.Client) string {
+	u := *c.BaseURL
+	if strings.HasSuffix(u.Path, "/api/v3/") {
+		u.Path = strings.TrimSuffix(u.Path, "v3/") + "graphql"
+		return u.String()
+	}
+	u.Path += "graphql"
+	return u.String()
+}
+
+// storeGraphQLPull caches a PR fetched with GraphQL as REST would have returned it
+func storeGraphQLPull(p cache.Cacher, org string, project string, g *gqlPullRequest) {
+	author := "ghost"
+	if g.Author != nil {
+		author = g.Author.Login
+	}
+
+	state := "closed"
+	if g.State == "OPEN" {
+		state = "open"
+	}
+
+	pr := &github.PullRequest{
+		Number:            github.Int(g.Number),
+		Title:             github.String(g.Title),
+		Body:              github.String(g.Body),
+		HTMLURL:           github.String(g.URL),
+		State:             github.String(state),
+		Draft:             github.Bool(g.IsDraft),
+		CreatedAt:         &g.CreatedAt,
+		UpdatedAt:         &g.UpdatedAt,
+		ClosedAt:          g.ClosedAt,
+		MergedAt:          g.MergedAt,
+		Merged:            github.Bool(g.Merged),
+		Additions:         github.Int(g.Additions),
+		Deletions:         github.Int(g.Deletions),
+		ChangedFiles:      github.Int(g.ChangedFiles),
+		AuthorAssociation: github.String(g.AuthorAssociation),
+		User:              &github.User{Login: github.String(author)},
+		Base:              &github.PullRequestBranch{Ref: github.String(g.BaseRefName)},
+	}
+	if g.MergeCommit != nil {
+		pr.MergeCommitSHA = github.String(g.MergeCommit.OID)
+	}
+	if g.MergedBy != nil {
+		pr.MergedBy = &github.User{Login: github.String(g.MergedBy.Login)}
+	}
+	// Labels filter PRs, so one with more than a page of them is left to REST entirely
+	if g.Labels.TotalCount > graphQLPage {
+		return
+	}
+	for _, l := range g.Labels.Nodes {
+		pr.Labels = append(pr.Labels, &github.Label{Name: github.String(l.Name)})
+	}
+	store(p, org, project, fmt.Sprintf("%s-%s-%s-%d", PullRequestPrefix, org, project, g.Number), &persist.Blob{GHPullRequest: pr})
+
+	if g.Files.TotalCount <= graphQLPage {
+		fs := []*github.CommitFile{}
+		for _, f := range g.Files.Nodes {
+			fs = append(fs, &github.CommitFile{
+				Filename:  github.String(f.Path),
+				Additions: github.Int(f.Additions),
+				Deletions: github.Int(f.Deletions),
+				Changes:   github.Int(f.Additions + f.Deletions),
+				Status:    github.String(gqlChangeTypes[f.ChangeType]),
+			})
+		}
+		store(p, org, project, fmt.Sprintf("%s-%s-%s-%d", PullRequestFilesPrefix, org, project, g.Number), &persist.Blob{GHCommitFiles: fs})
+	}
+
+	if g.Reviews.TotalCount <= graphQLPage {
+		rs := []*github.PullRequestReview{}
+		for _, r := range g.Reviews.Nodes {
+			login := "ghost"
+			if r.Author != nil {
+				login = r.Author.Login
+			}
+			rs = append(rs, &github.PullRequestReview{
+				ID:          github.Int64(r.DatabaseID),
+				User:        &github.User{Login: github.String(login)},
+				State:       github.String(r.State),
+				Body:        github.String(r.Body),
+				SubmittedAt: r.SubmittedAt,
+				HTMLURL:     github.String(r.URL),
+			})
+		}
+		storeJSON(p, org, project, fmt.Sprintf("%s-%s-%s-%d", PullRequestReviewsPrefix, org, project, g.Number), rs)
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Key prefixes name the kind of data a cache entry holds. Entries for a repository are keyed
+// <prefix>-<org>-<project>-<number>, file contents <prefix>-<org>-<project>:<path>, pages of lists <prefix>-<org>-<project>-<page>-q<query hash>,
+// team memberships <prefix>-<org>-<team>, pages of an organization's repositories <prefix>-<org>-<page>-q<query hash>,
+// and search counts <prefix>-<org>-q<query hash>.
+const (
+	PullRequestPrefix         = "pr"
+	PullRequestFilesPrefix    = "pr-listfiles"
+	PullRequestCommentsPrefix = "pr-comments"
+	PullRequestReviewsPrefix  = "pr-reviews"
+	IssuePrefix               = "issue"
+	IssueStateReasonPrefix    = "issue-state-reason"
+	IssueCommentsPrefix       = "issue-comments"
+	IssueTimelinePrefix       = "issue-timeline"
+	ContentsPrefix            = "contents"
+	TeamMembersPrefix         = "team-members"
+	PullRequestListPrefix     = "list-pulls"
+	IssueListPrefix           = "list-issues"
+	RepositoryListPrefix      = "list-repos"
+	SearchCountPrefix         = "search-count"
+)
+
+// Kinds groups the key prefixes by the kind of data users select them by
+var Kinds = map[string][]string{
+	"pr":       {PullRequestPrefix, PullRequestFilesPrefix},
+	"issue":    {IssuePrefix, IssueStateReasonPrefix, IssueTimelinePrefix},
+	"comments": {PullRequestCommentsPrefix, IssueCommentsPrefix},
+	"reviews":  {PullRequestReviewsPrefix},
+	"contents": {ContentsPrefix},
+	"teams":    {TeamMembersPrefix},
+	"lists":    {PullRequestListPrefix, IssueListPrefix, RepositoryListPrefix},
+	"search":   {SearchCountPrefix},
+}
+
+// prefixes are the key prefixes, longest first, so that pr-comments isn't mistaken for pr
+var prefixes = func() []string {
+	ps := []string{}
+	for _, kps := range Kinds {
+		ps = append(ps, kps...)
+	}
+	sort.Slice(ps, func(i, j int) bool { return len(ps[i]) > len(ps[j]) })
+	return ps
+}()
+
+// idSuffix is what follows the repository in keys for pull requests, issues, and pages of lists of them
+const idSuffix = `\d+(-max\d+|-q[0-9a-f]{8})?`
+
+// numberedRe matches the idSuffix of a key
+var numberedRe = regexp.MustCompile(`^` + idSuffix + `$`)
+
+// KeyPrefix returns the prefix of a cache key, or "" if it wasn't written by this package
+func KeyPrefix(key string) string {
+	for _, p := range prefixes {
+		if strings.HasPrefix(key, p+"-") {
+			return p
+		}
+	}
+	return ""
+}
+
+// KeyKind returns the kind of data a cache key holds, as named in Kinds, or "" if unknown
+func KeyKind(key string) string {
+	p := KeyPrefix(key)
+	if p == "" {
+		return ""
+	}
+	for k, kps := range Kinds {
+		for _, kp := range kps {
+			if kp == p {
+				return k
+			}
+		}
+	}
+	return ""
+}
+
+// contentsRepo returns the part of a contents key, after its prefix, which names the repository
+func contentsRepo(org string, project string) string {
+	return org + "-" + project + ":"
+}
+
+// KeyRepo returns whether a cache key holds data for the org/project repository, ignoring case.
+// Team memberships belong to no repository.
+func KeyRepo(key string, org string, project string) bool {
+	p := KeyPrefix(key)
+	if p == "" || p == TeamMembersPrefix {
+		return false
+	}
+
+	rest := strings.ToLower(strings.TrimPrefix(key, p+"-"))
+	// Repository names can't contain a colon, so a path can't be mistaken for the end of one
+	if p == ContentsPrefix {
+		return strings.HasPrefix(rest, strings.ToLower(contentsRepo(org, project)))
+	}
+
+	want := strings.ToLower(org + "-" + project + "-")
+	if !strings.HasPrefix(rest, want) {
+		return false
+	}
+	// Projects may contain dashes: org-test-infra-5 must not match org/test
+	return numberedRe.MatchString(strings.TrimPrefix(rest, want))
+}
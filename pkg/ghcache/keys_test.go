@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import "testing"
+
+func TestKeyRepo(t *testing.T) {
+	tests := []struct {
+		key  string
+		want bool
+	}{
+		{"pr-org-test-5", true},
+		{"pr-listfiles-Org-Test-5", true},
+		{"list-pulls-org-test-2-q0123abcd", true},
+		{"issue-org-test-7-max100", true},
+		{"contents-org-test:CODEOWNERS", true},
+		{"contents-org-test:.github/CODEOWNERS", true},
+		// Projects may contain dashes, which must not be read as the end of a shorter project's name
+		{"pr-org-test-infra-5", false},
+		{"contents-org-test-infra:CODEOWNERS", false},
+		{"contents-org-test-infra:test-CODEOWNERS", false},
+		{"contents-org-testing:CODEOWNERS", false},
+		{"team-members-org-test", false},
+		{"unknown-org-test-5", false},
+	}
+
+	for _, tc := range tests {
+		if got := KeyRepo(tc.key, "org", "test"); got != tc.want {
+			t.Errorf("KeyRepo(%q, org, test) = %v, want %v", tc.key, got, tc.want)
+		}
+	}
+
+	if !KeyRepo("contents-org-test-infra:CODEOWNERS", "org", "test-infra") {
+		t.Errorf("KeyRepo() doesn't match contents of org/test-infra")
+	}
+}
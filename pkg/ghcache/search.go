@@ -0,0 +1,72 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+// SearchIssuesCount returns how many issues and PRs within org match a search query. Counts are cached however old,
+// so should only be searched for what doesn't change, such as the PRs merged before a date.
+func SearchIssuesCount(ctx context.Context, p cache.Cacher, c *github.Client, org string, q string) (int, error) {
+	h := fnv.New32a()
+	h.Write([]byte(q))
+	key := fmt.Sprintf("%s-%s-q%08x", SearchCountPrefix, org, h.Sum32())
+
+	if val := getValue(p, key, time.Time{}); val != nil {
+		var n int
+		if err := loadJSON(val, &n); err == nil {
+			return n, nil
+		}
+		logrus.Warningf("unreadable cache entry for %v, refetching", key)
+	}
+
+	logrus.Debugf("cache miss for %v", key)
+	if Offline {
+		return 0, offlineMiss(key)
+	}
+
+	opts := &github.SearchOptions{ListOptions: github.ListOptions{PerPage: 1}}
+	for {
+		r, _, err := c.Search.Issues(ctx, q, opts)
+		// The search API has a lower limit of its own, which go-github enforces without asking GitHub
+		var rle *github.RateLimitError
+		if errors.As(err, &rle) {
+			wait := time.Until(rle.Rate.Reset.Time) + time.Second
+			logrus.Infof("search rate limit reached: pausing %s until it resets", wait.Round(time.Second))
+			select {
+			case <-ctx.Done():
+				return 0, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+		if err != nil {
+			return 0, fmt.Errorf("search %q: %w", q, err)
+		}
+
+		storeJSON(p, org, "", key, r.GetTotal())
+		return r.GetTotal(), nil
+	}
+}
@@ -0,0 +1,211 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ghcache
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/triage-party/pkg/persist"
+
+	"github.com/google/pullsheet/pkg/cache"
+)
+
+// Counters count cache hits and misses by key prefix
+type Counters struct {
+	mu     sync.Mutex
+	hits   map[string]int64
+	misses map[string]int64
+}
+
+// RunCounters are the counters for the current process
+var RunCounters = &Counters{hits: map[string]int64{}, misses: map[string]int64{}}
+
+func (c *Counters) record(key string, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if hit {
+		c.hits[KeyPrefix(key)]++
+	} else {
+		c.misses[KeyPrefix(key)]++
+	}
+}
+
+// CounterSnapshot is a copy of counters at a point in time
+type CounterSnapshot struct {
+	Hits   map[string]int64 `json:"hits"`
+	Misses map[string]int64 `json:"misses"`
+}
+
+// Snapshot returns a copy of the counters
+func (c *Counters) Snapshot() CounterSnapshot {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := CounterSnapshot{Hits: map[string]int64{}, Misses: map[string]int64{}}
+	for p, n := range c.hits {
+		s.Hits[p] = n
+	}
+	for p, n := range c.misses {
+		s.Misses[p] = n
+	}
+	return s
+}
+
+// Totals returns the hits and misses of every prefix
+func (s CounterSnapshot) Totals() (hits int64, misses int64) {
+	for _, n := range s.Hits {
+		hits += n
+	}
+	for _, n := range s.Misses {
+		misses += n
+	}
+	return hits, misses
+}
+
+// String returns a one-line summary, or an empty string if nothing was looked up
+func (c *Counters) String() string {
+	hits, misses := c.Snapshot().Totals()
+	if hits+misses == 0 {
+		return ""
+	}
+	if Offline {
+		return fmt.Sprintf("offline: %d entries served from cache, %d missing", hits, misses)
+	}
+	return fmt.Sprintf("cache: %d hits, %d misses", hits, misses)
+}
+
+// get looks up a cache entry, counting whether it was found. While Offline, entries of any age are found.
+func get(p cache.Cacher, key string, t time.Time) *persist.Blob {
+	if Offline {
+		t = time.Time{}
+	}
+	val := p.Get(key, t)
+	RunCounters.record(key, val != nil)
+	return val
+}
+
+// getValue looks up a cached value, as get does a blob
+func getValue(p cache.Cacher, key string, t time.Time) *cache.Value {
+	if Offline {
+		t = time.Time{}
+	}
+	val := p.GetValue(key, t)
+	RunCounters.record(key, val != nil)
+	return val
+}
+
+// Group summarizes a set of cache entries
+type Group struct {
+	Name    string    `json:"name"`
+	Entries int       `json:"entries"`
+	Bytes   int64     `json:"bytes"`
+	Oldest  time.Time `json:"oldest"`
+	Newest  time.Time `json:"newest"`
+}
+
+func (g *Group) add(e cache.Entry) {
+	g.Entries++
+	g.Bytes += e.Size
+	if g.Oldest.IsZero() || e.Created.Before(g.Oldest) {
+		g.Oldest = e.Created
+	}
+	if e.Created.After(g.Newest) {
+		g.Newest = e.Created
+	}
+}
+
+// AgeBuckets are the upper bounds of the age distribution reported by Summarize
+var AgeBuckets = []time.Duration{24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour, 90 * 24 * time.Hour}
+
+// Summary describes the entries of a cache
+type Summary struct {
+	Total Group `json:"total"`
+	// Prefixes groups entries by key prefix, with "other" for keys not written by this package
+	Prefixes []Group `json:"prefixes"`
+	// Repos groups entries by the org-project part of their keys, as the dash separating the two is ambiguous
+	Repos []Group `json:"repos"`
+	// Ages groups entries by age, with names such as "<7d" and ">=90d"
+	Ages []Group `json:"ages"`
+}
+
+// repoPartRe finds the org-project part of keys for pull requests, issues, and lists of them
+var repoPartRe = regexp.MustCompile(`^(.+)-` + idSuffix + `$`)
+
+// Summarize groups cache entries by prefix, repository, and age
+func Summarize(es []cache.Entry, now time.Time) Summary {
+	s := Summary{Total: Group{Name: "total"}}
+	prefixes := map[string]*Group{}
+	repos := map[string]*Group{}
+	ages := make([]Group, len(AgeBuckets)+1)
+	for i, b := range AgeBuckets {
+		ages[i].Name = fmt.Sprintf("<%dd", int(b.Hours()/24))
+	}
+	ages[len(AgeBuckets)].Name = fmt.Sprintf(">=%dd", int(AgeBuckets[len(AgeBuckets)-1].Hours()/24))
+
+	for _, e := range es {
+		s.Total.add(e)
+
+		p := KeyPrefix(e.Key)
+		name := p
+		if name == "" {
+			name = "other"
+		}
+		if prefixes[name] == nil {
+			prefixes[name] = &Group{Name: name}
+		}
+		prefixes[name].add(e)
+
+		// Contents keys end in a path, and team keys name no repository, so neither can be attributed
+		if p != "" && p != ContentsPrefix && p != TeamMembersPrefix {
+			if m := repoPartRe.FindStringSubmatch(e.Key[len(p)+1:]); m != nil {
+				if repos[m[1]] == nil {
+					repos[m[1]] = &Group{Name: m[1]}
+				}
+				repos[m[1]].add(e)
+			}
+		}
+
+		i := 0
+		for i < len(AgeBuckets) && now.Sub(e.Created) >= AgeBuckets[i] {
+			i++
+		}
+		ages[i].add(e)
+	}
+
+	s.Prefixes = sortedGroups(prefixes)
+	s.Repos = sortedGroups(repos)
+	s.Ages = ages
+	return s
+}
+
+// sortedGroups returns groups by descending size, then name
+func sortedGroups(m map[string]*Group) []Group {
+	gs := []Group{}
+	for _, g := range m {
+		gs = append(gs, *g)
+	}
+	sort.Slice(gs, func(i, j int) bool {
+		if gs[i].Bytes != gs[j].Bytes {
+			return gs[i].Bytes > gs[j].Bytes
+		}
+		return gs[i].Name < gs[j].Name
+	})
+	return gs
+}
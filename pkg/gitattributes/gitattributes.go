@@ -0,0 +1,219 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gitattributes parses .gitattributes files, as far as needed to find
+// files that linguist considers generated or vendored.
+package gitattributes
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Attribute states
+const (
+	Unspecified = ""
+	Set         = "true"
+	Unset       = "false"
+)
+
+// Rule is a single .gitattributes line
+type Rule struct {
+	Line    int
+	Pattern string
+	// Attrs maps attribute names to Set, Unset, Unspecified, or a value
+	Attrs map[string]string
+	re    *regexp.Regexp
+	base  bool
+}
+
+// Match returns whether a repository-relative path matches the rule
+func (r *Rule) Match(p string) bool {
+	p = strings.TrimPrefix(p, "/")
+	if r.base {
+		return r.re.MatchString(path.Base(p))
+	}
+	return r.re.MatchString(p)
+}
+
+// Attributes is a parsed .gitattributes file
+type Attributes struct {
+	Rules []*Rule
+}
+
+// Get returns the state of an attribute for a path. As with git, later lines override earlier ones.
+func (a *Attributes) Get(p string, attr string) string {
+	for i := len(a.Rules) - 1; i >= 0; i-- {
+		r := a.Rules[i]
+		v, ok := r.Attrs[attr]
+		if !ok || !r.Match(p) {
+			continue
+		}
+		return v
+	}
+	return Unspecified
+}
+
+// Generated returns whether linguist would consider a path generated or vendored
+func (a *Attributes) Generated(p string) bool {
+	for _, attr := range []string{"linguist-generated", "linguist-vendored"} {
+		if v := a.Get(p, attr); v != Unspecified && v != Unset {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse parses a .gitattributes file, returning the rules that could be read and an error per malformed line
+func Parse(r io.Reader) (*Attributes, []error) {
+	a := &Attributes{}
+	errs := []error{}
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		pattern, rest := splitPattern(line)
+		if strings.HasPrefix(pattern, "!") {
+			errs = append(errs, fmt.Errorf("line %d: negative patterns are not allowed: %q", n, pattern))
+			continue
+		}
+
+		// Macro definitions do not apply to paths
+		if strings.HasPrefix(pattern, "[attr]") {
+			continue
+		}
+
+		re, base, err := compile(pattern)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: pattern %q: %v", n, pattern, err))
+			continue
+		}
+
+		a.Rules = append(a.Rules, &Rule{
+			Line:    n,
+			Pattern: pattern,
+			Attrs:   parseAttrs(rest),
+			re:      re,
+			base:    base,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return a, errs
+}
+
+// splitPattern splits a line into its pattern, which may be quoted, and the attributes that follow
+func splitPattern(line string) (string, string) {
+	if strings.HasPrefix(line, `"`) {
+		if end := strings.Index(line[1:], `"`); end >= 0 {
+			return line[1 : end+1], line[end+2:]
+		}
+	}
+
+	fields := strings.Fields(line)
+	return fields[0], strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+}
+
+// parseAttrs parses attribute assignments: "attr", "-attr", "!attr", or "attr=value"
+func parseAttrs(s string) map[string]string {
+	attrs := map[string]string{}
+	for _, f := range strings.Fields(s) {
+		switch {
+		case strings.HasPrefix(f, "-"):
+			attrs[f[1:]] = Unset
+		case strings.HasPrefix(f, "!"):
+			attrs[f[1:]] = Unspecified
+		case strings.Contains(f, "="):
+			kv := strings.SplitN(f, "=", 2)
+			attrs[kv[0]] = kv[1]
+		default:
+			attrs[f] = Set
+		}
+	}
+	return attrs
+}
+
+// compile converts a gitattributes glob to a regular expression. Patterns without a slash match
+// the basename at any depth; otherwise they are anchored to the repository root. Unlike
+// .gitignore, a pattern naming a directory does not match the files within it.
+func compile(pattern string) (*regexp.Regexp, bool, error) {
+	p := strings.TrimPrefix(pattern, "/")
+	base := !strings.Contains(pattern, "/")
+	if p == "" {
+		return nil, false, fmt.Errorf("empty pattern")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("^")
+
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				sb.WriteString(regexp.QuoteMeta(string(p[i])))
+			}
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' && (i == 0 || p[i-1] == '/') {
+				i++
+				switch {
+				case i+1 < len(p) && p[i+1] == '/':
+					// "**/" matches zero or more directories
+					i++
+					sb.WriteString("(?:.*/)?")
+				case i+1 == len(p):
+					// A trailing "/**" matches everything inside
+					sb.WriteString(".*")
+				default:
+					sb.WriteString("[^/]*")
+				}
+				continue
+			}
+			sb.WriteString("[^/]*")
+		case '?':
+			sb.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				return nil, false, fmt.Errorf("unterminated character class")
+			}
+			class := p[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			sb.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+
+	re, err := regexp.Compile(sb.String())
+	return re, base, err
+}
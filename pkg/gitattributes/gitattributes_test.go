@@ -0,0 +1,112 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gitattributes
+
+import (
+	"strings"
+	"testing"
+)
+
+const testFile = `# Linguist overrides
+*.pb.go         linguist-generated
+vendor/**       linguist-vendored
+/dist/*.js      linguist-generated=true
+docs/**         linguist-documentation
+**/mocks/*.go   linguist-generated
+
+# Later lines override earlier ones
+vendor/ours/**  -linguist-vendored
+*.pb.go         text eol=lf
+"with space.txt" linguist-generated
+
+[attr]binary -diff -merge -text
+`
+
+func TestGenerated(t *testing.T) {
+	a, errs := Parse(strings.NewReader(testFile))
+	if len(errs) > 0 {
+		t.Fatalf("Parse() errors: %v", errs)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"README.md", false},
+		// Patterns without a slash match the basename at any depth
+		{"api.pb.go", true},
+		{"pkg/api/v1/api.pb.go", true},
+		// A later line setting other attributes doesn't undo linguist-generated
+		{"/pkg/api.pb.go", true},
+		{"vendor/github.com/x/y.go", true},
+		{"vendor/ours/y.go", false},
+		// Patterns with a slash are anchored to the root
+		{"src/vendor/x.go", false},
+		{"dist/app.js", true},
+		{"dist/js/app.js", false},
+		{"docs/index.md", false},
+		{"mocks/client.go", true},
+		{"pkg/mocks/client.go", true},
+		{"pkg/mocks/client_test.txt", false},
+		{"with space.txt", true},
+	}
+
+	for _, tc := range tests {
+		if got := a.Generated(tc.path); got != tc.want {
+			t.Errorf("Generated(%q) = %v, want %v", tc.path, got, tc.want)
+		}
+	}
+}
+
+// TestDirectoryPatterns checks that, unlike .gitignore, a pattern naming a directory doesn't match the files within it
+func TestDirectoryPatterns(t *testing.T) {
+	a, _ := Parse(strings.NewReader("vendor linguist-vendored\n/third_party linguist-vendored\n"))
+	for _, p := range []string{"vendor/x.go", "third_party/x.go"} {
+		if a.Generated(p) {
+			t.Errorf("Generated(%q) = true, want false", p)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	a, _ := Parse(strings.NewReader("* text=auto\n*.sh eol=lf -diff !text\n"))
+	tests := []struct {
+		path string
+		attr string
+		want string
+	}{
+		{"x.go", "text", "auto"},
+		{"x.sh", "text", Unspecified},
+		{"x.sh", "diff", Unset},
+		{"x.sh", "eol", "lf"},
+		{"x.go", "eol", Unspecified},
+	}
+
+	for _, tc := range tests {
+		if got := a.Get(tc.path, tc.attr); got != tc.want {
+			t.Errorf("Get(%q, %q) = %q, want %q", tc.path, tc.attr, got, tc.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	a, errs := Parse(strings.NewReader("*.go text\n!*.md text\n[abc text\n*.md text\n"))
+	if len(errs) != 2 {
+		t.Errorf("Parse() returned %d errors, want 2: %v", len(errs), errs)
+	}
+	if len(a.Rules) != 2 {
+		t.Errorf("Parse() returned %d rules, want the 2 valid ones", len(a.Rules))
+	}
+}
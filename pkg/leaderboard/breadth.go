@@ -0,0 +1,147 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// repoOf returns the org/project a GitHub HTML URL belongs to
+func repoOf(url string) string {
+	org, project := repo.ParseURL(url)
+	return org + "/" + project
+}
+
+// spansRepos returns whether the data covers more than one repository
+func spansRepos(d Data) bool {
+	seen := map[string]bool{}
+	for _, pr := range d.PRs {
+		seen[repoOf(pr.URL)] = true
+	}
+	for _, i := range d.Issues {
+		seen[repoOf(i.URL)] = true
+	}
+	return len(seen) > 1
+}
+
+// userRepos tracks the distinct repositories per user, keyed case-insensitively
+type userRepos struct {
+	names map[string]string
+	repos map[string]map[string]bool
+}
+
+func newUserRepos() *userRepos {
+	return &userRepos{names: map[string]string{}, repos: map[string]map[string]bool{}}
+}
+
+func (u *userRepos) add(user string, url string) {
+	if user == "" {
+		return
+	}
+	key := strings.ToLower(user)
+	if u.names[key] == "" {
+		u.names[key] = user
+		u.repos[key] = map[string]bool{}
+	}
+	u.repos[key][repoOf(url)] = true
+}
+
+// DistinctRepos returns the number of repositories a user contributed to
+func (u *userRepos) DistinctRepos(user string) int {
+	return len(u.repos[strings.ToLower(user)])
+}
+
+// mergedRepos returns the repositories each user merged PRs into, honoring MinPerRepo
+func (o *Options) mergedRepos(prs []*repo.PRSummary) *userRepos {
+	// user -> repo -> delta
+	deltas := map[string]map[string]int{}
+	names := map[string]string{}
+	urls := map[string]map[string]string{}
+
+	for _, pr := range prs {
+		key := strings.ToLower(pr.User)
+		r := repoOf(pr.URL)
+		if deltas[key] == nil {
+			deltas[key] = map[string]int{}
+			urls[key] = map[string]string{}
+			names[key] = pr.User
+		}
+		deltas[key][r] += pr.Delta
+		urls[key][r] = pr.URL
+	}
+
+	ur := newUserRepos()
+	for key, rs := range deltas {
+		for r, delta := range rs {
+			if delta < o.MinPerRepo {
+				continue
+			}
+			ur.add(names[key], urls[key][r])
+		}
+	}
+	return ur
+}
+
+func (o *Options) breadthChart(prs []*repo.PRSummary, _ []string) chart {
+	ur := o.mergedRepos(prs)
+
+	prCount := map[string]int{}
+	for _, pr := range prs {
+		prCount[strings.ToLower(pr.User)]++
+	}
+
+	items := []item{}
+	for key, name := range ur.names {
+		items = append(items, item{Name: name, Count: ur.DistinctRepos(name), tiebreak: prCount[key]})
+	}
+
+	return chart{
+		ID:     "breadth",
+		Title:  o.msg("chart.breadth.title"),
+		Metric: o.msg("chart.breadth.metric"),
+		Items:  o.rankedItems(items),
+	}
+}
+
+func (o *Options) reachChart(d Data, _ []string) chart {
+	ur := newUserRepos()
+	for _, pr := range d.PRs {
+		ur.add(pr.User, pr.URL)
+	}
+	for _, r := range d.Reviews {
+		ur.add(r.Reviewer, r.URL)
+	}
+	for _, i := range d.Issues {
+		ur.add(i.Author, i.URL)
+		ur.add(i.Closer, i.URL)
+	}
+	for _, c := range d.Comments {
+		ur.add(c.Commenter, c.URL)
+	}
+
+	items := []item{}
+	for _, name := range ur.names {
+		items = append(items, item{Name: name, Count: ur.DistinctRepos(name)})
+	}
+
+	return chart{
+		ID:     "reach",
+		Title:  o.msg("chart.reach.title"),
+		Metric: o.msg("chart.reach.metric"),
+		Items:  o.rankedItems(items),
+	}
+}
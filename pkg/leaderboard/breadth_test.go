@@ -0,0 +1,94 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+func counts(c chart) map[string]int {
+	got := map[string]int{}
+	for _, i := range c.Items {
+		got[i.Name] = i.Count
+	}
+	return got
+}
+
+func TestSpansRepos(t *testing.T) {
+	one := Data{PRs: []*repo.PRSummary{
+		{URL: "https://github.com/google/pullsheet/pull/1"},
+		{URL: "https://github.com/google/pullsheet/pull/2"},
+	}}
+	if spansRepos(one) {
+		t.Errorf("spansRepos() = true for one repository")
+	}
+
+	two := one
+	two.Issues = []*repo.IssueSummary{{URL: "https://github.com/google/triage-party/issues/3"}}
+	if !spansRepos(two) {
+		t.Errorf("spansRepos() = false for two repositories")
+	}
+}
+
+func TestBreadthChart(t *testing.T) {
+	prs := []*repo.PRSummary{
+		{User: "alice", URL: "https://github.com/google/pullsheet/pull/1", Delta: 10},
+		{User: "Alice", URL: "https://github.com/google/triage-party/pull/2", Delta: 3},
+		{User: "alice", URL: "https://github.com/google/triage-party/pull/3", Delta: 3},
+		{User: "bob", URL: "https://github.com/google/pullsheet/pull/4", Delta: 10},
+		{User: "bob", URL: "https://github.com/google/triage-party/pull/5", Delta: 5},
+		{User: "carol", URL: "https://github.com/google/pullsheet/pull/6", Delta: 1},
+	}
+
+	o := DefaultOptions()
+	if got, want := counts(o.breadthChart(prs, nil)), map[string]int{"alice": 2, "bob": 2, "carol": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("breadthChart() = %v, want %v", got, want)
+	}
+	// alice has more PRs, which breaks the tie
+	if items := o.breadthChart(prs, nil).Items; items[0].Name != "alice" {
+		t.Errorf("breadthChart() ranks %q first, want alice", items[0].Name)
+	}
+
+	// MinPerRepo applies to the sum of a user's deltas in each repository
+	o.MinPerRepo = 6
+	if got, want := counts(o.breadthChart(prs, nil)), map[string]int{"alice": 2, "bob": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("breadthChart() with MinPerRepo = %v, want %v", got, want)
+	}
+}
+
+func TestReachChart(t *testing.T) {
+	d := Data{
+		PRs: []*repo.PRSummary{
+			{User: "alice", URL: "https://github.com/google/pullsheet/pull/1"},
+		},
+		Reviews: []*repo.ReviewSummary{
+			{Reviewer: "Alice", URL: "https://github.com/google/triage-party/pull/2"},
+			{Reviewer: "bob", URL: "https://github.com/google/pullsheet/pull/1"},
+		},
+		Issues: []*repo.IssueSummary{
+			{Author: "carol", Closer: "alice", URL: "https://github.com/google/go-github/issues/3"},
+		},
+		Comments: []*repo.CommentSummary{
+			{Commenter: "bob", URL: "https://github.com/google/pullsheet/issues/4"},
+		},
+	}
+
+	if got, want := counts(DefaultOptions().reachChart(d, nil)), map[string]int{"alice": 3, "bob": 1, "carol": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reachChart() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// chartInput is what charts are built from
+type chartInput struct {
+	o     *Options
+	since time.Time
+	until time.Time
+	users []string
+	d     Data
+	// allPRs are the PRs of d including those ExcludeSelfMerged leaves out
+	allPRs []*repo.PRSummary
+}
+
+// chartBuilder builds a chart, or returns false if the data can't support it, such as a triage chart without triage data
+type chartBuilder func(in chartInput) (chart, bool)
+
+// always builds a chart any data supports
+func always(build func(in chartInput) chart) chartBuilder {
+	return func(in chartInput) (chart, bool) {
+		return build(in), true
+	}
+}
+
+// chartBuilders build each chart by ID. Every chart registered here can be chosen with Charts.
+var chartBuilders = map[string]chartBuilder{
+	"reviewCounts":    always(func(in chartInput) chart { return in.o.reviewsChart(in.d.Reviews, in.users) }),
+	"reviewWords":     always(func(in chartInput) chart { return in.o.reviewWordsChart(in.d.Reviews, in.users) }),
+	"reviewComments":  always(func(in chartInput) chart { return in.o.reviewCommentsChart(in.d.Reviews, in.users) }),
+	"prApprovers":     always(func(in chartInput) chart { return in.o.approversChart(in.d.Reviews, in.users) }),
+	"reviewLatency":   always(func(in chartInput) chart { return in.o.reviewLatencyChart(in.d.Reviews, in.users) }),
+	"prCounts":        always(func(in chartInput) chart { return in.o.mergeChart(in.d.PRs, in.users) }),
+	"prDeltas":        always(func(in chartInput) chart { return in.o.deltaChart(in.d.PRs, in.users) }),
+	"prSize":          always(func(in chartInput) chart { return in.o.sizeChart(in.d.PRs, in.users) }),
+	"prSizeBuckets":   always(func(in chartInput) chart { return in.o.sizeBucketChart(in.d.PRs, in.users) }),
+	"prLatency":       always(func(in chartInput) chart { return in.o.latencyChart(in.d.PRs, in.users) }),
+	"streaks":         always(func(in chartInput) chart { return in.o.streakChart(in.until, in.d.PRs) }),
+	"comments":        always(func(in chartInput) chart { return in.o.commentsChart(in.d.Comments, in.users) }),
+	"commentWords":    always(func(in chartInput) chart { return in.o.commentWordsChart(in.d.Comments, in.users) }),
+	"issueCloser":     always(func(in chartInput) chart { return in.o.issueCloserChart(in.d.Issues, in.users) }),
+	"issueCloseTime":  always(func(in chartInput) chart { return in.o.closeTimeChart(in.d.Issues, in.users) }),
+	"issueCloseTimes": always(func(in chartInput) chart { return in.o.closeTimesChart(in.d.Issues, in.users) }),
+	"triagers": func(in chartInput) (chart, bool) {
+		return in.o.triagerChart(in.d.Triage, in.users), in.d.Triage != nil
+	},
+	"newContributors": func(in chartInput) (chart, bool) {
+		return in.o.newContributorsChart(in.d.NewContributors, in.d.PRs), in.d.NewContributors != nil
+	},
+	"selfMerges": func(in chartInput) (chart, bool) {
+		return in.o.selfMergeChart(in.allPRs), selfMergeTotals(in.allPRs) != nil
+	},
+	"hotFiles": func(in chartInput) (chart, bool) {
+		return in.o.hotFilesChart(in.d.PRs), hasFiles(in.d.PRs)
+	},
+	"hotDirs": func(in chartInput) (chart, bool) {
+		return in.o.hotDirsChart(in.d.PRs), hasFiles(in.d.PRs)
+	},
+	"trendPRs":    func(in chartInput) (chart, bool) { return in.o.trendChart("trendPRs", in.since, in.until, in.d) },
+	"trendDeltas": func(in chartInput) (chart, bool) { return in.o.trendChart("trendDeltas", in.since, in.until, in.d) },
+	"trendIssues": func(in chartInput) (chart, bool) { return in.o.trendChart("trendIssues", in.since, in.until, in.d) },
+	"breadth": func(in chartInput) (chart, bool) {
+		return in.o.breadthChart(in.d.PRs, in.users), spansRepos(in.d)
+	},
+	"reach": func(in chartInput) (chart, bool) {
+		return in.o.reachChart(in.d, in.users), spansRepos(in.d)
+	},
+	"repoPRs": func(in chartInput) (chart, bool) {
+		ps := projectStatistics(in.d.PRs)
+		return in.o.repoPRsChart(ps), spansRepos(in.d) && len(ps) > 1
+	},
+	"repoDeltas": func(in chartInput) (chart, bool) {
+		ps := projectStatistics(in.d.PRs)
+		return in.o.repoDeltasChart(ps), spansRepos(in.d) && len(ps) > 1
+	},
+}
+
+// fileCharts are the charts drawn from the files each PR changed, which are otherwise not always fetched
+var fileCharts = []string{"hotFiles", "hotDirs"}
+
+// NeedsFiles returns whether Charts chooses a chart drawn from the files each PR changed, as the default of every
+// chart does
+func (o *Options) NeedsFiles() bool {
+	for _, id := range fileCharts {
+		if o.chartRank(id) >= 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ChartIDs returns the ID of every chart, sorted
+func ChartIDs() []string {
+	ids := []string{}
+	for id := range chartBuilders {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ValidCharts returns an error naming the first unknown chart ID, and listing the valid ones
+func ValidCharts(ids []string) error {
+	for _, id := range ids {
+		if _, ok := chartBuilders[id]; !ok {
+			return fmt.Errorf("unknown chart %q, choose from: %s", id, strings.Join(ChartIDs(), ", "))
+		}
+	}
+	return nil
+}
+
+// chartRank returns the position of a chart in Charts, or -1 if Charts doesn't select it
+func (o *Options) chartRank(id string) int {
+	if o.Charts == nil {
+		return 0
+	}
+	for i, c := range o.Charts {
+		if c == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// buildCharts builds the charts with the given IDs which Charts selects and the data supports, in the order Charts
+// gives, if any
+func (o *Options) buildCharts(in chartInput, ids ...string) []chart {
+	chosen := []string{}
+	for _, id := range ids {
+		if o.chartRank(id) >= 0 {
+			chosen = append(chosen, id)
+		}
+	}
+	sort.SliceStable(chosen, func(i, j int) bool { return o.chartRank(chosen[i]) < o.chartRank(chosen[j]) })
+
+	charts := []chart{}
+	for _, id := range chosen {
+		if ch, ok := chartBuilders[id](in); ok {
+			charts = append(charts, ch)
+		}
+	}
+	return charts
+}
+
+// orderCategories drops categories left empty by Charts, and orders the rest by their first chart in Charts.
+// Categories of tables alone keep their place after those.
+func (o *Options) orderCategories(cats []category) []category {
+	kept := []category{}
+	for _, c := range cats {
+		if len(c.Charts) > 0 || len(c.Tables) > 0 {
+			kept = append(kept, c)
+		}
+	}
+	if o.Charts == nil {
+		return kept
+	}
+
+	first := func(c category) int {
+		if len(c.Charts) == 0 {
+			return len(o.Charts)
+		}
+		return o.chartRank(c.Charts[0].ID)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return first(kept[i]) < first(kept[j]) })
+	return kept
+}
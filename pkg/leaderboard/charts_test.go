@@ -0,0 +1,39 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import "testing"
+
+func TestNeedsFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		charts []string
+		want   bool
+	}{
+		// The default is every chart, hot paths included
+		{"default", nil, true},
+		{"hot files", []string{"prCounts", "hotFiles"}, true},
+		{"hot dirs", []string{"hotDirs"}, true},
+		{"deltas only", []string{"prCounts", "prDeltas"}, false},
+	}
+
+	for _, tc := range tests {
+		o := DefaultOptions()
+		o.Charts = tc.charts
+		if got := o.NeedsFiles(); got != tc.want {
+			t.Errorf("%s: NeedsFiles() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// ownershipTables returns a CODEOWNERS coverage table per project
+func (o *Options) ownershipTables(summaries []*repo.OwnershipSummary) []table {
+	tables := []table{}
+	idx := map[string]int{}
+
+	for _, s := range summaries {
+		i, ok := idx[s.Project]
+		if !ok {
+			i = len(tables)
+			idx[s.Project] = i
+			tables = append(tables, table{
+				ID:          fmt.Sprintf("codeowners%d", i),
+				Title:       s.Project,
+				Description: o.msg("table.codeowners.description"),
+				Columns:     []string{o.msg("column.line"), o.msg("column.pattern"), o.msg("column.owners"), o.msg("column.prs"), o.msg("column.ownerReviewed"), o.msg("column.idleOwners")},
+			})
+		}
+
+		tables[i].Rows = append(tables[i].Rows, []string{
+			strconv.Itoa(s.Line),
+			s.Pattern,
+			s.Owners,
+			o.formatNumber(s.PRs),
+			o.formatNumber(s.OwnerReviewed),
+			s.IdleOwners,
+		})
+	}
+
+	return tables
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// Normalization methods for impact metrics
+const (
+	ZScore     = "zscore"
+	Percentile = "percentile"
+)
+
+// ImpactMetrics are the metrics an impact score may weigh, in display order
+var ImpactMetrics = []string{"merged_prs", "delta", "reviews", "review_words", "issues_closed", "comment_words"}
+
+// ImpactConfig is the weighting of each metric in the impact score. The config file is YAML:
+//
+//	normalize: zscore
+//	weights:
+//	  merged_prs: 1
+//	  reviews: 2
+//	  comment_words: 0.5
+//
+// Metrics without a weight do not contribute.
+type ImpactConfig struct {
+	Normalize string             `yaml:"normalize"`
+	Weights   map[string]float64 `yaml:"weights"`
+}
+
+// LoadImpact reads and validates an impact score config file
+func LoadImpact(path string) (*ImpactConfig, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ic := &ImpactConfig{}
+	if err := yaml.UnmarshalStrict(b, ic); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	if ic.Normalize == "" {
+		ic.Normalize = ZScore
+	}
+	if ic.Normalize != ZScore && ic.Normalize != Percentile {
+		return nil, fmt.Errorf("%s: normalize must be %q or %q, not %q", path, ZScore, Percentile, ic.Normalize)
+	}
+
+	known := map[string]bool{}
+	for _, m := range ImpactMetrics {
+		known[m] = true
+	}
+	for m := range ic.Weights {
+		if !known[m] {
+			return nil, fmt.Errorf("%s: unknown metric %q, choose from: %s", path, m, strings.Join(ImpactMetrics, ", "))
+		}
+	}
+
+	if len(ic.Weights) == 0 {
+		return nil, fmt.Errorf("%s: no weights given", path)
+	}
+
+	return ic, nil
+}
+
+// impactScore is a user's composite score, and what each metric contributed to it
+type impactScore struct {
+	Name          string
+	Score         float64
+	Contributions map[string]float64
+}
+
+// impactValues returns the raw value of each metric per user. Users without any activity for a metric are absent from it.
+func (o *Options) impactValues(d Data, users []string) map[string]map[string]float64 {
+	matchUser := map[string]bool{}
+	for _, u := range users {
+		matchUser[strings.ToLower(u)] = true
+	}
+
+	vs := map[string]map[string]float64{}
+	for _, m := range ImpactMetrics {
+		vs[m] = map[string]float64{}
+	}
+
+	for _, pr := range d.PRs {
+		vs["merged_prs"][pr.User]++
+		vs["delta"][pr.User] += float64(pr.Delta)
+	}
+
+	for _, r := range d.Reviews {
+		vs["reviews"][r.Reviewer]++
+		vs["review_words"][r.Reviewer] += float64(r.Words)
+	}
+
+	for _, i := range o.closedByOthers(d.Issues, matchUser) {
+		vs["issues_closed"][i.Closer]++
+	}
+
+	for _, c := range d.Comments {
+		vs["comment_words"][c.Commenter] += float64(c.Words)
+	}
+
+	return vs
+}
+
+// closedByOthers returns the issues closed by someone other than their author, as counted by the closers chart
+func (o *Options) closedByOthers(is []*repo.IssueSummary, matchUser map[string]bool) []*repo.IssueSummary {
+	closed := []*repo.IssueSummary{}
+	for _, i := range is {
+		if i.Author == i.Closer || o.Repo.IsBotLogin(i.Closer) {
+			continue
+		}
+		if o.CompletedOnly && i.StateReason != repo.CompletedReason {
+			continue
+		}
+		if len(matchUser) > 0 && !matchUser[strings.ToLower(i.Closer)] {
+			continue
+		}
+		closed = append(closed, i)
+	}
+	return closed
+}
+
+// normalize rescales each value relative to the others. A metric with zero variance can't tell users apart, so every value becomes 0.
+func normalize(vs map[string]float64, method string) map[string]float64 {
+	out := map[string]float64{}
+	if len(vs) == 0 {
+		return out
+	}
+
+	var sum float64
+	for _, v := range vs {
+		sum += v
+	}
+	mean := sum / float64(len(vs))
+
+	var sq float64
+	for _, v := range vs {
+		sq += (v - mean) * (v - mean)
+	}
+	sd := math.Sqrt(sq / float64(len(vs)))
+
+	for u, v := range vs {
+		if sd == 0 {
+			out[u] = 0
+			continue
+		}
+
+		switch method {
+		case Percentile:
+			// Ties share the midpoint of the ranks they span
+			below, equal := 0, 0
+			for _, w := range vs {
+				if w < v {
+					below++
+				} else if w == v {
+					equal++
+				}
+			}
+			out[u] = (float64(below) + float64(equal)/2) / float64(len(vs))
+		default:
+			out[u] = (v - mean) / sd
+		}
+	}
+	return out
+}
+
+// impactScores returns the weighted composite score of every active user, highest first
+func (o *Options) impactScores(d Data, users []string, ic *ImpactConfig) []impactScore {
+	vs := o.impactValues(d, users)
+
+	names := map[string]bool{}
+	norm := map[string]map[string]float64{}
+	for _, m := range ImpactMetrics {
+		norm[m] = normalize(vs[m], ic.Normalize)
+		for u := range vs[m] {
+			names[u] = true
+		}
+	}
+
+	scores := []impactScore{}
+	for u := range names {
+		s := impactScore{Name: u, Contributions: map[string]float64{}}
+		for _, m := range ImpactMetrics {
+			// Missing from the metric entirely contributes nothing
+			c := ic.Weights[m] * norm[m][u]
+			s.Contributions[m] = c
+			s.Score += c
+		}
+		scores = append(scores, s)
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return scores[i].Name < scores[j].Name
+	})
+	return scores
+}
+
+// impactTable returns the ranked impact scores, with a column per weighted metric
+func (o *Options) impactTable(d Data, users []string, ic *ImpactConfig) table {
+	metrics := []string{}
+	terms := []string{}
+	for _, m := range ImpactMetrics {
+		if ic.Weights[m] == 0 {
+			continue
+		}
+		metrics = append(metrics, m)
+		terms = append(terms, fmt.Sprintf("%s × %s(%s)", o.formatDecimal(ic.Weights[m]), ic.Normalize, o.msg("impact."+m)))
+	}
+
+	cols := []string{o.msg("column.user"), o.msg("column.score")}
+	for _, m := range metrics {
+		cols = append(cols, o.msg("impact."+m))
+	}
+
+	rows := [][]string{}
+	scores := o.impactScores(d, users, ic)
+	if o.TopX > 0 && len(scores) > o.TopX {
+		scores = scores[:o.TopX]
+	}
+
+	for _, s := range scores {
+		row := []string{s.Name, o.formatDecimal(s.Score)}
+		for _, m := range metrics {
+			row = append(row, o.formatDecimal(s.Contributions[m]))
+		}
+		rows = append(rows, row)
+	}
+
+	return table{
+		ID:          "impact",
+		Title:       o.msg("table.impact.title"),
+		Description: o.msg("table.impact.description", strings.Join(terms, " + ")),
+		Columns:     cols,
+		Rows:        rows,
+	}
+}
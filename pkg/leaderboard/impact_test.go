@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// equalData returns activity in which every user has the same count of every metric
+func equalData(users []string) Data {
+	d := Data{}
+	for _, u := range users {
+		d.PRs = append(d.PRs, &repo.PRSummary{User: u, Delta: 10})
+		d.Reviews = append(d.Reviews, &repo.ReviewSummary{Reviewer: u, Words: 20})
+		d.Issues = append(d.Issues, &repo.IssueSummary{Author: "reporter", Closer: u})
+		d.Comments = append(d.Comments, &repo.CommentSummary{Commenter: u, Words: 30})
+	}
+	return d
+}
+
+func TestImpactScoresZeroVariance(t *testing.T) {
+	users := []string{"alice", "bob", "carol"}
+	weights := map[string]float64{}
+	for _, m := range ImpactMetrics {
+		weights[m] = 1
+	}
+
+	for _, method := range []string{ZScore, Percentile} {
+		t.Run(method, func(t *testing.T) {
+			o := DefaultOptions()
+			ic := &ImpactConfig{Normalize: method, Weights: weights}
+
+			scores := o.impactScores(equalData(users), nil, ic)
+			if len(scores) != len(users) {
+				t.Fatalf("impactScores() returned %d scores, want %d", len(scores), len(users))
+			}
+			for _, s := range scores {
+				if math.IsNaN(s.Score) || math.IsInf(s.Score, 0) {
+					t.Errorf("%s score = %v, want a finite value", s.Name, s.Score)
+				}
+				if s.Score != 0 {
+					t.Errorf("%s score = %v, want 0", s.Name, s.Score)
+				}
+				for m, c := range s.Contributions {
+					if math.IsNaN(c) || math.IsInf(c, 0) {
+						t.Errorf("%s %s contribution = %v, want a finite value", s.Name, m, c)
+					}
+				}
+			}
+
+			// Ties are broken by name, so equal users keep a stable order
+			for i, s := range scores {
+				if s.Name != users[i] {
+					t.Errorf("scores[%d] = %s, want %s", i, s.Name, users[i])
+				}
+			}
+
+			tbl := o.impactTable(equalData(users), nil, ic)
+			for _, row := range tbl.Rows {
+				for _, cell := range row {
+					if strings.Contains(cell, "NaN") || strings.Contains(cell, "Inf") {
+						t.Errorf("impactTable() row %v has a non-finite cell %q", row, cell)
+					}
+				}
+			}
+		})
+	}
+}
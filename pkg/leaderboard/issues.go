@@ -15,12 +15,20 @@
 package leaderboard
 
 import (
+	"math"
+	"sort"
 	"strings"
 
 	"github.com/google/pullsheet/pkg/repo"
 )
 
-func issueCloserChart(is []*repo.IssueSummary, users []string) chart {
+// closeTimeBuckets are the upper bounds, in days, of each bar of the time-to-close histogram but the last
+var closeTimeBuckets = []float64{1, 7, 30, 90, 365}
+
+// closeTimeNames are the message keys of each bar of the time-to-close histogram
+var closeTimeNames = []string{"closeTime.day", "closeTime.week", "closeTime.month", "closeTime.quarter", "closeTime.year", "closeTime.longer"}
+
+func (o *Options) issueCloserChart(is []*repo.IssueSummary, users []string) chart {
 	matchUser := map[string]bool{}
 	for _, u := range users {
 		matchUser[strings.ToLower(u)] = true
@@ -32,7 +40,10 @@ func issueCloserChart(is []*repo.IssueSummary, users []string) chart {
 			if len(matchUser) > 0 && !matchUser[strings.ToLower(i.Closer)] {
 				continue
 			}
-			if !strings.HasSuffix(i.Closer, "bot") {
+			if o.CompletedOnly && i.StateReason != repo.CompletedReason {
+				continue
+			}
+			if !o.Repo.IsBotLogin(i.Closer) {
 				uMap[i.Closer]++
 			}
 		}
@@ -40,13 +51,84 @@ func issueCloserChart(is []*repo.IssueSummary, users []string) chart {
 
 	return chart{
 		ID:     "issueCloser",
-		Title:  "Top Closers",
-		Metric: "# of issues closed (excludes authored)",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.issueCloser.title"),
+		Metric: o.msg("chart.issueCloser.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
+	}
+}
+
+// closeTimeIssues returns the closed issues time-to-close charts count
+func (o *Options) closeTimeIssues(is []*repo.IssueSummary) []*repo.IssueSummary {
+	kept := []*repo.IssueSummary{}
+	for _, i := range is {
+		// Rows for issues being opened have no closer
+		if i.Closer == "" || i.DaysOpen == nil {
+			continue
+		}
+		if o.QuickCloses > 0 {
+			if o.Repo.IsBotLogin(i.Closer) {
+				continue
+			}
+			if i.Author == i.Closer && *i.DaysOpen*24 < o.QuickCloses.Hours() {
+				continue
+			}
+		}
+		kept = append(kept, i)
+	}
+	return kept
+}
+
+// closeTimeChart shows the median days each user took to close issues from their creation, fastest first
+func (o *Options) closeTimeChart(is []*repo.IssueSummary, _ []string) chart {
+	days := map[string][]float64{}
+	for _, i := range o.closeTimeIssues(is) {
+		if o.Repo.IsBotLogin(i.Closer) {
+			continue
+		}
+		if o.CompletedOnly && i.StateReason != repo.CompletedReason {
+			continue
+		}
+		days[i.Closer] = append(days[i.Closer], *i.DaysOpen)
+	}
+
+	items := []item{}
+	for u, ds := range days {
+		m := median(ds)
+		// Many issues are closed within a day, so hours tell them apart
+		items = append(items, item{Name: u, Count: int(math.Round(m)), tiebreak: int(math.Round(m * 24))})
+	}
+
+	return chart{
+		ID:     "issueCloseTime",
+		Title:  o.msg("chart.issueCloseTime.title"),
+		Metric: o.msg("chart.issueCloseTime.metric"),
+		Items:  o.lowestItems(items),
+	}
+}
+
+// closeTimesChart is a histogram of how long issues closed within the period were open
+func (o *Options) closeTimesChart(is []*repo.IssueSummary, _ []string) chart {
+	counts := make([]int, len(closeTimeNames))
+	for _, i := range o.closeTimeIssues(is) {
+		days := *i.DaysOpen
+		counts[sort.Search(len(closeTimeBuckets), func(b int) bool { return days < closeTimeBuckets[b] })]++
+	}
+
+	items := []item{}
+	for b, n := range counts {
+		items = append(items, item{Name: o.msg(closeTimeNames[b]), Count: n})
+	}
+
+	return chart{
+		ID:     "issueCloseTimes",
+		Title:  o.msg("chart.issueCloseTimes.title"),
+		Object: o.msg("column.closeTime"),
+		Metric: o.msg("chart.issueCloseTimes.metric"),
+		Items:  items,
 	}
 }
 
-func commentWordsChart(cs []*repo.CommentSummary, _ []string) chart {
+func (o *Options) commentWordsChart(cs []*repo.CommentSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, c := range cs {
 		if c.IssueAuthor != c.Commenter {
@@ -56,13 +138,13 @@ func commentWordsChart(cs []*repo.CommentSummary, _ []string) chart {
 
 	return chart{
 		ID:     "commentWords",
-		Title:  "Most Helpful",
-		Metric: "# of words (excludes authored)",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.commentWords.title"),
+		Metric: o.msg("chart.commentWords.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
 
-func commentsChart(cs []*repo.CommentSummary, _ []string) chart {
+func (o *Options) commentsChart(cs []*repo.CommentSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, c := range cs {
 		uMap[c.Commenter] += c.Comments
@@ -70,8 +152,24 @@ func commentsChart(cs []*repo.CommentSummary, _ []string) chart {
 
 	return chart{
 		ID:     "comments",
-		Title:  "Most Active",
-		Metric: "# of comments",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.comments.title"),
+		Metric: o.msg("chart.comments.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
+	}
+}
+
+func (o *Options) triagerChart(ts []*repo.TriageSummary, _ []string) chart {
+	uMap := map[string]int{}
+	for _, t := range ts {
+		if t.Action == "labeled" || t.Action == "unlabeled" {
+			uMap[t.Actor]++
+		}
+	}
+
+	return chart{
+		ID:     "triagers",
+		Title:  o.msg("chart.triagers.title"),
+		Metric: o.msg("chart.triagers.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
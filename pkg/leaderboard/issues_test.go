@@ -0,0 +1,175 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// closed returns an issue by author closed by closer after days open
+func closed(author string, closer string, days float64) *repo.IssueSummary {
+	return &repo.IssueSummary{Author: author, Closer: closer, DaysOpen: &days, StateReason: repo.CompletedReason}
+}
+
+func TestCloseTimesChart(t *testing.T) {
+	is := []*repo.IssueSummary{
+		closed("a", "b", 0),
+		closed("a", "b", 0.99),
+		// Bounds belong to the longer bar
+		closed("a", "b", 1),
+		closed("a", "b", 6.5),
+		closed("a", "b", 7),
+		closed("a", "b", 29.9),
+		closed("a", "b", 30),
+		closed("a", "b", 364),
+		closed("a", "b", 365),
+		closed("a", "b", 1000),
+		// Opened rather than closed, or with inconsistent timestamps
+		{Author: "a"},
+		{Author: "a", Closer: "b"},
+	}
+
+	o := DefaultOptions()
+	c := o.closeTimesChart(is, nil)
+	got := []int{}
+	for _, i := range c.Items {
+		got = append(got, i.Count)
+	}
+	if want := []int{2, 2, 2, 1, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("closeTimesChart() counts = %v, want %v", got, want)
+	}
+	if c.Items[0].Name != o.msg("closeTime.day") || c.Items[5].Name != o.msg("closeTime.longer") {
+		t.Errorf("closeTimesChart() bars = %q to %q, want the day to longer bars", c.Items[0].Name, c.Items[5].Name)
+	}
+}
+
+func TestCloseTimeChart(t *testing.T) {
+	is := []*repo.IssueSummary{
+		// Median of an even count is the mean of the middle two
+		closed("a", "alice", 1),
+		closed("a", "alice", 2),
+		closed("a", "alice", 4),
+		closed("a", "alice", 100),
+		// Rounds to the same day as alice's 3, but is quicker in hours
+		closed("a", "bob", 2.6),
+		closed("a", "carol", 0.2),
+		closed("a", "dependabot[bot]", 0),
+	}
+
+	c := DefaultOptions().closeTimeChart(is, nil)
+	got := map[string]int{}
+	names := []string{}
+	for _, i := range c.Items {
+		got[i.Name] = i.Count
+		names = append(names, i.Name)
+	}
+	if want := []string{"carol", "bob", "alice"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("closeTimeChart() order = %v, want %v", names, want)
+	}
+	if want := map[string]int{"alice": 3, "bob": 3, "carol": 0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("closeTimeChart() days = %v, want %v", got, want)
+	}
+}
+
+func TestCloseTimeIssuesQuickCloses(t *testing.T) {
+	is := []*repo.IssueSummary{
+		closed("alice", "alice", 0.01),
+		closed("alice", "alice", 2),
+		closed("alice", "bob", 0.01),
+		closed("alice", "renovate[bot]", 5),
+	}
+
+	o := DefaultOptions()
+	if got := len(o.closeTimeIssues(is)); got != 4 {
+		t.Errorf("closeTimeIssues() kept %d issues, want all 4", got)
+	}
+
+	// Issues closed by bots, or by their author within an hour, are left out
+	o.QuickCloses = time.Hour
+	kept := []string{}
+	for _, i := range o.closeTimeIssues(is) {
+		kept = append(kept, i.Closer)
+	}
+	if want := []string{"alice", "bob"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("closeTimeIssues() kept issues closed by %v, want %v", kept, want)
+	}
+	if *o.closeTimeIssues(is)[0].DaysOpen != 2 {
+		t.Errorf("closeTimeIssues() kept the quick close")
+	}
+}
+
+func TestIssueCloserChart(t *testing.T) {
+	is := []*repo.IssueSummary{
+		closed("carol", "alice", 1),
+		closed("carol", "ALICE", 1),
+		closed("carol", "alice", 1),
+		closed("carol", "talbot", 1),
+		closed("carol", "dependabot[bot]", 1),
+		closed("carol", "github-actions[bot]", 1),
+		// Closing their own issue isn't counted
+		closed("bob", "bob", 1),
+		closed("carol", "bob", 1),
+	}
+
+	tests := []struct {
+		name  string
+		users []string
+		want  map[string]int
+	}{
+		{name: "no users", want: map[string]int{"alice": 3, "talbot": 1, "bob": 1}},
+		{name: "mixed case users", users: []string{"ALICE", "TalBot"}, want: map[string]int{"alice": 3, "talbot": 1}},
+		{name: "bot user", users: []string{"dependabot[bot]"}, want: map[string]int{}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			o := DefaultOptions()
+			// Leaderboards merge the spellings of a login before charting them
+			d := o.sameLogins(Data{Issues: is})
+			c := o.issueCloserChart(d.Issues, tc.users)
+
+			got := map[string]int{}
+			for _, i := range c.Items {
+				got[i.Name] = i.Count
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("issueCloserChart() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTriagerChart(t *testing.T) {
+	ts := []*repo.TriageSummary{
+		{Actor: "alice", Action: "labeled"},
+		{Actor: "alice", Action: "unlabeled"},
+		{Actor: "bob", Action: "labeled"},
+		// Milestones aren't labels
+		{Actor: "bob", Action: "milestoned"},
+		{Actor: "carol", Action: "demilestoned"},
+	}
+
+	got := map[string]int{}
+	for _, i := range DefaultOptions().triagerChart(ts, nil).Items {
+		got[i.Name] = i.Count
+	}
+	if want := map[string]int{"alice": 2, "bob": 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("triagerChart() = %v, want %v", got, want)
+	}
+}
@@ -16,89 +16,201 @@ package leaderboard
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"html/template"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
-	"text/template"
 	"time"
 
+	"github.com/google/pullsheet/pkg/digest"
 	"github.com/google/pullsheet/pkg/repo"
 )
 
 const dateForm = "2006-01-02"
 
-// TopX is how many items to include in graphs
-var TopX = 15
-
+// category is a titled group of charts and tables. Render, RenderText, and RenderJSON all draw from them, so
+// their numbers agree.
 type category struct {
-	Title  string
-	Charts []chart
+	Title  string  `json:"title"`
+	Charts []chart `json:"charts,omitempty"`
+	Tables []table `json:"tables,omitempty"`
 }
 
 type chart struct {
-	ID     string
-	Title  string
-	Object string
-	Metric string
-	Items  []item
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	Object string `json:"object,omitempty"`
+	Metric string `json:"metric"`
+	// Series names the stacked segments of each bar, if it has more than one, or the lines of a Line chart
+	Series []string `json:"series,omitempty"`
+	// Line charts draw each series as a line through the items, which are points in time
+	Line  bool   `json:"line,omitempty"`
+	Items []item `json:"items"`
 }
 
 type item struct {
-	Name  string
-	Count int
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	// URL links to the activity counted, if any
+	URL string `json:"url,omitempty"`
+	// tiebreak orders items with equal counts, highest first
+	tiebreak int
+	// Values are the counts of each of the chart's Series, which sum to Count
+	Values []int `json:"values,omitempty"`
+	// Others is whether the item adds up those left out of the chart
+	Others bool `json:"others,omitempty"`
+}
+
+type table struct {
+	ID          string     `json:"id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Columns     []string   `json:"columns"`
+	Rows        [][]string `json:"rows"`
+}
+
+// Data is the collected data a leaderboard is rendered from
+type Data struct {
+	PRs      []*repo.PRSummary
+	Reviews  []*repo.ReviewSummary
+	Issues   []*repo.IssueSummary
+	Comments []*repo.CommentSummary
+	// Triage is nil if issue events were not collected
+	Triage []*repo.TriageSummary
+	// Ownership is nil if CODEOWNERS coverage was not requested
+	Ownership []*repo.OwnershipSummary
+	// NewContributors is nil if new contributors were not looked for
+	NewContributors []*repo.NewContributorSummary
+	// Warnings are the non-fatal problems encountered while collecting data, shown only if present
+	Warnings []digest.Group
+}
+
+// tmplName is the name of the template a leaderboard page is executed from
+const tmplName = "leaderboard.html"
+
+// LoadTemplates parses the templates in dir, named *.html or *.tmpl, over the embedded page templates. A file named
+// leaderboard.html replaces the page, and user.html the page of a user's activity; others may be used from them as
+// {{ template "header.html" . }}. An empty dir keeps the embedded templates alone. The result is meant for Options.Templates.
+func LoadTemplates(dir string) (*template.Template, error) {
+	t, err := template.New(tmplName).Funcs(template.FuncMap{
+		// replaced for each page, to format numbers in its locale
+		"number": fmt.Sprint,
+		"width":  barWidth,
+	}).Parse(leaderboardTmpl)
+	if err != nil {
+		return nil, fmt.Errorf("parse embedded template: %w", err)
+	}
+	if _, err := t.New(userTmplName).Parse(userTmpl); err != nil {
+		return nil, fmt.Errorf("parse embedded template: %w", err)
+	}
+
+	if dir != "" {
+		var paths []string
+		for _, pattern := range []string{"*.html", "*.tmpl"} {
+			ps, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return nil, fmt.Errorf("glob: %w", err)
+			}
+			paths = append(paths, ps...)
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no *.html or *.tmpl templates in %s", dir)
+		}
+
+		for _, path := range paths {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("read template: %w", err)
+			}
+			// Parse errors name the file and line, as in "template: leaderboard.html:12: ..."
+			if _, err := t.New(filepath.Base(path)).Parse(string(b)); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// templates returns a copy of the page templates which formats numbers in the locale of o
+func (o *Options) templates() (*template.Template, error) {
+	t := o.Templates
+	if t == nil {
+		var err error
+		if t, err = LoadTemplates(""); err != nil {
+			return nil, err
+		}
+	}
+	t, err := t.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("clone: %w", err)
+	}
+	return t.Funcs(template.FuncMap{"number": o.formatNumber}), nil
 }
 
 // Render returns an HTML formatted leaderboard page
-func Render(title string, since time.Time, until time.Time, users []string, prs []*repo.PRSummary, reviews []*repo.ReviewSummary, issues []*repo.IssueSummary, comments []*repo.CommentSummary) (string, error) {
-	funcMap := template.FuncMap{}
-	tmpl, err := template.New("LeaderBoard").Funcs(funcMap).Parse(leaderboardTmpl)
+func (o *Options) Render(title string, since time.Time, until time.Time, users []string, d Data) (string, error) {
+	return o.render(title, since, until, users, d, false)
+}
+
+// RenderStatic returns a leaderboard page which loads nothing from the network, drawing charts with plain HTML
+func (o *Options) RenderStatic(title string, since time.Time, until time.Time, users []string, d Data) (string, error) {
+	return o.render(title, since, until, users, d, true)
+}
+
+// RenderJSON returns the charts and tables of a leaderboard page as JSON, for building other dashboards from
+func (o *Options) RenderJSON(title string, since time.Time, until time.Time, users []string, d Data) ([]byte, error) {
+	data := struct {
+		Title      string       `json:"title"`
+		From       string       `json:"from"`
+		Until      string       `json:"until"`
+		SelfMerged *mergeTotals `json:"self_merged,omitempty"`
+		Categories []category   `json:"categories"`
+	}{
+		Title:      title,
+		From:       since.Format(dateForm),
+		Until:      until.Format(dateForm),
+		SelfMerged: selfMergeTotals(o.withoutExcluded(d).PRs),
+		Categories: o.categories(since, until, users, d),
+	}
+
+	return json.MarshalIndent(data, "", "  ")
+}
+
+func (o *Options) render(title string, since time.Time, until time.Time, users []string, d Data, static bool) (string, error) {
+	t, err := o.templates()
 	if err != nil {
-		return "", fmt.Errorf("parsefiles: %v", err)
+		return "", err
 	}
 
 	data := struct {
-		Title      string
-		From       string
-		Until      string
-		Command    string
-		Categories []category
+		Title       string
+		PageTitle   string
+		CommandLine string
+		From        string
+		Until       string
+		Command     string
+		SelfMerged  string
+		Categories  []category
+		Static      bool
 	}{
-		Title:   title,
-		From:    since.Format(dateForm),
-		Until:   until.Format(dateForm),
-		Command: filepath.Base(os.Args[0]) + " " + strings.Join(os.Args[1:], " "),
-		Categories: []category{
-			{
-				Title: "Reviewers",
-				Charts: []chart{
-					reviewsChart(reviews, users),
-					reviewWordsChart(reviews, users),
-					reviewCommentsChart(reviews, users),
-				},
-			},
-			{
-				Title: "Pull Requests",
-				Charts: []chart{
-					mergeChart(prs, users),
-					deltaChart(prs, users),
-					sizeChart(prs, users),
-				},
-			},
-			{
-				Title: "Issues",
-				Charts: []chart{
-					commentsChart(comments, users),
-					commentWordsChart(comments, users),
-					issueCloserChart(issues, users),
-				},
-			},
-		},
+		Title:       title,
+		PageTitle:   o.msg("page.title", title),
+		CommandLine: o.msg("page.commandLine"),
+		From:        o.formatDate(since),
+		Until:       o.formatDate(until),
+		Command:     filepath.Base(os.Args[0]) + " " + strings.Join(os.Args[1:], " "),
+		SelfMerged:  o.selfMergeSummary(o.withoutExcluded(d).PRs),
+		Categories:  o.categories(since, until, users, d),
+		Static:      static,
 	}
 
 	var tpl bytes.Buffer
-	if err = tmpl.Execute(&tpl, data); err != nil {
+	if err := t.ExecuteTemplate(&tpl, tmplName, data); err != nil {
 		return "", fmt.Errorf("execute: %w", err)
 	}
 
@@ -106,15 +218,166 @@ func Render(title string, since time.Time, until time.Time, users []string, prs
 	return out, nil
 }
 
-func topItems(items []item) []item {
-	sort.Slice(items, func(i, j int) bool { return items[i].Count > items[j].Count })
+// categories returns the charts to display, grouped by category
+func (o *Options) categories(since time.Time, until time.Time, users []string, d Data) []category {
+	d = o.sameLogins(o.withoutExcluded(d))
+	allPRs := d.PRs
+	if o.ExcludeSelfMerged {
+		d.PRs = withoutSelfMerged(d.PRs)
+	}
+
+	in := chartInput{o: o, since: since, until: until, users: users, d: d, allPRs: allPRs}
+	cats := []category{
+		{
+			Title:  o.msg("category.reviewers"),
+			Charts: o.buildCharts(in, "reviewCounts", "reviewWords", "reviewComments", "prApprovers", "reviewLatency"),
+		},
+		{
+			Title:  o.msg("category.pullRequests"),
+			Charts: o.buildCharts(in, "prCounts", "prDeltas", "prSize", "prSizeBuckets", "prLatency", "streaks", "selfMerges"),
+		},
+		{
+			Title:  o.msg("category.issues"),
+			Charts: o.buildCharts(in, "comments", "commentWords", "issueCloser", "issueCloseTime", "issueCloseTimes", "triagers"),
+		},
+	}
+
+	cats = append(cats, category{Title: o.msg("category.hotPaths"), Charts: o.buildCharts(in, fileCharts...)})
+
+	if d.NewContributors != nil {
+		cats = append(cats, category{
+			Title:  o.msg("category.newContributors"),
+			Charts: o.buildCharts(in, "newContributors"),
+			Tables: []table{o.newContributorsTable(d.NewContributors)},
+		})
+	}
+
+	cats = append(cats, category{Title: o.msg("category.trends"), Charts: o.buildCharts(in, "trendPRs", "trendDeltas", "trendIssues")})
+
+	if t, ok := o.ticketTable(d.PRs); ok {
+		cats = append(cats, category{Title: o.msg("category.tickets"), Tables: []table{t}})
+	}
+
+	if spansRepos(d) {
+		cats = append(cats, category{
+			Title:  o.msg("category.breadth"),
+			Charts: o.buildCharts(in, "breadth", "reach"),
+		})
+
+		if ps := projectStatistics(d.PRs); len(ps) > 1 {
+			cats = append(cats, category{
+				Title:  o.msg("category.projects"),
+				Charts: o.buildCharts(in, "repoPRs", "repoDeltas"),
+				Tables: []table{o.projectTable(ps)},
+			})
+		}
+	}
 
-	if len(items) > TopX {
-		items = items[:TopX]
+	if o.Impact != nil {
+		cats = append(cats, category{Title: o.msg("category.impact"), Tables: []table{o.impactTable(d, users, o.Impact)}})
+	}
+
+	if d.Ownership != nil {
+		cats = append(cats, category{Title: o.msg("category.codeOwners"), Tables: o.ownershipTables(d.Ownership)})
+	}
+
+	if len(d.Warnings) > 0 {
+		cats = append(cats, category{Title: o.msg("category.warnings"), Tables: []table{o.warningsTable(d.Warnings)}})
+	}
+
+	cats = o.orderCategories(cats)
+
+	repos := dataRepos(d)
+	if o.MemberCharts {
+		cats = append(cats, o.memberCategories(since, until, users, d, repos)...)
+	}
+
+	o.linkItems(cats, repos, since, until)
+
+	return cats
+}
+
+// topItems returns the items with the highest counts, up to TopX of them with at least MinCount. Those left out are
+// added up as one last item, so that the chart's total is unchanged.
+func (o *Options) topItems(items []item) []item {
+	shown, rest := o.cutItems(sortItems(items, true))
+	if len(rest) == 0 {
+		return shown
+	}
+
+	others := item{Name: o.msg("item.others", len(rest)), Others: true}
+	for _, i := range rest {
+		others.Count += i.Count
+		if len(i.Values) > 0 && others.Values == nil {
+			others.Values = make([]int, len(i.Values))
+		}
+		for idx, v := range i.Values {
+			others.Values[idx] += v
+		}
+	}
+	return append(shown, others)
+}
+
+// rankedItems is topItems for counts which don't add up, such as averages, so leaves the rest out entirely
+func (o *Options) rankedItems(items []item) []item {
+	shown, _ := o.cutItems(sortItems(items, true))
+	return shown
+}
+
+// lowestItems is rankedItems for charts where lower is better, with ties broken by the lowest tiebreak. MinCount
+// doesn't apply, as the lowest counts are the best.
+func (o *Options) lowestItems(items []item) []item {
+	items = sortItems(items, false)
+	if o.TopX > 0 && len(items) > o.TopX {
+		items = items[:o.TopX]
 	}
 	return items
 }
 
+// sortItems sorts items by count and then tiebreak, highest first if desc, and then by name
+func sortItems(items []item, desc bool) []item {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Count != items[j].Count {
+			return (items[i].Count > items[j].Count) == desc
+		}
+		if items[i].tiebreak != items[j].tiebreak {
+			return (items[i].tiebreak > items[j].tiebreak) == desc
+		}
+		return items[i].Name < items[j].Name
+	})
+	return items
+}
+
+// cutItems splits items sorted highest first into those to show, the first TopX with at least MinCount, and the rest
+func (o *Options) cutItems(items []item) ([]item, []item) {
+	n := len(items)
+	if o.TopX > 0 && n > o.TopX {
+		n = o.TopX
+	}
+	for n > 0 && items[n-1].Count < o.MinCount {
+		n--
+	}
+	return items[:n], items[n:]
+}
+
+// barWidth returns the percentage width of an item's bar, relative to the largest in the chart and leaving room for its count
+func barWidth(items []item, count int) int {
+	max := 0
+	for _, i := range items {
+		// Everyone else may outnumber the leader, but shouldn't dwarf their bars
+		if i.Count > max && !i.Others {
+			max = i.Count
+		}
+	}
+	if max <= 0 || count <= 0 {
+		return 0
+	}
+	if count > max {
+		return 80
+	}
+	return count * 80 / max
+}
+
 func mapToItems(m map[string]int) []item {
 	items := []item{}
 	for u, count := range m {
@@ -122,3 +385,55 @@ func mapToItems(m map[string]int) []item {
 	}
 	return items
 }
+
+// withoutExcluded returns data without the activity of the users o.Repo excludes, which may have been collected
+// before they were excluded
+func (o *Options) withoutExcluded(d Data) Data {
+	if len(o.Repo.ExcludeUsers) == 0 {
+		return d
+	}
+
+	prs := []*repo.PRSummary{}
+	for _, pr := range d.PRs {
+		if !o.Repo.Excluded(pr.User) {
+			prs = append(prs, pr)
+		}
+	}
+	d.PRs = prs
+
+	reviews := []*repo.ReviewSummary{}
+	for _, r := range d.Reviews {
+		if !o.Repo.Excluded(r.Reviewer) {
+			reviews = append(reviews, r)
+		}
+	}
+	d.Reviews = reviews
+
+	issues := []*repo.IssueSummary{}
+	for _, i := range d.Issues {
+		if !o.Repo.Excluded(i.Closer) {
+			issues = append(issues, i)
+		}
+	}
+	d.Issues = issues
+
+	comments := []*repo.CommentSummary{}
+	for _, c := range d.Comments {
+		if !o.Repo.Excluded(c.Commenter) {
+			comments = append(comments, c)
+		}
+	}
+	d.Comments = comments
+
+	if d.Triage != nil {
+		triage := []*repo.TriageSummary{}
+		for _, t := range d.Triage {
+			if !o.Repo.Excluded(t.Actor) {
+				triage = append(triage, t)
+			}
+		}
+		d.Triage = triage
+	}
+
+	return d
+}
@@ -16,13 +16,15 @@ package leaderboard
 
 const leaderboardTmpl = `<html>
 <head>
-    <title>{{ .Title }} - Leaderboard</title>
+    <title>{{ .PageTitle }}</title>
+    {{ if not .Static }}
     <link rel="preconnect" href="https://fonts.gstatic.com">
     <link href="https://fonts.googleapis.com/css2?family=Open+Sans:wght@300;400;600;700&display=swap" rel="stylesheet">
     <script type="text/javascript" src="https://www.gstatic.com/charts/loader.js"></script>
     <script type="text/javascript">
         google.charts.load("current", {packages:["corechart"]});
     </script>
+    {{ end }}
     <style>
     body {
        font-family: 'Open Sans', sans-serif;
@@ -93,13 +95,63 @@ const leaderboardTmpl = `<html>
         text-align: center;
     }
 
+    table.data {
+        border-collapse: collapse;
+        font-size: small;
+        color: #333;
+    }
+
+    table.data th, table.data td {
+        padding: 0.25em 0.75em;
+        border-bottom: 1px solid #eee;
+        text-align: left;
+    }
+
+    table.bars {
+        width: 450px;
+        font-size: small;
+        color: #333;
+    }
+
+    table.bars td.name {
+        width: 30%;
+        text-align: right;
+        white-space: nowrap;
+    }
+
+    table.bars div.bar {
+        display: inline-block;
+        height: 1.2em;
+        margin-right: 0.25em;
+        vertical-align: middle;
+        background-color: rgba(66,133,244,0.75);
+    }
+
+    table.bars div.bar.seg, span.seg {
+        margin-right: 0;
+    }
+
+    span.seg {
+        display: inline-block;
+        width: 0.8em;
+        height: 0.8em;
+        margin: 0 0.25em 0 0.75em;
+    }
+
+    .seg0 { background-color: rgba(66,133,244,0.75) !important; }
+    .seg1 { background-color: rgba(219,68,55,0.75) !important; }
+    .seg2 { background-color: rgba(244,160,0,0.75) !important; }
+    .seg3 { background-color: rgba(15,157,88,0.75) !important; }
+    .seg4 { background-color: rgba(171,71,188,0.75) !important; }
+
     </style>
 </head>
 <body>
     <h1>{{ .Title }}</h1>
     <div class="subtitle">{{.From}} &mdash; {{.Until}}</div>
+    {{ if .SelfMerged }}<div class="subtitle">{{ .SelfMerged }}</div>{{ end }}
 
-    <h2 class="cli">Command-line</h2>
+    <h2 class="cli">{{ .CommandLine }}</h2>
     <pre>{{.Command}}</pre>
 
     {{ range .Categories }}
@@ -109,14 +161,32 @@ const leaderboardTmpl = `<html>
             <div class="board">
             <h3>{{ .Title }}</h3>
             <p>{{ .Metric }}</p>
+            {{ if and $.Static .Line }}
+            <table class="data" id="chart_{{ .ID }}">
+                <tr><th>{{ .Object }}</th>{{ range .Series }}<th>{{ . }}</th>{{ end }}</tr>
+                {{ range .Items }}<tr><td>{{ .Name }}</td>{{ range .Values }}<td>{{ number . }}</td>{{ end }}</tr>
+                {{ end }}
+            </table>
+            {{ else if $.Static }}
+            {{ $items := .Items }}
+            {{ if .Series }}<p>{{ range $i, $s := .Series }}<span class="seg seg{{ $i }}"></span>{{ $s }}{{ end }}</p>{{ end }}
+            <table class="bars" id="chart_{{ .ID }}">
+                {{ range .Items }}<tr><td class="name">{{ if .URL }}<a href="{{ .URL }}">{{ .Name }}</a>{{ else }}{{ .Name }}{{ end }}</td><td>{{ if .Values }}{{ range $i, $v := .Values }}<div class="bar seg seg{{ $i }}" style="width: {{ width $items $v }}%"></div>{{ end }} {{ else }}<div class="bar" style="width: {{ width $items .Count }}%"></div>{{ end }}{{ number .Count }}</td></tr>
+                {{ end }}
+            </table>
+            {{ else }}
             <div id="chart_{{ .ID }}" style="width: 450px; height: 350px;"></div>
             <script type="text/javascript">
-                google.charts.setOnLoadCallback(draw{{ .ID}});
-
-                function draw{{.ID}}() {
+                google.charts.setOnLoadCallback(function() {
                     var data = new google.visualization.arrayToDataTable([
-                    ['{{.Object}}', '{{.Metric}}', { role: 'annotation' }],
-                    {{ range .Items }}["{{.Name}}", {{.Count}}, "{{.Count}}"],
+                    {{ if .Line }}
+                    ['{{.Object}}', {{ range .Series }}'{{.}}', {{ end }}],
+                    {{ range .Items }}["{{.Name}}", {{ range .Values }}{{.}}, {{ end }}],
+                    {{ end }}
+                    {{ else }}
+                    ['{{.Object}}', {{ if .Series }}{{ range .Series }}'{{.}}', {{ end }}{{ else }}'{{.Metric}}', {{ end }}{ role: 'annotation' }],
+                    {{ range .Items }}["{{.Name}}", {{ if .Values }}{{ range .Values }}{{.}}, {{ end }}{{ else }}{{.Count}}, {{ end }}"{{ number .Count }}"],
+                    {{ end }}
                     {{ end }}
                     ]);
 
@@ -129,14 +199,37 @@ const leaderboardTmpl = `<html>
                         y: { side: 'top'} // Top x-axis.
                         }
                     },
-                    legend: { position: "none" },
+                    legend: { position: {{ if gt (len .Series) 1 }}"top"{{ else }}"none"{{ end }} },
+                    isStacked: {{ if and .Series (not .Line) }}true{{ else }}false{{ end }},
+                    vAxis: { minValue: 0 },
                     bar: { groupWidth: "85%" }
                     };
 
-                   var chart = new google.visualization.BarChart(document.getElementById('chart_{{.ID }}'));
+                   var urls = [{{ range .Items }}"{{.URL}}", {{ end }}];
+
+                   var chart = new google.visualization.{{ if .Line }}LineChart{{ else }}BarChart{{ end }}(document.getElementById('chart_{{.ID }}'));
+                   google.visualization.events.addListener(chart, 'select', function() {
+                       var sel = chart.getSelection();
+                       if (sel.length > 0 && sel[0].row != null && urls[sel[0].row]) {
+                           window.open(urls[sel[0].row], '_blank');
+                       }
+                   });
                    chart.draw(data, options);
-                };
+                });
             </script>
+            {{ end }}
+            </div>
+        {{ end }}
+
+        {{ range .Tables }}
+            <div class="board" id="table_{{ .ID }}">
+            <h3>{{ .Title }}</h3>
+            <p>{{ .Description }}</p>
+            <table class="data">
+                <tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr>
+                {{ range .Rows }}<tr>{{ range . }}<td>{{ . }}</td>{{ end }}</tr>
+                {{ end }}
+            </table>
             </div>
         {{ end }}
     {{ end}}
@@ -0,0 +1,193 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+const script = `<script>alert("x")</script>`
+
+// hostileData is data whose user-controlled text tries to inject markup and script
+func hostileData() Data {
+	return Data{
+		PRs: []*repo.PRSummary{
+			{URL: "https://github.com/org/project/pull/1", Date: "2021-03-02", User: "alice", Project: "project", Title: script, Delta: 10, OpenedAt: "2021-03-01"},
+			{URL: "https://github.com/org/project/pull/2", Date: "2021-03-03", User: `bob"];alert(1);//`, Project: "project", Title: "Fix", Delta: 5, OpenedAt: "2021-03-01"},
+		},
+		NewContributors: []*repo.NewContributorSummary{
+			{User: "alice", URL: "https://github.com/org/project/pull/1", Date: "2021-03-02", Project: "project", Title: script},
+		},
+	}
+}
+
+func TestRenderEscapes(t *testing.T) {
+	o := DefaultOptions()
+	since := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for name, render := range map[string]func(string, time.Time, time.Time, []string, Data) (string, error){
+		"Render":       o.Render,
+		"RenderStatic": o.RenderStatic,
+	} {
+		t.Run(name, func(t *testing.T) {
+			out, err := render(script, since, until, nil, hostileData())
+			if err != nil {
+				t.Fatalf("%s() returned error: %v", name, err)
+			}
+			if strings.Contains(out, script) || strings.Contains(out, `<script>alert`) {
+				t.Errorf("%s() output contains an unescaped title", name)
+			}
+			if !strings.Contains(out, "&lt;script&gt;") {
+				t.Errorf("%s() output is missing the escaped title", name)
+			}
+			if strings.Contains(out, `bob"];alert(1)`) {
+				t.Errorf("%s() output contains an unescaped login in script", name)
+			}
+		})
+	}
+}
+
+func TestTicketTable(t *testing.T) {
+	o := DefaultOptions()
+	if _, ok := o.ticketTable([]*repo.PRSummary{{User: "alice"}}); ok {
+		t.Errorf("ticketTable() without tracker keys returned a table")
+	}
+
+	tbl, ok := o.ticketTable([]*repo.PRSummary{
+		{User: "alice", Delta: 1500, TrackerKeys: "PROJ-1"},
+		{User: "bob", Delta: 5, TrackerKeys: "PROJ-1"},
+		{User: "carol", Delta: 1},
+	})
+	if !ok || len(tbl.Rows) != 2 {
+		t.Fatalf("ticketTable() = %+v, %v, want a row per key", tbl, ok)
+	}
+	if got, want := tbl.Rows[0], []string{"PROJ-1", "2", "1,505", "alice, bob"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ticketTable() first row = %v, want %v", got, want)
+	}
+}
+
+func TestWarningsTable(t *testing.T) {
+	gs := []digest.Group{
+		{Category: digest.FetchFailed, Repo: "org/a", Count: 1200, Examples: []string{"a/1", "a/2"}},
+	}
+
+	got := DefaultOptions().warningsTable(gs).Rows
+	want := [][]string{{digest.FetchFailed, "org/a", "1,200", "a/1 a/2"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("warningsTable() rows = %q, want %q", got, want)
+	}
+}
+
+func TestMemberData(t *testing.T) {
+	d := Data{
+		PRs:      []*repo.PRSummary{{User: "alice", MemberAtTime: "true"}, {User: "bob", MemberAtTime: "false"}},
+		Reviews:  []*repo.ReviewSummary{{Reviewer: "carol", MemberAtTime: ""}, {Reviewer: "alice", MemberAtTime: "true"}},
+		Issues:   []*repo.IssueSummary{{Closer: "bob", MemberAtTime: "false"}},
+		Comments: []*repo.CommentSummary{{Commenter: "alice", MemberAtTime: "true"}},
+	}
+
+	members := memberData(d, "true")
+	if len(members.PRs) != 1 || len(members.Reviews) != 1 || len(members.Issues) != 0 || len(members.Comments) != 1 {
+		t.Errorf("memberData(true) = %d PRs, %d reviews, %d issues, %d comments, want 1, 1, 0, 1",
+			len(members.PRs), len(members.Reviews), len(members.Issues), len(members.Comments))
+	}
+
+	// Contributions by users not in the history are in neither
+	others := memberData(d, "false")
+	if len(others.PRs) != 1 || len(others.Reviews) != 0 || len(others.Issues) != 1 || len(others.Comments) != 0 {
+		t.Errorf("memberData(false) = %d PRs, %d reviews, %d issues, %d comments, want 1, 0, 1, 0",
+			len(others.PRs), len(others.Reviews), len(others.Issues), len(others.Comments))
+	}
+}
+
+func TestRenderStaticOffline(t *testing.T) {
+	o := DefaultOptions()
+	since := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	out, err := o.RenderStatic("Board", since, until, nil, hostileData())
+	if err != nil {
+		t.Fatalf("RenderStatic() returned error: %v", err)
+	}
+	for _, remote := range []string{`src="http`, `rel="stylesheet"`, `rel="preconnect"`, "google.visualization"} {
+		if strings.Contains(out, remote) {
+			t.Errorf("RenderStatic() output contains %q, loading from the network", remote)
+		}
+	}
+}
+
+func TestBarWidth(t *testing.T) {
+	items := []item{{Name: "alice", Count: 10}, {Name: "bob", Count: 5}, {Name: "others", Count: 40, Others: true}}
+	tests := []struct {
+		count int
+		want  int
+	}{
+		{10, 80},
+		{5, 40},
+		{0, 0},
+		// Everyone else is capped at the leader's width
+		{40, 80},
+	}
+
+	for _, tc := range tests {
+		if got := barWidth(items, tc.count); got != tc.want {
+			t.Errorf("barWidth(%d) = %d, want %d", tc.count, got, tc.want)
+		}
+	}
+	if got := barWidth(nil, 3); got != 0 {
+		t.Errorf("barWidth() = %d for an empty chart, want 0", got)
+	}
+}
+
+func TestWithoutExcluded(t *testing.T) {
+	d := Data{
+		PRs:      []*repo.PRSummary{{User: "alice"}, {User: "Robot"}},
+		Reviews:  []*repo.ReviewSummary{{Reviewer: "robot"}, {Reviewer: "bob"}},
+		Issues:   []*repo.IssueSummary{{Author: "robot", Closer: "carol"}, {Closer: "ROBOT"}},
+		Comments: []*repo.CommentSummary{{Commenter: "robot"}},
+		Triage:   []*repo.TriageSummary{{Actor: "robot"}, {Actor: "dave"}},
+	}
+
+	o := DefaultOptions()
+	if got := o.withoutExcluded(d); !reflect.DeepEqual(got, d) {
+		t.Errorf("withoutExcluded() changed the data with no users excluded")
+	}
+
+	o.Repo.ExcludeUsers = map[string]bool{"robot": true}
+	got := o.withoutExcluded(d)
+	if len(got.PRs) != 1 || got.PRs[0].User != "alice" {
+		t.Errorf("withoutExcluded() PRs = %v, want alice's", got.PRs)
+	}
+	if len(got.Reviews) != 1 || got.Reviews[0].Reviewer != "bob" {
+		t.Errorf("withoutExcluded() reviews = %v, want bob's", got.Reviews)
+	}
+	// Issues are credited to their closer, whoever opened them
+	if len(got.Issues) != 1 || got.Issues[0].Closer != "carol" {
+		t.Errorf("withoutExcluded() issues = %v, want carol's", got.Issues)
+	}
+	if len(got.Comments) != 0 {
+		t.Errorf("withoutExcluded() comments = %v, want none", got.Comments)
+	}
+	if len(got.Triage) != 1 || got.Triage[0].Actor != "dave" {
+		t.Errorf("withoutExcluded() triage = %v, want dave's", got.Triage)
+	}
+}
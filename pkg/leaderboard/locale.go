@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultLocale is used for any message missing from the selected locale
+const defaultLocale = "en"
+
+//go:embed locales/*.json
+var localeFS embed.FS
+
+var (
+	catalogs     map[string]map[string]string
+	catalogsOnce sync.Once
+	warned       sync.Map
+)
+
+// loadCatalogs parses the embedded message catalogs, keyed by locale
+func loadCatalogs() map[string]map[string]string {
+	catalogsOnce.Do(func() {
+		catalogs = map[string]map[string]string{}
+		entries, err := localeFS.ReadDir("locales")
+		if err != nil {
+			panic(err)
+		}
+
+		for _, e := range entries {
+			b, err := localeFS.ReadFile(path.Join("locales", e.Name()))
+			if err != nil {
+				panic(err)
+			}
+
+			m := map[string]string{}
+			if err := json.Unmarshal(b, &m); err != nil {
+				panic(fmt.Sprintf("%s: %v", e.Name(), err))
+			}
+			catalogs[strings.TrimSuffix(e.Name(), ".json")] = m
+		}
+	})
+	return catalogs
+}
+
+// Locales returns the available locales
+func Locales() []string {
+	ls := []string{}
+	for l := range loadCatalogs() {
+		ls = append(ls, l)
+	}
+	sort.Strings(ls)
+	return ls
+}
+
+// ValidLocale returns an error if a locale has no catalog
+func ValidLocale(locale string) error {
+	if _, ok := loadCatalogs()[locale]; !ok {
+		return fmt.Errorf("unknown locale %q, choose from: %s", locale, strings.Join(Locales(), ", "))
+	}
+	return nil
+}
+
+// msg returns the message for a key in the current locale, formatted with args
+func (o *Options) msg(key string, args ...interface{}) string {
+	cs := loadCatalogs()
+
+	m, ok := cs[o.Locale][key]
+	if !ok {
+		if _, seen := warned.LoadOrStore(o.Locale+"/"+key, true); !seen && o.Locale != defaultLocale {
+			logrus.Warningf("locale %q has no message for %q, using %q", o.Locale, key, defaultLocale)
+		}
+
+		m, ok = cs[defaultLocale][key]
+		if !ok {
+			return key
+		}
+	}
+
+	if len(args) == 0 {
+		return m
+	}
+	return fmt.Sprintf(m, args...)
+}
+
+// formatDate formats a date in the current locale
+func (o *Options) formatDate(t time.Time) string {
+	return t.Format(o.msg("date.format"))
+}
+
+// formatNumber formats an integer with the current locale's thousands separator
+func (o *Options) formatNumber(n int) string {
+	s := strconv.Itoa(n)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+
+	sep := o.msg("number.thousands")
+	var sb strings.Builder
+	for i, r := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			sb.WriteString(sep)
+		}
+		sb.WriteRune(r)
+	}
+
+	if neg {
+		return "-" + sb.String()
+	}
+	return sb.String()
+}
+
+// formatDecimal formats a number to two decimal places with the current locale's decimal separator
+func (o *Options) formatDecimal(f float64) string {
+	return strings.Replace(strconv.FormatFloat(f, 'f', 2, 64), ".", o.msg("number.decimal"), 1)
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// verbRe matches format verbs, which translations may index to reorder them
+var verbRe = regexp.MustCompile(`%(?:\[\d+\])?([a-z])`)
+
+// verbs returns the sorted verbs of a message
+func verbs(m string) []string {
+	vs := []string{}
+	for _, v := range verbRe.FindAllStringSubmatch(strings.ReplaceAll(m, "%%", ""), -1) {
+		vs = append(vs, v[1])
+	}
+	sort.Strings(vs)
+	return vs
+}
+
+// TestCatalogsComplete checks every catalog translates every English message, with the same format verbs
+func TestCatalogsComplete(t *testing.T) {
+	cs := loadCatalogs()
+	en := cs[defaultLocale]
+	for _, l := range Locales() {
+		for key, want := range en {
+			got, ok := cs[l][key]
+			if !ok {
+				t.Errorf("%s has no message for %q", l, key)
+				continue
+			}
+			if gv, wv := verbs(got), verbs(want); !reflect.DeepEqual(gv, wv) {
+				t.Errorf("%s message for %q has verbs %v, want %v", l, key, gv, wv)
+			}
+		}
+		for key := range cs[l] {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s has a message for %q, which English lacks", l, key)
+			}
+		}
+	}
+}
+
+func TestValidLocale(t *testing.T) {
+	for _, l := range []string{"en", "ja", "pt"} {
+		if err := ValidLocale(l); err != nil {
+			t.Errorf("ValidLocale(%q) = %v", l, err)
+		}
+	}
+	if err := ValidLocale("xx"); err == nil {
+		t.Errorf("ValidLocale(%q) succeeded", "xx")
+	}
+}
+
+func TestMsgFallsBack(t *testing.T) {
+	o := DefaultOptions()
+	o.Locale = "pt"
+	en := loadCatalogs()[defaultLocale]
+	en["test.missing"] = "English %d"
+	defer delete(en, "test.missing")
+
+	if got := o.msg("test.missing", 3); got != "English 3" {
+		t.Errorf("msg() = %q, want the English message", got)
+	}
+	if got := o.msg("test.unknown"); got != "test.unknown" {
+		t.Errorf("msg() = %q for an unknown key, want the key", got)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	d := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		locale      string
+		wantDate    string
+		wantNumbers []string
+		wantDecimal string
+	}{
+		{"en", "2021-03-05", []string{"0", "999", "1,000", "-1,234,567"}, "3.14"},
+		{"ja", "2021年03月05日", []string{"0", "999", "1,000", "-1,234,567"}, "3.14"},
+		{"pt", "05/03/2021", []string{"0", "999", "1.000", "-1.234.567"}, "3,14"},
+	}
+
+	for _, tc := range tests {
+		o := DefaultOptions()
+		o.Locale = tc.locale
+		if got := o.formatDate(d); got != tc.wantDate {
+			t.Errorf("%s formatDate() = %q, want %q", tc.locale, got, tc.wantDate)
+		}
+		for i, n := range []int{0, 999, 1000, -1234567} {
+			if got := o.formatNumber(n); got != tc.wantNumbers[i] {
+				t.Errorf("%s formatNumber(%d) = %q, want %q", tc.locale, n, got, tc.wantNumbers[i])
+			}
+		}
+		if got := o.formatDecimal(3.14159); got != tc.wantDecimal {
+			t.Errorf("%s formatDecimal() = %q, want %q", tc.locale, got, tc.wantDecimal)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// spellings counts how often each spelling of a login appears, by lowercased login
+type spellings struct {
+	// opts decide the login each alias is credited to
+	opts  *repo.Options
+	count map[string]map[string]int
+}
+
+func (s spellings) add(logins ...string) {
+	for _, l := range logins {
+		if l == "" {
+			continue
+		}
+		l = s.opts.Canonical(l)
+		k := strings.ToLower(l)
+		if s.count[k] == nil {
+			s.count[k] = map[string]int{}
+		}
+		s.count[k][l]++
+	}
+}
+
+// name returns the most common spelling of login, or the first in sort order among those as common
+func (s spellings) name(login string) string {
+	if login == "" {
+		return login
+	}
+	login = s.opts.Canonical(login)
+	best, bestN := login, -1
+	for l, n := range s.count[strings.ToLower(login)] {
+		if n > bestN || (n == bestN && l < best) {
+			best, bestN = l, n
+		}
+	}
+	return best
+}
+
+// names is name for comma delimited logins
+func (s spellings) names(logins string) string {
+	if logins == "" {
+		return logins
+	}
+	ls := strings.Split(logins, ",")
+	for i, l := range ls {
+		ls[i] = s.name(l)
+	}
+	return strings.Join(ls, ",")
+}
+
+// sameLogins returns data with each login spelled one way, as GitHub logins ignore case, so that "Alice" and "alice"
+// are counted as one user. Aliases are applied too, for data collected before they were added.
+func (o *Options) sameLogins(d Data) Data {
+	s := spellings{opts: o.Repo, count: map[string]map[string]int{}}
+	for _, pr := range d.PRs {
+		s.add(pr.User)
+		s.add(strings.Split(pr.Reviewers, ",")...)
+		s.add(strings.Split(pr.Approvers, ",")...)
+	}
+	for _, r := range d.Reviews {
+		s.add(r.Reviewer, r.PRAuthor)
+	}
+	for _, i := range d.Issues {
+		s.add(i.Author, i.Closer)
+		s.add(strings.Split(i.Assignees, ",")...)
+	}
+	for _, c := range d.Comments {
+		s.add(c.Commenter, c.IssueAuthor)
+	}
+	for _, t := range d.Triage {
+		s.add(t.Actor)
+	}
+	for _, n := range d.NewContributors {
+		s.add(n.User)
+	}
+
+	// Summaries are copied rather than changed, as they may be shared with other renders of the same data
+	prs := []*repo.PRSummary{}
+	for _, pr := range d.PRs {
+		c := *pr
+		c.User = s.name(pr.User)
+		c.Reviewers = s.names(pr.Reviewers)
+		c.Approvers = s.names(pr.Approvers)
+		prs = append(prs, &c)
+	}
+	d.PRs = prs
+
+	reviews := []*repo.ReviewSummary{}
+	for _, r := range d.Reviews {
+		c := *r
+		c.Reviewer = s.name(r.Reviewer)
+		c.PRAuthor = s.name(r.PRAuthor)
+		reviews = append(reviews, &c)
+	}
+	d.Reviews = reviews
+
+	issues := []*repo.IssueSummary{}
+	for _, i := range d.Issues {
+		c := *i
+		c.Author = s.name(i.Author)
+		c.Closer = s.name(i.Closer)
+		c.Assignees = s.names(i.Assignees)
+		issues = append(issues, &c)
+	}
+	d.Issues = issues
+
+	comments := []*repo.CommentSummary{}
+	for _, cs := range d.Comments {
+		c := *cs
+		c.Commenter = s.name(cs.Commenter)
+		c.IssueAuthor = s.name(cs.IssueAuthor)
+		comments = append(comments, &c)
+	}
+	d.Comments = comments
+
+	if d.Triage != nil {
+		triage := []*repo.TriageSummary{}
+		for _, t := range d.Triage {
+			c := *t
+			c.Actor = s.name(t.Actor)
+			triage = append(triage, &c)
+		}
+		d.Triage = triage
+	}
+
+	if d.NewContributors != nil {
+		ncs := []*repo.NewContributorSummary{}
+		for _, n := range d.NewContributors {
+			c := *n
+			c.User = s.name(n.User)
+			ncs = append(ncs, &c)
+		}
+		d.NewContributors = ncs
+	}
+
+	return d
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"testing"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+func TestSameLogins(t *testing.T) {
+	o := DefaultOptions()
+	if err := o.Repo.AddAlias("Alice-Old=alice"); err != nil {
+		t.Fatal(err)
+	}
+
+	pr := &repo.PRSummary{User: "Alice", Reviewers: "BOB,alice-old", Approvers: "Bob"}
+	d := Data{
+		PRs: []*repo.PRSummary{
+			pr,
+			{User: "alice"},
+			{User: "alice"},
+			{User: "alice-old"},
+			{User: "bob"},
+		},
+		Reviews:  []*repo.ReviewSummary{{Reviewer: "Bob", PRAuthor: "ALICE"}},
+		Issues:   []*repo.IssueSummary{{Author: "bob", Closer: "Alice-Old", Assignees: "Bob,alice"}},
+		Comments: []*repo.CommentSummary{{Commenter: "bob", IssueAuthor: "Alice"}},
+	}
+
+	got := o.sameLogins(d)
+
+	// The most common spelling wins, with aliases credited to their login first
+	if u := got.PRs[0].User; u != "alice" {
+		t.Errorf("PR user = %q, want alice", u)
+	}
+	if u := got.PRs[3].User; u != "alice" {
+		t.Errorf("aliased PR user = %q, want alice", u)
+	}
+	// bob and Bob are as common, so the first in sort order wins
+	if r := got.PRs[0].Reviewers; r != "Bob,alice" {
+		t.Errorf("PR reviewers = %q, want Bob,alice", r)
+	}
+	if a := got.PRs[0].Approvers; a != "Bob" {
+		t.Errorf("PR approvers = %q, want Bob", a)
+	}
+	if r := got.Reviews[0]; r.Reviewer != "Bob" || r.PRAuthor != "alice" {
+		t.Errorf("review = %s of %s, want Bob of alice", r.Reviewer, r.PRAuthor)
+	}
+	if i := got.Issues[0]; i.Author != "Bob" || i.Closer != "alice" || i.Assignees != "Bob,alice" {
+		t.Errorf("issue = %s closed by %s assigned %s, want Bob closed by alice assigned Bob,alice", i.Author, i.Closer, i.Assignees)
+	}
+	if c := got.Comments[0]; c.Commenter != "Bob" || c.IssueAuthor != "alice" {
+		t.Errorf("comment = %s on %s's issue, want Bob on alice's", c.Commenter, c.IssueAuthor)
+	}
+
+	// The summaries given are left alone, as other renders may share them
+	if pr.User != "Alice" || pr.Reviewers != "BOB,alice-old" {
+		t.Errorf("sameLogins() changed its input to %+v", pr)
+	}
+}
+
+func TestSameLoginsCharts(t *testing.T) {
+	d := Data{PRs: []*repo.PRSummary{
+		{User: "Alice", Delta: 1},
+		{User: "alice", Delta: 1},
+		{User: "ALICE", Delta: 1},
+	}}
+
+	o := DefaultOptions()
+	c := o.mergeChart(o.sameLogins(d).PRs, nil)
+	if len(c.Items) != 1 || c.Items[0].Count != 3 {
+		t.Errorf("merged PRs chart = %+v, want one user with 3 PRs", c.Items)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"time"
+)
+
+// memberCategories returns charts restricted to contributions made as an org member, and as a non-member.
+// Contributions by users absent from the membership history appear in neither.
+func (o *Options) memberCategories(since time.Time, until time.Time, users []string, d Data, repos []string) []category {
+	variants := []struct {
+		title  string
+		suffix string
+		member string
+	}{
+		{title: o.msg("category.members"), suffix: "Members", member: "true"},
+		{title: o.msg("category.nonMembers"), suffix: "NonMembers", member: "false"},
+	}
+
+	cats := []category{}
+	for _, v := range variants {
+		md := memberData(d, v.member)
+		cat := category{
+			Title:  v.title,
+			Charts: o.buildCharts(chartInput{o: o, since: since, until: until, users: users, d: md}, "prCounts", "reviewCounts", "issueCloser", "comments"),
+		}
+		if len(cat.Charts) == 0 {
+			continue
+		}
+
+		// Links are looked up by chart ID, so must be set before the IDs are made unique
+		o.linkItems([]category{cat}, repos, since, until)
+		for i := range cat.Charts {
+			cat.Charts[i].ID += v.suffix
+		}
+
+		cats = append(cats, cat)
+	}
+
+	return cats
+}
+
+// memberData returns the contributions whose MemberAtTime matches member
+func memberData(d Data, member string) Data {
+	md := Data{}
+	for _, pr := range d.PRs {
+		if pr.MemberAtTime == member {
+			md.PRs = append(md.PRs, pr)
+		}
+	}
+	for _, r := range d.Reviews {
+		if r.MemberAtTime == member {
+			md.Reviews = append(md.Reviews, r)
+		}
+	}
+	for _, i := range d.Issues {
+		if i.MemberAtTime == member {
+			md.Issues = append(md.Issues, i)
+		}
+	}
+	for _, c := range d.Comments {
+		if c.MemberAtTime == member {
+			md.Comments = append(md.Comments, c)
+		}
+	}
+
+	return md
+}
@@ -0,0 +1,55 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// newContributorsChart shows how many PRs each new contributor merged, linking to their first
+func (o *Options) newContributorsChart(ns []*repo.NewContributorSummary, prs []*repo.PRSummary) chart {
+	merged := map[string]int{}
+	for _, pr := range prs {
+		merged[pr.User]++
+	}
+
+	items := []item{}
+	for _, n := range ns {
+		items = append(items, item{Name: n.User, Count: merged[n.User], URL: n.URL})
+	}
+
+	return chart{
+		ID:     "newContributors",
+		Title:  o.msg("chart.newContributors.title"),
+		Metric: o.msg("chart.newContributors.metric"),
+		Items:  o.topItems(items),
+	}
+}
+
+// newContributorsTable lists every new contributor with their first merged PR, earliest first
+func (o *Options) newContributorsTable(ns []*repo.NewContributorSummary) table {
+	rows := [][]string{}
+	for _, n := range ns {
+		rows = append(rows, []string{n.User, n.Date, n.Title, n.URL})
+	}
+
+	return table{
+		ID:          "newContributors",
+		Title:       o.msg("table.newContributors.title"),
+		Description: o.msg("table.newContributors.description", len(ns)),
+		Columns:     []string{o.msg("column.user"), o.msg("column.date"), o.msg("column.title"), o.msg("column.url")},
+		Rows:        rows,
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"html/template"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// Options decide what a leaderboard shows, and how. Every run, and every server job, has its own, so pages with
+// different settings can be rendered at once.
+type Options struct {
+	// Repo are the options the data was collected with, which also decide whose activity counts and whom it is
+	// credited to
+	Repo *repo.Options
+
+	// TopX is how many items to include in graphs, or 0 for all of them
+	TopX int
+	// MinCount is the lowest count of an item included in graphs
+	MinCount int
+	// Charts are the IDs of the charts to include, in the order to show them, or nil for every chart in the default
+	// order
+	Charts []string
+	// MemberCharts adds chart variants split by organization membership at the time of each contribution
+	MemberCharts bool
+	// TrendUsers adds a line per top user to trend charts, alongside the total
+	TrendUsers bool
+	// PathDepth is how many leading path components name a directory in the hot directories chart
+	PathDepth int
+	// MinPerRepo is the minimum delta a user must merge into a repository for it to count toward their breadth
+	MinPerRepo int
+	// MinReviews is how many reviews a user needs to appear in the review latency chart, so one quick review
+	// doesn't top it
+	MinReviews int
+	// CompletedOnly only credits closers for issues closed as completed, rather than as not planned
+	CompletedOnly bool
+	// QuickCloses, if set, leaves issues closed by bots, or by their author within this long, out of time-to-close
+	// charts
+	QuickCloses time.Duration
+	// ExcludeSelfMerged leaves PRs merged by their own author out of every chart and table but the self-merge chart
+	ExcludeSelfMerged bool
+	// Impact configures the composite impact score, or is nil if it is disabled
+	Impact *ImpactConfig
+
+	// Locale is the language leaderboards are rendered in
+	Locale string
+	// Templates are the page templates, as LoadTemplates returns them, or nil for the embedded ones
+	Templates *template.Template
+	// WebURL is the web address of the GitHub instance searches are linked to, such as a GitHub Enterprise Server
+	WebURL string
+	// UserLinks returns the address of a user's activity page, linked from their chart items in place of a GitHub
+	// search, or is nil to link searches
+	UserLinks func(login string) string
+}
+
+// DefaultOptions returns the options used when none are given on the command line
+func DefaultOptions() *Options {
+	return &Options{
+		Repo:       repo.DefaultOptions(),
+		TopX:       15,
+		PathDepth:  2,
+		MinReviews: 5,
+		Locale:     defaultLocale,
+		WebURL:     repo.WebURL,
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// hasFiles returns whether any PR lists its changed files, which are only fetched when something needs them
+func hasFiles(prs []*repo.PRSummary) bool {
+	for _, pr := range prs {
+		if pr.Files != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// prPaths returns the paths a PR changed, leaving out those matching o.Repo.IgnorePathRe and the count of paths past
+// --max-files-listed
+func (o *Options) prPaths(pr *repo.PRSummary) []string {
+	paths := []string{}
+	for _, p := range strings.Split(pr.Files, "\n") {
+		if p == "" || (strings.HasPrefix(p, "(+") && strings.HasSuffix(p, " more)")) {
+			continue
+		}
+		if o.Repo.IgnorePathRe != nil && o.Repo.IgnorePathRe.MatchString(p) {
+			continue
+		}
+		paths = append(paths, p)
+	}
+	return paths
+}
+
+// pathDir returns the first PathDepth directories of a path, ending in "/", or "/" for a file at the top level
+func (o *Options) pathDir(p string) string {
+	parts := strings.Split(p, "/")
+	dirs := parts[:len(parts)-1]
+	if len(dirs) > o.PathDepth {
+		dirs = dirs[:o.PathDepth]
+	}
+	return strings.Join(dirs, "/") + "/"
+}
+
+// hotPathCounts returns how many PRs changed each path, and each directory. Paths are prefixed with the PR's
+// repository if the PRs span several.
+func (o *Options) hotPathCounts(prs []*repo.PRSummary) (map[string]int, map[string]int) {
+	projects := map[string]bool{}
+	for _, pr := range prs {
+		projects[pr.Project] = true
+	}
+
+	files := map[string]int{}
+	dirs := map[string]int{}
+	for _, pr := range prs {
+		prefix := ""
+		if len(projects) > 1 {
+			prefix = pr.Project + ":"
+		}
+
+		seen := map[string]bool{}
+		for _, p := range o.prPaths(pr) {
+			files[prefix+p]++
+			if d := prefix + o.pathDir(p); !seen[d] {
+				seen[d] = true
+				dirs[d]++
+			}
+		}
+	}
+	return files, dirs
+}
+
+// hotFilesChart shows the paths changed by the most PRs
+func (o *Options) hotFilesChart(prs []*repo.PRSummary) chart {
+	files, _ := o.hotPathCounts(prs)
+	return chart{
+		ID:     "hotFiles",
+		Title:  o.msg("chart.hotFiles.title"),
+		Object: o.msg("column.path"),
+		Metric: o.msg("chart.hotFiles.metric"),
+		Items:  o.rankedItems(mapToItems(files)),
+	}
+}
+
+// hotDirsChart shows the directories, PathDepth deep, changed by the most PRs
+func (o *Options) hotDirsChart(prs []*repo.PRSummary) chart {
+	_, dirs := o.hotPathCounts(prs)
+	return chart{
+		ID:     "hotDirs",
+		Title:  o.msg("chart.hotDirs.title"),
+		Object: o.msg("column.directory"),
+		Metric: o.msg("chart.hotDirs.metric", o.PathDepth),
+		Items:  o.rankedItems(mapToItems(dirs)),
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// projectStats are the merged PRs of one repository
+type projectStats struct {
+	Repo  string
+	PRs   int
+	Delta int
+	// userPRs counts the PRs of each user
+	userPRs map[string]int
+}
+
+// projectStatistics returns the merged PRs of each repository, most PRs first
+func projectStatistics(prs []*repo.PRSummary) []*projectStats {
+	m := map[string]*projectStats{}
+	for _, pr := range prs {
+		r := repoOf(pr.URL)
+		if m[r] == nil {
+			m[r] = &projectStats{Repo: r, userPRs: map[string]int{}}
+		}
+		m[r].PRs++
+		m[r].Delta += pr.Delta
+		m[r].userPRs[pr.User]++
+	}
+
+	ps := []*projectStats{}
+	for _, p := range m {
+		ps = append(ps, p)
+	}
+	sort.Slice(ps, func(i, j int) bool {
+		if ps[i].PRs != ps[j].PRs {
+			return ps[i].PRs > ps[j].PRs
+		}
+		return ps[i].Repo < ps[j].Repo
+	})
+	return ps
+}
+
+func (o *Options) repoPRsChart(ps []*projectStats) chart {
+	items := []item{}
+	for _, p := range ps {
+		items = append(items, item{Name: p.Repo, Count: p.PRs, tiebreak: p.Delta})
+	}
+
+	return chart{
+		ID:     "repoPRs",
+		Title:  o.msg("chart.repoPRs.title"),
+		Metric: o.msg("chart.repoPRs.metric"),
+		Items:  o.topItems(items),
+	}
+}
+
+func (o *Options) repoDeltasChart(ps []*projectStats) chart {
+	items := []item{}
+	for _, p := range ps {
+		items = append(items, item{Name: p.Repo, Count: p.Delta, tiebreak: p.PRs})
+	}
+
+	return chart{
+		ID:     "repoDeltas",
+		Title:  o.msg("chart.repoDeltas.title"),
+		Metric: o.msg("chart.repoDeltas.metric"),
+		Items:  o.topItems(items),
+	}
+}
+
+// projectTable returns a row per repository, listing how many PRs each contributor merged into it
+func (o *Options) projectTable(ps []*projectStats) table {
+	rows := [][]string{}
+	for _, p := range ps {
+		users := []item{}
+		for u, n := range p.userPRs {
+			users = append(users, item{Name: u, Count: n})
+		}
+		sort.Slice(users, func(i, j int) bool {
+			if users[i].Count != users[j].Count {
+				return users[i].Count > users[j].Count
+			}
+			return users[i].Name < users[j].Name
+		})
+
+		contributors := []string{}
+		for _, u := range users {
+			contributors = append(contributors, fmt.Sprintf("%s (%s)", u.Name, o.formatNumber(u.Count)))
+		}
+
+		rows = append(rows, []string{
+			p.Repo,
+			o.formatNumber(p.PRs),
+			o.formatNumber(p.Delta),
+			strings.Join(contributors, ", "),
+		})
+	}
+
+	return table{
+		ID:          "projects",
+		Title:       o.msg("table.projects.title"),
+		Description: o.msg("table.projects.description"),
+		Columns:     []string{o.msg("column.repository"), o.msg("column.prs"), o.msg("column.delta"), o.msg("column.contributors")},
+		Rows:        rows,
+	}
+}
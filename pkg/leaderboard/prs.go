@@ -15,10 +15,13 @@
 package leaderboard
 
 import (
+	"math"
+	"sort"
+
 	"github.com/google/pullsheet/pkg/repo"
 )
 
-func mergeChart(prs []*repo.PRSummary, _ []string) chart {
+func (o *Options) mergeChart(prs []*repo.PRSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, pr := range prs {
 		uMap[pr.User]++
@@ -26,13 +29,13 @@ func mergeChart(prs []*repo.PRSummary, _ []string) chart {
 
 	return chart{
 		ID:     "prCounts",
-		Title:  "Most Active",
-		Metric: "# of Pull Requests Merged",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.prCounts.title"),
+		Metric: o.msg("chart.prCounts.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
 
-func deltaChart(prs []*repo.PRSummary, _ []string) chart {
+func (o *Options) deltaChart(prs []*repo.PRSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, pr := range prs {
 		uMap[pr.User] += pr.Delta
@@ -40,13 +43,13 @@ func deltaChart(prs []*repo.PRSummary, _ []string) chart {
 
 	return chart{
 		ID:     "prDeltas",
-		Title:  "Big Movers",
-		Metric: "Lines of code (delta)",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.prDeltas.title"),
+		Metric: o.msg("chart.prDeltas.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
 
-func sizeChart(prs []*repo.PRSummary, _ []string) chart {
+func (o *Options) sizeChart(prs []*repo.PRSummary, _ []string) chart {
 	sz := map[string][]int{}
 	for _, pr := range prs {
 		sz[pr.User] = append(sz[pr.User], pr.Delta-pr.Deleted)
@@ -64,8 +67,78 @@ func sizeChart(prs []*repo.PRSummary, _ []string) chart {
 
 	return chart{
 		ID:     "prSize",
-		Title:  "Most difficult to review",
-		Metric: "Average PR size (added+changed)",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.prSize.title"),
+		Metric: o.msg("chart.prSize.metric"),
+		Items:  o.rankedItems(mapToItems(uMap)),
+	}
+}
+
+// sizeBucketChart shows how many PRs each user merged of each size, so one giant PR doesn't outweigh many small ones
+func (o *Options) sizeBucketChart(prs []*repo.PRSummary, _ []string) chart {
+	index := map[string]int{}
+	for i, b := range repo.SizeBuckets {
+		index[b] = i
+	}
+
+	counts := map[string][]int{}
+	for _, pr := range prs {
+		// Summaries saved before size buckets existed have none
+		b := pr.SizeBucket
+		if b == "" {
+			b = o.Repo.SizeBucket(pr.Delta)
+		}
+		if counts[pr.User] == nil {
+			counts[pr.User] = make([]int, len(repo.SizeBuckets))
+		}
+		counts[pr.User][index[b]]++
+	}
+
+	items := []item{}
+	for u, vs := range counts {
+		total := 0
+		for _, v := range vs {
+			total += v
+		}
+		items = append(items, item{Name: u, Count: total, Values: vs})
+	}
+
+	return chart{
+		ID:     "prSizeBuckets",
+		Title:  o.msg("chart.prSizeBuckets.title"),
+		Metric: o.msg("chart.prSizeBuckets.metric"),
+		Series: repo.SizeBuckets,
+		Items:  o.topItems(items),
+	}
+}
+
+// latencyChart shows the median hours each user's PRs took to merge, slowest first
+func (o *Options) latencyChart(prs []*repo.PRSummary, _ []string) chart {
+	hours := map[string][]float64{}
+	for _, pr := range prs {
+		if pr.HoursToMerge != nil {
+			hours[pr.User] = append(hours[pr.User], *pr.HoursToMerge)
+		}
+	}
+
+	uMap := map[string]int{}
+	for u, hs := range hours {
+		uMap[u] = int(math.Round(median(hs)))
+	}
+
+	return chart{
+		ID:     "prLatency",
+		Title:  o.msg("chart.prLatency.title"),
+		Metric: o.msg("chart.prLatency.metric"),
+		Items:  o.rankedItems(mapToItems(uMap)),
+	}
+}
+
+// median returns the median of a non-empty list, which it sorts
+func median(xs []float64) float64 {
+	sort.Float64s(xs)
+	mid := len(xs) / 2
+	if len(xs)%2 == 0 {
+		return (xs[mid-1] + xs[mid]) / 2
 	}
+	return xs[mid]
 }
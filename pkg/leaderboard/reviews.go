@@ -15,10 +15,12 @@
 package leaderboard
 
 import (
+	"math"
+
 	"github.com/google/pullsheet/pkg/repo"
 )
 
-func reviewsChart(reviews []*repo.ReviewSummary, _ []string) chart {
+func (o *Options) reviewsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, r := range reviews {
 		uMap[r.Reviewer]++
@@ -26,13 +28,13 @@ func reviewsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 
 	return chart{
 		ID:     "reviewCounts",
-		Title:  "Most Influential",
-		Metric: "# of Merged PRs reviewed",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.reviewCounts.title"),
+		Metric: o.msg("chart.reviewCounts.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
 
-func reviewCommentsChart(reviews []*repo.ReviewSummary, _ []string) chart {
+func (o *Options) reviewCommentsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, r := range reviews {
 		uMap[r.Reviewer] += r.ReviewComments
@@ -40,13 +42,13 @@ func reviewCommentsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 
 	return chart{
 		ID:     "reviewComments",
-		Title:  "Most Demanding",
-		Metric: "# of Review Comments in merged PRs",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.reviewComments.title"),
+		Metric: o.msg("chart.reviewComments.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
 	}
 }
 
-func reviewWordsChart(reviews []*repo.ReviewSummary, _ []string) chart {
+func (o *Options) reviewWordsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 	uMap := map[string]int{}
 	for _, r := range reviews {
 		uMap[r.Reviewer] += r.Words
@@ -54,8 +56,56 @@ func reviewWordsChart(reviews []*repo.ReviewSummary, _ []string) chart {
 
 	return chart{
 		ID:     "reviewWords",
-		Title:  "Most Helpful",
-		Metric: "# of words written in merged PRs",
-		Items:  topItems(mapToItems(uMap)),
+		Title:  o.msg("chart.reviewWords.title"),
+		Metric: o.msg("chart.reviewWords.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
+	}
+}
+
+// approversChart counts the PRs each user approved or requested changes on, crediting reviewers who decide rather than comment
+func (o *Options) approversChart(reviews []*repo.ReviewSummary, _ []string) chart {
+	uMap := map[string]int{}
+	for _, r := range reviews {
+		if r.Reviewer == r.PRAuthor || o.Repo.IsBotLogin(r.Reviewer) {
+			continue
+		}
+		if r.Approvals+r.ChangeRequests > 0 {
+			uMap[r.Reviewer]++
+		}
+	}
+
+	return chart{
+		ID:     "prApprovers",
+		Title:  o.msg("chart.prApprovers.title"),
+		Metric: o.msg("chart.prApprovers.metric"),
+		Items:  o.topItems(mapToItems(uMap)),
+	}
+}
+
+// reviewLatencyChart shows the median hours each user took to first review a PR, fastest first
+func (o *Options) reviewLatencyChart(reviews []*repo.ReviewSummary, _ []string) chart {
+	hours := map[string][]float64{}
+	for _, r := range reviews {
+		if r.ReviewHours == nil || r.Reviewer == r.PRAuthor || o.Repo.IsBotLogin(r.Reviewer) {
+			continue
+		}
+		hours[r.Reviewer] = append(hours[r.Reviewer], *r.ReviewHours)
+	}
+
+	items := []item{}
+	for u, hs := range hours {
+		if len(hs) < o.MinReviews {
+			continue
+		}
+		m := median(hs)
+		// Many reviews come within the hour, so minutes tell them apart
+		items = append(items, item{Name: u, Count: int(math.Round(m)), tiebreak: int(math.Round(m * 60))})
+	}
+
+	return chart{
+		ID:     "reviewLatency",
+		Title:  o.msg("chart.reviewLatency.title"),
+		Metric: o.msg("chart.reviewLatency.metric"),
+		Items:  o.lowestItems(items),
 	}
 }
@@ -0,0 +1,65 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// reviewed returns reviews by reviewer of alice's PRs, first submitted after each of hours
+func reviewed(reviewer string, hours ...float64) []*repo.ReviewSummary {
+	rs := []*repo.ReviewSummary{}
+	for _, h := range hours {
+		h := h
+		rs = append(rs, &repo.ReviewSummary{Reviewer: reviewer, PRAuthor: "alice", ReviewHours: &h})
+	}
+	return rs
+}
+
+func TestReviewLatencyChart(t *testing.T) {
+	rs := []*repo.ReviewSummary{}
+	rs = append(rs, reviewed("bob", 1, 2, 3, 50, 60)...)
+	// Ties on the rounded median are broken by minutes
+	rs = append(rs, reviewed("carol", 2.6, 2.6, 2.6, 3.4, 3.4)...)
+	rs = append(rs, reviewed("dave", 0.5, 0.5, 0.5, 0.5)...)
+	rs = append(rs, reviewed("renovate[bot]", 0, 0, 0, 0, 0)...)
+	// Comments without a submitted review, and reviews of their own PRs, don't count
+	rs = append(rs, &repo.ReviewSummary{Reviewer: "erin", PRAuthor: "alice"})
+	rs = append(rs, reviewed("alice", 0, 0, 0, 0, 0)...)
+
+	o := DefaultOptions()
+	c := o.reviewLatencyChart(rs, nil)
+	got := map[string]int{}
+	names := []string{}
+	for _, i := range c.Items {
+		got[i.Name] = i.Count
+		names = append(names, i.Name)
+	}
+	if want := []string{"carol", "bob"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("reviewLatencyChart() order = %v, want %v", names, want)
+	}
+	if want := map[string]int{"bob": 3, "carol": 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("reviewLatencyChart() hours = %v, want %v", got, want)
+	}
+
+	// dave has too few reviews until the threshold is lowered
+	o.MinReviews = 4
+	if c := o.reviewLatencyChart(rs, nil); len(c.Items) != 3 || c.Items[0].Name != "dave" {
+		t.Errorf("reviewLatencyChart() with 4 minimum reviews = %+v, want dave first", c.Items)
+	}
+}
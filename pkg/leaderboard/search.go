@@ -0,0 +1,159 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// searchKind is the kind of activity a search link shows
+type searchKind int
+
+const (
+	searchNone searchKind = iota
+	searchMerged
+	searchReviewed
+	searchClosedIssues
+	searchCommented
+	searchInvolved
+	// searchRepoMerged links an item naming a repository, rather than a user, to the PRs merged into it
+	searchRepoMerged
+)
+
+// chartSearch maps chart IDs to the activity their items link to
+var chartSearch = map[string]searchKind{
+	"prCounts":       searchMerged,
+	"prDeltas":       searchMerged,
+	"prSize":         searchMerged,
+	"prSizeBuckets":  searchMerged,
+	"prLatency":      searchMerged,
+	"streaks":        searchMerged,
+	"selfMerges":     searchMerged,
+	"breadth":        searchMerged,
+	"reviewCounts":   searchReviewed,
+	"reviewComments": searchReviewed,
+	"reviewWords":    searchReviewed,
+	"prApprovers":    searchReviewed,
+	"reviewLatency":  searchReviewed,
+	"issueCloser":    searchClosedIssues,
+	"issueCloseTime": searchClosedIssues,
+	"comments":       searchCommented,
+	"commentWords":   searchCommented,
+	"triagers":       searchInvolved,
+	"reach":          searchInvolved,
+	"repoPRs":        searchRepoMerged,
+	"repoDeltas":     searchRepoMerged,
+}
+
+// searchURL returns a GitHub search URL for a user's activity within repos during a period. For searchRepoMerged, user
+// is the repository instead.
+func (o *Options) searchURL(kind searchKind, user string, repos []string, since time.Time, until time.Time) string {
+	period := fmt.Sprintf("%s..%s", since.Format(dateForm), until.Format(dateForm))
+
+	var terms []string
+	switch kind {
+	case searchMerged:
+		terms = []string{"is:pr", "is:merged", "author:" + user, "merged:" + period}
+	case searchReviewed:
+		terms = []string{"is:pr", "is:merged", "reviewed-by:" + user, "merged:" + period}
+	case searchClosedIssues:
+		// GitHub search cannot filter by who closed an issue, so this is the closest approximation
+		terms = []string{"is:issue", "is:closed", "involves:" + user, "closed:" + period}
+	case searchCommented:
+		terms = []string{"is:issue", "commenter:" + user, "updated:" + period}
+	case searchInvolved:
+		terms = []string{"involves:" + user, "updated:" + period}
+	case searchRepoMerged:
+		terms = []string{"is:pr", "is:merged", "merged:" + period}
+		repos = []string{user}
+	default:
+		return ""
+	}
+
+	q := strings.Join(append(terms, scopeTerms(strings.Join(terms, " "), repos)...), " ")
+	return o.WebURL + "/search?q=" + url.QueryEscape(q)
+}
+
+// scopeTerms returns repo: qualifiers, falling back to org: qualifiers if they would make the query too long
+func scopeTerms(base string, repos []string) []string {
+	rs := []string{}
+	orgs := map[string]bool{}
+	for _, r := range repos {
+		rs = append(rs, "repo:"+r)
+		orgs["org:"+strings.Split(r, "/")[0]] = true
+	}
+	sort.Strings(rs)
+
+	if len(base)+len(strings.Join(rs, " "))+1 <= repo.MaxSearchQuery {
+		return rs
+	}
+
+	scoped := []string{}
+	for org := range orgs {
+		scoped = append(scoped, org)
+	}
+	sort.Strings(scoped)
+	return scoped
+}
+
+// dataRepos returns the sorted org/project of every repository the data covers
+func dataRepos(d Data) []string {
+	seen := map[string]bool{}
+	for _, pr := range d.PRs {
+		seen[repoOf(pr.URL)] = true
+	}
+	for _, r := range d.Reviews {
+		seen[repoOf(r.URL)] = true
+	}
+	for _, i := range d.Issues {
+		seen[repoOf(i.URL)] = true
+	}
+	for _, c := range d.Comments {
+		seen[repoOf(c.URL)] = true
+	}
+
+	rs := []string{}
+	for r := range seen {
+		rs = append(rs, r)
+	}
+	sort.Strings(rs)
+	return rs
+}
+
+// linkItems sets a link on each chart item which does not already have one: the page of the user it names, if
+// UserLinks is set, or else a search of their activity
+func (o *Options) linkItems(cats []category, repos []string, since time.Time, until time.Time) {
+	for _, cat := range cats {
+		for _, ch := range cat.Charts {
+			kind := chartSearch[ch.ID]
+			for i := range ch.Items {
+				if ch.Items[i].URL != "" || ch.Items[i].Others {
+					continue
+				}
+				if o.UserLinks != nil && kind != searchNone && kind != searchRepoMerged {
+					ch.Items[i].URL = o.UserLinks(ch.Items[i].Name)
+					continue
+				}
+				ch.Items[i].URL = o.searchURL(kind, ch.Items[i].Name, repos, since, until)
+			}
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	searchSince = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	searchUntil = time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// searchQuery returns the query of a search URL
+func searchQuery(t *testing.T, s string) string {
+	t.Helper()
+	u, err := url.Parse(s)
+	if err != nil {
+		t.Fatalf("parse %q: %v", s, err)
+	}
+	return u.Query().Get("q")
+}
+
+func TestSearchURL(t *testing.T) {
+	repos := []string{"google/pullsheet", "google/triage-party"}
+	tests := []struct {
+		kind searchKind
+		want string
+	}{
+		{searchMerged, "is:pr is:merged author:alice merged:2021-03-01..2021-04-01 repo:google/pullsheet repo:google/triage-party"},
+		{searchReviewed, "is:pr is:merged reviewed-by:alice merged:2021-03-01..2021-04-01 repo:google/pullsheet repo:google/triage-party"},
+		{searchClosedIssues, "is:issue is:closed involves:alice closed:2021-03-01..2021-04-01 repo:google/pullsheet repo:google/triage-party"},
+		{searchCommented, "is:issue commenter:alice updated:2021-03-01..2021-04-01 repo:google/pullsheet repo:google/triage-party"},
+		{searchInvolved, "involves:alice updated:2021-03-01..2021-04-01 repo:google/pullsheet repo:google/triage-party"},
+	}
+
+	o := DefaultOptions()
+	for _, tc := range tests {
+		got := o.searchURL(tc.kind, "alice", repos, searchSince, searchUntil)
+		if !strings.HasPrefix(got, o.WebURL+"/search?q=") {
+			t.Errorf("searchURL(%d) = %q, want a search of %s", tc.kind, got, o.WebURL)
+		}
+		if q := searchQuery(t, got); q != tc.want {
+			t.Errorf("searchURL(%d) query = %q, want %q", tc.kind, q, tc.want)
+		}
+	}
+
+	// A repository item is scoped to itself
+	want := "is:pr is:merged merged:2021-03-01..2021-04-01 repo:google/pullsheet"
+	if q := searchQuery(t, o.searchURL(searchRepoMerged, "google/pullsheet", repos, searchSince, searchUntil)); q != want {
+		t.Errorf("searchURL(searchRepoMerged) query = %q, want %q", q, want)
+	}
+
+	if got := o.searchURL(searchNone, "alice", repos, searchSince, searchUntil); got != "" {
+		t.Errorf("searchURL(searchNone) = %q, want none", got)
+	}
+}
+
+func TestScopeTermsFallsBackToOrgs(t *testing.T) {
+	repos := []string{}
+	for i := 0; i < 20; i++ {
+		repos = append(repos, fmt.Sprintf("kubernetes/project-%d", i))
+	}
+	repos = append(repos, "google/pullsheet")
+
+	got := scopeTerms("is:pr is:merged author:alice", repos)
+	if want := []string{"org:google", "org:kubernetes"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("scopeTerms() = %v, want %v", got, want)
+	}
+}
+
+func TestLinkItems(t *testing.T) {
+	cats := []category{{Charts: []chart{
+		{ID: "prCounts", Items: []item{
+			{Name: "alice"},
+			{Name: "bob", URL: "https://example.com/bob"},
+			{Name: "others", Others: true},
+		}},
+		{ID: "repoPRs", Items: []item{{Name: "google/pullsheet"}}},
+		{ID: "unknown", Items: []item{{Name: "carol"}}},
+	}}}
+	repos := []string{"google/pullsheet"}
+
+	o := DefaultOptions()
+	o.linkItems(cats, repos, searchSince, searchUntil)
+	prs := cats[0].Charts[0].Items
+	if !strings.Contains(searchQuery(t, prs[0].URL), "author:alice") {
+		t.Errorf("alice links to %q, want a search of their PRs", prs[0].URL)
+	}
+	if prs[1].URL != "https://example.com/bob" {
+		t.Errorf("bob's link was replaced with %q", prs[1].URL)
+	}
+	if prs[2].URL != "" {
+		t.Errorf("others links to %q, want no link", prs[2].URL)
+	}
+	if got := cats[0].Charts[2].Items[0].URL; got != "" {
+		t.Errorf("an item of a chart without searches links to %q", got)
+	}
+
+	// User pages take the place of searches, but not for repositories
+	cats[0].Charts[0].Items[0].URL = ""
+	o.UserLinks = func(login string) string { return "/users/" + login }
+	o.linkItems(cats, repos, searchSince, searchUntil)
+	if got := cats[0].Charts[0].Items[0].URL; got != "/users/alice" {
+		t.Errorf("alice links to %q with UserLinks, want /users/alice", got)
+	}
+	cats[0].Charts[1].Items[0].URL = ""
+	o.linkItems(cats, repos, searchSince, searchUntil)
+	if got := cats[0].Charts[1].Items[0].URL; !strings.Contains(searchQuery(t, got), "repo:google/pullsheet") {
+		t.Errorf("google/pullsheet links to %q with UserLinks, want a search of its PRs", got)
+	}
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"math"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// selfMergeMinPRs is how many PRs a user must have merged to appear in the self-merge chart
+const selfMergeMinPRs = 5
+
+// mergeTotals counts the merged PRs for which it is known who merged them, and how many their author merged
+type mergeTotals struct {
+	SelfMerged int `json:"self_merged"`
+	Merged     int `json:"merged"`
+}
+
+// selfMergeTotals returns the totals of PRs recording who merged them, which summaries collected by older versions
+// don't, or nil if there are none
+func selfMergeTotals(prs []*repo.PRSummary) *mergeTotals {
+	t := &mergeTotals{}
+	for _, pr := range prs {
+		if pr.MergedBy == "" {
+			continue
+		}
+		t.Merged++
+		if pr.SelfMerged {
+			t.SelfMerged++
+		}
+	}
+	if t.Merged == 0 {
+		return nil
+	}
+	return t
+}
+
+// selfMergeSummary returns a sentence giving the share of PRs merged by their author, or "" if it isn't known
+func (o *Options) selfMergeSummary(prs []*repo.PRSummary) string {
+	t := selfMergeTotals(prs)
+	if t == nil {
+		return ""
+	}
+	return o.msg("header.selfMerged", percent(t.SelfMerged, t.Merged), t.SelfMerged, t.Merged)
+}
+
+// percent returns n as a whole percentage of total
+func percent(n int, total int) int {
+	return int(math.Round(float64(n) * 100 / float64(total)))
+}
+
+// withoutSelfMerged returns the PRs not merged by their author
+func withoutSelfMerged(prs []*repo.PRSummary) []*repo.PRSummary {
+	kept := []*repo.PRSummary{}
+	for _, pr := range prs {
+		if !pr.SelfMerged {
+			kept = append(kept, pr)
+		}
+	}
+	return kept
+}
+
+// selfMergeChart shows the percentage of each user's PRs they merged themselves, among those who merged at least
+// selfMergeMinPRs PRs with a known merger
+func (o *Options) selfMergeChart(prs []*repo.PRSummary) chart {
+	totals := map[string]*mergeTotals{}
+	for _, pr := range prs {
+		if pr.MergedBy == "" {
+			continue
+		}
+		if totals[pr.User] == nil {
+			totals[pr.User] = &mergeTotals{}
+		}
+		totals[pr.User].Merged++
+		if pr.SelfMerged {
+			totals[pr.User].SelfMerged++
+		}
+	}
+
+	items := []item{}
+	for u, t := range totals {
+		if t.Merged < selfMergeMinPRs || t.SelfMerged == 0 {
+			continue
+		}
+		items = append(items, item{Name: u, Count: percent(t.SelfMerged, t.Merged), tiebreak: t.SelfMerged})
+	}
+
+	return chart{
+		ID:     "selfMerges",
+		Title:  o.msg("chart.selfMerges.title"),
+		Metric: o.msg("chart.selfMerges.metric", selfMergeMinPRs),
+		Items:  o.rankedItems(items),
+	}
+}
@@ -0,0 +1,85 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// streak is a user's longest run of consecutive weeks with a merged PR
+type streak struct {
+	weeks int
+	// active is whether the run reaches the last week of the period
+	active bool
+}
+
+// longestStreak returns the longest run of consecutive weeks among week starts, preferring the latest of equal runs
+func longestStreak(starts []time.Time, last time.Time) streak {
+	sort.Slice(starts, func(i, j int) bool { return starts[i].Before(starts[j]) })
+
+	best := streak{}
+	run := 0
+	for i, w := range starts {
+		switch {
+		case i > 0 && w.Equal(starts[i-1]):
+			continue
+		case i > 0 && w.Equal(starts[i-1].AddDate(0, 0, 7)):
+			run++
+		default:
+			run = 1
+		}
+		if run >= best.weeks {
+			best = streak{weeks: run, active: w.Equal(last)}
+		}
+	}
+	return best
+}
+
+// streakChart shows each user's longest run of consecutive ISO weeks with a merged PR, with ties broken by their
+// number of PRs. Streaks reaching the last week of the period, which may yet grow, are told apart from those that ended.
+func (o *Options) streakChart(until time.Time, prs []*repo.PRSummary) chart {
+	weeks := map[string][]time.Time{}
+	total := map[string]int{}
+	for _, pr := range prs {
+		t, err := time.Parse(dateForm, pr.Date)
+		if err != nil {
+			continue
+		}
+		weeks[pr.User] = append(weeks[pr.User], weekStart(t))
+		total[pr.User]++
+	}
+
+	last := weekStart(until)
+	items := []item{}
+	for u, ws := range weeks {
+		s := longestStreak(ws, last)
+		vs := []int{0, s.weeks}
+		if s.active {
+			vs = []int{s.weeks, 0}
+		}
+		items = append(items, item{Name: u, Count: s.weeks, tiebreak: total[u], Values: vs})
+	}
+
+	return chart{
+		ID:     "streaks",
+		Title:  o.msg("chart.streaks.title"),
+		Metric: o.msg("chart.streaks.metric"),
+		Series: []string{o.msg("streak.active"), o.msg("streak.ended")},
+		Items:  o.rankedItems(items),
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// day returns midnight UTC of a date formatted as dateForm
+func day(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse(dateForm, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestWeekStart(t *testing.T) {
+	tokyo := time.FixedZone("JST", 9*60*60)
+	tests := []struct {
+		in   time.Time
+		want string
+	}{
+		{in: time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC), want: "2021-03-01"},
+		{in: time.Date(2021, 3, 7, 23, 59, 0, 0, time.UTC), want: "2021-03-01"},
+		{in: time.Date(2021, 3, 8, 0, 0, 0, 0, time.UTC), want: "2021-03-08"},
+		// ISO weeks span the new year
+		{in: time.Date(2021, 1, 2, 12, 0, 0, 0, time.UTC), want: "2020-12-28"},
+		// Monday morning in Tokyo is still Sunday in UTC
+		{in: time.Date(2021, 3, 8, 8, 0, 0, 0, tokyo), want: "2021-03-01"},
+	}
+
+	for _, tc := range tests {
+		if got := weekStart(tc.in).Format(dateForm); got != tc.want {
+			t.Errorf("weekStart(%s) = %s, want %s", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestLongestStreak(t *testing.T) {
+	last := "2021-03-29"
+	tests := []struct {
+		name  string
+		weeks []string
+		want  streak
+	}{
+		{name: "none", want: streak{}},
+		{name: "one", weeks: []string{"2021-03-01"}, want: streak{weeks: 1}},
+		{name: "consecutive", weeks: []string{"2021-03-01", "2021-03-08", "2021-03-15"}, want: streak{weeks: 3}},
+		{name: "gap", weeks: []string{"2021-03-01", "2021-03-08", "2021-03-22"}, want: streak{weeks: 2}},
+		{name: "several PRs a week", weeks: []string{"2021-03-01", "2021-03-01", "2021-03-08", "2021-03-08", "2021-03-15"}, want: streak{weeks: 3}},
+		{name: "unsorted", weeks: []string{"2021-03-15", "2021-03-01", "2021-03-08"}, want: streak{weeks: 3}},
+		{name: "new year", weeks: []string{"2020-12-21", "2020-12-28", "2021-01-04"}, want: streak{weeks: 3}},
+		{name: "active", weeks: []string{"2021-03-15", "2021-03-22", "2021-03-29"}, want: streak{weeks: 3, active: true}},
+		{name: "longer ended", weeks: []string{"2021-03-01", "2021-03-08", "2021-03-15", "2021-03-29"}, want: streak{weeks: 3}},
+		// Of equal runs, the latest is the one shown
+		{name: "tie", weeks: []string{"2021-03-01", "2021-03-08", "2021-03-22", "2021-03-29"}, want: streak{weeks: 2, active: true}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			starts := []time.Time{}
+			for _, w := range tc.weeks {
+				starts = append(starts, day(t, w))
+			}
+			if got := longestStreak(starts, day(t, last)); got != tc.want {
+				t.Errorf("longestStreak() = %+v, want %+v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestStreakChart(t *testing.T) {
+	prs := []*repo.PRSummary{}
+	for user, dates := range map[string][]string{
+		// Wednesday to Sunday of consecutive weeks, and still going
+		"alice": {"2021-03-17", "2021-03-21", "2021-03-24", "2021-04-01"},
+		// A longer streak, which ended
+		"bob":   {"2021-03-01", "2021-03-09", "2021-03-16", "2021-03-23", "2021-03-24"},
+		"carol": {"2021-03-03"},
+	} {
+		for _, d := range dates {
+			prs = append(prs, &repo.PRSummary{User: user, Date: d})
+		}
+	}
+
+	o := DefaultOptions()
+	c := o.streakChart(time.Date(2021, 4, 1, 12, 0, 0, 0, time.UTC), prs)
+
+	got := map[string][]int{}
+	names := []string{}
+	for _, i := range c.Items {
+		got[i.Name] = i.Values
+		names = append(names, i.Name)
+	}
+	if want := []string{"bob", "alice", "carol"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("streak chart order = %v, want %v", names, want)
+	}
+	// Values are the active and the ended weeks
+	want := map[string][]int{"alice": {3, 0}, "bob": {0, 4}, "carol": {0, 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streak chart values = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,262 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiDim   = "\x1b[2m"
+	ansiBlue  = "\x1b[34m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// TextOptions controls how a leaderboard is rendered as text
+type TextOptions struct {
+	// Width is the maximum line width, or 0 for unlimited
+	Width int
+	// Color enables ANSI colors, and should only be set when writing to a terminal
+	Color bool
+}
+
+// RenderText writes a leaderboard as aligned text tables, one per chart
+func (o *Options) RenderText(w io.Writer, title string, since time.Time, until time.Time, users []string, d Data, opts TextOptions) error {
+	var sb strings.Builder
+
+	sb.WriteString(fitLine(colorize(title, ansiBold+ansiBlue, opts.Color), title, opts.Width))
+	sb.WriteString("\n")
+	period := fmt.Sprintf("%s - %s", o.formatDate(since), o.formatDate(until))
+	sb.WriteString(fitLine(colorize(period, ansiDim, opts.Color), period, opts.Width))
+	sb.WriteString("\n")
+	if s := o.selfMergeSummary(o.withoutExcluded(d).PRs); s != "" {
+		sb.WriteString(fitLine(colorize(s, ansiDim, opts.Color), s, opts.Width))
+		sb.WriteString("\n")
+	}
+
+	for _, cat := range o.categories(since, until, users, d) {
+		sb.WriteString("\n")
+		heading := "== " + cat.Title + " =="
+		sb.WriteString(fitLine(colorize(heading, ansiBold, opts.Color), heading, opts.Width))
+		sb.WriteString("\n")
+
+		for _, ch := range cat.Charts {
+			sb.WriteString("\n")
+			if ch.Line {
+				sb.WriteString(textTable(o.lineTable(ch), opts))
+				continue
+			}
+			sb.WriteString(o.textChart(ch, opts))
+		}
+
+		for _, t := range cat.Tables {
+			sb.WriteString("\n")
+			sb.WriteString(textTable(t, opts))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// textChart renders a single chart as a table of rank, name, and count
+func (o *Options) textChart(ch chart, opts TextOptions) string {
+	var sb strings.Builder
+
+	heading := ch.Title + ": " + ch.Metric
+	sb.WriteString(fitLine(colorize(heading, ansiCyan, opts.Color), heading, opts.Width))
+	sb.WriteString("\n")
+
+	if len(ch.Items) == 0 {
+		sb.WriteString("  " + o.msg("text.noData") + "\n")
+		return sb.String()
+	}
+
+	rankWidth := len(strconv.Itoa(len(ch.Items)))
+	nameWidth := 0
+	countWidth := 0
+	seriesWidth := 0
+	for _, i := range ch.Items {
+		if w := displayWidth(i.Name); w > nameWidth {
+			nameWidth = w
+		}
+		if w := len(o.formatNumber(i.Count)); w > countWidth {
+			countWidth = w
+		}
+		if w := displayWidth(o.seriesText(ch.Series, i.Values)); w > seriesWidth {
+			seriesWidth = w
+		}
+	}
+
+	// "  " + rank + "  " + name + "  " + count + series
+	fixed := 2 + rankWidth + 2 + 2 + countWidth + seriesWidth
+	if opts.Width > 0 && fixed+nameWidth > opts.Width {
+		nameWidth = opts.Width - fixed
+		if nameWidth < 1 {
+			nameWidth = 1
+		}
+	}
+
+	for idx, i := range ch.Items {
+		name := padRight(truncate(i.Name, nameWidth), nameWidth)
+		if opts.Color {
+			name = colorize(name, ansiBold, true)
+		}
+		// Everyone else isn't ranked
+		rank := strconv.Itoa(idx + 1)
+		if i.Others {
+			rank = ""
+		}
+		fmt.Fprintf(&sb, "  %*s  %s  %*s%s\n", rankWidth, rank, name, countWidth, o.formatNumber(i.Count), o.seriesText(ch.Series, i.Values))
+	}
+
+	return sb.String()
+}
+
+// seriesText returns the non-zero counts of a stacked item by series, such as "  XS 3  M 1", or nothing if it has none
+func (o *Options) seriesText(series []string, values []int) string {
+	var sb strings.Builder
+	for i, v := range values {
+		if v > 0 && i < len(series) {
+			fmt.Fprintf(&sb, "  %s %s", series[i], o.formatNumber(v))
+		}
+	}
+	return sb.String()
+}
+
+// textTable renders a table with aligned columns, truncating the last column to fit
+func textTable(t table, opts TextOptions) string {
+	var sb strings.Builder
+
+	heading := t.Title + ": " + t.Description
+	sb.WriteString(fitLine(colorize(heading, ansiCyan, opts.Color), heading, opts.Width))
+	sb.WriteString("\n")
+
+	widths := make([]int, len(t.Columns))
+	for i, c := range t.Columns {
+		widths[i] = displayWidth(c)
+	}
+	for _, r := range t.Rows {
+		for i, c := range r {
+			if w := displayWidth(c); i < len(widths) && w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+
+	line := func(cells []string, style string) {
+		parts := []string{}
+		for i, c := range cells {
+			if i < len(cells)-1 {
+				c = padRight(c, widths[i])
+			}
+			parts = append(parts, c)
+		}
+		l := "  " + strings.Join(parts, "  ")
+		if opts.Width > 0 {
+			l = truncate(l, opts.Width)
+		}
+		sb.WriteString(colorize(l, style, opts.Color && style != ""))
+		sb.WriteString("\n")
+	}
+
+	line(t.Columns, ansiBold)
+	for _, r := range t.Rows {
+		line(r, "")
+	}
+
+	return sb.String()
+}
+
+// colorize wraps s in an ANSI escape sequence if enabled
+func colorize(s string, code string, enabled bool) string {
+	if !enabled || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+// fitLine returns styled, or a truncated plain if it would not fit within width
+func fitLine(styled string, plain string, width int) string {
+	if width <= 0 || displayWidth(plain) <= width {
+		return styled
+	}
+	return truncate(plain, width)
+}
+
+// displayWidth returns the number of terminal columns a string occupies
+func displayWidth(s string) int {
+	w := 0
+	for _, r := range s {
+		w += runeWidth(r)
+	}
+	return w
+}
+
+// runeWidth returns the number of terminal columns a rune occupies
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r):
+		return 0
+	case unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hangul, r) || unicode.Is(unicode.Hiragana, r) || unicode.Is(unicode.Katakana, r):
+		return 2
+	case r >= 0xFF01 && r <= 0xFF60, r >= 0xFFE0 && r <= 0xFFE6:
+		// Fullwidth forms
+		return 2
+	case r >= 0x1F300 && r <= 0x1FAFF:
+		// Emoji
+		return 2
+	default:
+		return 1
+	}
+}
+
+// truncate shortens s to fit within width columns, marking it with an ellipsis
+func truncate(s string, width int) string {
+	if displayWidth(s) <= width {
+		return s
+	}
+	if width <= 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	used := 0
+	for _, r := range s {
+		rw := runeWidth(r)
+		if used+rw > width-1 {
+			break
+		}
+		sb.WriteRune(r)
+		used += rw
+	}
+	sb.WriteString("…")
+	return sb.String()
+}
+
+// padRight pads s with spaces to occupy width columns
+func padRight(s string, width int) string {
+	if w := displayWidth(s); w < width {
+		return s + strings.Repeat(" ", width-w)
+	}
+	return s
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+func TestDisplayWidth(t *testing.T) {
+	for s, want := range map[string]int{
+		"":            0,
+		"alice":       5,
+		"日本語":         6,
+		"ｆｕｌｌ":        8,
+		"e\u0301":     1,
+		"ship 🚀":      7,
+		"\u200balice": 5,
+	} {
+		if got := displayWidth(s); got != want {
+			t.Errorf("displayWidth(%q) = %d, want %d", s, got, want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s     string
+		width int
+		want  string
+	}{
+		{s: "alice", width: 5, want: "alice"},
+		{s: "alice", width: 4, want: "ali…"},
+		{s: "alice", width: 1, want: "…"},
+		{s: "alice", width: 0, want: ""},
+		// A wide rune which would straddle the limit is dropped whole
+		{s: "日本語", width: 4, want: "日…"},
+		{s: "日本語", width: 5, want: "日本…"},
+	}
+
+	for _, tc := range tests {
+		got := truncate(tc.s, tc.width)
+		if got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.s, tc.width, got, tc.want)
+		}
+		if displayWidth(got) > tc.width {
+			t.Errorf("truncate(%q, %d) is %d columns wide", tc.s, tc.width, displayWidth(got))
+		}
+	}
+}
+
+func TestPadRight(t *testing.T) {
+	if got := padRight("日本", 6); got != "日本  " {
+		t.Errorf("padRight() = %q, want two spaces after 4 columns", got)
+	}
+	if got := padRight("alice", 3); got != "alice" {
+		t.Errorf("padRight() = %q, want it unchanged when wider", got)
+	}
+}
+
+func TestRenderText(t *testing.T) {
+	d := Data{PRs: []*repo.PRSummary{
+		{URL: "https://github.com/org/project/pull/1", Date: "2021-03-02", User: "alice", Project: "project", Delta: 10, OpenedAt: "2021-03-01"},
+		{URL: "https://github.com/org/project/pull/2", Date: "2021-03-03", User: "a-contributor-with-a-very-long-login-indeed", Project: "project", Delta: 5, OpenedAt: "2021-03-01"},
+	}}
+	since := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	o := DefaultOptions()
+	var plain bytes.Buffer
+	if err := o.RenderText(&plain, "Leaderboard", since, until, nil, d, TextOptions{Width: 40}); err != nil {
+		t.Fatalf("RenderText() returned error: %v", err)
+	}
+	out := plain.String()
+	if strings.Contains(out, "\x1b[") {
+		t.Errorf("RenderText() without color wrote escape sequences")
+	}
+	if !strings.Contains(out, "alice") || !strings.Contains(out, "…") {
+		t.Errorf("RenderText() = %q, want alice and a truncated login", out)
+	}
+	for _, l := range strings.Split(out, "\n") {
+		if w := displayWidth(l); w > 40 {
+			t.Errorf("line %q is %d columns wide, want at most 40", l, w)
+		}
+	}
+
+	var color bytes.Buffer
+	if err := o.RenderText(&color, "Leaderboard", since, until, nil, d, TextOptions{Color: true}); err != nil {
+		t.Fatalf("RenderText() returned error: %v", err)
+	}
+	if !strings.Contains(color.String(), ansiBold+ansiBlue+"Leaderboard"+ansiReset) {
+		t.Errorf("RenderText() with color has no colored title")
+	}
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// ticketTable returns a table of contributions per tracker key, or false if no PR referenced one
+func (o *Options) ticketTable(prs []*repo.PRSummary) (table, bool) {
+	ts, untracked := repo.TicketSummaries(prs)
+	if len(ts) == 0 || (len(ts) == 1 && ts[0].Key == repo.Untracked) {
+		return table{}, false
+	}
+
+	rows := [][]string{}
+	for _, t := range ts {
+		rows = append(rows, []string{
+			t.Key,
+			o.formatNumber(t.PRs),
+			o.formatNumber(t.Delta),
+			strings.ReplaceAll(t.Contributors, ",", ", "),
+		})
+	}
+
+	return table{
+		ID:          "tickets",
+		Title:       o.msg("table.tickets.title"),
+		Description: o.msg("table.tickets.description", untracked*100),
+		Columns:     []string{o.msg("column.ticket"), o.msg("column.prs"), o.msg("column.delta"), o.msg("column.contributors")},
+		Rows:        rows,
+	}, true
+}
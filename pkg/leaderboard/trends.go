@@ -0,0 +1,195 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"sort"
+	"time"
+)
+
+// trendTopUsers is how many users trend charts draw lines for when TrendUsers is set
+const trendTopUsers = 5
+
+// monthlyAfter is how long a period may be before trends are bucketed by month rather than by week
+const monthlyAfter = 26 * 7 * 24 * time.Hour
+
+// trendPeriods returns the start of each week, beginning on Monday as ISO weeks do, or of each month, that overlaps
+// since to until, and whether they are months
+func trendPeriods(since time.Time, until time.Time) ([]time.Time, bool) {
+	since = since.UTC()
+	until = until.UTC()
+	monthly := until.Sub(since) > monthlyAfter
+
+	start := weekStart(since)
+	if monthly {
+		start = time.Date(since.Year(), since.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	starts := []time.Time{}
+	for t := start; !t.After(until); {
+		starts = append(starts, t)
+		if monthly {
+			t = t.AddDate(0, 1, 0)
+		} else {
+			t = t.AddDate(0, 0, 7)
+		}
+	}
+	return starts, monthly
+}
+
+// weekStart returns the start of the ISO week containing t's UTC day: the Monday on or before it. Weeks spanning a
+// new year start in the old one, as ISO weeks do.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+// trendSeries sums values per period, overall and per user
+type trendSeries struct {
+	starts []time.Time
+	total  []int
+	users  map[string][]int
+	sums   map[string]int
+}
+
+func newTrendSeries(starts []time.Time) *trendSeries {
+	return &trendSeries{starts: starts, total: make([]int, len(starts)), users: map[string][]int{}, sums: map[string]int{}}
+}
+
+// add adds n to the period containing date, a YYYY-MM-DD date, ignoring dates outside every period
+func (s *trendSeries) add(date string, user string, n int) {
+	t, err := time.Parse(dateForm, date)
+	if err != nil {
+		return
+	}
+	i := sort.Search(len(s.starts), func(i int) bool { return s.starts[i].After(t) }) - 1
+	if i < 0 {
+		return
+	}
+
+	s.total[i] += n
+	if user == "" {
+		return
+	}
+	if s.users[user] == nil {
+		s.users[user] = make([]int, len(s.starts))
+	}
+	s.users[user][i] += n
+	s.sums[user] += n
+}
+
+// chart returns a line chart of the series, with a point for every period, including those without activity
+func (s *trendSeries) chart(o *Options, id string, monthly bool) chart {
+	period := o.msg("trend.week")
+	if monthly {
+		period = o.msg("trend.month")
+	}
+
+	series := []string{o.msg("trend.total")}
+	lines := [][]int{s.total}
+	if o.TrendUsers {
+		top := []item{}
+		for u, n := range s.sums {
+			top = append(top, item{Name: u, Count: n})
+		}
+		sort.Slice(top, func(i, j int) bool {
+			if top[i].Count != top[j].Count {
+				return top[i].Count > top[j].Count
+			}
+			return top[i].Name < top[j].Name
+		})
+		if len(top) > trendTopUsers {
+			top = top[:trendTopUsers]
+		}
+		for _, u := range top {
+			series = append(series, u.Name)
+			lines = append(lines, s.users[u.Name])
+		}
+	}
+
+	items := []item{}
+	for i, start := range s.starts {
+		vs := []int{}
+		for _, l := range lines {
+			vs = append(vs, l[i])
+		}
+		items = append(items, item{Name: o.formatDate(start), Count: s.total[i], Values: vs})
+	}
+
+	return chart{
+		ID:     id,
+		Title:  o.msg("chart." + id + ".title"),
+		Object: o.msg("column.period"),
+		Metric: o.msg("chart."+id+".metric", period),
+		Series: series,
+		Line:   true,
+		Items:  items,
+	}
+}
+
+// trendChart returns a line chart of merged PRs (trendPRs), delta (trendDeltas), or closed issues (trendIssues) over
+// time, or false if the period is too short to show a trend or there is nothing to chart
+func (o *Options) trendChart(id string, since time.Time, until time.Time, d Data) (chart, bool) {
+	starts, monthly := trendPeriods(since, until)
+	if len(starts) < 2 {
+		return chart{}, false
+	}
+
+	s := newTrendSeries(starts)
+	switch id {
+	case "trendPRs":
+		for _, pr := range d.PRs {
+			s.add(pr.Date, pr.User, 1)
+		}
+	case "trendDeltas":
+		for _, pr := range d.PRs {
+			s.add(pr.Date, pr.User, pr.Delta)
+		}
+	case "trendIssues":
+		if len(d.Issues) == 0 {
+			return chart{}, false
+		}
+		for _, i := range d.Issues {
+			// Rows for issues being opened have no closer
+			if i.Closer == "" {
+				continue
+			}
+			s.add(i.Date, i.Closer, 1)
+		}
+	}
+
+	return s.chart(o, id, monthly), true
+}
+
+// lineTable returns a line chart as a table with a row per point, for renderers which can't draw lines
+func (o *Options) lineTable(ch chart) table {
+	rows := [][]string{}
+	for _, i := range ch.Items {
+		row := []string{i.Name}
+		for _, v := range i.Values {
+			row = append(row, o.formatNumber(v))
+		}
+		rows = append(rows, row)
+	}
+
+	return table{
+		ID:          ch.ID,
+		Title:       ch.Title,
+		Description: ch.Metric,
+		Columns:     append([]string{ch.Object}, ch.Series...),
+		Rows:        rows,
+	}
+}
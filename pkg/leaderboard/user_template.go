@@ -0,0 +1,79 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+// userTmpl is the page of a single user's activity, linked from their leaderboard entries
+const userTmpl = `<html>
+<head>
+    <title>{{ .PageTitle }}</title>
+    <style>
+    body {
+       font-family: 'Open Sans', sans-serif;
+       background-color: #f7f7fa;
+       padding: 1em;
+    }
+
+    h1 {
+      color: rgba(66,133,244);
+      margin-bottom: 0em;
+    }
+
+    .subtitle {
+      color: rgba(23,90,201);
+      font-size: small;
+    }
+
+    h2 {
+        color: #333;
+    }
+
+    p {
+        font-size: small;
+        color: #999;
+    }
+
+    table.data {
+        border-collapse: collapse;
+        font-size: small;
+        color: #333;
+        background-color: #fff;
+    }
+
+    table.data th, table.data td {
+        padding: 0.25em 0.75em;
+        border-bottom: 1px solid #eee;
+        text-align: left;
+    }
+    </style>
+</head>
+<body>
+    <h1>{{ .Login }}</h1>
+    <div class="subtitle">{{ .Title }}: {{.From}} &mdash; {{.Until}}</div>
+
+    {{ range .Activities }}
+        <h2>{{ .Title }} ({{ len .Rows }})</h2>
+        {{ if .Rows }}
+        <table class="data" id="activity_{{ .ID }}">
+            <tr>{{ range .Columns }}<th>{{ . }}</th>{{ end }}</tr>
+            {{ range .Rows }}<tr>{{ range .Cells }}<td>{{ . }}</td>{{ end }}<td><a href="{{ .URL }}">{{ .Title }}</a></td></tr>
+            {{ end }}
+        </table>
+        {{ else }}
+        <p>{{ $.NoActivity }}</p>
+        {{ end }}
+    {{ end }}
+</body>
+</html>
+`
@@ -0,0 +1,171 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// userTmplName is the name of the template a user's activity page is executed from
+const userTmplName = "user.html"
+
+// activity is a titled list of a user's contributions of one kind, newest first
+type activity struct {
+	ID      string
+	Title   string
+	Columns []string
+	Rows    []activityRow
+}
+
+// activityRow is a contribution: the Cells under each column but the last, then its Title linked to its URL
+type activityRow struct {
+	Date  string
+	Cells []string
+	Title string
+	URL   string
+}
+
+// UserPage returns the file name of a user's activity page. Logins are lowercased, as GitHub ignores their case,
+// and characters some filesystems don't allow, such as those of "[bot]", are replaced with "_".
+func UserPage(login string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, strings.ToLower(login))
+	return "user-" + name + ".html"
+}
+
+// UserLogins returns the sorted logins the leaderboard's charts may name, each of which has an activity page
+func (o *Options) UserLogins(d Data) []string {
+	d = o.sameLogins(o.withoutExcluded(d))
+	seen := map[string]bool{}
+	for _, pr := range d.PRs {
+		seen[pr.User] = true
+	}
+	for _, r := range d.Reviews {
+		seen[r.Reviewer] = true
+	}
+	for _, i := range d.Issues {
+		seen[i.Closer] = true
+	}
+	for _, c := range d.Comments {
+		seen[c.Commenter] = true
+	}
+	for _, t := range d.Triage {
+		seen[t.Actor] = true
+	}
+
+	logins := []string{}
+	for l := range seen {
+		if l != "" {
+			logins = append(logins, l)
+		}
+	}
+	sort.Strings(logins)
+	return logins
+}
+
+// RenderUser returns an HTML page listing a user's merged PRs, reviews, closed issues, and issue comments
+func (o *Options) RenderUser(title string, since time.Time, until time.Time, login string, d Data) (string, error) {
+	t, err := o.templates()
+	if err != nil {
+		return "", err
+	}
+
+	data := struct {
+		Title      string
+		PageTitle  string
+		Login      string
+		From       string
+		Until      string
+		NoActivity string
+		Activities []activity
+	}{
+		Title:      title,
+		PageTitle:  o.msg("page.user", login, o.msg("page.title", title)),
+		Login:      login,
+		From:       o.formatDate(since),
+		Until:      o.formatDate(until),
+		NoActivity: o.msg("page.noActivity"),
+		Activities: o.userActivities(login, o.sameLogins(o.withoutExcluded(d))),
+	}
+
+	var tpl bytes.Buffer
+	if err := t.ExecuteTemplate(&tpl, userTmplName, data); err != nil {
+		return "", fmt.Errorf("execute: %w", err)
+	}
+	return tpl.String(), nil
+}
+
+// userActivities returns a user's contributions, by kind
+func (o *Options) userActivities(login string, d Data) []activity {
+	prs := activity{
+		ID:      "prs",
+		Title:   o.msg("user.prs"),
+		Columns: []string{o.msg("column.date"), o.msg("column.repository"), o.msg("column.delta"), o.msg("column.title")},
+	}
+	for _, pr := range d.PRs {
+		if strings.EqualFold(pr.User, login) {
+			prs.Rows = append(prs.Rows, activityRow{Date: pr.Date, Cells: []string{pr.Date, pr.Project, o.formatNumber(pr.Delta)}, Title: pr.Title, URL: pr.URL})
+		}
+	}
+
+	reviews := activity{
+		ID:      "reviews",
+		Title:   o.msg("user.reviews"),
+		Columns: []string{o.msg("column.date"), o.msg("column.repository"), o.msg("column.comments"), o.msg("column.words"), o.msg("column.title")},
+	}
+	for _, r := range d.Reviews {
+		if strings.EqualFold(r.Reviewer, login) {
+			cells := []string{r.Date, r.Project, o.formatNumber(r.PRComments + r.ReviewComments), o.formatNumber(r.Words)}
+			reviews.Rows = append(reviews.Rows, activityRow{Date: r.Date, Cells: cells, Title: r.Title, URL: r.URL})
+		}
+	}
+
+	issues := activity{
+		ID:      "issues",
+		Title:   o.msg("user.issues"),
+		Columns: []string{o.msg("column.date"), o.msg("column.repository"), o.msg("column.title")},
+	}
+	for _, i := range d.Issues {
+		if strings.EqualFold(i.Closer, login) {
+			issues.Rows = append(issues.Rows, activityRow{Date: i.Date, Cells: []string{i.Date, i.Project}, Title: i.Title, URL: i.URL})
+		}
+	}
+
+	comments := activity{
+		ID:      "comments",
+		Title:   o.msg("user.comments"),
+		Columns: []string{o.msg("column.date"), o.msg("column.repository"), o.msg("column.comments"), o.msg("column.words"), o.msg("column.title")},
+	}
+	for _, c := range d.Comments {
+		if strings.EqualFold(c.Commenter, login) {
+			cells := []string{c.Date, c.Project, o.formatNumber(c.Comments), o.formatNumber(c.Words)}
+			comments.Rows = append(comments.Rows, activityRow{Date: c.Date, Cells: cells, Title: c.Title, URL: c.URL})
+		}
+	}
+
+	as := []activity{prs, reviews, issues, comments}
+	for _, a := range as {
+		sort.SliceStable(a.Rows, func(i, j int) bool { return a.Rows[i].Date > a.Rows[j].Date })
+	}
+	return as
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRenderUserEscapes(t *testing.T) {
+	o := DefaultOptions()
+	since := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	for _, login := range []string{"alice", `bob"];alert(1);//`} {
+		out, err := o.RenderUser(script, since, until, login, hostileData())
+		if err != nil {
+			t.Fatalf("RenderUser(%q) returned error: %v", login, err)
+		}
+		if strings.Contains(out, script) || strings.Contains(out, `<script>alert`) {
+			t.Errorf("RenderUser(%q) output contains an unescaped title", login)
+		}
+		if strings.Contains(out, `bob"]`) {
+			t.Errorf("RenderUser(%q) output contains an unescaped login", login)
+		}
+	}
+}
+
+func TestUserPage(t *testing.T) {
+	tests := map[string]string{
+		"alice":               "user-alice.html",
+		"Alice":               "user-alice.html",
+		"dependabot[bot]":     "user-dependabot_bot_.html",
+		"../../etc/passwd":    "user-.._.._etc_passwd.html",
+		"renovate-bot.v2_old": "user-renovate-bot.v2_old.html",
+	}
+	for login, want := range tests {
+		if got := UserPage(login); got != want {
+			t.Errorf("UserPage(%q) = %q, want %q", login, got, want)
+		}
+	}
+}
+
+func TestUserLogins(t *testing.T) {
+	o := DefaultOptions()
+	o.Repo.ExcludeUsers["bob\"];alert(1);//"] = true
+	got := strings.Join(o.UserLogins(hostileData()), ",")
+	if got != "alice" {
+		t.Errorf("UserLogins() = %s, want alice", got)
+	}
+}
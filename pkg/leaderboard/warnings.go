@@ -0,0 +1,37 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"strings"
+
+	"github.com/google/pullsheet/pkg/digest"
+)
+
+// warningsTable summarizes the non-fatal warnings encountered while collecting data
+func (o *Options) warningsTable(gs []digest.Group) table {
+	t := table{
+		ID:          "warnings",
+		Title:       o.msg("table.warnings.title"),
+		Description: o.msg("table.warnings.description"),
+		Columns:     []string{o.msg("column.category"), o.msg("column.repository"), o.msg("column.count"), o.msg("column.examples")},
+	}
+
+	for _, g := range gs {
+		t.Rows = append(t.Rows, []string{g.Category, g.Repo, o.formatNumber(g.Count), strings.Join(g.Examples, " ")})
+	}
+
+	return t
+}
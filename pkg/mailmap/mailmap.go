@@ -0,0 +1,199 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailmap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Identity is a name and e-mail pair as found in commit metadata
+type Identity struct {
+	Name  string
+	Email string
+}
+
+// ParseError describes a line that could not be parsed
+type ParseError struct {
+	Line int
+	Text string
+	Err  string
+}
+
+func (e ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s: %q", e.Line, e.Err, e.Text)
+}
+
+type entry struct {
+	proper Identity
+	// commit name is optional: when empty, any name with the commit e-mail matches
+	commitName string
+}
+
+// Mailmap maps commit identities to their canonical form
+type Mailmap struct {
+	// lowercase commit e-mail -> entries
+	entries map[string][]entry
+}
+
+// New returns an empty Mailmap
+func New() *Mailmap {
+	return &Mailmap{entries: map[string][]entry{}}
+}
+
+// Len returns the number of mappings
+func (m *Mailmap) Len() int {
+	n := 0
+	for _, es := range m.entries {
+		n += len(es)
+	}
+	return n
+}
+
+// Parse parses a .mailmap file, returning the mappings that could be read and an error per malformed line.
+//
+// The four supported line shapes are:
+//
+//	Proper Name <commit@email>
+//	<proper@email> <commit@email>
+//	Proper Name <proper@email> <commit@email>
+//	Proper Name <proper@email> Commit Name <commit@email>
+func Parse(r io.Reader) (*Mailmap, []ParseError) {
+	m := New()
+	errs := []ParseError{}
+
+	scanner := bufio.NewScanner(r)
+	n := 0
+	for scanner.Scan() {
+		n++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		e, commitEmail, err := parseLine(line)
+		if err != "" {
+			errs = append(errs, ParseError{Line: n, Text: scanner.Text(), Err: err})
+			continue
+		}
+		m.add(commitEmail, e)
+	}
+
+	if err := scanner.Err(); err != nil {
+		errs = append(errs, ParseError{Line: n + 1, Err: err.Error()})
+	}
+
+	return m, errs
+}
+
+// parseLine returns the entry and commit e-mail for a line, or a description of why it is malformed
+func parseLine(line string) (entry, string, string) {
+	names := []string{}
+	emails := []string{}
+
+	rest := line
+	for rest != "" {
+		open := strings.Index(rest, "<")
+		if open < 0 {
+			return entry{}, "", "text after last e-mail"
+		}
+		end := strings.Index(rest[open:], ">")
+		if end < 0 {
+			return entry{}, "", "unterminated e-mail"
+		}
+		names = append(names, strings.TrimSpace(rest[:open]))
+		emails = append(emails, strings.TrimSpace(rest[open+1:open+end]))
+		rest = strings.TrimSpace(rest[open+end+1:])
+	}
+
+	switch len(emails) {
+	case 1:
+		// Proper Name <commit@email>
+		if names[0] == "" {
+			return entry{}, "", "missing name"
+		}
+		return entry{proper: Identity{Name: names[0]}}, emails[0], ""
+	case 2:
+		// <proper@email> <commit@email>, Proper Name <proper@email> <commit@email>,
+		// or Proper Name <proper@email> Commit Name <commit@email>
+		return entry{proper: Identity{Name: names[0], Email: emails[0]}, commitName: names[1]}, emails[1], ""
+	default:
+		return entry{}, "", fmt.Sprintf("expected 1 or 2 e-mails, found %d", len(emails))
+	}
+}
+
+func (m *Mailmap) add(commitEmail string, e entry) {
+	key := strings.ToLower(commitEmail)
+	m.entries[key] = append(m.entries[key], e)
+}
+
+// CommitEmails returns the lowercased commit e-mails m has mappings for, sorted
+func (m *Mailmap) CommitEmails() []string {
+	emails := []string{}
+	for e := range m.entries {
+		emails = append(emails, e)
+	}
+	sort.Strings(emails)
+	return emails
+}
+
+// Merge adds all mappings from o into m
+func (m *Mailmap) Merge(o *Mailmap) {
+	for k, es := range o.entries {
+		m.entries[k] = append(m.entries[k], es...)
+	}
+}
+
+// Resolve returns the canonical identity for a commit name and e-mail.
+//
+// Entries matching both name and e-mail win over entries matching the e-mail alone.
+// Fields without a mapping are returned unchanged.
+func (m *Mailmap) Resolve(id Identity) Identity {
+	es := m.entries[strings.ToLower(id.Email)]
+
+	var match *entry
+	for i := range es {
+		if es[i].commitName == "" {
+			if match == nil {
+				match = &es[i]
+			}
+			continue
+		}
+		if strings.EqualFold(es[i].commitName, id.Name) {
+			match = &es[i]
+			break
+		}
+	}
+
+	if match == nil {
+		return id
+	}
+
+	out := id
+	if match.proper.Name != "" {
+		out.Name = match.proper.Name
+	}
+	if match.proper.Email != "" {
+		out.Email = match.proper.Email
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailmap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		in      Identity
+		want    Identity
+		errs    int
+	}{
+		{
+			name:    "name only",
+			content: "Alice Smith <alice@old.example>",
+			in:      Identity{Name: "alice", Email: "alice@old.example"},
+			want:    Identity{Name: "Alice Smith", Email: "alice@old.example"},
+		},
+		{
+			name:    "email only",
+			content: "<alice@example.com> <alice@old.example>",
+			in:      Identity{Name: "alice", Email: "ALICE@old.example"},
+			want:    Identity{Name: "alice", Email: "alice@example.com"},
+		},
+		{
+			name:    "name and email",
+			content: "Alice Smith <alice@example.com> <alice@old.example>",
+			in:      Identity{Name: "alice", Email: "alice@old.example"},
+			want:    Identity{Name: "Alice Smith", Email: "alice@example.com"},
+		},
+		{
+			name:    "commit name must match",
+			content: "Alice Smith <alice@example.com> Al <shared@example.com>",
+			in:      Identity{Name: "Bob", Email: "shared@example.com"},
+			want:    Identity{Name: "Bob", Email: "shared@example.com"},
+		},
+		{
+			name:    "commit name beats email alone",
+			content: "<team@example.com> <shared@example.com>\nAlice Smith <alice@example.com> al <shared@example.com>",
+			in:      Identity{Name: "Al", Email: "shared@example.com"},
+			want:    Identity{Name: "Alice Smith", Email: "alice@example.com"},
+		},
+		{
+			name:    "comments and blank lines",
+			content: "# maintained by hand\n\nAlice Smith <alice@example.com> <alice@old.example> # renamed\n   \n",
+			in:      Identity{Name: "alice", Email: "alice@old.example"},
+			want:    Identity{Name: "Alice Smith", Email: "alice@example.com"},
+		},
+		{
+			name:    "commented out mapping",
+			content: "# Alice Smith <alice@example.com> <alice@old.example>",
+			in:      Identity{Name: "alice", Email: "alice@old.example"},
+			want:    Identity{Name: "alice", Email: "alice@old.example"},
+		},
+		{
+			name:    "malformed lines are skipped",
+			content: "Alice Smith\n<alice@example.com\nBob <bob@example.com> trailing\n<alice@example.com> <alice@old.example>",
+			in:      Identity{Name: "alice", Email: "alice@old.example"},
+			want:    Identity{Name: "alice", Email: "alice@example.com"},
+			errs:    3,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			m, errs := Parse(strings.NewReader(tc.content))
+			if len(errs) != tc.errs {
+				t.Errorf("Parse() returned %d errors, want %d: %v", len(errs), tc.errs, errs)
+			}
+			if got := m.Resolve(tc.in); got != tc.want {
+				t.Errorf("Resolve(%+v) = %+v, want %+v", tc.in, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseErrorLine(t *testing.T) {
+	_, errs := Parse(strings.NewReader("# header\nAlice <alice@example.com>\n<broken\n"))
+	if len(errs) != 1 {
+		t.Fatalf("Parse() returned %d errors, want 1: %v", len(errs), errs)
+	}
+	if errs[0].Line != 3 {
+		t.Errorf("error line = %d, want 3", errs[0].Line)
+	}
+}
+
+func TestCommitEmails(t *testing.T) {
+	m, _ := Parse(strings.NewReader("Bob <B@example.com>\nAlice <a@example.com>\nAl <alice@example.com> <a@example.com>"))
+	got := strings.Join(m.CommitEmails(), ",")
+	if want := "a@example.com,b@example.com"; got != want {
+		t.Errorf("CommitEmails() = %s, want %s", got, want)
+	}
+}
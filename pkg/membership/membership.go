@@ -0,0 +1,145 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package membership tracks when users were members of an organization, as
+// GitHub does not expose membership history. The history is a YAML file of
+// membership stints per user:
+//
+//	alice:
+//	  - joined: 2019-05-01
+//	    left: 2021-06-30
+//	  - joined: 2022-01-10
+//	bob:
+//	  - joined: 2020-03-01
+//
+// Both dates are inclusive, and a stint without a left date is ongoing.
+package membership
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+const dateForm = "2006-01-02"
+
+// Stint is a single period of membership
+type Stint struct {
+	Joined time.Time
+	// Left is zero if the user is still a member
+	Left time.Time
+}
+
+// Contains returns whether t falls on or between the join and leave dates
+func (s Stint) Contains(t time.Time) bool {
+	d := day(t)
+	if d.Before(s.Joined) {
+		return false
+	}
+	return s.Left.IsZero() || !d.After(s.Left)
+}
+
+// History is the membership stints of each user
+type History struct {
+	stints map[string][]Stint
+}
+
+// Load reads a membership history file
+func Load(path string) (*History, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return h, nil
+}
+
+// Parse parses and validates a membership history, rejecting overlapping stints for the same user
+func Parse(r io.Reader) (*History, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string][]struct {
+		Joined string `yaml:"joined"`
+		Left   string `yaml:"left"`
+	}{}
+	if err := yaml.UnmarshalStrict(b, &raw); err != nil {
+		return nil, err
+	}
+
+	h := &History{stints: map[string][]Stint{}}
+	for user, rs := range raw {
+		ss := []Stint{}
+		for _, r := range rs {
+			s := Stint{}
+			if s.Joined, err = time.Parse(dateForm, r.Joined); err != nil {
+				return nil, fmt.Errorf("%s: joined: %v", user, err)
+			}
+			if r.Left != "" {
+				if s.Left, err = time.Parse(dateForm, r.Left); err != nil {
+					return nil, fmt.Errorf("%s: left: %v", user, err)
+				}
+				if s.Left.Before(s.Joined) {
+					return nil, fmt.Errorf("%s: left %s before joining %s", user, r.Left, r.Joined)
+				}
+			}
+			ss = append(ss, s)
+		}
+
+		sort.Slice(ss, func(i, j int) bool { return ss[i].Joined.Before(ss[j].Joined) })
+		for i := 1; i < len(ss); i++ {
+			prev := ss[i-1]
+			if prev.Left.IsZero() || !prev.Left.Before(ss[i].Joined) {
+				return nil, fmt.Errorf("%s: stint joined %s overlaps stint joined %s", user, ss[i].Joined.Format(dateForm), prev.Joined.Format(dateForm))
+			}
+		}
+
+		h.stints[strings.ToLower(user)] = ss
+	}
+
+	return h, nil
+}
+
+// MemberAt returns whether a user was a member at a given time, and whether their membership is known at all
+func (h *History) MemberAt(user string, t time.Time) (member bool, known bool) {
+	ss, ok := h.stints[strings.ToLower(user)]
+	if !ok {
+		return false, false
+	}
+
+	for _, s := range ss {
+		if s.Contains(t) {
+			return true, true
+		}
+	}
+	return false, true
+}
+
+// day truncates a time to midnight UTC, matching how stint dates are parsed
+func day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package membership
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const history = `
+Alice:
+  - joined: 2019-05-01
+    left: 2021-06-30
+  - joined: 2022-01-10
+bob:
+  - joined: 2020-03-01
+carol: []
+`
+
+func TestMemberAt(t *testing.T) {
+	h, err := Parse(strings.NewReader(history))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	tests := []struct {
+		user       string
+		at         time.Time
+		wantMember bool
+		wantKnown  bool
+	}{
+		{"alice", time.Date(2019, 4, 30, 23, 59, 0, 0, time.UTC), false, true},
+		// Both dates are inclusive, whatever the time of day
+		{"alice", time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC), true, true},
+		{"ALICE", time.Date(2021, 6, 30, 23, 59, 0, 0, time.UTC), true, true},
+		{"alice", time.Date(2021, 7, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"alice", time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), true, true},
+		{"bob", time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC), false, true},
+		{"bob", time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC), true, true},
+		{"carol", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), false, true},
+		{"dave", time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC), false, false},
+	}
+
+	for _, tc := range tests {
+		member, known := h.MemberAt(tc.user, tc.at)
+		if member != tc.wantMember || known != tc.wantKnown {
+			t.Errorf("MemberAt(%q, %s) = %v, %v, want %v, %v", tc.user, tc.at, member, known, tc.wantMember, tc.wantKnown)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "overlapping",
+			in:   "alice:\n  - joined: 2019-05-01\n    left: 2021-06-30\n  - joined: 2021-06-30\n",
+			want: "overlaps",
+		},
+		{
+			name: "after an ongoing stint",
+			in:   "alice:\n  - joined: 2022-01-10\n  - joined: 2019-05-01\n",
+			want: "overlaps",
+		},
+		{
+			name: "left before joining",
+			in:   "alice:\n  - joined: 2021-06-30\n    left: 2019-05-01\n",
+			want: "before joining",
+		},
+		{
+			name: "bad date",
+			in:   "alice:\n  - joined: May 2019\n",
+			want: "joined",
+		},
+		{
+			name: "unknown field",
+			in:   "alice:\n  - joined: 2019-05-01\n    until: 2021-06-30\n",
+			want: "until",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tc.in))
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("Parse() = %v, want an error containing %q", err, tc.want)
+			}
+		})
+	}
+}
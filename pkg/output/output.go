@@ -0,0 +1,314 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package output writes a set of artifacts to a directory atomically, so that
+// consumers watching the directory never observe a partially written run.
+package output
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// ManifestName is the name of the manifest file within an output directory
+	ManifestName = "manifest.json"
+	// SuccessName is the marker written once every artifact is in place
+	SuccessName = "_SUCCESS"
+	// PartialName marks a directory left behind by a failed run
+	PartialName = "_PARTIAL"
+)
+
+// Artifact describes a single file within an output directory
+type Artifact struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// Manifest describes the contents of an output directory
+type Manifest struct {
+	Created   time.Time  `json:"created"`
+	Completed bool       `json:"completed"`
+	Artifacts []Artifact `json:"artifacts"`
+	// Sources describes which data each derived field was computed from
+	Sources map[string]string `json:"sources,omitempty"`
+}
+
+// Dir is an output directory being written
+type Dir struct {
+	path     string
+	tmp      string
+	manifest Manifest
+}
+
+// Create begins writing an output directory. Artifacts are staged in a sibling directory until Commit is called.
+func Create(path string) (*Dir, error) {
+	path = filepath.Clean(path)
+	parent := filepath.Dir(path)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("mkdir: %v", err)
+	}
+
+	tmp, err := ioutil.TempDir(parent, "."+filepath.Base(path)+".partial-")
+	if err != nil {
+		return nil, fmt.Errorf("temp dir: %v", err)
+	}
+
+	d := &Dir{path: path, tmp: tmp, manifest: Manifest{Created: time.Now()}}
+
+	// Marks the staging directory until it is committed, in case the run dies
+	if err := writeFile(filepath.Join(tmp, PartialName), nil); err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("staging output for %s in %s", path, tmp)
+	return d, nil
+}
+
+// Staging returns the directory artifacts are written to before being committed
+func (d *Dir) Staging() string {
+	return d.tmp
+}
+
+// SetSources records which data each derived field was computed from
+func (d *Dir) SetSources(sources map[string]string) {
+	d.manifest.Sources = sources
+}
+
+// Write writes and syncs a single artifact
+func (d *Dir) Write(name string, content []byte) error {
+	if err := writeFile(filepath.Join(d.tmp, name), content); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(content)
+	d.manifest.Artifacts = append(d.manifest.Artifacts, Artifact{
+		Name:   name,
+		Size:   len(content),
+		SHA256: hex.EncodeToString(sum[:]),
+	})
+	return nil
+}
+
+// Commit writes the manifest and success marker, then moves the directory into place, replacing any previous output
+func (d *Dir) Commit() error {
+	d.manifest.Completed = true
+	sort.Slice(d.manifest.Artifacts, func(i, j int) bool { return d.manifest.Artifacts[i].Name < d.manifest.Artifacts[j].Name })
+
+	m, err := json.MarshalIndent(d.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("manifest: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(d.tmp, ManifestName), m); err != nil {
+		return err
+	}
+
+	if err := os.Remove(filepath.Join(d.tmp, PartialName)); err != nil {
+		return fmt.Errorf("remove partial marker: %v", err)
+	}
+
+	if err := writeFile(filepath.Join(d.tmp, SuccessName), nil); err != nil {
+		return err
+	}
+
+	if err := syncDir(d.tmp); err != nil {
+		return err
+	}
+
+	// Move the previous output aside rather than deleting it, so a failed rename leaves it recoverable
+	old := ""
+	if _, err := os.Stat(d.path); err == nil {
+		old = d.tmp + ".old"
+		if err := os.Rename(d.path, old); err != nil {
+			return fmt.Errorf("move previous output: %v", err)
+		}
+	}
+
+	if err := os.Rename(d.tmp, d.path); err != nil {
+		if old != "" {
+			if rerr := os.Rename(old, d.path); rerr != nil {
+				logrus.Errorf("unable to restore previous output from %s: %v", old, rerr)
+			}
+		}
+		return fmt.Errorf("rename: %v", err)
+	}
+
+	if err := syncDir(filepath.Dir(d.path)); err != nil {
+		return err
+	}
+
+	if old != "" {
+		if err := os.RemoveAll(old); err != nil {
+			logrus.Warningf("unable to remove previous output %s: %v", old, err)
+		}
+	}
+
+	logrus.Infof("wrote %d artifacts to %s", len(d.manifest.Artifacts), d.path)
+	return nil
+}
+
+// File is a single file written atomically. Its content goes to a temporary file beside it, which replaces the file
+// only once committed, so readers never see it half written.
+type File struct {
+	path      string
+	f         *os.File
+	committed bool
+}
+
+// CreateFile begins writing a file. Nothing at path changes until Commit is called.
+func CreateFile(path string) (*File, error) {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".partial-")
+	if err != nil {
+		return nil, fmt.Errorf("temp file: %v", err)
+	}
+
+	// Temporary files are private, but the output shouldn't be
+	if err := f.Chmod(0o644); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, fmt.Errorf("chmod %s: %v", f.Name(), err)
+	}
+	return &File{path: path, f: f}, nil
+}
+
+// Write writes to the temporary file
+func (f *File) Write(p []byte) (int, error) {
+	return f.f.Write(p)
+}
+
+// Commit syncs the file and moves it into place, replacing any previous file
+func (f *File) Commit() error {
+	if err := f.f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %v", f.f.Name(), err)
+	}
+	if err := f.f.Close(); err != nil {
+		return fmt.Errorf("close %s: %v", f.f.Name(), err)
+	}
+	if err := os.Rename(f.f.Name(), f.path); err != nil {
+		os.Remove(f.f.Name())
+		return fmt.Errorf("rename: %v", err)
+	}
+	f.committed = true
+	return syncDir(filepath.Dir(f.path))
+}
+
+// Close discards the file unless it was committed, leaving any previous file in place
+func (f *File) Close() error {
+	if f.committed {
+		return nil
+	}
+	f.f.Close()
+	if err := os.Remove(f.f.Name()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove %s: %v", f.f.Name(), err)
+	}
+	return nil
+}
+
+// WriteFile atomically writes content to path
+func WriteFile(path string, content []byte) error {
+	f, err := CreateFile(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.Write(content); err != nil {
+		return fmt.Errorf("write %s: %v", path, err)
+	}
+	return f.Commit()
+}
+
+// Verify checks that an output directory is complete and that each artifact matches its manifest checksum
+func Verify(path string) ([]string, error) {
+	b, err := ioutil.ReadFile(filepath.Join(path, ManifestName))
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %v", err)
+	}
+
+	m := Manifest{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %v", err)
+	}
+
+	problems := []string{}
+	if !m.Completed {
+		problems = append(problems, "manifest is not marked completed")
+	}
+	if _, err := os.Stat(filepath.Join(path, SuccessName)); err != nil {
+		problems = append(problems, fmt.Sprintf("missing %s marker", SuccessName))
+	}
+	if _, err := os.Stat(filepath.Join(path, PartialName)); err == nil {
+		problems = append(problems, fmt.Sprintf("found %s marker", PartialName))
+	}
+
+	for _, a := range m.Artifacts {
+		content, err := ioutil.ReadFile(filepath.Join(path, a.Name))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", a.Name, err))
+			continue
+		}
+
+		sum := sha256.Sum256(content)
+		if got := hex.EncodeToString(sum[:]); got != a.SHA256 {
+			problems = append(problems, fmt.Sprintf("%s: checksum %s, expected %s", a.Name, got, a.SHA256))
+		}
+	}
+
+	return problems, nil
+}
+
+// writeFile writes a file and syncs it to disk
+func writeFile(path string, content []byte) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create: %v", err)
+	}
+
+	if _, err := f.Write(content); err != nil {
+		f.Close()
+		return fmt.Errorf("write %s: %v", path, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync %s: %v", path, err)
+	}
+
+	return f.Close()
+}
+
+// syncDir syncs a directory so that renames and new entries within it are durable
+func syncDir(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open %s: %v", path, err)
+	}
+	defer f.Close()
+
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("sync %s: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func write(t *testing.T, path string, files map[string]string) *Dir {
+	t.Helper()
+	d, err := Create(path)
+	if err != nil {
+		t.Fatalf("Create(%q): %v", path, err)
+	}
+	for name, content := range files {
+		if err := d.Write(name, []byte(content)); err != nil {
+			t.Fatalf("Write(%q): %v", name, err)
+		}
+	}
+	return d
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(b)
+}
+
+func TestCommit(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	d := write(t, path, map[string]string{"prs.csv": "a,b\n", "issues.csv": "c,d\n"})
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("output exists before Commit: %v", err)
+	}
+	if err := d.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if got := read(t, filepath.Join(path, "prs.csv")); got != "a,b\n" {
+		t.Errorf("prs.csv = %q, want %q", got, "a,b\n")
+	}
+	problems, err := Verify(path)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("Verify() = %v, want no problems", problems)
+	}
+}
+
+func TestCommitReplaces(t *testing.T) {
+	parent := t.TempDir()
+	path := filepath.Join(parent, "out")
+	if err := write(t, path, map[string]string{"prs.csv": "old", "stale.csv": "old"}).Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := write(t, path, map[string]string{"prs.csv": "new"}).Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if got := read(t, filepath.Join(path, "prs.csv")); got != "new" {
+		t.Errorf("prs.csv = %q, want %q", got, "new")
+	}
+	if _, err := os.Stat(filepath.Join(path, "stale.csv")); !os.IsNotExist(err) {
+		t.Errorf("stale.csv survived replacement: %v", err)
+	}
+
+	// Neither staging directories nor the previous output are left behind
+	entries, err := ioutil.ReadDir(parent)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	if len(entries) != 1 {
+		names := []string{}
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		t.Errorf("%s contains %v, want only out", parent, names)
+	}
+}
+
+func TestFailedRunLeavesPrevious(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out")
+	if err := write(t, path, map[string]string{"prs.csv": "old"}).Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	// A run which dies before committing
+	d := write(t, path, map[string]string{"prs.csv": "new"})
+
+	if got := read(t, filepath.Join(path, "prs.csv")); got != "old" {
+		t.Errorf("prs.csv = %q, want %q", got, "old")
+	}
+	if _, err := os.Stat(filepath.Join(d.Staging(), PartialName)); err != nil {
+		t.Errorf("staging directory isn't marked %s: %v", PartialName, err)
+	}
+	if _, err := os.Stat(filepath.Join(d.Staging(), SuccessName)); !os.IsNotExist(err) {
+		t.Errorf("staging directory is marked %s: %v", SuccessName, err)
+	}
+}
+
+func TestVerifyProblems(t *testing.T) {
+	tests := []struct {
+		name   string
+		damage func(path string) error
+		want   string
+	}{
+		{
+			name:   "modified",
+			damage: func(path string) error { return ioutil.WriteFile(filepath.Join(path, "prs.csv"), []byte("x"), 0o644) },
+			want:   "prs.csv: checksum",
+		},
+		{
+			name:   "missing",
+			damage: func(path string) error { return os.Remove(filepath.Join(path, "prs.csv")) },
+			want:   "prs.csv:",
+		},
+		{
+			name:   "no success marker",
+			damage: func(path string) error { return os.Remove(filepath.Join(path, SuccessName)) },
+			want:   "missing " + SuccessName,
+		},
+		{
+			name:   "partial marker",
+			damage: func(path string) error { return ioutil.WriteFile(filepath.Join(path, PartialName), nil, 0o644) },
+			want:   "found " + PartialName,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out")
+			if err := write(t, path, map[string]string{"prs.csv": "a,b\n"}).Commit(); err != nil {
+				t.Fatalf("Commit: %v", err)
+			}
+			if err := tc.damage(path); err != nil {
+				t.Fatalf("damage: %v", err)
+			}
+
+			problems, err := Verify(path)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if len(problems) != 1 || !strings.HasPrefix(problems[0], tc.want) {
+				t.Errorf("Verify() = %q, want one problem starting with %q", problems, tc.want)
+			}
+		})
+	}
+}
+
+func TestVerifyNoManifest(t *testing.T) {
+	if _, err := Verify(t.TempDir()); err == nil {
+		t.Errorf("Verify() of a directory without a manifest succeeded")
+	}
+}
+
+func TestCreateFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prs.csv")
+	if err := ioutil.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := CreateFile(path)
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	if _, err := f.Write([]byte("new")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if got := read(t, path); got != "old" {
+		t.Errorf("%s = %q before Commit, want %q", path, got, "old")
+	}
+	if err := f.Commit(); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close after Commit: %v", err)
+	}
+
+	if got := read(t, path); got != "new" {
+		t.Errorf("%s = %q, want %q", path, got, "new")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o644 {
+		t.Errorf("%s has mode %v, want 0644", path, info.Mode().Perm())
+	}
+}
+
+func TestCreateFileDiscarded(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "prs.csv")
+	if err := ioutil.WriteFile(path, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := CreateFile(path)
+	if err != nil {
+		t.Fatalf("CreateFile: %v", err)
+	}
+	if _, err := f.Write([]byte("half a ro")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	if got := read(t, path); got != "old" {
+		t.Errorf("%s = %q, want the previous %q", path, got, "old")
+	}
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("%s has %d entries, want the temporary file removed", dir, len(entries))
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data.json")
+	if err := WriteFile(path, []byte("{}")); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if got := read(t, path); got != "{}" {
+		t.Errorf("%s = %q, want %q", path, got, "{}")
+	}
+
+	if err := WriteFile(filepath.Join(t.TempDir(), "missing", "data.json"), nil); err == nil {
+		t.Errorf("WriteFile() into a missing directory succeeded")
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// Canonical returns the login activity by login is credited to: its alias, if it has one, or else login itself
+func (o *Options) Canonical(login string) string {
+	if a, ok := o.Aliases[strings.ToLower(login)]; ok {
+		return a
+	}
+	return login
+}
+
+// AddAlias credits activity by old to login, accepting "old=new" pairs as given on the command line
+func (o *Options) AddAlias(pair string) error {
+	parts := strings.SplitN(pair, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" || strings.TrimSpace(parts[1]) == "" {
+		return fmt.Errorf("alias %q is not of the form old=new", pair)
+	}
+	o.Aliases[strings.ToLower(strings.TrimSpace(parts[0]))] = strings.TrimSpace(parts[1])
+	return nil
+}
+
+// LoadAliases adds the aliases in a YAML file mapping old logins to new ones:
+//
+//	alice-old: alice
+//	BobSmith: bob
+func (o *Options) LoadAliases(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	m := map[string]string{}
+	if err := yaml.UnmarshalStrict(b, &m); err != nil {
+		return fmt.Errorf("%s: %v", path, err)
+	}
+
+	for old, login := range m {
+		if err := o.AddAlias(old + "=" + login); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestAliases(t *testing.T) {
+	o := DefaultOptions()
+	if err := o.AddAlias(" Alice-Old = alice "); err != nil {
+		t.Fatalf("AddAlias() returned error: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "aliases.yaml")
+	if err := ioutil.WriteFile(path, []byte("BobSmith: bob\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.LoadAliases(path); err != nil {
+		t.Fatalf("LoadAliases() returned error: %v", err)
+	}
+
+	for login, want := range map[string]string{
+		"alice-old": "alice",
+		"ALICE-OLD": "alice",
+		"bobsmith":  "bob",
+		"carol":     "carol",
+		"":          "",
+	} {
+		if got := o.Canonical(login); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", login, got, want)
+		}
+	}
+
+	for _, pair := range []string{"alice", "=alice", "alice=", " = "} {
+		if err := o.AddAlias(pair); err == nil {
+			t.Errorf("AddAlias(%q) returned no error", pair)
+		}
+	}
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := ioutil.WriteFile(bad, []byte("- alice\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.LoadAliases(bad); err == nil {
+		t.Errorf("LoadAliases() of a list returned no error")
+	}
+}
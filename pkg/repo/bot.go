@@ -0,0 +1,64 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// DefaultBotPatterns match the logins of bots which are ordinary user accounts, so GitHub doesn't mark them as bots
+var DefaultBotPatterns = []string{`-bot$`, `-robot$`, `(?i)^codecov`, `(?i)^travis`}
+
+func compileBotPatterns(patterns []string) []*regexp.Regexp {
+	res := []*regexp.Regexp{}
+	for _, p := range patterns {
+		res = append(res, regexp.MustCompile(p))
+	}
+	return res
+}
+
+// IsBot returns whether a user is a bot: GitHub says so, its login matches BotRes, or its bio says it closes stale issues
+func (o *Options) IsBot(u *github.User) bool {
+	if o.IncludeBots {
+		return false
+	}
+
+	if strings.EqualFold(u.GetType(), "bot") || strings.Contains(u.GetBio(), "stale issues") {
+		return true
+	}
+
+	return o.IsBotLogin(u.GetLogin())
+}
+
+// IsBotLogin is IsBot for when only a login is known, such as in a summary
+func (o *Options) IsBotLogin(login string) bool {
+	if o.IncludeBots {
+		return false
+	}
+
+	if strings.HasSuffix(login, "[bot]") {
+		return true
+	}
+
+	for _, re := range o.BotRes {
+		if re.MatchString(login) {
+			return true
+		}
+	}
+	return false
+}
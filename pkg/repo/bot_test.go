@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestIsBotLogin(t *testing.T) {
+	o := DefaultOptions()
+	for login, want := range map[string]bool{
+		"dependabot[bot]":     true,
+		"github-actions[bot]": true,
+		"k8s-ci-robot":        true,
+		"release-bot":         true,
+		"Codecov-io":          true,
+		"talbot":              false,
+		"abbot":               false,
+		"alice":               false,
+	} {
+		if got := o.IsBotLogin(login); got != want {
+			t.Errorf("IsBotLogin(%q) = %v, want %v", login, got, want)
+		}
+	}
+
+	o.IncludeBots = true
+	if o.IsBotLogin("dependabot[bot]") {
+		t.Errorf("IsBotLogin() = true with IncludeBots, want false")
+	}
+}
+
+func TestIsBot(t *testing.T) {
+	o := DefaultOptions()
+	tests := []struct {
+		u    *github.User
+		want bool
+	}{
+		{u: &github.User{Login: github.String("renovate"), Type: github.String("Bot")}, want: true},
+		{u: &github.User{Login: github.String("fejta"), Bio: github.String("I close stale issues")}, want: true},
+		{u: &github.User{Login: github.String("talbot"), Type: github.String("User")}, want: false},
+	}
+
+	for _, tc := range tests {
+		if got := o.IsBot(tc.u); got != tc.want {
+			t.Errorf("IsBot(%s) = %v, want %v", tc.u.GetLogin(), got, tc.want)
+		}
+	}
+}
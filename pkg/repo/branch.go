@@ -0,0 +1,88 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// branchMatcher matches branch names, ignoring case, against exact names, glob patterns such as release-*,
+// and regular expressions prefixed with ~
+type branchMatcher struct {
+	exact map[string]bool
+	globs []string
+	res   []*regexp.Regexp
+	// patterns are the original forms of res, for logging
+	patterns []string
+}
+
+// newBranchMatcher returns a matcher for branch patterns, or an error if one is malformed
+func newBranchMatcher(branches []string) (*branchMatcher, error) {
+	m := &branchMatcher{exact: map[string]bool{}}
+	for _, b := range branches {
+		switch {
+		case strings.HasPrefix(b, "~"):
+			re, err := regexp.Compile("(?i)" + strings.TrimPrefix(b, "~"))
+			if err != nil {
+				return nil, fmt.Errorf("bad branch regex %q: %v", b, err)
+			}
+			m.res = append(m.res, re)
+			m.patterns = append(m.patterns, b)
+		case strings.ContainsAny(b, "*?["):
+			if _, err := path.Match(b, ""); err != nil {
+				return nil, fmt.Errorf("bad branch pattern %q: %v", b, err)
+			}
+			m.globs = append(m.globs, strings.ToLower(b))
+		default:
+			m.exact[strings.ToLower(b)] = true
+		}
+	}
+	return m, nil
+}
+
+// ValidBranchPatterns returns an error if any branch pattern is malformed
+func ValidBranchPatterns(branches []string) error {
+	_, err := newBranchMatcher(branches)
+	return err
+}
+
+// empty returns whether there are no patterns, in which case every branch should be accepted
+func (m *branchMatcher) empty() bool {
+	return len(m.exact) == 0 && len(m.globs) == 0 && len(m.res) == 0
+}
+
+// match returns the pattern a branch matches, and whether it matched any
+func (m *branchMatcher) match(branch string) (string, bool) {
+	b := strings.ToLower(branch)
+	if m.exact[b] {
+		return b, true
+	}
+
+	for _, g := range m.globs {
+		if ok, _ := path.Match(g, b); ok {
+			return g, true
+		}
+	}
+
+	for i, re := range m.res {
+		if re.MatchString(branch) {
+			return m.patterns[i], true
+		}
+	}
+	return "", false
+}
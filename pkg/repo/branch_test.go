@@ -0,0 +1,66 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "testing"
+
+func TestBranchMatcher(t *testing.T) {
+	m, err := newBranchMatcher([]string{"main", "Release-*", "~^v[0-9]+\\.x$"})
+	if err != nil {
+		t.Fatalf("newBranchMatcher() returned error: %v", err)
+	}
+
+	tests := []struct {
+		branch      string
+		wantPattern string
+		wantOK      bool
+	}{
+		{"main", "main", true},
+		{"MAIN", "main", true},
+		{"release-1.2", "release-*", true},
+		{"RELEASE-1.2", "release-*", true},
+		// Globs don't cross slashes
+		{"release-1/hotfix", "", false},
+		{"v1.x", `~^v[0-9]+\.x$`, true},
+		{"V12.X", `~^v[0-9]+\.x$`, true},
+		{"v1.2", "", false},
+		{"mainline", "", false},
+	}
+
+	for _, tc := range tests {
+		p, ok := m.match(tc.branch)
+		if p != tc.wantPattern || ok != tc.wantOK {
+			t.Errorf("match(%q) = %q, %v, want %q, %v", tc.branch, p, ok, tc.wantPattern, tc.wantOK)
+		}
+	}
+}
+
+func TestBranchMatcherEmpty(t *testing.T) {
+	m, err := newBranchMatcher(nil)
+	if err != nil {
+		t.Fatalf("newBranchMatcher() returned error: %v", err)
+	}
+	if !m.empty() {
+		t.Errorf("empty() = false without patterns")
+	}
+}
+
+func TestValidBranchPatterns(t *testing.T) {
+	for _, bad := range []string{"release-[", "~(unclosed"} {
+		if err := ValidBranchPatterns([]string{"main", bad}); err == nil {
+			t.Errorf("ValidBranchPatterns() accepted %q", bad)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+)
+
+var (
+	// closesRe matches GitHub's closing keywords followed by an issue reference: #N, org/repo#N, or an issue URL
+	closesRe = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?):?\s+(?:(https?://[^\s/]+)/([\w.-]+)/([\w.-]+)/issues/(\d+)|([\w.-]+)/([\w.-]+)#(\d+)|#(\d+))\b`)
+	// codeRe matches fenced code blocks and inline code, where GitHub ignores closing keywords
+	codeRe = regexp.MustCompile("(?s)```.*?```|~~~.*?~~~|`[^`\n]*`")
+)
+
+// closesIssues returns the deduplicated URLs of the issues a PR says it closes, resolving short references against the PR's own repository
+func closesIssues(prURL string, texts ...string) []string {
+	base := WebURL
+	if u, err := url.Parse(prURL); err == nil && u.Host != "" {
+		base = u.Scheme + "://" + u.Host
+	}
+	org, project := ParseURL(prURL)
+
+	seen := map[string]bool{}
+	urls := []string{}
+	for _, t := range texts {
+		for _, m := range closesRe.FindAllStringSubmatch(codeRe.ReplaceAllString(t, ""), -1) {
+			var u string
+			switch {
+			case m[1] != "":
+				u = fmt.Sprintf("%s/%s/%s/issues/%s", m[1], m[2], m[3], m[4])
+			case m[5] != "":
+				u = fmt.Sprintf("%s/%s/%s/issues/%s", base, m[5], m[6], m[7])
+			default:
+				u = fmt.Sprintf("%s/%s/%s/issues/%s", base, org, project, m[8])
+			}
+
+			if seen[u] {
+				continue
+			}
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestClosesIssues(t *testing.T) {
+	const pr = "https://github.com/org/project/pull/100"
+	tests := []struct {
+		name  string
+		texts []string
+		want  []string
+	}{
+		{
+			name:  "short reference",
+			texts: []string{"Fixes #12"},
+			want:  []string{"https://github.com/org/project/issues/12"},
+		},
+		{
+			name:  "every keyword",
+			texts: []string{"close #1, closes #2, closed #3, fix #4, fixes #5, fixed #6, resolve #7, resolves #8, resolved #9"},
+			want: []string{
+				"https://github.com/org/project/issues/1", "https://github.com/org/project/issues/2",
+				"https://github.com/org/project/issues/3", "https://github.com/org/project/issues/4",
+				"https://github.com/org/project/issues/5", "https://github.com/org/project/issues/6",
+				"https://github.com/org/project/issues/7", "https://github.com/org/project/issues/8",
+				"https://github.com/org/project/issues/9",
+			},
+		},
+		{
+			name:  "case and colon",
+			texts: []string{"RESOLVES: #3"},
+			want:  []string{"https://github.com/org/project/issues/3"},
+		},
+		{
+			name:  "other repository",
+			texts: []string{"closes other/tool#4"},
+			want:  []string{"https://github.com/other/tool/issues/4"},
+		},
+		{
+			name:  "issue URL",
+			texts: []string{"Fixed https://github.com/other/tool/issues/5."},
+			want:  []string{"https://github.com/other/tool/issues/5"},
+		},
+		{
+			name:  "deduplicated across title and body",
+			texts: []string{"Fix #6", "This fixes #6 and closes #7"},
+			want:  []string{"https://github.com/org/project/issues/6", "https://github.com/org/project/issues/7"},
+		},
+		{
+			name:  "mentions aren't closes",
+			texts: []string{"Related to #8, see #9, prefixes #10"},
+			want:  []string{},
+		},
+		{
+			name:  "code is ignored",
+			texts: []string{"Run `git commit -m 'fixes #11'`\n```\ncloses #12\n```\nResolves #13"},
+			want:  []string{"https://github.com/org/project/issues/13"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := closesIssues(pr, tc.texts...); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("closesIssues() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestClosesIssuesEnterprise(t *testing.T) {
+	got := closesIssues("https://ghe.example.com/org/project/pull/1", "Closes #2")
+	if want := []string{"https://ghe.example.com/org/project/issues/2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("closesIssues() = %q, want %q", got, want)
+	}
+}
+
+func TestPullSummaryClosesIssues(t *testing.T) {
+	pr := testPR(1, "Fix #2")
+	pr.Body = github.String("Also closes #3.")
+
+	want := "https://github.com/org/project/issues/2,https://github.com/org/project/issues/3"
+	if got := summarize(t, DefaultOptions(), pr, testFiles(1)).ClosesIssues; got != want {
+		t.Errorf("PullSummary() ClosesIssues = %q, want %q", got, want)
+	}
+}
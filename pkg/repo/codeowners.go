@@ -0,0 +1,135 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/codeowners"
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/ghcache"
+)
+
+// OwnershipSummary is a summary of review coverage for a single CODEOWNERS rule
+type OwnershipSummary struct {
+	Project       string `json:"project" desc:"Repository name, without the organization"`
+	Line          int    `json:"line" desc:"Line number of the rule in CODEOWNERS"`
+	Pattern       string `json:"pattern" desc:"Path pattern of the rule"`
+	Owners        string `json:"owners" desc:"Space delimited owners, as written in CODEOWNERS"`
+	PRs           int    `json:"prs" desc:"Merged PRs touching paths governed by the rule"`
+	OwnerReviewed int    `json:"owner_reviewed" desc:"Of those PRs, how many a listed owner reviewed"`
+	IdleOwners    string `json:"idle_owners" desc:"Space delimited owners who reviewed none of those PRs"`
+}
+
+// Codeowners returns the parsed CODEOWNERS file for a repository, or nil if it has none
+func Codeowners(ctx context.Context, c *client.Client, t time.Time, org string, project string) (*codeowners.Ruleset, error) {
+	for _, path := range codeowners.Paths {
+		content, err := ghcache.RepositoriesGetContents(ctx, c.Cache, c.GitHubClient, t, org, project, path)
+		if err != nil {
+			return nil, err
+		}
+
+		if content == "" {
+			continue
+		}
+
+		rs, errs := codeowners.Parse(strings.NewReader(content))
+		for _, e := range errs {
+			digest.Add(digest.ParseError, org+"/"+project, path, "%v", e)
+		}
+
+		logrus.Infof("%s/%s %s has %d rules", org, project, path, len(rs.Rules))
+		return rs, nil
+	}
+
+	logrus.Infof("%s/%s has no CODEOWNERS file", org, project)
+	return nil, nil
+}
+
+// OwnershipCoverage cross-references CODEOWNERS rules with the merged PRs and reviews of a project
+func OwnershipCoverage(rs *codeowners.Ruleset, org string, project string, prs []*PRSummary, reviews []*ReviewSummary) []*OwnershipSummary {
+	// PR URL -> lowercase reviewer -> true
+	reviewers := map[string]map[string]bool{}
+	for _, r := range reviews {
+		if reviewers[r.URL] == nil {
+			reviewers[r.URL] = map[string]bool{}
+		}
+		reviewers[r.URL][strings.ToLower(r.Reviewer)] = true
+	}
+
+	// rule -> PR URLs governed by it
+	touched := map[*codeowners.Rule]map[string]bool{}
+	for _, pr := range prs {
+		o, p := ParseURL(pr.URL)
+		if o != org || p != project {
+			continue
+		}
+
+		for _, f := range listedPaths(pr.Files) {
+			rule := rs.Owner(f)
+			if rule == nil {
+				continue
+			}
+			if touched[rule] == nil {
+				touched[rule] = map[string]bool{}
+			}
+			touched[rule][pr.URL] = true
+		}
+	}
+
+	result := []*OwnershipSummary{}
+	for _, rule := range rs.Rules {
+		s := &OwnershipSummary{
+			Project: project,
+			Line:    rule.Line,
+			Pattern: rule.Pattern,
+			Owners:  strings.Join(rule.Owners, " "),
+			PRs:     len(touched[rule]),
+		}
+
+		active := map[string]bool{}
+		for url := range touched[rule] {
+			reviewed := false
+			for _, o := range rule.Owners {
+				// Teams and e-mail addresses cannot be matched against review logins, so never count as reviewing
+				login := strings.ToLower(strings.TrimPrefix(o, "@"))
+				if reviewers[url][login] {
+					reviewed = true
+					active[o] = true
+				}
+			}
+			if reviewed {
+				s.OwnerReviewed++
+			}
+		}
+
+		idle := []string{}
+		for _, o := range rule.Owners {
+			if !active[o] {
+				idle = append(idle, o)
+			}
+		}
+		s.IdleOwners = strings.Join(idle, " ")
+
+		result = append(result, s)
+	}
+
+	return result
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+)
+
+// Estimate is how many API requests fetching a repository's merged PRs is expected to take, beyond listing them
+type Estimate struct {
+	Repo string `json:"repo"`
+	// PRs are those listed as merged within the window. Branch, label, and association filters need their details,
+	// so aren't applied, making this an upper bound.
+	PRs int `json:"prs"`
+	// DetailCalls, FileCalls, and ReviewCalls are the requests for PRs whose data isn't cached. Files and reviews
+	// take a request per 100, so at least this many.
+	DetailCalls int `json:"detail_calls"`
+	FileCalls   int `json:"file_calls"`
+	ReviewCalls int `json:"review_calls"`
+	// Lookups are the cache lookups the PRs need, of which Hits are cached
+	Lookups int `json:"lookups"`
+	Hits    int `json:"hits"`
+}
+
+// Requests returns the total estimated requests
+func (e Estimate) Requests() int {
+	return e.DetailCalls + e.FileCalls + e.ReviewCalls
+}
+
+// EstimatePulls lists a project's PRs, as MergedPulls does, then estimates the requests needed to fetch the rest of
+// their data from what is cached, without fetching it
+func EstimatePulls(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, files bool, reviews bool) (Estimate, error) {
+	e := Estimate{Repo: org + "/" + project}
+
+	matchUser := map[string]bool{}
+	for _, u := range users {
+		matchUser[strings.ToLower(u)] = true
+	}
+
+	prs, err := listedPulls(ctx, c, opts, org, project, since, until, users, matchUser)
+	if err != nil {
+		return e, err
+	}
+
+	for _, pr := range prs {
+		m := pr.GetMergedAt()
+		if m.IsZero() || m.Before(since) || m.After(until) {
+			continue
+		}
+		e.PRs++
+
+		t := pr.GetUpdatedAt()
+		checks := []struct {
+			want   bool
+			prefix string
+			calls  *int
+		}{
+			{true, ghcache.PullRequestPrefix, &e.DetailCalls},
+			{files, ghcache.PullRequestFilesPrefix, &e.FileCalls},
+			{reviews, ghcache.PullRequestReviewsPrefix, &e.ReviewCalls},
+		}
+		for _, ch := range checks {
+			if !ch.want {
+				continue
+			}
+			e.Lookups++
+			if ghcache.Cached(c.Cache, t, ch.prefix, org, project, pr.GetNumber()) {
+				e.Hits++
+				continue
+			}
+			*ch.calls++
+		}
+	}
+
+	return e, nil
+}
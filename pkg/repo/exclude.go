@@ -0,0 +1,31 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// Excluded returns whether a login is in ExcludeUsers, ignoring case
+func (o *Options) Excluded(login string) bool {
+	return o.ExcludeUsers[strings.ToLower(login)]
+}
+
+// ignored returns whether a user's activity is left out, as a bot or an excluded user
+func (o *Options) ignored(u *github.User) bool {
+	return o.IsBot(u) || o.Excluded(u.GetLogin())
+}
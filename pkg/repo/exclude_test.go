@@ -0,0 +1,48 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestIgnored(t *testing.T) {
+	o := DefaultOptions()
+	o.ExcludeUsers = map[string]bool{"release-robot": true}
+
+	tests := []struct {
+		login string
+		kind  string
+		want  bool
+	}{
+		{"alice", "User", false},
+		// Exclusion ignores case
+		{"Release-Robot", "User", true},
+		{"dependabot[bot]", "Bot", true},
+	}
+
+	for _, tc := range tests {
+		u := &github.User{Login: github.String(tc.login), Type: github.String(tc.kind)}
+		if got := o.ignored(u); got != tc.want {
+			t.Errorf("ignored(%q) = %v, want %v", tc.login, got, tc.want)
+		}
+	}
+
+	if !o.Excluded("RELEASE-ROBOT") || o.Excluded("alice") {
+		t.Errorf("Excluded() doesn't match ExcludeUsers ignoring case")
+	}
+}
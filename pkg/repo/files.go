@@ -16,6 +16,7 @@ package repo
 
 import (
 	"context"
+	"fmt"
 	"path/filepath"
 	"strings"
 	"time"
@@ -28,7 +29,7 @@ import (
 )
 
 // FilteredFiles returns a list of commit files that matter
-func FilteredFiles(ctx context.Context, c *client.Client, t time.Time, org string, project string, num int) ([]*github.CommitFile, error) {
+func FilteredFiles(ctx context.Context, c *client.Client, opts *Options, t time.Time, org string, project string, num int) ([]*github.CommitFile, error) {
 	logrus.Infof("Fetching file list for #%d", num)
 
 	changed, err := ghcache.PullRequestsListFiles(ctx, c.Cache, c.GitHubClient, t, org, project, num)
@@ -40,7 +41,7 @@ func FilteredFiles(ctx context.Context, c *client.Client, t time.Time, org strin
 
 	files := []*github.CommitFile{}
 	for _, cf := range changed {
-		if ignorePathRe.MatchString(cf.GetFilename()) {
+		if opts.IgnorePathRe.MatchString(cf.GetFilename()) {
 			logrus.Infof("ignoring %s", cf.GetFilename())
 			continue
 		}
@@ -52,11 +53,28 @@ func FilteredFiles(ctx context.Context, c *client.Client, t time.Time, org strin
 	return files, err
 }
 
+// FilteredFilesOf returns the FilteredFiles of each of a repository's PRs, in the same order, fetching them in parallel
+func FilteredFilesOf(ctx context.Context, c *client.Client, opts *Options, org string, project string, prs []*github.PullRequest) ([][]*github.CommitFile, error) {
+	files := make([][]*github.CommitFile, len(prs))
+	err := parallel(ctx, len(prs), Concurrency, func(ctx context.Context, i int) error {
+		fs, err := FilteredFiles(ctx, c, opts, prs[i].GetUpdatedAt(), org, project, prs[i].GetNumber())
+		if err != nil {
+			return fmt.Errorf("#%d: %w", prs[i].GetNumber(), err)
+		}
+		files[i] = fs
+		return nil
+	})
+	return files, err
+}
+
 // prType returns what kind of PR it thinks this may be
-func prType(files []github.CommitFile) string {
+func (o *Options) prType(files []github.CommitFile) string {
 	result := ""
 	for _, cf := range files {
 		f := cf.GetFilename()
+		if o.IgnorePathRe.MatchString(f) {
+			continue
+		}
 		ext := strings.TrimLeft(filepath.Ext(f), ".")
 
 		if strings.Contains(filepath.Dir(f), "docs/") || strings.Contains(filepath.Dir(f), "examples/") || strings.Contains(filepath.Dir(f), "site/") {
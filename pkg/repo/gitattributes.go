@@ -0,0 +1,67 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/gitattributes"
+)
+
+// Gitattributes returns the parsed .gitattributes of a repository's default branch, or nil if it has none
+func Gitattributes(ctx context.Context, c *client.Client, t time.Time, org string, project string) (*gitattributes.Attributes, error) {
+	content, err := ghcache.RepositoriesGetContents(ctx, c.Cache, c.GitHubClient, t, org, project, ".gitattributes")
+	if err != nil {
+		return nil, err
+	}
+
+	if content == "" {
+		logrus.Debugf("%s/%s has no .gitattributes", org, project)
+		return nil, nil
+	}
+
+	a, errs := gitattributes.Parse(strings.NewReader(content))
+	for _, e := range errs {
+		digest.Add(digest.ParseError, org+"/"+project, ".gitattributes", "%v", e)
+	}
+
+	logrus.Infof("%s/%s .gitattributes has %d rules", org, project, len(a.Rules))
+	return a, nil
+}
+
+// ExcludeGenerated removes generated and vendored files, returning those kept and the number of changed lines excluded
+func ExcludeGenerated(a *gitattributes.Attributes, files []*github.CommitFile) ([]*github.CommitFile, int) {
+	kept := []*github.CommitFile{}
+	excluded := 0
+
+	for _, f := range files {
+		if a.Generated(f.GetFilename()) {
+			logrus.Debugf("ignoring generated %s", f.GetFilename())
+			excluded += f.GetAdditions() + f.GetDeletions()
+			continue
+		}
+		kept = append(kept, f)
+	}
+
+	return kept, excluded
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/gitattributes"
+)
+
+func TestExcludeGenerated(t *testing.T) {
+	a, errs := gitattributes.Parse(strings.NewReader("*.pb.go linguist-generated\nvendor/** linguist-vendored\n"))
+	if len(errs) > 0 {
+		t.Fatalf("Parse: %v", errs)
+	}
+
+	kept, excluded := ExcludeGenerated(a, []*github.CommitFile{
+		changed("pkg/api.go", 10),
+		changed("pkg/api.pb.go", 300),
+		changed("vendor/x/y.go", 50),
+	})
+	if len(kept) != 1 || kept[0].GetFilename() != "pkg/api.go" {
+		t.Errorf("ExcludeGenerated() kept %v, want pkg/api.go", kept)
+	}
+	if excluded != 350 {
+		t.Errorf("ExcludeGenerated() excluded %d lines, want 350", excluded)
+	}
+}
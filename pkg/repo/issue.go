@@ -23,51 +23,174 @@ import (
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
 	"github.com/google/pullsheet/pkg/ghcache"
 )
 
+// Reasons GitHub records for closing an issue
+const (
+	CompletedReason  = "completed"
+	NotPlannedReason = "not_planned"
+)
+
 // IssueSummary is a summary of a single PR
 type IssueSummary struct {
-	URL     string
-	Date    string
-	Author  string
-	Closer  string
-	Project string
-	Type    string
-	Title   string
+	URL            string   `json:"url" desc:"Issue URL"`
+	Date           string   `json:"date" desc:"Close date (YYYY-MM-DD), or creation date if Event is opened"`
+	Author         string   `json:"author" desc:"Login of the issue author"`
+	Closer         string   `json:"closer" desc:"Login of the user who closed the issue, empty if Event is opened"`
+	Project        string   `json:"project" desc:"Repository name, without the organization"`
+	Type           string   `json:"type" desc:"Reserved, currently always empty"`
+	Title          string   `json:"title" desc:"Issue title"`
+	MemberAtTime   string   `json:"member_at_time" desc:"true or false for whether Closer was an org member when closed, empty if unknown" when:"--membership-history"`
+	Labels         string   `json:"labels" desc:"Comma delimited label names"`
+	Milestone      string   `json:"milestone" desc:"Title of the issue's milestone, empty if it has none"`
+	Comments       int      `json:"comments" desc:"Number of comments on the issue"`
+	PlusOne        int      `json:"plus_one" desc:"Number of +1 reactions to the issue"`
+	Heart          int      `json:"heart" desc:"Number of heart reactions to the issue"`
+	TotalReactions int      `json:"total_reactions" desc:"Number of reactions of any kind to the issue"`
+	Assignees      string   `json:"assignees" desc:"Comma delimited logins the issue is assigned to"`
+	Event          string   `json:"event" desc:"opened or closed, for whether the row counts the issue's creation or its closing"`
+	StateReason    string   `json:"state_reason" desc:"Why the issue was closed: completed or not_planned. Empty if Event is opened." when:"Event is closed"`
+	DaysOpen       *float64 `json:"days_open" desc:"Days from the issue's creation to its closing, empty if Event is opened or the timestamps are inconsistent" when:"Event is closed"`
 }
 
 // ClosedIssues returns a list of closed issues within a project
-func ClosedIssues(ctx context.Context, c *client.Client, org string, project string, since time.Time, until time.Time, users []string) ([]*IssueSummary, error) {
-	closed, err := issues(ctx, c, org, project, since, until, users, "closed")
+func ClosedIssues(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string) ([]*IssueSummary, error) {
+	result := []*IssueSummary{}
+	err := ClosedIssuesTo(ctx, c, opts, org, project, since, until, users, func(s *IssueSummary) error {
+		result = append(result, s)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return result, nil
+}
 
-	result := make([]*IssueSummary, 0, len(closed))
-	for _, i := range closed {
-		result = append(result, &IssueSummary{
-			URL:     i.GetHTMLURL(),
-			Date:    i.GetClosedAt().Format(dateForm),
-			Author:  i.GetUser().GetLogin(),
-			Closer:  i.GetClosedBy().GetLogin(),
-			Project: project,
-			Title:   i.GetTitle(),
-		})
+// ClosedIssuesTo is ClosedIssues, passing each summary to emit as soon as its issue is fetched rather than collecting them
+func ClosedIssuesTo(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, emit func(*IssueSummary) error) error {
+	return eachIssue(ctx, c, opts, org, project, since, until, users, "closed", false, func(i *github.Issue) error {
+		if opts.Excluded(i.GetClosedBy().GetLogin()) {
+			logrus.Infof("Skipping issue #%d (closed by excluded user %s)", i.GetNumber(), i.GetClosedBy().GetLogin())
+			return nil
+		}
+
+		reason := ghcache.IssueStateReason(c.Cache, issueDate(i), org, project, i.GetNumber())
+		// Issues closed before GitHub recorded reasons, or cached before pullsheet did, were almost always fixed
+		if reason == "" {
+			reason = CompletedReason
+		}
+		if opts.ClosedReason != "" && reason != opts.ClosedReason {
+			logrus.Infof("Skipping issue #%d (closed as %s)", i.GetNumber(), reason)
+			return nil
+		}
+
+		s := issueSummary(opts, project, i)
+		s.Date = i.GetClosedAt().Format(dateForm)
+		s.StateReason = reason
+		s.Closer = opts.Canonical(i.GetClosedBy().GetLogin())
+		s.MemberAtTime = opts.memberAtTime(s.Closer, s.Date)
+		s.Event = "closed"
+		s.DaysOpen = daysOpen(i.GetCreatedAt(), i.GetClosedAt())
+		return emit(s)
+	})
+}
+
+// daysOpen returns the days from an issue's creation to its closing, or nil if the timestamps are inconsistent
+func daysOpen(created time.Time, closed time.Time) *float64 {
+	if created.IsZero() || closed.Before(created) {
+		return nil
 	}
+	d := closed.Sub(created).Hours() / 24
+	return &d
+}
 
+// OpenIssues returns a list of issues opened within a project, whether or not they have since been closed
+func OpenIssues(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string) ([]*IssueSummary, error) {
+	result := []*IssueSummary{}
+	err := OpenIssuesTo(ctx, c, opts, org, project, since, until, users, func(s *IssueSummary) error {
+		result = append(result, s)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
+// OpenIssuesTo is OpenIssues, passing each summary to emit as soon as its issue is fetched rather than collecting them
+func OpenIssuesTo(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, emit func(*IssueSummary) error) error {
+	return eachIssue(ctx, c, opts, org, project, since, until, users, "all", true, func(i *github.Issue) error {
+		if opts.Excluded(i.GetUser().GetLogin()) {
+			logrus.Infof("Skipping issue #%d (opened by excluded user %s)", i.GetNumber(), i.GetUser().GetLogin())
+			return nil
+		}
+
+		s := issueSummary(opts, project, i)
+		s.Date = i.GetCreatedAt().Format(dateForm)
+		s.Event = "opened"
+		return emit(s)
+	})
+}
+
+// issueSummary returns the fields of an issue's summary which don't depend on whether it counts its opening or closing
+func issueSummary(opts *Options, project string, i *github.Issue) *IssueSummary {
+	return &IssueSummary{
+		URL:            i.GetHTMLURL(),
+		Author:         opts.Canonical(i.GetUser().GetLogin()),
+		Project:        project,
+		Title:          i.GetTitle(),
+		Labels:         labelNames(i.Labels),
+		Milestone:      i.GetMilestone().GetTitle(),
+		Comments:       i.GetComments(),
+		PlusOne:        i.GetReactions().GetPlusOne(),
+		Heart:          i.GetReactions().GetHeart(),
+		TotalReactions: i.GetReactions().GetTotalCount(),
+		Assignees:      opts.userLogins(i.Assignees),
+	}
+}
+
+// labelNames returns the comma delimited names of labels. CSV quoting keeps names containing commas in one cell.
+func labelNames(labels []*github.Label) string {
+	names := []string{}
+	for _, l := range labels {
+		names = append(names, l.GetName())
+	}
+	return strings.Join(names, ",")
+}
+
+// userLogins returns the comma delimited logins of users
+func (o *Options) userLogins(users []*github.User) string {
+	logins := []string{}
+	for _, u := range users {
+		logins = append(logins, o.Canonical(u.GetLogin()))
+	}
+	return strings.Join(logins, ",")
+}
+
 // issues returns a list of issues in a project
-func issues(ctx context.Context, c *client.Client, org string, project string, since time.Time, until time.Time, users []string, state string) ([]*github.Issue, error) {
+func issues(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, state string) ([]*github.Issue, error) {
 	result := []*github.Issue{}
-	opts := &github.IssueListByRepoOptions{
+	err := eachIssue(ctx, c, opts, org, project, since, until, users, state, false, func(i *github.Issue) error {
+		result = append(result, i)
+		return nil
+	})
+	return result, err
+}
+
+// eachIssue calls fn with each issue in a project, as it is fetched. Issues are within the window if they were closed in it,
+// or still open, unless opened is set, in which case they must have been created in it.
+func eachIssue(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, state string, opened bool, fn func(*github.Issue) error) error {
+	lo := &github.IssueListByRepoOptions{
 		State:     state,
+		Assignee:  opts.Assignee,
 		Sort:      "updated",
 		Direction: "desc",
+		// GitHub leaves out issues last updated before since, so listing ends at the first page reaching them
+		Since: since,
 		ListOptions: github.ListOptions{
-			PerPage: 100,
+			PerPage: PerPage,
 		},
 	}
 
@@ -76,43 +199,80 @@ func issues(ctx context.Context, c *client.Client, org string, project string, s
 		matchUser[strings.ToLower(u)] = true
 	}
 
-	logrus.Infof("Gathering issues for %s/%s, users=%q: %+v", org, project, users, opts)
-	for page := 1; page != 0; {
-		opts.ListOptions.Page = page
-		issues, resp, err := c.GitHubClient.Issues.ListByRepo(ctx, org, project, opts)
+	found := 0
+	// Issues updated while listing move to the first page, so one may be listed again on a later page
+	seen := map[int]bool{}
+	logrus.Infof("Gathering issues for %s/%s, users=%q: %+v", org, project, users, lo)
+	err := eachPage(ctx, func(ctx context.Context, page int) (interface{}, int, error) {
+		o := *lo
+		o.ListOptions.Page = page
+		issues, resp, err := ghcache.IssuesListByRepo(ctx, c.Cache, c.GitHubClient, org, project, &o)
 		if err != nil {
-			return result, err
+			return nil, 0, err
 		}
 
+		next := resp.NextPage
+		// Listing stops within this page, so the next isn't worth prefetching
+		if len(issues) == 0 || issues[len(issues)-1].GetUpdatedAt().Before(since) {
+			next = 0
+		}
+		return issues, next, nil
+	}, func(items interface{}, page int) (bool, error) {
+		issues := items.([]*github.Issue)
 		logrus.Infof("Processing page %d of %s/%s issue results ...", page, org, project)
 
-		page = resp.NextPage
+		// Filtering by assignee may leave nothing at all
+		if len(issues) == 0 {
+			return false, nil
+		}
 		logrus.Infof("Current issue updated at %s", issues[0].GetUpdatedAt())
 
 		for _, i := range issues {
+			// The issues passed to fn so far are kept
+			if ctx.Err() != nil {
+				return false, ErrInterrupted
+			}
 			if i.IsPullRequest() {
 				continue
 			}
-			if i.GetClosedAt().After(until) {
+			if seen[i.GetNumber()] {
+				logrus.Infof("Skipping issue #%d (listed twice, as it was updated while listing)", i.GetNumber())
+				continue
+			}
+			seen[i.GetNumber()] = true
+			if opened && i.GetCreatedAt().After(until) {
+				logrus.Infof("issue #%d created at %s", i.GetNumber(), i.GetCreatedAt())
+				continue
+			}
+
+			if !opened && i.GetClosedAt().After(until) {
 				logrus.Infof("issue #%d closed at %s", i.GetNumber(), i.GetUpdatedAt())
 				continue
 			}
 
 			if i.GetUpdatedAt().Before(since) {
 				logrus.Infof("Hit issue #%d updated at %s", i.GetNumber(), i.GetUpdatedAt())
-				page = 0
-				break
+				return false, nil
+			}
+
+			if opened && i.GetCreatedAt().Before(since) {
+				continue
 			}
 
-			if !i.GetClosedAt().IsZero() && i.GetClosedAt().Before(since) {
+			if !opened && !i.GetClosedAt().IsZero() && i.GetClosedAt().Before(since) {
 				continue
 			}
 
-			if state != "" && i.GetState() != state {
+			if state != "" && state != "all" && i.GetState() != state {
 				logrus.Infof("Skipping issue #%d (state=%q)", i.GetNumber(), i.GetState())
 				continue
 			}
 
+			if opts.Milestone != "" && !strings.EqualFold(i.GetMilestone().GetTitle(), opts.Milestone) {
+				logrus.Infof("Skipping issue #%d (milestone=%q)", i.GetNumber(), i.GetMilestone().GetTitle())
+				continue
+			}
+
 			t := issueDate(i)
 
 			logrus.Infof("Fetching #%d (closed %s, updated %s): %q", i.GetNumber(), i.GetClosedAt().Format(dateForm), i.GetUpdatedAt().Format(dateForm), i.GetTitle())
@@ -123,28 +283,42 @@ func issues(ctx context.Context, c *client.Client, org string, project string, s
 				full, err = ghcache.IssuesGet(ctx, c.Cache, c.GitHubClient, t, org, project, i.GetNumber())
 			}
 			if err != nil {
-				logrus.Errorf("failed IssuesGet: %v", err)
-				break
+				digest.Add(digest.FetchFailed, org+"/"+project, i.GetHTMLURL(), "IssuesGet: %v", err)
+				return true, nil
 			}
 
-			creator := strings.ToLower(full.GetUser().GetLogin())
-			closer := strings.ToLower(full.GetClosedBy().GetLogin())
+			creator := strings.ToLower(opts.Canonical(full.GetUser().GetLogin()))
+			closer := strings.ToLower(opts.Canonical(full.GetClosedBy().GetLogin()))
+			if opened {
+				closer = ""
+			}
 			if len(matchUser) > 0 && !matchUser[creator] && !matchUser[closer] {
 				continue
 			}
 
-			result = append(result, full)
+			if err := fn(full); err != nil {
+				return false, err
+			}
+			found++
 		}
+		return true, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return ErrInterrupted
+		}
+		return err
 	}
 
-	logrus.Infof("Returning %d issues", len(result))
-	return result, nil
+	logrus.Infof("Returning %d issues", found)
+	return nil
 }
 
+// issueDate returns when an issue last changed, which cached data about it must be newer than
 func issueDate(i *github.Issue) time.Time {
-	t := i.GetClosedAt()
+	t := i.GetUpdatedAt()
 	if t.IsZero() {
-		t = i.GetUpdatedAt()
+		t = i.GetClosedAt()
 	}
 	if t.IsZero() {
 		t = i.GetCreatedAt()
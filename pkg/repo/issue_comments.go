@@ -17,107 +17,162 @@ package repo
 import (
 	"context"
 	"fmt"
+	"sort"
 	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
 	"github.com/google/pullsheet/pkg/ghcache"
 )
 
 // CommentSummary a summary of a users reviews on an issue
 type CommentSummary struct {
-	URL         string
-	Date        string
-	Project     string
-	Commenter   string
-	IssueAuthor string
-	IssueState  string
-	Comments    int
-	Words       int
-	Title       string
+	URL          string `json:"url" desc:"Issue URL"`
+	Date         string `json:"date" desc:"Date of the commenter's last comment in the period (YYYY-MM-DD)"`
+	Project      string `json:"project" desc:"Repository name, without the organization"`
+	Commenter    string `json:"commenter" desc:"Login of the commenter"`
+	IssueAuthor  string `json:"issue_author" desc:"Login of the issue author"`
+	IssueState   string `json:"issue_state" desc:"Issue state: open or closed"`
+	Comments     int    `json:"comments" desc:"Comments by the commenter in the period"`
+	Words        int    `json:"words" desc:"Words written by the commenter in the period"`
+	Title        string `json:"title" desc:"Issue title"`
+	Truncated    bool   `json:"truncated" desc:"Whether the issue had more comments than were fetched" when:"--max-comments-per-issue"`
+	MemberAtTime string `json:"member_at_time" desc:"true or false for whether Commenter was an org member at Date, empty if unknown" when:"--membership-history"`
 }
 
-// IssueComments returns a list of issue comment summaries
-func IssueComments(ctx context.Context, c *client.Client, org string, project string, since time.Time, until time.Time, users []string) ([]*CommentSummary, error) {
-	is, err := issues(ctx, c, org, project, since, until, nil, "")
+// IssueComments returns a list of issue comment summaries. If maxComments is positive, at most that many comments are considered per issue.
+func IssueComments(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, maxComments int) ([]*CommentSummary, error) {
+	is, err := issues(ctx, c, opts, org, project, since, until, nil, "")
 	if err != nil {
-		return nil, fmt.Errorf("issues: %v", err)
+		return nil, fmt.Errorf("issues: %w", err)
 	}
 
 	logrus.Infof("found %d issues to check comments on", len(is))
-	reviews := []*CommentSummary{}
 
 	matchUser := map[string]bool{}
 	for _, u := range users {
 		matchUser[strings.ToLower(u)] = true
 	}
 
-	for _, i := range is {
+	// Each worker writes only to its own index, keeping the output order deterministic
+	perIssue := make([][]*CommentSummary, len(is))
+	var done, found int64
+
+	err = parallel(ctx, len(is), Concurrency, func(ctx context.Context, idx int) error {
+		i := is[idx]
 		if i.IsPullRequest() {
-			continue
+			return nil
 		}
 
-		// username -> summary
-		iMap := map[string]*CommentSummary{}
-
-		cs, err := ghcache.IssuesListComments(ctx, c.Cache, c.GitHubClient, issueDate(i), org, project, i.GetNumber())
+		cs, err := ghcache.IssuesListComments(ctx, c.Cache, c.GitHubClient, issueDate(i), org, project, i.GetNumber(), maxComments)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
-		for _, c := range cs {
-			commenter := c.GetUser().GetLogin()
-			if c.CreatedAt.After(until) {
-				continue
-			}
+		cs, truncated := capComments(cs, maxComments)
+		if truncated {
+			digest.Add(digest.Truncated, org+"/"+project, i.GetHTMLURL(), "more than %d comments, only counting the first %d", maxComments, maxComments)
+		}
 
-			if c.CreatedAt.Before(since) {
-				continue
-			}
+		perIssue[idx] = commentSummaries(opts, i, cs, project, since, until, matchUser, truncated)
+
+		Report(ProgressEvent{
+			Phase: "issues",
+			Repo:  org + "/" + project,
+			Done:  int(atomic.AddInt64(&done, 1)),
+			Total: len(is),
+			Items: int(atomic.AddInt64(&found, int64(len(cs)))),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if len(matchUser) > 0 && !matchUser[strings.ToLower(commenter)] {
-				continue
-			}
+	reviews := []*CommentSummary{}
+	for _, rs := range perIssue {
+		reviews = append(reviews, rs...)
+	}
 
-			if commenter == i.GetUser().GetLogin() {
-				continue
-			}
+	return reviews, nil
+}
 
-			if isBot(c.GetUser()) {
-				continue
-			}
+// capComments returns the first max comments, and whether any were left out. A max of 0 keeps every comment.
+func capComments(cs []*github.IssueComment, max int) ([]*github.IssueComment, bool) {
+	if max > 0 && len(cs) > max {
+		return cs[:max], true
+	}
+	return cs, false
+}
 
-			body := strings.TrimSpace(i.GetBody())
-			if (strings.HasPrefix(body, "/") || strings.HasPrefix(body, "cc")) && len(body) < 64 {
-				logrus.Infof("ignoring tag comment: %q", body)
-				continue
-			}
+// commentSummaries summarizes the comments on an issue by commenter, sorted by commenter
+func commentSummaries(opts *Options, i *github.Issue, cs []*github.IssueComment, project string, since time.Time, until time.Time, matchUser map[string]bool, truncated bool) []*CommentSummary {
+	// username -> summary
+	iMap := map[string]*CommentSummary{}
 
-			wordCount := wordCount(c.GetBody())
-
-			if iMap[commenter] == nil {
-				iMap[commenter] = &CommentSummary{
-					URL:         i.GetHTMLURL(),
-					IssueAuthor: i.GetUser().GetLogin(),
-					IssueState:  i.GetState(),
-					Commenter:   commenter,
-					Project:     project,
-					Title:       strings.TrimSpace(i.GetTitle()),
-				}
-			}
+	for _, c := range cs {
+		commenter := opts.Canonical(c.GetUser().GetLogin())
+		if c.CreatedAt.After(until) {
+			continue
+		}
+
+		if c.CreatedAt.Before(since) {
+			continue
+		}
+
+		if len(matchUser) > 0 && !matchUser[strings.ToLower(commenter)] {
+			continue
+		}
 
-			iMap[commenter].Comments++
-			iMap[commenter].Date = c.CreatedAt.Format(dateForm)
-			iMap[commenter].Words += wordCount
-			logrus.Infof("%d word comment by %s: %q for %s/%s #%d", wordCount, commenter, strings.TrimSpace(c.GetBody()), org, project, i.GetNumber())
+		if commenter == opts.Canonical(i.GetUser().GetLogin()) {
+			continue
 		}
 
-		for _, rs := range iMap {
-			reviews = append(reviews, rs)
+		if opts.ignored(c.GetUser()) {
+			continue
 		}
+
+		body := strings.TrimSpace(i.GetBody())
+		if (strings.HasPrefix(body, "/") || strings.HasPrefix(body, "cc")) && len(body) < 64 {
+			logrus.Infof("ignoring tag comment: %q", body)
+			continue
+		}
+
+		wordCount := wordCount(c.GetBody())
+
+		if iMap[commenter] == nil {
+			iMap[commenter] = &CommentSummary{
+				URL:         i.GetHTMLURL(),
+				IssueAuthor: opts.Canonical(i.GetUser().GetLogin()),
+				IssueState:  i.GetState(),
+				Commenter:   commenter,
+				Project:     project,
+				Title:       strings.TrimSpace(i.GetTitle()),
+				Truncated:   truncated,
+			}
+		}
+
+		iMap[commenter].Comments++
+		iMap[commenter].Date = c.CreatedAt.Format(dateForm)
+		iMap[commenter].Words += wordCount
+		logrus.Infof("%d word comment by %s: %q for %s", wordCount, commenter, strings.TrimSpace(c.GetBody()), i.GetHTMLURL())
+	}
+
+	commenters := []string{}
+	for u := range iMap {
+		commenters = append(commenters, u)
 	}
+	sort.Strings(commenters)
 
-	return reviews, err
+	result := []*CommentSummary{}
+	for _, u := range commenters {
+		iMap[u].MemberAtTime = opts.memberAtTime(u, iMap[u].Date)
+		result = append(result, iMap[u])
+	}
+	return result
 }
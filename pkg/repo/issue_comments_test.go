@@ -0,0 +1,76 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// testComments returns n comments, each by a different commenter, a day apart from start
+func testComments(n int, start time.Time) []*github.IssueComment {
+	cs := []*github.IssueComment{}
+	for i := 0; i < n; i++ {
+		login := fmt.Sprintf("user%d", i)
+		created := start.AddDate(0, 0, i)
+		body := "looks good to me, thanks"
+		cs = append(cs, &github.IssueComment{User: &github.User{Login: &login}, CreatedAt: &created, Body: &body})
+	}
+	return cs
+}
+
+func TestCapComments(t *testing.T) {
+	start := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	author := "author"
+	url := "https://github.com/org/project/issues/1"
+	issue := &github.Issue{User: &github.User{Login: &author}, HTMLURL: &url}
+
+	tests := []struct {
+		name          string
+		comments      int
+		max           int
+		wantComments  int
+		wantTruncated bool
+	}{
+		{name: "no cap", comments: 30, max: 0, wantComments: 30},
+		{name: "under", comments: 4, max: 5, wantComments: 4},
+		{name: "at", comments: 5, max: 5, wantComments: 5},
+		{name: "over", comments: 6, max: 5, wantComments: 5, wantTruncated: true},
+		{name: "none", comments: 0, max: 5, wantComments: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cs, truncated := capComments(testComments(tc.comments, start), tc.max)
+			if len(cs) != tc.wantComments || truncated != tc.wantTruncated {
+				t.Fatalf("capComments() = %d comments, truncated %v, want %d, %v", len(cs), truncated, tc.wantComments, tc.wantTruncated)
+			}
+
+			// Every commenter's row carries the flag
+			sums := commentSummaries(DefaultOptions(), issue, cs, "project", start, start.AddDate(1, 0, 0), nil, truncated)
+			if len(sums) != tc.wantComments {
+				t.Fatalf("commentSummaries() returned %d rows, want %d", len(sums), tc.wantComments)
+			}
+			for _, s := range sums {
+				if s.Truncated != tc.wantTruncated {
+					t.Errorf("%s row Truncated = %v, want %v", s.Commenter, s.Truncated, tc.wantTruncated)
+				}
+			}
+		})
+	}
+}
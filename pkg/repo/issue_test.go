@@ -0,0 +1,78 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestDaysOpen(t *testing.T) {
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		created time.Time
+		closed  time.Time
+		want    float64
+		wantNil bool
+	}{
+		{name: "same time", created: created, closed: created, want: 0},
+		{name: "hours", created: created, closed: created.Add(6 * time.Hour), want: 0.25},
+		{name: "days", created: created, closed: created.AddDate(0, 0, 10), want: 10},
+		{name: "closed before created", created: created, closed: created.Add(-time.Minute), wantNil: true},
+		{name: "no creation time", closed: created, wantNil: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := daysOpen(tc.created, tc.closed)
+			if tc.wantNil {
+				if got != nil {
+					t.Errorf("daysOpen() = %v, want nil", *got)
+				}
+				return
+			}
+			if got == nil || *got != tc.want {
+				t.Errorf("daysOpen() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestIssueSummaryCounts(t *testing.T) {
+	i := &github.Issue{
+		HTMLURL:  github.String("https://github.com/org/project/issues/7"),
+		Comments: github.Int(12),
+		Reactions: &github.Reactions{
+			TotalCount: github.Int(9),
+			PlusOne:    github.Int(5),
+			Heart:      github.Int(3),
+			Laugh:      github.Int(1),
+		},
+	}
+
+	s := issueSummary(DefaultOptions(), "project", i)
+	if s.Comments != 12 || s.PlusOne != 5 || s.Heart != 3 || s.TotalReactions != 9 {
+		t.Errorf("issueSummary() counts = %d comments, %d +1, %d heart, %d total, want 12, 5, 3, 9", s.Comments, s.PlusOne, s.Heart, s.TotalReactions)
+	}
+
+	// Issues listed without reactions count none
+	s = issueSummary(DefaultOptions(), "project", &github.Issue{})
+	if s.Comments != 0 || s.PlusOne != 0 || s.Heart != 0 || s.TotalReactions != 0 {
+		t.Errorf("issueSummary() counts = %d comments, %d +1, %d heart, %d total, want none", s.Comments, s.PlusOne, s.Heart, s.TotalReactions)
+	}
+}
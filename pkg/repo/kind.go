@@ -0,0 +1,50 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"regexp"
+)
+
+// Kinds of PR, by what their title says they do
+const (
+	NormalKind     = "normal"
+	RevertKind     = "revert"
+	CherryPickKind = "cherry-pick"
+)
+
+var (
+	// revertRe matches the titles GitHub's revert button generates, which nest when a revert is reverted
+	revertRe = regexp.MustCompile(`^Revert ".*"$`)
+	// cherryPickRe matches the titles of cherry-picks to release branches, made by hand or by automation
+	cherryPickRe = regexp.MustCompile(`(?i)^(\[cherry-pick\]|automated cherry pick of\b)`)
+)
+
+// prKind returns the kind of PR a title describes. A revert of a revert is still a revert, as the reverted work was already counted.
+func prKind(title string) string {
+	switch {
+	case revertRe.MatchString(title):
+		return RevertKind
+	case cherryPickRe.MatchString(title):
+		return CherryPickKind
+	default:
+		return NormalKind
+	}
+}
+
+// excludedKind returns whether PRs of a kind should be skipped
+func (o *Options) excludedKind(kind string) bool {
+	return (kind == RevertKind && o.ExcludeReverts) || (kind == CherryPickKind && o.ExcludeCherryPicks)
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+)
+
+func TestPRKind(t *testing.T) {
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Add a feature", NormalKind},
+		{`Revert "Add a feature"`, RevertKind},
+		{`Revert "Revert "Add a feature""`, RevertKind},
+		// Only the title GitHub generates counts
+		{"Revert the feature flag default", NormalKind},
+		{"[cherry-pick] Fix the crash", CherryPickKind},
+		{"[Cherry-Pick] Fix the crash", CherryPickKind},
+		{"Automated cherry pick of #123: Fix the crash", CherryPickKind},
+		{"Document how to cherry-pick", NormalKind},
+	}
+
+	for _, tc := range tests {
+		if got := prKind(tc.title); got != tc.want {
+			t.Errorf("prKind(%q) = %q, want %q", tc.title, got, tc.want)
+		}
+	}
+}
+
+func TestPullSummaryExcludesKinds(t *testing.T) {
+	prs := map[*github.PullRequest][]github.CommitFile{
+		testPR(1, "Add a feature"):               testFiles(1),
+		testPR(2, `  Revert "Add a feature"`):    testFiles(1),
+		testPR(3, "[cherry-pick] Add a feature"): testFiles(1),
+	}
+
+	tests := []struct {
+		name        string
+		reverts     bool
+		cherryPicks bool
+		want        map[string]string
+	}{
+		{
+			name: "tagged",
+			want: map[string]string{
+				"https://github.com/org/project/pull/1": NormalKind,
+				"https://github.com/org/project/pull/2": RevertKind,
+				"https://github.com/org/project/pull/3": CherryPickKind,
+			},
+		},
+		{
+			name:    "reverts excluded",
+			reverts: true,
+			want: map[string]string{
+				"https://github.com/org/project/pull/1": NormalKind,
+				"https://github.com/org/project/pull/3": CherryPickKind,
+			},
+		},
+		{
+			name:        "cherry-picks excluded",
+			cherryPicks: true,
+			want: map[string]string{
+				"https://github.com/org/project/pull/1": NormalKind,
+				"https://github.com/org/project/pull/2": RevertKind,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.ExcludeReverts = tc.reverts
+			opts.ExcludeCherryPicks = tc.cherryPicks
+
+			sum, err := PullSummary(opts, prs, since, until)
+			if err != nil {
+				t.Fatalf("PullSummary() returned error: %v", err)
+			}
+			got := map[string]string{}
+			for _, s := range sum {
+				got[s.URL] = s.Kind
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("PullSummary() kinds = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
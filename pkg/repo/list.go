@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+)
+
+// RepoVisibilities are the visibilities a RepoFilter may select
+var RepoVisibilities = []string{"all", "public", "private", "internal"}
+
+// RepoFilter narrows the repositories ListRepoNames returns
+type RepoFilter struct {
+	// IncludeArchived includes archived repositories, which are otherwise skipped
+	IncludeArchived bool
+	// Topics requires at least one of these topics, if any are given
+	Topics []string
+	// Languages requires a primary language among these, ignoring case, if any are given
+	Languages []string
+	// Visibility is one of RepoVisibilities, filtered by GitHub. Empty is the same as all.
+	Visibility string
+}
+
+// ListRepoNames returns the org/name of every repository in an organization which could have activity and
+// passes the filter: empty repositories which have never been pushed to are always skipped.
+func ListRepoNames(ctx context.Context, c *client.Client, org string, f RepoFilter) ([]string, error) {
+	visibility := f.Visibility
+	if visibility == "" {
+		visibility = "all"
+	}
+
+	opts := &github.RepositoryListByOrgOptions{
+		Type:        visibility,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	topics := lowerSet(f.Topics)
+	languages := lowerSet(f.Languages)
+
+	names := []string{}
+	for {
+		rs, resp, err := ghcache.RepositoriesListByOrg(ctx, c.Cache, c.GitHubClient, org, opts)
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", org, err)
+		}
+
+		for _, r := range rs {
+			if r.GetArchived() && !f.IncludeArchived {
+				logrus.Infof("skipping archived repository %s", r.GetFullName())
+				continue
+			}
+
+			if len(languages) > 0 && !languages[strings.ToLower(r.GetLanguage())] {
+				logrus.Infof("skipping %s repository %s", r.GetLanguage(), r.GetFullName())
+				continue
+			}
+
+			if len(topics) > 0 && !hasTopic(r.Topics, topics) {
+				logrus.Infof("skipping repository %s with topics %v", r.GetFullName(), r.Topics)
+				continue
+			}
+
+			if r.GetPushedAt().IsZero() || r.GetSize() == 0 {
+				logrus.Infof("skipping empty repository %s", r.GetFullName())
+				continue
+			}
+
+			names = append(names, r.GetFullName())
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+
+	return names, nil
+}
+
+// hasTopic returns whether any of a repository's topics is in a lowercased set
+func hasTopic(topics []string, set map[string]bool) bool {
+	for _, t := range topics {
+		if set[strings.ToLower(t)] {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidRepoPatterns returns an error if any repository pattern is malformed
+func ValidRepoPatterns(patterns []string) error {
+	for _, p := range patterns {
+		if _, err := path.Match(p, ""); err != nil {
+			return fmt.Errorf("bad repository pattern %q: %v", p, err)
+		}
+	}
+	return nil
+}
+
+// RepoExcluded returns whether an org/name repository matches any of the org/name glob patterns, ignoring case
+func RepoExcluded(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(strings.ToLower(p), strings.ToLower(name)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "testing"
+
+func TestRepoExcluded(t *testing.T) {
+	patterns := []string{"org/*-archive", "Other/Legacy", "org/tmp-?"}
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"org/docs-archive", true},
+		// Globs don't cross the slash
+		{"org/docs/x-archive", false},
+		{"other/legacy", true},
+		{"OTHER/LEGACY", true},
+		{"org/tmp-1", true},
+		{"org/tmp-12", false},
+		{"org/project", false},
+		{"elsewhere/docs-archive", false},
+	}
+
+	for _, tc := range tests {
+		if got := RepoExcluded(tc.name, patterns); got != tc.want {
+			t.Errorf("RepoExcluded(%q) = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	if RepoExcluded("org/project", nil) {
+		t.Errorf("RepoExcluded() = true without patterns")
+	}
+}
+
+func TestValidRepoPatterns(t *testing.T) {
+	if err := ValidRepoPatterns([]string{"org/*", "org/[a-c]*"}); err != nil {
+		t.Errorf("ValidRepoPatterns() = %v", err)
+	}
+	if err := ValidRepoPatterns([]string{"org/*", "org/[a-c"}); err == nil {
+		t.Errorf("ValidRepoPatterns() accepted an unterminated character class")
+	}
+}
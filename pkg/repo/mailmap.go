@@ -0,0 +1,83 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/ghcache"
+	"github.com/google/pullsheet/pkg/mailmap"
+)
+
+// Mailmap returns the identity mappings from a repository's .mailmap, or an empty map if it has none
+func Mailmap(ctx context.Context, c *client.Client, t time.Time, org string, project string) (*mailmap.Mailmap, error) {
+	content, err := ghcache.RepositoriesGetContents(ctx, c.Cache, c.GitHubClient, t, org, project, ".mailmap")
+	if err != nil {
+		return nil, err
+	}
+
+	if content == "" {
+		logrus.Debugf("%s/%s has no .mailmap", org, project)
+		return mailmap.New(), nil
+	}
+
+	m, errs := mailmap.Parse(strings.NewReader(content))
+	for _, e := range errs {
+		digest.Add(digest.ParseError, org+"/"+project, ".mailmap", "%v", e)
+	}
+
+	logrus.Infof("%s/%s .mailmap has %d mappings", org, project, m.Len())
+	return m, nil
+}
+
+// noreplyLogin returns the login of a GitHub noreply address, such as 123+alice@users.noreply.github.com, or "" for
+// any other address
+func noreplyLogin(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || !strings.HasPrefix(strings.ToLower(email[at+1:]), "users.noreply.") {
+		return ""
+	}
+	local := email[:at]
+	if i := strings.Index(local, "+"); i >= 0 {
+		local = local[i+1:]
+	}
+	return local
+}
+
+// AddMailmapAliases credits the login of each GitHub noreply address a .mailmap maps to another noreply address to
+// the login of that address, returning how many aliases were added. Aliases already set, such as those given with
+// --user-alias, take precedence.
+func (o *Options) AddMailmapAliases(m *mailmap.Mailmap) int {
+	n := 0
+	for _, e := range m.CommitEmails() {
+		from := noreplyLogin(e)
+		to := noreplyLogin(m.Resolve(mailmap.Identity{Email: e}).Email)
+		if from == "" || to == "" || strings.EqualFold(from, to) {
+			continue
+		}
+		if _, ok := o.Aliases[strings.ToLower(from)]; ok {
+			continue
+		}
+		o.Aliases[strings.ToLower(from)] = o.Canonical(to)
+		n++
+	}
+	return n
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/mailmap"
+)
+
+func TestNoreplyLogin(t *testing.T) {
+	tests := map[string]string{
+		"alice@users.noreply.github.com":       "alice",
+		"123+alice@users.noreply.github.com":   "alice",
+		"bob@users.noreply.github.example.com": "bob",
+		"alice@example.com":                    "",
+		"not an address":                       "",
+		"123+alice@Users.Noreply.GitHub.com":   "alice",
+		"noreply@github.com":                   "",
+	}
+	for email, want := range tests {
+		if got := noreplyLogin(email); got != want {
+			t.Errorf("noreplyLogin(%q) = %q, want %q", email, got, want)
+		}
+	}
+}
+
+func TestAddMailmapAliases(t *testing.T) {
+	m, _ := mailmap.Parse(strings.NewReader(`
+<1+alice@users.noreply.github.com> <2+alice-old@users.noreply.github.com>
+Bob <bob@example.com> <bob-work@users.noreply.github.com>
+Carol <carol@users.noreply.github.com>
+<dan@users.noreply.github.com> <danny@users.noreply.github.com>
+`))
+
+	o := DefaultOptions()
+	if err := o.AddAlias("danny=daniel"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := o.AddMailmapAliases(m); n != 1 {
+		t.Errorf("AddMailmapAliases() = %d, want 1", n)
+	}
+	for login, want := range map[string]string{
+		"alice-old": "alice",
+		"Alice-Old": "alice",
+		"bob-work":  "bob-work",
+		"carol":     "carol",
+		"danny":     "daniel",
+	} {
+		if got := o.Canonical(login); got != want {
+			t.Errorf("Canonical(%q) = %q, want %q", login, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/pullsheet/pkg/digest"
+)
+
+// memberAtTime returns "true" or "false" for whether a user was a member on a summary date, or "" if unknown
+func (o *Options) memberAtTime(user string, date string) string {
+	if o.Membership == nil || user == "" {
+		return ""
+	}
+
+	t, err := time.Parse(dateForm, date)
+	if err != nil {
+		digest.Add(digest.SuspectDate, "", user, "unparseable date %q: %v", date, err)
+		return ""
+	}
+
+	member, known := o.Membership.MemberAt(user, t)
+	if !known {
+		return ""
+	}
+	return strconv.FormatBool(member)
+}
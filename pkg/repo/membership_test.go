@@ -0,0 +1,53 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/pullsheet/pkg/membership"
+)
+
+func TestMemberAtTime(t *testing.T) {
+	h, err := membership.Parse(strings.NewReader("alice:\n  - joined: 2021-03-01\n    left: 2021-03-31\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	o := DefaultOptions()
+	if got := o.memberAtTime("alice", "2021-03-15"); got != "" {
+		t.Errorf("memberAtTime() = %q without a history, want none", got)
+	}
+
+	o.Membership = h
+	tests := []struct {
+		user string
+		date string
+		want string
+	}{
+		{"alice", "2021-03-01", "true"},
+		{"alice", "2021-03-31", "true"},
+		{"alice", "2021-04-01", "false"},
+		{"bob", "2021-03-15", ""},
+		{"", "2021-03-15", ""},
+		{"alice", "March 15th", ""},
+	}
+	for _, tc := range tests {
+		if got := o.memberAtTime(tc.user, tc.date); got != tc.want {
+			t.Errorf("memberAtTime(%q, %q) = %q, want %q", tc.user, tc.date, got, tc.want)
+		}
+	}
+}
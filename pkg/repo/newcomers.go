@@ -0,0 +1,125 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+)
+
+// NewContributorSummary is an author whose first merged PR in the queried repositories was within the period
+type NewContributorSummary struct {
+	User    string `json:"user" desc:"Login of the new contributor"`
+	URL     string `json:"url" desc:"URL of their first PR merged within the period"`
+	Date    string `json:"date" desc:"Merge date of that PR (YYYY-MM-DD)"`
+	Project string `json:"project" desc:"Repository name of that PR, without the organization"`
+	Title   string `json:"title" desc:"Title of that PR"`
+}
+
+// NewContributors returns the authors of prs who had no PR merged into the same repositories before since, with
+// their first PR merged after it, earliest first
+func NewContributors(ctx context.Context, c *client.Client, opts *Options, prs []*PRSummary, since time.Time) ([]*NewContributorSummary, error) {
+	first := map[string]*PRSummary{}
+	orgRepos := map[string]map[string]bool{}
+	for _, pr := range prs {
+		if f := first[pr.User]; f == nil || pr.Date < f.Date || (pr.Date == f.Date && pr.URL < f.URL) {
+			first[pr.User] = pr
+		}
+		org, project := ParseURL(pr.URL)
+		if orgRepos[org] == nil {
+			orgRepos[org] = map[string]bool{}
+		}
+		orgRepos[org][org+"/"+project] = true
+	}
+
+	merged := "merged:<" + since.UTC().Format(searchTime)
+	if !opts.AssumeNewAfter.IsZero() {
+		merged = fmt.Sprintf("merged:%s..%s", opts.AssumeNewAfter.UTC().Format(searchTime), since.UTC().Add(-time.Second).Format(searchTime))
+	}
+
+	ns := []*NewContributorSummary{}
+	for u, pr := range first {
+		isNew := true
+		if opts.AssumeNewAfter.Before(since) {
+			var err error
+			isNew, err = noEarlierPulls(ctx, c, u, merged, orgRepos)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !isNew {
+			continue
+		}
+
+		logrus.Infof("%s is a new contributor: first merged %s on %s", u, pr.URL, pr.Date)
+		ns = append(ns, &NewContributorSummary{User: u, URL: pr.URL, Date: pr.Date, Project: pr.Project, Title: pr.Title})
+	}
+
+	sort.Slice(ns, func(i, j int) bool {
+		if ns[i].Date != ns[j].Date {
+			return ns[i].Date < ns[j].Date
+		}
+		return ns[i].User < ns[j].User
+	})
+	return ns, nil
+}
+
+// noEarlierPulls returns whether a search finds no PRs by user merged within the merged qualifier, in any of the
+// repositories. Repositories are searched in as few queries as fit GitHub's query length limit.
+func noEarlierPulls(ctx context.Context, c *client.Client, user string, merged string, orgRepos map[string]map[string]bool) (bool, error) {
+	base := fmt.Sprintf("is:pr is:merged author:%s %s", user, merged)
+
+	orgs := []string{}
+	for org := range orgRepos {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+
+	for _, org := range orgs {
+		rs := []string{}
+		for r := range orgRepos[org] {
+			rs = append(rs, "repo:"+r)
+		}
+		sort.Strings(rs)
+
+		for len(rs) > 0 {
+			q := base
+			n := 0
+			for n < len(rs) && (n == 0 || len(q)+1+len(rs[n]) <= MaxSearchQuery) {
+				q += " " + rs[n]
+				n++
+			}
+			rs = rs[n:]
+
+			count, err := ghcache.SearchIssuesCount(ctx, c.Cache, c.GitHubClient, org, q)
+			if err != nil {
+				return false, err
+			}
+			logrus.Debugf("%d PRs match %q", count, q)
+			if count > 0 {
+				return false, nil
+			}
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/pullsheet/pkg/membership"
+)
+
+// Options decide which PRs and issues are collected, and how they are summarized. Every run, and every server job,
+// has its own, so jobs with different settings can be fetched at once.
+type Options struct {
+	// IgnorePathRe matches changed paths left out of PR deltas and types
+	IgnorePathRe *regexp.Regexp
+	// TruncatePathRe matches changed paths which count for at most 10 added lines
+	TruncatePathRe *regexp.Regexp
+	// CountExtensions are the lowercased file extensions, with a leading dot, whose changes count toward a PR's
+	// delta, if any
+	CountExtensions map[string]bool
+	// MaxFilesListed caps how many paths a PR's Files column lists, followed by a line counting the rest, or 0 for
+	// no limit. Every file still counts toward the PR's delta.
+	MaxFilesListed int
+	// MinDelta and MaxDelta skip PRs whose Delta is below or above them, unless 0
+	MinDelta int
+	MaxDelta int
+	// SizeLimits are the largest delta of each size bucket but the last, which holds every larger PR
+	SizeLimits []int
+	// RespectGitattributes excludes files marked linguist-generated or linguist-vendored from PR deltas
+	RespectGitattributes bool
+
+	// IncludeDrafts includes PRs which were still drafts when closed, which some orgs close rather than delete
+	IncludeDrafts bool
+	// RequireApproval skips PRs merged without an approving review from someone other than the author
+	RequireApproval bool
+	// Associations are the uppercased author associations, such as MEMBER or CONTRIBUTOR, a PR's author must have,
+	// if any
+	Associations map[string]bool
+	// ExcludeReverts skips PRs which revert another
+	ExcludeReverts bool
+	// ExcludeCherryPicks skips PRs which cherry-pick another onto a different branch
+	ExcludeCherryPicks bool
+	// OwnedBy restricts PRs to files governed by this CODEOWNERS owner (ex: @org/team), if set
+	OwnedBy string
+	// OwnedFraction is the minimum fraction of a PR's changed lines that must be owned for it to be included.
+	// The default of 0 includes any PR which touches an owned file.
+	OwnedFraction float64
+
+	// UseSearch finds merged PRs with the Search API, rather than by listing every PR closed since the window began
+	UseSearch bool
+	// UseGraphQL fetches the details, files, and reviews of listed PRs with GraphQL, many per query, rather than
+	// with REST calls for each. They are cached where the REST calls' results would be, so runs of either kind
+	// share them.
+	UseGraphQL bool
+
+	// Milestone restricts issues to those in the milestone with this title, ignoring case, if set
+	Milestone string
+	// Assignee restricts issues to those assigned to this login, if set. GitHub also accepts "none" and "*".
+	Assignee string
+	// ClosedReason restricts closed issues to those closed for this reason, if set
+	ClosedReason string
+
+	// AssumeNewAfter is how far back NewContributors looks for earlier merged PRs, or zero to look back forever.
+	// Authors with none merged since are new, whatever they merged before.
+	AssumeNewAfter time.Time
+
+	// ExcludeUsers are the lowercased logins left out of every report, such as service accounts IsBot doesn't
+	// recognize. Exclusion takes precedence over any list of users to match.
+	ExcludeUsers map[string]bool
+	// Aliases map lowercased logins to the login their activity is credited to, such as an account's name before
+	// it was renamed
+	Aliases map[string]string
+	// BotRes match the logins of accounts treated as bots, in addition to those GitHub marks as bots
+	BotRes []*regexp.Regexp
+	// IncludeBots treats every account as a person, for orgs which want bot activity counted
+	IncludeBots bool
+
+	// TrackerKeyRe is how issue-tracker keys are extracted from PR titles and bodies
+	TrackerKeyRe *regexp.Regexp
+	// Membership is the organization membership history to annotate summaries with, if any
+	Membership *membership.History
+}
+
+// DefaultOptions returns the options used when none are given on the command line
+func DefaultOptions() *Options {
+	return &Options{
+		IgnorePathRe:    regexp.MustCompile(DefaultIgnorePathPattern),
+		TruncatePathRe:  regexp.MustCompile(DefaultTruncatePathPattern),
+		CountExtensions: map[string]bool{},
+		MaxFilesListed:  100,
+		SizeLimits:      []int{9, 49, 249, 999},
+		Associations:    map[string]bool{},
+		ExcludeUsers:    map[string]bool{},
+		Aliases:         map[string]string{},
+		BotRes:          compileBotPatterns(DefaultBotPatterns),
+		TrackerKeyRe:    regexp.MustCompile(DefaultTrackerKeyPattern),
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"strings"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/codeowners"
+)
+
+// ownerMatches returns whether a CODEOWNERS owner list contains an owner, ignoring case and the leading @
+func ownerMatches(owners []string, want string) bool {
+	want = strings.TrimPrefix(want, "@")
+	for _, o := range owners {
+		if strings.EqualFold(strings.TrimPrefix(o, "@"), want) {
+			return true
+		}
+	}
+	return false
+}
+
+// OwnedFiles returns the files governed by an owner, and the fraction of changed lines they account for
+func OwnedFiles(rs *codeowners.Ruleset, owner string, files []*github.CommitFile) ([]*github.CommitFile, float64) {
+	owned := []*github.CommitFile{}
+	total := 0
+	ownedLines := 0
+
+	for _, f := range files {
+		lines := f.GetAdditions() + f.GetDeletions()
+		total += lines
+
+		rule := rs.Owner(f.GetFilename())
+		if rule == nil || !ownerMatches(rule.Owners, owner) {
+			continue
+		}
+
+		owned = append(owned, f)
+		ownedLines += lines
+	}
+
+	if len(owned) == 0 {
+		return owned, 0
+	}
+
+	// Renames and mode changes have no changed lines, but still touch owned files
+	if total == 0 {
+		return owned, 1
+	}
+
+	return owned, float64(ownedLines) / float64(total)
+}
+
+// IncludeOwned returns whether a PR with the given owned fraction of changes should be included
+func (o *Options) IncludeOwned(owned []*github.CommitFile, fraction float64) bool {
+	return len(owned) > 0 && fraction >= o.OwnedFraction
+}
+
+// InheritOwnership drops reviews and comments which are not on one of the given PR URLs
+func InheritOwnership(urls map[string]bool, reviews []*ReviewSummary, comments []*CommentSummary) ([]*ReviewSummary, []*CommentSummary) {
+	rs := []*ReviewSummary{}
+	for _, r := range reviews {
+		if urls[r.URL] {
+			rs = append(rs, r)
+		}
+	}
+
+	cs := []*CommentSummary{}
+	for _, c := range comments {
+		if urls[c.URL] {
+			cs = append(cs, c)
+		}
+	}
+
+	return rs, cs
+}
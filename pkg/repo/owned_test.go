@@ -0,0 +1,137 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/codeowners"
+)
+
+func changed(name string, lines int) *github.CommitFile {
+	return &github.CommitFile{Filename: &name, Additions: &lines, Deletions: github.Int(0)}
+}
+
+func TestOwnedFiles(t *testing.T) {
+	rs, errs := codeowners.Parse(strings.NewReader("* @org/everyone\n/pkg/ @org/Core @alice\n/docs/ @org/docs\n"))
+	if len(errs) > 0 {
+		t.Fatalf("Parse: %v", errs)
+	}
+
+	tests := []struct {
+		name         string
+		owner        string
+		files        []*github.CommitFile
+		wantOwned    []string
+		wantFraction float64
+	}{
+		{
+			name:         "partly owned",
+			owner:        "@org/core",
+			files:        []*github.CommitFile{changed("pkg/a.go", 30), changed("docs/a.md", 10)},
+			wantOwned:    []string{"pkg/a.go"},
+			wantFraction: 0.75,
+		},
+		{
+			name:         "without @",
+			owner:        "alice",
+			files:        []*github.CommitFile{changed("pkg/a.go", 30)},
+			wantOwned:    []string{"pkg/a.go"},
+			wantFraction: 1,
+		},
+		{
+			// The last matching rule wins, so the catch-all doesn't own pkg/
+			name:         "overridden",
+			owner:        "@org/everyone",
+			files:        []*github.CommitFile{changed("pkg/a.go", 30), changed("main.go", 10)},
+			wantOwned:    []string{"main.go"},
+			wantFraction: 0.25,
+		},
+		{
+			name:         "no changed lines",
+			owner:        "@org/docs",
+			files:        []*github.CommitFile{changed("docs/renamed.md", 0)},
+			wantOwned:    []string{"docs/renamed.md"},
+			wantFraction: 1,
+		},
+		{
+			name:         "unowned",
+			owner:        "@org/docs",
+			files:        []*github.CommitFile{changed("pkg/a.go", 30)},
+			wantOwned:    []string{},
+			wantFraction: 0,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			owned, fraction := OwnedFiles(rs, tc.owner, tc.files)
+			got := []string{}
+			for _, f := range owned {
+				got = append(got, f.GetFilename())
+			}
+			if !reflect.DeepEqual(got, tc.wantOwned) {
+				t.Errorf("OwnedFiles() owned %v, want %v", got, tc.wantOwned)
+			}
+			if fraction != tc.wantFraction {
+				t.Errorf("OwnedFiles() fraction = %v, want %v", fraction, tc.wantFraction)
+			}
+		})
+	}
+}
+
+func TestIncludeOwned(t *testing.T) {
+	owned := []*github.CommitFile{changed("pkg/a.go", 1)}
+
+	o := DefaultOptions()
+	if o.IncludeOwned(nil, 0) {
+		t.Errorf("IncludeOwned() included a PR which touches no owned files")
+	}
+	if !o.IncludeOwned(owned, 0.01) {
+		t.Errorf("IncludeOwned() skipped a PR touching an owned file, with no minimum fraction")
+	}
+
+	o.OwnedFraction = 0.5
+	if o.IncludeOwned(owned, 0.49) {
+		t.Errorf("IncludeOwned() included a PR below OwnedFraction")
+	}
+	if !o.IncludeOwned(owned, 0.5) {
+		t.Errorf("IncludeOwned() skipped a PR at OwnedFraction")
+	}
+}
+
+func TestInheritOwnership(t *testing.T) {
+	urls := map[string]bool{"https://github.com/org/repo/pull/1": true}
+	reviews := []*ReviewSummary{
+		{Reviewer: "alice", URL: "https://github.com/org/repo/pull/1"},
+		{Reviewer: "bob", URL: "https://github.com/org/repo/pull/2"},
+	}
+	comments := []*CommentSummary{
+		{Commenter: "carol", URL: "https://github.com/org/repo/pull/2"},
+		{Commenter: "dave", URL: "https://github.com/org/repo/pull/1"},
+	}
+
+	rs, cs := InheritOwnership(urls, reviews, comments)
+	if len(rs) != 1 || rs[0].Reviewer != "alice" {
+		t.Errorf("InheritOwnership() kept reviews %v, want alice's", rs)
+	}
+	if len(cs) != 1 || cs[0].Commenter != "dave" {
+		t.Errorf("InheritOwnership() kept comments %v, want dave's", cs)
+	}
+}
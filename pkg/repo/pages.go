@@ -0,0 +1,70 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+)
+
+// PerPage is how many items each page of a PR or issue listing holds, at most 100
+var PerPage = 100
+
+// fetchedPage is a page of a listing, fetched by a pageFetcher
+type fetchedPage struct {
+	num   int
+	items interface{}
+	// next is the number of the page after it, or 0 if it is the last page worth fetching
+	next int
+	err  error
+}
+
+// pageFetcher fetches a page of a listing, returning its items and the number of the next page worth fetching. It
+// should return 0 for the next page once the listing has passed the window, so that it isn't prefetched in vain.
+type pageFetcher func(ctx context.Context, page int) (items interface{}, next int, err error)
+
+// eachPage calls fn with the items of each page of a listing in turn, starting with page 1. While fn handles a page,
+// the next is fetched in the background, so that per-item requests and list requests overlap, but at most one page
+// is ever outstanding. fn returns false to stop early, abandoning any page being prefetched.
+func eachPage(ctx context.Context, fetch pageFetcher, fn func(items interface{}, page int) (bool, error)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := func(num int) <-chan fetchedPage {
+		ch := make(chan fetchedPage, 1)
+		go func() {
+			items, next, err := fetch(ctx, num)
+			ch <- fetchedPage{num: num, items: items, next: next, err: err}
+		}()
+		return ch
+	}
+
+	pending := start(1)
+	for {
+		p := <-pending
+		if p.err != nil {
+			return p.err
+		}
+
+		pending = nil
+		if p.next != 0 {
+			pending = start(p.next)
+		}
+
+		more, err := fn(p.items, p.num)
+		if err != nil || !more || pending == nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+)
+
+func TestEachPage(t *testing.T) {
+	// Closed once each page is fetched
+	fetched := map[int]chan bool{1: make(chan bool), 2: make(chan bool), 3: make(chan bool)}
+	fetch := func(ctx context.Context, page int) (interface{}, int, error) {
+		close(fetched[page])
+		next := page + 1
+		if page == 3 {
+			next = 0
+		}
+		return []int{page * 10, page*10 + 1}, next, nil
+	}
+
+	handled := []int{}
+	err := eachPage(context.Background(), fetch, func(items interface{}, page int) (bool, error) {
+		// The next page is fetched while this one is handled
+		if next := fetched[page+1]; next != nil {
+			select {
+			case <-next:
+			case <-time.After(5 * time.Second):
+				t.Fatalf("page %d wasn't prefetched while page %d was handled", page+1, page)
+			}
+		}
+		handled = append(handled, items.([]int)...)
+		return true, nil
+	})
+	if err != nil {
+		t.Fatalf("eachPage() returned error: %v", err)
+	}
+	if want := []int{10, 11, 20, 21, 30, 31}; !reflect.DeepEqual(handled, want) {
+		t.Errorf("handled %v, want %v", handled, want)
+	}
+}
+
+func TestEachPageStops(t *testing.T) {
+	abandoned := make(chan error, 1)
+	fetch := func(ctx context.Context, page int) (interface{}, int, error) {
+		if page == 2 {
+			// The prefetch is cancelled once fn stops
+			<-ctx.Done()
+			abandoned <- ctx.Err()
+			return nil, 0, ctx.Err()
+		}
+		return page, page + 1, nil
+	}
+
+	pages := 0
+	err := eachPage(context.Background(), fetch, func(items interface{}, page int) (bool, error) {
+		pages++
+		return false, nil
+	})
+	if err != nil || pages != 1 {
+		t.Errorf("eachPage() = %v after %d pages, want nil after 1", err, pages)
+	}
+	if err := <-abandoned; err == nil {
+		t.Errorf("prefetch of page 2 wasn't cancelled")
+	}
+
+	boom := errors.New("boom")
+	err = eachPage(context.Background(), func(ctx context.Context, page int) (interface{}, int, error) {
+		if page == 2 {
+			return nil, 0, boom
+		}
+		return page, page + 1, nil
+	}, func(items interface{}, page int) (bool, error) {
+		return true, nil
+	})
+	if !errors.Is(err, boom) {
+		t.Errorf("eachPage() = %v, want the fetch error", err)
+	}
+}
+
+func TestListedPullsPerPage(t *testing.T) {
+	old := PerPage
+	defer func() { PerPage = old }()
+	PerPage = 25
+
+	perPage := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		perPage = r.URL.Query().Get("per_page")
+		w.Write([]byte("[]"))
+	}))
+	defer srv.Close()
+
+	p, err := cache.New(cache.Config{Backend: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc := github.NewClient(srv.Client())
+	gc.BaseURL, _ = url.Parse(srv.URL + "/")
+
+	if _, err := listedPulls(context.Background(), &client.Client{Cache: p, GitHubClient: gc}, DefaultOptions(), "org", "project", since, until, nil, nil); err != nil {
+		t.Fatalf("listedPulls() returned error: %v", err)
+	}
+	if perPage != "25" {
+		t.Errorf("per_page = %q, want 25", perPage)
+	}
+}
@@ -16,61 +16,264 @@ package repo
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"path/filepath"
 	"regexp"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
 	"github.com/google/pullsheet/pkg/ghcache"
 )
 
 const dateForm = "2006-01-02"
 
-var (
-	ignorePathRe = regexp.MustCompile(`go\.mod|go\.sum|vendor/|third_party|ignore|schemas/v\d|schema/v\d|Gopkg.lock|.DS_Store|\.json$|\.pb\.go|references/api/grpc|docs/commands/|pb\.gw\.go|proto/.*\.tmpl|proto/.*\.md`)
-	truncRe      = regexp.MustCompile(`changelog|CHANGELOG|Gopkg.toml`)
-	commentRe    = regexp.MustCompile(`<!--.*?>`)
+const (
+	// DefaultIgnorePathPattern matches changed paths which are usually generated, vendored, or otherwise not authored
+	DefaultIgnorePathPattern = `go\.mod|go\.sum|vendor/|third_party|ignore|schemas/v\d|schema/v\d|Gopkg.lock|.DS_Store|\.json$|\.pb\.go|references/api/grpc|docs/commands/|pb\.gw\.go|proto/.*\.tmpl|proto/.*\.md`
+	// DefaultTruncatePathPattern matches changed paths which are mostly auto-generated, whose additions are capped
+	DefaultTruncatePathPattern = `changelog|CHANGELOG|Gopkg.toml`
 )
 
-// MergedPulls returns a list of pull requests in a project
-func MergedPulls(ctx context.Context, c *client.Client, org string, project string, since time.Time, until time.Time, users []string, branches []string) ([]*github.PullRequest, error) {
-	var result []*github.PullRequest
+var commentRe = regexp.MustCompile(`<!--.*?>`)
 
-	opts := &github.PullRequestListOptions{
-		State:     "closed",
-		Sort:      "updated",
-		Direction: "desc",
-		ListOptions: github.ListOptions{
-			PerPage: 100,
-		},
+// ValidAssociations are the author associations GitHub reports
+var ValidAssociations = []string{"COLLABORATOR", "CONTRIBUTOR", "FIRST_TIMER", "FIRST_TIME_CONTRIBUTOR", "MANNEQUIN", "MEMBER", "NONE", "OWNER"}
+
+// counted returns whether a changed path counts toward a PR's delta
+func (o *Options) counted(path string) bool {
+	return len(o.CountExtensions) == 0 || o.CountExtensions[strings.ToLower(filepath.Ext(path))]
+}
+
+// moreFilesRe matches the line counting the paths left out of a Files column
+var moreFilesRe = regexp.MustCompile(`^\(\+\d+ more\)$`)
+
+// listedPaths returns the paths listed in a Files column, without the line counting those left out
+func listedPaths(files string) []string {
+	paths := []string{}
+	for _, f := range strings.Split(files, "\n") {
+		if f == "" || moreFilesRe.MatchString(f) {
+			continue
+		}
+		paths = append(paths, f)
 	}
+	return paths
+}
+
+// MergedPulls returns a list of pull requests in a project. If labels are given, PRs must have at least one of them,
+// and PRs with any of excludeLabels are left out.
+func MergedPulls(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, branches []string, labels []string, excludeLabels []string) ([]*github.PullRequest, error) {
+	var result []*github.PullRequest
 
 	matchUser := map[string]bool{}
 	for _, u := range users {
 		matchUser[strings.ToLower(u)] = true
 	}
 
-	matchBranch := map[string]bool{}
-	for _, b := range branches {
-		matchBranch[strings.ToLower(b)] = true
+	matchBranch, err := newBranchMatcher(branches)
+	if err != nil {
+		return nil, err
+	}
+
+	matchLabel := lowerSet(labels)
+	excludeLabel := lowerSet(excludeLabels)
+
+	var candidates []*github.PullRequest
+	searched := false
+	if opts.UseSearch {
+		candidates, err = searchedPulls(ctx, c, opts, org, project, since, until, users, matchUser)
+		switch {
+		case err == nil:
+			searched = true
+		case ctx.Err() != nil:
+			return nil, ErrInterrupted
+		default:
+			logrus.Warningf("searching %s/%s failed, listing its pull requests instead: %v", org, project, err)
+		}
 	}
 
-	logrus.Infof("Gathering pull requests for %s/%s, users=%q: %+v", org, project, users, opts)
-	for page := 1; page != 0; {
-		opts.ListOptions.Page = page
-		prs, resp, err := c.GitHubClient.PullRequests.List(ctx, org, project, opts)
+	if !searched {
+		candidates, err = listedPulls(ctx, c, opts, org, project, since, until, users, matchUser)
 		if err != nil {
+			if ctx.Err() != nil {
+				return result, ErrInterrupted
+			}
 			return result, err
 		}
+	}
+
+	if opts.UseGraphQL {
+		if err := ghcache.PullRequestsGetBatch(ctx, c.Cache, c.GitHubClient, org, project, candidates); err != nil {
+			if ctx.Err() != nil {
+				return result, ErrInterrupted
+			}
+			logrus.Warningf("fetching %s/%s pull requests with GraphQL failed, using REST instead: %v", org, project, err)
+		}
+	}
+
+	// Each worker writes only to its own index, so results keep the order they were listed in. Skipped PRs stay nil.
+	full := make([]*github.PullRequest, len(candidates))
+	var unapproved, examined, included int64
+
+	err = parallel(ctx, len(candidates), Concurrency, func(ctx context.Context, idx int) error {
+		pr := candidates[idx]
+		defer func() {
+			Report(ProgressEvent{
+				Phase: "prs",
+				Repo:  org + "/" + project,
+				Done:  int(atomic.AddInt64(&examined, 1)),
+				Total: len(candidates),
+				Items: int(atomic.LoadInt64(&included)),
+			})
+		}()
+
+		logrus.Infof("Fetching PR #%d by %s (updated %s): %q", pr.GetNumber(), pr.GetUser().GetLogin(), pr.GetUpdatedAt(), pr.GetTitle())
+		fullPR, err := ghcache.PullRequestsGet(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber())
+		if err != nil {
+			time.Sleep(1 * time.Second)
+			fullPR, err = ghcache.PullRequestsGet(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber())
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				digest.Add(digest.FetchFailed, org+"/"+project, pr.GetHTMLURL(), "PullRequestsGet: %v", err)
+				return nil
+			}
+		}
+
+		branch := fullPR.GetBase().GetRef()
+		if !matchBranch.empty() {
+			p, ok := matchBranch.match(branch)
+			if !ok {
+				logrus.Errorf("#%d merged to %s, skipping", pr.GetNumber(), branch)
+				return nil
+			}
+			logrus.Debugf("#%d merged to %s, matching %q", pr.GetNumber(), branch, p)
+		}
 
+		if len(matchLabel) > 0 && !hasLabel(fullPR.Labels, matchLabel) {
+			logrus.Infof("#%d has none of the labels %v, skipping", pr.GetNumber(), labels)
+			return nil
+		}
+
+		if hasLabel(fullPR.Labels, excludeLabel) {
+			logrus.Infof("#%d has an excluded label, skipping", pr.GetNumber())
+			return nil
+		}
+
+		if len(opts.Associations) > 0 && !opts.Associations[fullPR.GetAuthorAssociation()] {
+			logrus.Infof("#%d author is %s, skipping", pr.GetNumber(), fullPR.GetAuthorAssociation())
+			return nil
+		}
+
+		if !fullPR.GetMerged() || fullPR.GetMergeCommitSHA() == "" {
+			logrus.Infof("#%d was not merged, skipping", pr.GetNumber())
+			return nil
+		}
+
+		if fullPR.GetMergedAt().Before(since) {
+			logrus.Infof("#%d was merged earlier than %s, skipping", pr.GetNumber(), since)
+			return nil
+		}
+
+		// Checked last, as it costs an API call. Reviews are cached, so reporting reviewers later is free.
+		if opts.RequireApproval {
+			_, approvers, err := PullReviewers(ctx, c, opts, org, project, fullPR)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				digest.Add(digest.FetchFailed, org+"/"+project, pr.GetHTMLURL(), "PullRequestsListReviews: %v", err)
+				return nil
+			}
+			if len(approvers) == 0 {
+				logrus.Infof("#%d was merged without approval, skipping", pr.GetNumber())
+				atomic.AddInt64(&unapproved, 1)
+				return nil
+			}
+		}
+
+		full[idx] = fullPR
+		atomic.AddInt64(&included, 1)
+		return nil
+	})
+
+	// Those finished before an interruption are returned, with the PRs not yet examined left out
+	interrupted := err != nil && ctx.Err() != nil
+	if err != nil && !interrupted {
+		return result, err
+	}
+
+	for _, pr := range full {
+		if pr != nil {
+			result = append(result, pr)
+		}
+	}
+
+	if interrupted {
+		logrus.Warningf("Interrupted, returning the %d pull request results found so far", len(result))
+		return result, ErrInterrupted
+	}
+
+	if opts.RequireApproval {
+		logrus.Infof("Skipped %d pull requests in %s/%s merged without approval", unapproved, org, project)
+	}
+	logrus.Infof("Returning %d pull request results", len(result))
+	return result, nil
+}
+
+// listedPulls lists a project's closed PRs updated since the window began, returning those which pass the filters
+// that need no further API calls
+func listedPulls(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, matchUser map[string]bool) ([]*github.PullRequest, error) {
+	lo := &github.PullRequestListOptions{
+		State:     "closed",
+		Sort:      "updated",
+		Direction: "desc",
+		ListOptions: github.ListOptions{
+			PerPage: PerPage,
+		},
+	}
+
+	logrus.Infof("Gathering pull requests for %s/%s, users=%q: %+v", org, project, users, lo)
+	candidates := []*github.PullRequest{}
+	// PRs updated while listing move to the first page, so one may be listed again on a later page
+	seen := map[int]bool{}
+	err := eachPage(ctx, func(ctx context.Context, page int) (interface{}, int, error) {
+		o := *lo
+		o.ListOptions.Page = page
+		prs, resp, err := ghcache.PullRequestsList(ctx, c.Cache, c.GitHubClient, org, project, &o)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		next := resp.NextPage
+		// Listing stops within this page, so the next isn't worth prefetching
+		if len(prs) == 0 || prs[len(prs)-1].GetUpdatedAt().Before(since) {
+			next = 0
+		}
+		return prs, next, nil
+	}, func(items interface{}, page int) (bool, error) {
+		prs := items.([]*github.PullRequest)
 		logrus.Infof("Processing page %d of %s/%s pull request results (looking for %s)...", page, org, project, since)
 
-		page = resp.NextPage
+		// A repository may have no closed PRs at all
+		if len(prs) == 0 {
+			return false, nil
+		}
 		logrus.Infof("Current PR updated at %s", prs[0].GetUpdatedAt())
 		for _, pr := range prs {
+			if seen[pr.GetNumber()] {
+				logrus.Infof("Skipping PR#%d (listed twice, as it was updated while listing)", pr.GetNumber())
+				continue
+			}
+			seen[pr.GetNumber()] = true
+
 			if pr.GetClosedAt().After(until) {
 				logrus.Infof("PR#%d closed at %s", pr.GetNumber(), pr.GetUpdatedAt())
 				continue
@@ -78,20 +281,19 @@ func MergedPulls(ctx context.Context, c *client.Client, org string, project stri
 
 			if pr.GetUpdatedAt().Before(since) {
 				logrus.Infof("Hit PR#%d updated at %s", pr.GetNumber(), pr.GetUpdatedAt())
-				page = 0
-				break
+				return false, nil
 			}
 
 			if !pr.GetClosedAt().IsZero() && pr.GetClosedAt().Before(since) {
 				continue
 			}
 
-			uname := strings.ToLower(pr.GetUser().GetLogin())
+			uname := strings.ToLower(opts.Canonical(pr.GetUser().GetLogin()))
 			if len(matchUser) > 0 && !matchUser[uname] {
 				continue
 			}
 
-			if isBot(pr.GetUser()) {
+			if opts.ignored(pr.GetUser()) {
 				continue
 			}
 
@@ -100,59 +302,87 @@ func MergedPulls(ctx context.Context, c *client.Client, org string, project stri
 				continue
 			}
 
-			logrus.Infof("Fetching PR #%d by %s (updated %s): %q", pr.GetNumber(), pr.GetUser().GetLogin(), pr.GetUpdatedAt(), pr.GetTitle())
-			fullPR, err := ghcache.PullRequestsGet(ctx, c.Cache, c.GitHubClient, pr.GetMergedAt(), org, project, pr.GetNumber())
-			if err != nil {
-				time.Sleep(1 * time.Second)
-				fullPR, err = ghcache.PullRequestsGet(ctx, c.Cache, c.GitHubClient, pr.GetMergedAt(), org, project, pr.GetNumber())
-				if err != nil {
-					logrus.Errorf("failed PullRequestsGet: %v", err)
-					break
-				}
-			}
-
-			branch := fullPR.GetBase().GetRef()
-			if len(matchBranch) > 0 && !matchBranch[branch] {
-				logrus.Errorf("#%d merged to %s, skipping", pr.GetNumber(), branch)
+			if pr.GetDraft() && !opts.IncludeDrafts {
+				logrus.Infof("Skipping PR#%d by %s (draft)", pr.GetNumber(), pr.GetUser().GetLogin())
 				continue
 			}
 
-			if !fullPR.GetMerged() || fullPR.GetMergeCommitSHA() == "" {
-				logrus.Infof("#%d was not merged, skipping", pr.GetNumber())
-				continue
-			}
+			candidates = append(candidates, pr)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
 
-			if pr.GetMergedAt().Before(since) {
-				logrus.Infof("#%d was merged earlier than %s, skipping", pr.GetNumber(), since)
-				continue
-			}
+	return candidates, nil
+}
 
-			result = append(result, fullPR)
+// lowerSet returns a set of lowercased strings
+func lowerSet(ss []string) map[string]bool {
+	set := map[string]bool{}
+	for _, s := range ss {
+		set[strings.ToLower(s)] = true
+	}
+	return set
+}
+
+// hasLabel returns whether any of labels is in a set of lowercased names
+func hasLabel(labels []*github.Label, set map[string]bool) bool {
+	for _, l := range labels {
+		if set[strings.ToLower(l.GetName())] {
+			return true
 		}
 	}
-	logrus.Infof("Returning %d pull request results", len(result))
-	return result, nil
+	return false
 }
 
 // PRSummary is a summary of a single PR
 type PRSummary struct {
-	URL         string
-	Date        string
-	User        string
-	Project     string
-	Type        string
-	Title       string
-	Delta       int
-	Added       int
-	Deleted     int
-	FilesTotal  int
-	Files       string // newline delimited
-	Description string
+	URL                    string   `json:"url" desc:"Pull request URL"`
+	Date                   string   `json:"date" desc:"Merge date (YYYY-MM-DD), or close date if GitHub has no merge timestamp"`
+	User                   string   `json:"user" desc:"Login of the PR author"`
+	Project                string   `json:"project" desc:"Repository name, without the organization"`
+	Type                   string   `json:"type" desc:"Guessed kind of change: docs, tests, backend, frontend, or unknown" when:"PR files are fetched"`
+	Title                  string   `json:"title" desc:"Pull request title"`
+	Delta                  int      `json:"delta" desc:"Added plus Deleted"`
+	SizeBucket             string   `json:"size_bucket" desc:"XS, S, M, L, or XL by Delta, with the limits set by --size-buckets"`
+	Added                  int      `json:"added" desc:"Lines added, excluding generated paths, and paths without --count-extensions, when PR files are fetched"`
+	Deleted                int      `json:"deleted" desc:"Lines deleted, excluding generated paths, and paths without --count-extensions, when PR files are fetched"`
+	FilesTotal             int      `json:"files_total" desc:"Number of files GitHub reports as changed, before exclusions, or the number counted toward the delta with --count-extensions"`
+	Files                  string   `json:"files" desc:"Newline delimited changed paths, excluding generated paths. Past --max-files-listed, a last line counts the rest, ex: (+9900 more)" when:"PR files are fetched"`
+	Description            string   `json:"description" desc:"First 240 characters of the PR body, without HTML comments"`
+	TrackerKeys            string   `json:"tracker_keys" desc:"Comma delimited issue-tracker keys found in the title and body"`
+	MemberAtTime           string   `json:"member_at_time" desc:"true or false for whether User was an org member when merged, empty if unknown" when:"--membership-history"`
+	GeneratedLinesExcluded int      `json:"generated_lines_excluded" desc:"Changed lines excluded because .gitattributes marks their files generated or vendored" when:"--respect-gitattributes"`
+	Labels                 string   `json:"labels" desc:"Comma delimited label names"`
+	Reviewers              string   `json:"reviewers" desc:"Comma delimited logins of everyone but the author who reviewed the PR" when:"reviews are fetched, unless --skip-reviews"`
+	Approvers              string   `json:"approvers" desc:"Comma delimited logins of everyone but the author who approved the PR" when:"reviews are fetched, unless --skip-reviews"`
+	OpenedAt               string   `json:"opened_at" desc:"Creation date (YYYY-MM-DD)"`
+	HoursToMerge           *float64 `json:"hours_to_merge" desc:"Hours from creation to Date, empty if the timestamps are inconsistent"`
+	Draft                  bool     `json:"draft" desc:"Whether the PR was still a draft when closed" when:"--include-drafts"`
+	ClosesIssues           string   `json:"closes_issues" desc:"Comma delimited URLs of issues the title or body says the PR fixes, closes, or resolves"`
+	Branch                 string   `json:"branch" desc:"Base branch the PR was merged into"`
+	MergeSHA               string   `json:"merge_sha" desc:"SHA of the merge commit"`
+	Kind                   string   `json:"kind" desc:"normal, revert, or cherry-pick, guessed from the title"`
+	Association            string   `json:"association" desc:"Author's association with the repository, such as MEMBER, CONTRIBUTOR, or FIRST_TIME_CONTRIBUTOR"`
+	MergedBy               string   `json:"merged_by" desc:"Login of who merged the PR. If a bot merged it, its last approver, when reviews are fetched."`
+	SelfMerged             bool     `json:"self_merged" desc:"Whether MergedBy is the PR's author"`
 }
 
-// PullSummary converts GitHub PR data into a summarized view
-func PullSummary(prs map[*github.PullRequest][]github.CommitFile, since time.Time, until time.Time) ([]*PRSummary, error) {
+// PullSummary converts GitHub PR data into a summarized view. PRs with a nil file list take their delta from the PR itself.
+// PRs should be fetched in full, as MergedPulls does, since listings lack fields such as the merge commit.
+func PullSummary(opts *Options, prs map[*github.PullRequest][]github.CommitFile, since time.Time, until time.Time) ([]*PRSummary, error) {
 	sum := []*PRSummary{}
+	err := PullSummaryTo(opts, prs, since, until, func(s *PRSummary) error {
+		sum = append(sum, s)
+		return nil
+	})
+	return sum, err
+}
+
+// PullSummaryTo is PullSummary, passing each summary to emit as soon as it is made rather than collecting them
+func PullSummaryTo(opts *Options, prs map[*github.PullRequest][]github.CommitFile, since time.Time, until time.Time, emit func(*PRSummary) error) error {
 	seen := map[string]bool{}
 
 	for pr, files := range prs {
@@ -162,7 +392,15 @@ func PullSummary(prs map[*github.PullRequest][]github.CommitFile, since time.Tim
 		}
 		seen[pr.GetHTMLURL()] = true
 
-		_, project := ParseURL(pr.GetHTMLURL())
+		prk := prKind(strings.TrimSpace(pr.GetTitle()))
+		if opts.excludedKind(prk) {
+			logrus.Infof("skipping %s - %s", pr.GetHTMLURL(), prk)
+			continue
+		}
+
+		org, project := ParseURL(pr.GetHTMLURL())
+		keys := opts.trackerKeys(pr.GetTitle(), pr.GetBody())
+		closes := closesIssues(pr.GetHTMLURL(), pr.GetTitle(), pr.GetBody())
 		body := pr.GetBody()
 		body = commentRe.ReplaceAllString(body, "")
 
@@ -172,6 +410,7 @@ func PullSummary(prs map[*github.PullRequest][]github.CommitFile, since time.Tim
 		t := pr.GetMergedAt()
 		// Often the merge timestamp is empty :(
 		if t.IsZero() {
+			digest.Add(digest.SuspectDate, org+"/"+project, pr.GetHTMLURL(), "no merge timestamp, using close time %s", pr.GetClosedAt())
 			t = pr.GetClosedAt()
 		}
 
@@ -185,39 +424,146 @@ func PullSummary(prs map[*github.PullRequest][]github.CommitFile, since time.Tim
 			continue
 		}
 
+		var hours *float64
+		if h := t.Sub(pr.GetCreatedAt()).Hours(); pr.GetCreatedAt().IsZero() || h < 0 {
+			digest.Add(digest.SuspectDate, org+"/"+project, pr.GetHTMLURL(), "created at %s, after it closed at %s", pr.GetCreatedAt(), t)
+		} else {
+			h = math.Round(h*100) / 100
+			hours = &h
+		}
+
 		added := 0
-		paths := []string{}
+		// Vendoring PRs may change thousands of files, so only the first MaxFilesListed are listed
+		var paths strings.Builder
+		listed := 0
 		deleted := 0
+		kind := ""
+
+		if files == nil {
+			added = pr.GetAdditions()
+			deleted = pr.GetDeletions()
+		} else {
+			kind = opts.prType(files)
+		}
+
+		total := pr.GetChangedFiles()
+		if len(opts.CountExtensions) > 0 && files != nil {
+			total = 0
+		}
 
 		for _, f := range files {
+			if opts.MaxFilesListed == 0 || listed < opts.MaxFilesListed {
+				if listed > 0 {
+					paths.WriteByte('\n')
+				}
+				paths.WriteString(f.GetFilename())
+				listed++
+			}
+			if !opts.counted(f.GetFilename()) {
+				continue
+			}
+			if len(opts.CountExtensions) > 0 {
+				total++
+			}
+
 			// These files are mostly auto-generated
-			if truncRe.MatchString(f.GetFilename()) && f.GetAdditions() > 10 {
-				logrus.Infof("truncating %s from %d to %d lines added", f.GetFilename(), f.GetAdditions(), 10)
+			if opts.TruncatePathRe.MatchString(f.GetFilename()) && f.GetAdditions() > 10 {
+				digest.Add(digest.Truncated, org+"/"+project, pr.GetHTMLURL(), "%s truncated from %d to %d lines added", f.GetFilename(), f.GetAdditions(), 10)
 				added += 10
 			} else {
 				logrus.Infof("%s - %d added, %d deleted", f.GetFilename(), f.GetAdditions(), f.GetDeletions())
 				added += f.GetAdditions()
 			}
 			deleted += f.GetDeletions()
-			paths = append(paths, f.GetFilename())
 		}
 		logrus.Infof("%s had %d files to consider - %d added, %d deleted", pr.GetHTMLURL(), len(files), added, deleted)
+		if more := len(files) - listed; more > 0 {
+			fmt.Fprintf(&paths, "\n(+%d more)", more)
+		}
+
+		if delta := added + deleted; delta < opts.MinDelta || (opts.MaxDelta > 0 && delta > opts.MaxDelta) {
+			logrus.Debugf("skipping %s - delta of %d is outside of %d-%d", pr.GetHTMLURL(), delta, opts.MinDelta, opts.MaxDelta)
+			continue
+		}
 
-		sum = append(sum, &PRSummary{
-			URL:         pr.GetHTMLURL(),
-			Date:        t.Format(dateForm),
-			Project:     project,
-			Type:        prType(files),
-			Title:       pr.GetTitle(),
-			User:        pr.GetUser().GetLogin(),
-			Delta:       added + deleted,
-			Added:       added,
-			Deleted:     deleted,
-			FilesTotal:  pr.GetChangedFiles(),
-			Files:       strings.Join(paths, "\n"),
-			Description: body,
+		mergedBy := opts.Canonical(pr.GetMergedBy().GetLogin())
+		err := emit(&PRSummary{
+			URL:          pr.GetHTMLURL(),
+			Date:         t.Format(dateForm),
+			Project:      project,
+			Type:         kind,
+			Title:        pr.GetTitle(),
+			User:         opts.Canonical(pr.GetUser().GetLogin()),
+			Delta:        added + deleted,
+			SizeBucket:   opts.SizeBucket(added + deleted),
+			Added:        added,
+			Deleted:      deleted,
+			FilesTotal:   total,
+			Files:        paths.String(),
+			Description:  body,
+			TrackerKeys:  strings.Join(keys, ","),
+			MemberAtTime: opts.memberAtTime(pr.GetUser().GetLogin(), t.Format(dateForm)),
+			Labels:       labelNames(pr.Labels),
+			OpenedAt:     pr.GetCreatedAt().Format(dateForm),
+			HoursToMerge: hours,
+			Draft:        pr.GetDraft(),
+			ClosesIssues: strings.Join(closes, ","),
+			Branch:       pr.GetBase().GetRef(),
+			MergeSHA:     pr.GetMergeCommitSHA(),
+			Kind:         prk,
+			Association:  pr.GetAuthorAssociation(),
+			MergedBy:     mergedBy,
+			SelfMerged:   mergedBy != "" && strings.EqualFold(mergedBy, opts.Canonical(pr.GetUser().GetLogin())),
 		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CreditBotMerge credits the merge of a PR a bot merged to its last approver, as merge bots act on someone's approval.
+// Approvers are those PullReviewers returns, which leaves out the author.
+func CreditBotMerge(opts *Options, s *PRSummary, approvers []string) {
+	if len(approvers) == 0 || !opts.IsBotLogin(s.MergedBy) {
+		return
+	}
+	s.MergedBy = approvers[len(approvers)-1]
+	s.SelfMerged = strings.EqualFold(s.MergedBy, s.User)
+}
+
+// PullReviewers returns who reviewed and who approved a PR, in the order they first did so, excluding its author
+func PullReviewers(ctx context.Context, c *client.Client, opts *Options, org string, project string, pr *github.PullRequest) ([]string, []string, error) {
+	rs, err := ghcache.PullRequestsListReviews(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	author := opts.Canonical(pr.GetUser().GetLogin())
+	reviewers := []string{}
+	approvers := []string{}
+	seen := map[string]bool{}
+	approved := map[string]bool{}
+
+	for _, r := range rs {
+		login := opts.Canonical(r.GetUser().GetLogin())
+		// Pending reviews have not been submitted yet
+		if login == "" || login == author || r.GetState() == "PENDING" {
+			continue
+		}
+
+		if !seen[login] {
+			seen[login] = true
+			reviewers = append(reviewers, login)
+		}
+
+		if r.GetState() == "APPROVED" && !approved[login] {
+			approved[login] = true
+			approvers = append(approvers, login)
+		}
 	}
 
-	return sum, nil
+	logrus.Debugf("%s reviewers: %v, approvers: %v", pr.GetHTMLURL(), reviewers, approvers)
+	return reviewers, approvers, nil
 }
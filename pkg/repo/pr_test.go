@@ -0,0 +1,269 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+)
+
+var (
+	since  = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until  = time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	merged = time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)
+)
+
+// testPR returns a PR merged within the test window
+func testPR(num int, title string) *github.PullRequest {
+	opened := merged.Add(-48 * time.Hour)
+	return &github.PullRequest{
+		Number:    github.Int(num),
+		Title:     github.String(title),
+		HTMLURL:   github.String(fmt.Sprintf("https://github.com/org/project/pull/%d", num)),
+		User:      &github.User{Login: github.String("alice")},
+		CreatedAt: &opened,
+		MergedAt:  &merged,
+		ClosedAt:  &merged,
+	}
+}
+
+// testFiles returns n changed files, each adding a line
+func testFiles(n int) []github.CommitFile {
+	fs := []github.CommitFile{}
+	for i := 0; i < n; i++ {
+		fs = append(fs, github.CommitFile{Filename: github.String(fmt.Sprintf("pkg/f%05d.go", i)), Additions: github.Int(1)})
+	}
+	return fs
+}
+
+// summarize returns the summary of a single PR
+func summarize(t testing.TB, opts *Options, pr *github.PullRequest, files []github.CommitFile) *PRSummary {
+	t.Helper()
+	sum, err := PullSummary(opts, map[*github.PullRequest][]github.CommitFile{pr: files}, since, until)
+	if err != nil {
+		t.Fatalf("PullSummary() returned error: %v", err)
+	}
+	if len(sum) != 1 {
+		t.Fatalf("PullSummary() returned %d summaries, want 1", len(sum))
+	}
+	return sum[0]
+}
+
+func TestPullSummaryFilesListed(t *testing.T) {
+	tests := []struct {
+		max        int
+		files      int
+		wantListed int
+		wantMore   string
+	}{
+		{max: 100, files: 50, wantListed: 50},
+		{max: 100, files: 100, wantListed: 100},
+		{max: 100, files: 101, wantListed: 100, wantMore: "(+1 more)"},
+		{max: 100, files: 10000, wantListed: 100, wantMore: "(+9900 more)"},
+		{max: 0, files: 250, wantListed: 250},
+	}
+
+	for _, tc := range tests {
+		t.Run(fmt.Sprintf("%d of %d", tc.max, tc.files), func(t *testing.T) {
+			opts := DefaultOptions()
+			opts.MaxFilesListed = tc.max
+			s := summarize(t, opts, testPR(1, "Change files"), testFiles(tc.files))
+
+			lines := strings.Split(s.Files, "\n")
+			more := ""
+			if tc.wantMore != "" {
+				more = lines[len(lines)-1]
+			}
+			if more != tc.wantMore {
+				t.Errorf("last Files line = %q, want %q", more, tc.wantMore)
+			}
+			if got := listedPaths(s.Files); len(got) != tc.wantListed {
+				t.Errorf("listed %d paths, want %d", len(got), tc.wantListed)
+			}
+
+			// Every file still counts
+			if s.Added != tc.files || s.Delta != tc.files {
+				t.Errorf("Added = %d, Delta = %d, want %d", s.Added, s.Delta, tc.files)
+			}
+		})
+	}
+}
+
+func TestListedPaths(t *testing.T) {
+	got := listedPaths("a.go\nb/c.go\n(+12 more)")
+	if want := []string{"a.go", "b/c.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listedPaths() = %v, want %v", got, want)
+	}
+	if got := listedPaths(""); len(got) != 0 {
+		t.Errorf("listedPaths(\"\") = %v, want none", got)
+	}
+}
+
+func BenchmarkPullSummaryHugePR(b *testing.B) {
+	opts := DefaultOptions()
+	pr := testPR(1, "Vendor everything")
+	files := testFiles(10000)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		summarize(b, opts, pr, files)
+	}
+}
+
+// listedPR is a PR as a listing of closed PRs returns it, last updated days after since
+type listedPR struct {
+	num     int
+	updated int
+}
+
+// listServer returns a client of a GitHub API serving pages of closed PRs, merged when last updated, and the
+// numbers of the pages requested
+func listServer(t *testing.T, pages ...[]listedPR) (*client.Client, *[]int) {
+	t.Helper()
+	requested := []int{}
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		mu.Lock()
+		requested = append(requested, page)
+		mu.Unlock()
+
+		if page < len(pages) {
+			next := *r.URL
+			q := next.Query()
+			q.Set("page", strconv.Itoa(page+1))
+			next.RawQuery = q.Encode()
+			w.Header().Set("Link", fmt.Sprintf(`<http://%s%s>; rel="next"`, r.Host, next.RequestURI()))
+		}
+
+		prs := []*github.PullRequest{}
+		if page >= 1 && page <= len(pages) {
+			for _, l := range pages[page-1] {
+				at := since.Add(time.Duration(l.updated) * 24 * time.Hour)
+				pr := testPR(l.num, "Listed")
+				pr.State = github.String("closed")
+				pr.UpdatedAt, pr.MergedAt, pr.ClosedAt = &at, &at, &at
+				prs = append(prs, pr)
+			}
+		}
+		json.NewEncoder(w).Encode(prs)
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := cache.New(cache.Config{Backend: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc := github.NewClient(srv.Client())
+	gc.BaseURL, _ = url.Parse(srv.URL + "/")
+	return &client.Client{Cache: p, GitHubClient: gc}, &requested
+}
+
+// listedNumbers returns the number of each PR listedPulls returns
+func listedNumbers(t *testing.T, c *client.Client) []int {
+	t.Helper()
+	prs, err := listedPulls(context.Background(), c, DefaultOptions(), "org", "project", since, until, nil, nil)
+	if err != nil {
+		t.Fatalf("listedPulls() returned error: %v", err)
+	}
+	nums := []int{}
+	for _, pr := range prs {
+		nums = append(nums, pr.GetNumber())
+	}
+	return nums
+}
+
+func TestListedPullsStopsAtSince(t *testing.T) {
+	tests := []struct {
+		name          string
+		pages         [][]listedPR
+		wantPRs       []int
+		wantRequested []int
+	}{
+		{
+			name:          "older than since on page 2",
+			pages:         [][]listedPR{{{10, 20}, {9, 15}}, {{8, 10}, {7, -1}}, {{6, -2}, {5, -3}}},
+			wantPRs:       []int{10, 9, 8},
+			wantRequested: []int{1, 2},
+		},
+		{
+			name:          "page 1 ends at since",
+			pages:         [][]listedPR{{{10, 20}, {9, -1}}, {{8, -2}}},
+			wantPRs:       []int{10},
+			wantRequested: []int{1},
+		},
+		{
+			name:          "no closed PRs",
+			pages:         [][]listedPR{{}, {{8, 10}}},
+			wantPRs:       []int{},
+			wantRequested: []int{1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, requested := listServer(t, tc.pages...)
+			if got := listedNumbers(t, c); !reflect.DeepEqual(got, tc.wantPRs) {
+				t.Errorf("listedPulls() = %v, want %v", got, tc.wantPRs)
+			}
+			// Pages past since aren't prefetched in vain
+			if !reflect.DeepEqual(*requested, tc.wantRequested) {
+				t.Errorf("requested pages %v, want %v", *requested, tc.wantRequested)
+			}
+		})
+	}
+}
+
+func TestListedPullsDeduplicates(t *testing.T) {
+	// PR 9 was updated while listing, moving it from page 2 to page 1, and PR 8 shifted onto both
+	c, _ := listServer(t,
+		[]listedPR{{9, 21}, {10, 20}, {8, 15}},
+		[]listedPR{{8, 15}, {9, 12}, {7, 10}},
+		[]listedPR{{6, -1}},
+	)
+
+	if got, want := listedNumbers(t, c), []int{9, 10, 8, 7}; !reflect.DeepEqual(got, want) {
+		t.Errorf("listedPulls() = %v, want %v", got, want)
+	}
+}
+
+func TestPullSummaryWithoutFiles(t *testing.T) {
+	pr := testPR(1, "Skipped files")
+	pr.Additions, pr.Deletions = github.Int(70), github.Int(30)
+
+	s := summarize(t, DefaultOptions(), pr, nil)
+	if s.Delta != 100 || s.Added != 70 || s.Deleted != 30 {
+		t.Errorf("PullSummary() delta = %d (+%d -%d), want 100 (+70 -30)", s.Delta, s.Added, s.Deleted)
+	}
+	// Unknown rather than guessed
+	if s.Files != "" || s.Type != "" {
+		t.Errorf("PullSummary() Files = %q, Type = %q, want them empty", s.Files, s.Type)
+	}
+}
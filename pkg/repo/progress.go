@@ -0,0 +1,153 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProgressEvent describes how far along a collection phase is
+type ProgressEvent struct {
+	Phase string // ex: "issues"
+	// Repo is the org/project repository the event is about
+	Repo  string
+	Done  int
+	Total int
+	// Items is a running count of secondary items, such as comments, found so far.
+	// For the "repo" phase, which counts repositories, it is the position of a repository as it starts, or 0 as it finishes.
+	Items int
+}
+
+// Progress is called as collection phases make progress. It may be called from multiple goroutines.
+var Progress = func(ProgressEvent) {}
+
+// RunProgress follows the progress of the current process
+var RunProgress = &Tracker{}
+
+// Report records a progress event in RunProgress, then passes it to Progress
+func Report(e ProgressEvent) {
+	RunProgress.Observe(e)
+	Progress(e)
+}
+
+// Tracker follows progress events, to describe how far along a run is
+type Tracker struct {
+	mu sync.Mutex
+	// repos counts the repositories being collected from, of which reposDone have finished, and repo was the latest
+	// to start, at position repoPos
+	repos     int
+	reposDone int
+	repoPos   int
+	repo      string
+	// phase is the latest event within a repository, and phaseStart when its phase began there
+	phase      ProgressEvent
+	phaseStart time.Time
+}
+
+// Reset forgets all progress, as a new run begins
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.repos, t.reposDone, t.repoPos, t.repo = 0, 0, 0, ""
+	t.phase, t.phaseStart = ProgressEvent{}, time.Time{}
+}
+
+// Observe records a progress event
+func (t *Tracker) Observe(e ProgressEvent) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if e.Phase == "repo" {
+		t.repos = e.Total
+		t.reposDone = e.Done
+		if e.Items > 0 {
+			t.repoPos = e.Items
+			t.repo = e.Repo
+		}
+		return
+	}
+
+	if e.Phase != t.phase.Phase || e.Repo != t.phase.Repo {
+		t.phaseStart = time.Now()
+	}
+	t.phase = e
+}
+
+// Percent estimates how much of the current collection is done, from the repositories finished and the phase in
+// progress. It returns false if nothing has been reported.
+func (t *Tracker) Percent() (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.repos == 0 {
+		return 0, false
+	}
+
+	frac := 0.0
+	if t.phase.Total > 0 && t.reposDone < t.repos {
+		frac = float64(t.phase.Done) / float64(t.phase.Total)
+	}
+
+	p := (float64(t.reposDone) + frac) / float64(t.repos) * 100
+	if p > 100 {
+		p = 100
+	}
+	return p, true
+}
+
+// String describes the progress, ex: "repo 7/52: minikube — 340/812 PRs examined, 118 included, ETA 12m"
+func (t *Tracker) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	parts := []string{}
+	if t.repos > 0 {
+		parts = append(parts, fmt.Sprintf("repo %d/%d: %s", t.repoPos, t.repos, path.Base(t.repo)))
+	}
+
+	e := t.phase
+	if e.Phase != "" {
+		var s string
+		switch e.Phase {
+		case "prs":
+			s = fmt.Sprintf("%d/%d PRs examined, %d included", e.Done, e.Total, e.Items)
+		case "issues":
+			s = fmt.Sprintf("%d/%d issues examined, %d comments", e.Done, e.Total, e.Items)
+		default:
+			s = fmt.Sprintf("%s %d/%d", e.Phase, e.Done, e.Total)
+		}
+
+		if e.Done > 0 && e.Total > e.Done {
+			eta := time.Since(t.phaseStart) * time.Duration(e.Total-e.Done) / time.Duration(e.Done)
+			s += ", ETA " + shortDuration(eta)
+		}
+		parts = append(parts, s)
+	}
+
+	return strings.Join(parts, " — ")
+}
+
+// shortDuration rounds a duration for display, ex: 12m, or 40s if under a minute
+func shortDuration(d time.Duration) string {
+	if d < time.Minute {
+		return d.Round(time.Second).String()
+	}
+	return strings.TrimSuffix(d.Round(time.Minute).String(), "0s")
+}
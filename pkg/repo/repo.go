@@ -20,7 +20,11 @@ import (
 	"strings"
 )
 
-// ParseURL returns the organization and project for a URL or partial path
+// WebURL is the web address of the GitHub instance, such as a GitHub Enterprise Server, for links
+var WebURL = "https://github.com"
+
+// ParseURL returns the organization and project for a URL, of any GitHub instance, or partial path. A partial path
+// may start with a host name, such as github.example.com/org/project.
 func ParseURL(rawURL string) (org string, project string) {
 	u, err := url.Parse(rawURL)
 	if err == nil {
@@ -29,6 +33,10 @@ func ParseURL(rawURL string) (org string, project string) {
 			return p[1], p[2]
 		}
 
+		if len(p) >= 3 && strings.Contains(p[0], ".") {
+			return p[1], p[2]
+		}
+
 		if len(p) != 2 {
 			panic(fmt.Sprintf("%q from %q does not look like a repo", u.Path, rawURL))
 		}
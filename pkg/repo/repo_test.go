@@ -0,0 +1,51 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "testing"
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		in      string
+		org     string
+		project string
+	}{
+		{in: "org/project", org: "org", project: "project"},
+		{in: "https://github.com/org/project", org: "org", project: "project"},
+		{in: "https://github.example.com/org/project/pull/1", org: "org", project: "project"},
+		{in: "github.com/org/project", org: "org", project: "project"},
+		{in: "github.example.com/org/project", org: "org", project: "project"},
+	}
+
+	for _, tc := range tests {
+		org, project := ParseURL(tc.in)
+		if org != tc.org || project != tc.project {
+			t.Errorf("ParseURL(%q) = %q, %q, want %q, %q", tc.in, org, project, tc.org, tc.project)
+		}
+	}
+}
+
+func TestParseURLPanics(t *testing.T) {
+	for _, in := range []string{"project", "org/project/extra"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("ParseURL(%q) didn't panic", in)
+				}
+			}()
+			ParseURL(in)
+		}()
+	}
+}
@@ -24,7 +24,6 @@ import (
 	"unicode"
 
 	"github.com/blevesearch/segment"
-	"github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/pullsheet/pkg/client"
@@ -35,29 +34,35 @@ var notSegmentRe = regexp.MustCompile(`[/-_]+`)
 
 // ReviewSummary a summary of a users reviews on a PR
 type ReviewSummary struct {
-	URL            string
-	Date           string
-	Project        string
-	Reviewer       string
-	PRAuthor       string
-	PRComments     int
-	ReviewComments int
-	Words          int
-	Title          string
+	URL            string   `json:"url" desc:"Reviewed pull request URL"`
+	Date           string   `json:"date" desc:"Date of the reviewer's last comment or review on the PR (YYYY-MM-DD)"`
+	Project        string   `json:"project" desc:"Repository name, without the organization"`
+	Reviewer       string   `json:"reviewer" desc:"Login of the reviewer"`
+	PRAuthor       string   `json:"pr_author" desc:"Login of the PR author"`
+	PRComments     int      `json:"pr_comments" desc:"Conversation comments by the reviewer"`
+	ReviewComments int      `json:"review_comments" desc:"Inline review comments by the reviewer"`
+	Words          int      `json:"words" desc:"Words written by the reviewer across all comments"`
+	Approvals      int      `json:"approvals" desc:"Approving reviews submitted by the reviewer"`
+	ChangeRequests int      `json:"change_requests" desc:"Reviews requesting changes submitted by the reviewer"`
+	ReviewHours    *float64 `json:"review_hours" desc:"Hours from the PR's creation to the reviewer's first submitted review, empty if they never submitted one"`
+	Title          string   `json:"title" desc:"Pull request title"`
+	MemberAtTime   string   `json:"member_at_time" desc:"true or false for whether Reviewer was an org member at Date, empty if unknown" when:"--membership-history"`
 }
 
 type comment struct {
-	Author    string
-	Body      string
-	Review    bool
+	Author string
+	Body   string
+	Review bool
+	// State is the state of a submitted review, such as APPROVED, or empty for a comment
+	State     string
 	CreatedAt time.Time
 }
 
 // MergedReviews returns a list of pull requests in a project (merged only)
-func MergedReviews(ctx context.Context, c *client.Client, org string, project string, since time.Time, until time.Time, users []string) ([]*ReviewSummary, error) {
-	prs, err := MergedPulls(ctx, c, org, project, since, until, nil, nil)
+func MergedReviews(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string) ([]*ReviewSummary, error) {
+	prs, err := MergedPulls(ctx, c, opts, org, project, since, until, nil, nil, nil, nil)
 	if err != nil {
-		return nil, fmt.Errorf("pulls: %v", err)
+		return nil, fmt.Errorf("pulls: %w", err)
 	}
 
 	logrus.Infof("found %d PR's in %s/%s to find reviews for", len(prs), org, project)
@@ -74,27 +79,27 @@ func MergedReviews(ctx context.Context, c *client.Client, org string, project st
 		comments := []comment{}
 
 		// There is wickedness in the GitHub API: PR comments are available via the Issues API, and PR *review* comments are available via the PullRequests API
-		cs, err := ghcache.PullRequestsListComments(ctx, c.Cache, c.GitHubClient, pr.GetMergedAt(), org, project, pr.GetNumber())
+		cs, err := ghcache.PullRequestsListComments(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber())
 		if err != nil {
 			return nil, err
 		}
 
 		for idx := range cs {
-			if isBot(cs[idx].GetUser()) {
+			if opts.ignored(cs[idx].GetUser()) {
 				continue
 			}
 
 			body := strings.TrimSpace(cs[idx].GetBody())
-			comments = append(comments, comment{Author: cs[idx].GetUser().GetLogin(), Body: body, CreatedAt: cs[idx].GetCreatedAt(), Review: true})
+			comments = append(comments, comment{Author: opts.Canonical(cs[idx].GetUser().GetLogin()), Body: body, CreatedAt: cs[idx].GetCreatedAt(), Review: true})
 		}
 
-		is, err := ghcache.IssuesListComments(ctx, c.Cache, c.GitHubClient, pr.GetMergedAt(), org, project, pr.GetNumber())
+		is, err := ghcache.IssuesListComments(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber(), 0)
 		if err != nil {
 			return nil, err
 		}
 
 		for _, i := range is {
-			if isBot(i.GetUser()) {
+			if opts.ignored(i.GetUser()) {
 				continue
 			}
 
@@ -104,7 +109,35 @@ func MergedReviews(ctx context.Context, c *client.Client, org string, project st
 				continue
 			}
 
-			comments = append(comments, comment{Author: i.GetUser().GetLogin(), Body: body, CreatedAt: i.GetCreatedAt(), Review: false})
+			comments = append(comments, comment{Author: opts.Canonical(i.GetUser().GetLogin()), Body: body, CreatedAt: i.GetCreatedAt(), Review: false})
+		}
+
+		rs, err := ghcache.PullRequestsListReviews(ctx, c.Cache, c.GitHubClient, pr.GetUpdatedAt(), org, project, pr.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+
+		// login -> when they first submitted a review of any kind
+		firstReview := map[string]time.Time{}
+
+		for _, r := range rs {
+			if opts.ignored(r.GetUser()) {
+				continue
+			}
+
+			login := opts.Canonical(r.GetUser().GetLogin())
+			if r.GetState() != "PENDING" && !r.GetSubmittedAt().IsZero() {
+				if first, ok := firstReview[login]; !ok || r.GetSubmittedAt().Before(first) {
+					firstReview[login] = r.GetSubmittedAt()
+				}
+			}
+
+			// Inline comments were counted above, so only the verdict of a review matters here
+			state := r.GetState()
+			if state != "APPROVED" && state != "CHANGES_REQUESTED" {
+				continue
+			}
+			comments = append(comments, comment{Author: login, State: state, CreatedAt: r.GetSubmittedAt(), Review: true})
 		}
 
 		for _, c := range comments {
@@ -120,34 +153,47 @@ func MergedReviews(ctx context.Context, c *client.Client, org string, project st
 				continue
 			}
 
-			if c.Author == pr.GetUser().GetLogin() {
+			if c.Author == opts.Canonical(pr.GetUser().GetLogin()) {
 				continue
 			}
 
-			wordCount := wordCount(c.Body)
-
 			if prMap[c.Author] == nil {
 				prMap[c.Author] = &ReviewSummary{
 					URL:      pr.GetHTMLURL(),
-					PRAuthor: pr.GetUser().GetLogin(),
+					PRAuthor: opts.Canonical(pr.GetUser().GetLogin()),
 					Reviewer: c.Author,
 					Project:  project,
 					Title:    strings.TrimSpace(pr.GetTitle()),
 				}
 			}
 
-			if c.Review {
+			if date := c.CreatedAt.Format(dateForm); date > prMap[c.Author].Date {
+				prMap[c.Author].Date = date
+			}
+
+			switch {
+			case c.State == "APPROVED":
+				prMap[c.Author].Approvals++
+				continue
+			case c.State == "CHANGES_REQUESTED":
+				prMap[c.Author].ChangeRequests++
+				continue
+			case c.Review:
 				prMap[c.Author].ReviewComments++
-			} else {
+			default:
 				prMap[c.Author].PRComments++
 			}
 
-			prMap[c.Author].Date = c.CreatedAt.Format(dateForm)
+			wordCount := wordCount(c.Body)
 			prMap[c.Author].Words += wordCount
 			logrus.Infof("%d word comment by %s: %q for %s/%s #%d", wordCount, c.Author, strings.TrimSpace(c.Body), org, project, pr.GetNumber())
 		}
 
 		for _, rs := range prMap {
+			rs.MemberAtTime = opts.memberAtTime(rs.Reviewer, rs.Date)
+			if first, ok := firstReview[rs.Reviewer]; ok {
+				rs.ReviewHours = reviewHours(pr.GetCreatedAt(), first)
+			}
 			reviews = append(reviews, rs)
 		}
 	}
@@ -155,6 +201,16 @@ func MergedReviews(ctx context.Context, c *client.Client, org string, project st
 	return reviews, err
 }
 
+// reviewHours returns the hours from a PR's creation to a review, or nil if the timestamps are inconsistent. Review
+// requests would be a fairer start, but the timeline API does not say whose review was requested.
+func reviewHours(created time.Time, reviewed time.Time) *float64 {
+	if created.IsZero() || reviewed.Before(created) {
+		return nil
+	}
+	h := reviewed.Sub(created).Hours()
+	return &h
+}
+
 // wordCount counts words in a string, irrespective of language
 func wordCount(s string) int {
 	// Don't count certain items, like / or - as word segments
@@ -171,27 +227,3 @@ func wordCount(s string) int {
 	}
 	return words
 }
-
-func isBot(u *github.User) bool {
-	if u.GetType() == "bot" {
-		return true
-	}
-
-	if strings.Contains(u.GetBio(), "stale issues") {
-		return true
-	}
-
-	if strings.HasSuffix(u.GetLogin(), "bot") {
-		return true
-	}
-
-	if strings.Contains(u.GetLogin(), "[bot]") {
-		return true
-	}
-
-	if strings.HasPrefix(u.GetLogin(), "codecov") || strings.HasPrefix(u.GetLogin(), "Travis") {
-		return true
-	}
-
-	return false
-}
@@ -0,0 +1,33 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReviewHours(t *testing.T) {
+	created := time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	if h := reviewHours(created, created.Add(90*time.Minute)); h == nil || *h != 1.5 {
+		t.Errorf("reviewHours() = %v, want 1.5", h)
+	}
+	if h := reviewHours(created, created.Add(-time.Minute)); h != nil {
+		t.Errorf("reviewHours() of a review before creation = %v, want nil", *h)
+	}
+	if h := reviewHours(time.Time{}, created); h != nil {
+		t.Errorf("reviewHours() without a creation time = %v, want nil", *h)
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+)
+
+const (
+	// searchCap is the most results GitHub returns for a search, however many match
+	searchCap = 1000
+	// maxSearchAuthors is the most users searched for by author. Beyond it, PRs are filtered by user after searching.
+	maxSearchAuthors = 5
+	// searchTime is the form of times in search qualifiers
+	searchTime = "2006-01-02T15:04:05Z"
+)
+
+// MaxSearchQuery is the longest query GitHub search accepts
+const MaxSearchQuery = 256
+
+// searchedPulls searches for a project's PRs merged within the window, returning those which pass the filters that
+// need no further API calls. Windows matching more PRs than a search returns are split in half until none do.
+func searchedPulls(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, matchUser map[string]bool) ([]*github.PullRequest, error) {
+	q := fmt.Sprintf("repo:%s/%s is:pr is:merged", org, project)
+	if len(users) > 0 && len(users) <= maxSearchAuthors {
+		for _, u := range users {
+			q += " author:" + u
+		}
+	}
+
+	logrus.Infof("Searching for pull requests in %s/%s: %s", org, project, q)
+	is, err := searchWindow(ctx, c, q, since.UTC(), until.UTC())
+	if err != nil {
+		return nil, err
+	}
+
+	prs := []*github.PullRequest{}
+	for _, i := range is {
+		if len(matchUser) > 0 && !matchUser[strings.ToLower(opts.Canonical(i.GetUser().GetLogin()))] {
+			continue
+		}
+		if opts.ignored(i.GetUser()) {
+			continue
+		}
+
+		// Enough to fetch the PR in full, which is where everything else is read from
+		prs = append(prs, &github.PullRequest{
+			Number:    i.Number,
+			Title:     i.Title,
+			User:      i.User,
+			State:     i.State,
+			HTMLURL:   i.HTMLURL,
+			UpdatedAt: i.UpdatedAt,
+			ClosedAt:  i.ClosedAt,
+		})
+	}
+
+	logrus.Infof("Found %d merged pull requests in %s/%s", len(prs), org, project)
+	return prs, nil
+}
+
+// searchWindow returns the results of a search for PRs merged from since until until, newest first, splitting the
+// window if it matches more than a search returns
+func searchWindow(ctx context.Context, c *client.Client, q string, since time.Time, until time.Time) ([]*github.Issue, error) {
+	wq := fmt.Sprintf("%s merged:%s..%s", q, since.Format(searchTime), until.Format(searchTime))
+	opts := &github.SearchOptions{Sort: "updated", Order: "desc", ListOptions: github.ListOptions{PerPage: 100}}
+
+	is := []*github.Issue{}
+	for page := 1; page != 0; {
+		opts.Page = page
+		r, resp, err := c.GitHubClient.Search.Issues(ctx, wq, opts)
+		if err != nil {
+			return nil, fmt.Errorf("search %q: %w", wq, err)
+		}
+
+		// Times are to the second, so a second's window can't be split further
+		if r.GetTotal() > searchCap && until.Sub(since) > time.Second {
+			mid := since.Add(until.Sub(since) / 2).Truncate(time.Second)
+			logrus.Infof("%d pull requests merged %s..%s, more than a search returns: splitting at %s", r.GetTotal(), since.Format(searchTime), until.Format(searchTime), mid.Format(searchTime))
+
+			newer, err := searchWindow(ctx, c, q, mid.Add(time.Second), until)
+			if err != nil {
+				return nil, err
+			}
+			older, err := searchWindow(ctx, c, q, since, mid)
+			if err != nil {
+				return nil, err
+			}
+			return append(newer, older...), nil
+		}
+
+		if r.GetIncompleteResults() {
+			logrus.Warningf("GitHub timed out searching %q, so results may be incomplete", wq)
+		}
+
+		is = append(is, r.Issues...)
+		page = resp.NextPage
+	}
+
+	return is, nil
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import "fmt"
+
+// SizeBuckets are the names PRs are bucketed into by size, smallest first
+var SizeBuckets = []string{"XS", "S", "M", "L", "XL"}
+
+// SizeBucket returns the size bucket of a PR with the given delta
+func (o *Options) SizeBucket(delta int) string {
+	for i, limit := range o.SizeLimits {
+		if delta <= limit {
+			return SizeBuckets[i]
+		}
+	}
+	return SizeBuckets[len(SizeBuckets)-1]
+}
+
+// ValidSizeLimits returns an error unless limits has a positive, increasing limit for each size bucket but the last
+func ValidSizeLimits(limits []int) error {
+	if len(limits) != len(SizeBuckets)-1 {
+		return fmt.Errorf("%d size limits given, need %d: the largest delta of each of %v", len(limits), len(SizeBuckets)-1, SizeBuckets[:len(SizeBuckets)-1])
+	}
+	for i, l := range limits {
+		if l < 0 || (i > 0 && l <= limits[i-1]) {
+			return fmt.Errorf("size limits must be increasing and not negative: %v", limits)
+		}
+	}
+	return nil
+}
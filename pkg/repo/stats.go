@@ -0,0 +1,49 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// Stats counts API calls made and avoided during a run
+type Stats struct {
+	FileListsFetched int64
+	FileListsSkipped int64
+}
+
+// RunStats are the stats for the current process
+var RunStats = &Stats{}
+
+// FileListFetched records a PR file listing
+func (s *Stats) FileListFetched() {
+	atomic.AddInt64(&s.FileListsFetched, 1)
+}
+
+// FileListSkipped records a PR file listing avoided by the fetch plan
+func (s *Stats) FileListSkipped() {
+	atomic.AddInt64(&s.FileListsSkipped, 1)
+}
+
+// String returns a one-line summary, or an empty string if nothing was counted
+func (s *Stats) String() string {
+	fetched := atomic.LoadInt64(&s.FileListsFetched)
+	skipped := atomic.LoadInt64(&s.FileListsSkipped)
+	if fetched+skipped == 0 {
+		return ""
+	}
+	return fmt.Sprintf("PR file lists: %d fetched, %d skipped", fetched, skipped)
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"sort"
+	"strings"
+)
+
+// Untracked is the ticket key used for PRs which reference no tracker key
+const Untracked = "(untracked)"
+
+// DefaultTrackerKeyPattern matches issue-tracker keys such as PROJ-1234
+const DefaultTrackerKeyPattern = `[A-Z][A-Z0-9]+-\d+`
+
+// TicketSummary is a summary of the PRs referencing a single tracker key
+type TicketSummary struct {
+	Key          string `json:"key" desc:"Issue-tracker key, or (untracked) for PRs referencing none"`
+	PRs          int    `json:"prs" desc:"Merged PRs referencing the key"`
+	Delta        int    `json:"delta" desc:"Total delta of those PRs"`
+	Contributors string `json:"contributors" desc:"Comma delimited authors of those PRs"`
+}
+
+// trackerKeys returns the deduplicated tracker keys found in a series of strings
+func (o *Options) trackerKeys(texts ...string) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, t := range texts {
+		for _, k := range o.TrackerKeyRe.FindAllString(t, -1) {
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// TicketSummaries aggregates PRs by tracker key, sorted by delta.
+// It also returns the fraction of PRs which referenced no tracker key.
+func TicketSummaries(prs []*PRSummary) ([]*TicketSummary, float64) {
+	tMap := map[string]*TicketSummary{}
+	contributors := map[string]map[string]bool{}
+	untracked := 0
+
+	for _, pr := range prs {
+		keys := []string{}
+		if pr.TrackerKeys != "" {
+			keys = strings.Split(pr.TrackerKeys, ",")
+		}
+		if len(keys) == 0 {
+			untracked++
+			keys = []string{Untracked}
+		}
+
+		for _, k := range keys {
+			if tMap[k] == nil {
+				tMap[k] = &TicketSummary{Key: k}
+				contributors[k] = map[string]bool{}
+			}
+			tMap[k].PRs++
+			tMap[k].Delta += pr.Delta
+			contributors[k][pr.User] = true
+		}
+	}
+
+	result := []*TicketSummary{}
+	for k, t := range tMap {
+		users := []string{}
+		for u := range contributors[k] {
+			users = append(users, u)
+		}
+		sort.Strings(users)
+		t.Contributors = strings.Join(users, ",")
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Delta != result[j].Delta {
+			return result[i].Delta > result[j].Delta
+		}
+		return result[i].Key < result[j].Key
+	})
+
+	share := 0.0
+	if len(prs) > 0 {
+		share = float64(untracked) / float64(len(prs))
+	}
+
+	return result, share
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+func TestTrackerKeys(t *testing.T) {
+	o := DefaultOptions()
+	tests := []struct {
+		texts []string
+		want  []string
+	}{
+		{texts: []string{"PROJ-12: fix the thing", "Also fixes PROJ-7 and PROJ-12"}, want: []string{"PROJ-12", "PROJ-7"}},
+		{texts: []string{"AB2-1 and ab-3 and A-4 and UTF-8"}, want: []string{"AB2-1", "UTF-8"}},
+		{texts: []string{"no keys here", ""}, want: []string{}},
+	}
+
+	for _, tc := range tests {
+		if got := o.trackerKeys(tc.texts...); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("trackerKeys(%q) = %v, want %v", tc.texts, got, tc.want)
+		}
+	}
+
+	o.TrackerKeyRe = regexp.MustCompile(`#\d+`)
+	if got := o.trackerKeys("Fixes #12"); !reflect.DeepEqual(got, []string{"#12"}) {
+		t.Errorf("trackerKeys() with a custom pattern = %v, want [#12]", got)
+	}
+}
+
+func TestTicketSummaries(t *testing.T) {
+	prs := []*PRSummary{
+		{User: "alice", Delta: 10, TrackerKeys: "PROJ-1"},
+		{User: "bob", Delta: 5, TrackerKeys: "PROJ-1,PROJ-2"},
+		{User: "alice", Delta: 100},
+		{User: "carol", Delta: 1, TrackerKeys: "PROJ-3"},
+	}
+
+	ts, untracked := TicketSummaries(prs)
+	got := []TicketSummary{}
+	for _, ts := range ts {
+		got = append(got, *ts)
+	}
+	want := []TicketSummary{
+		{Key: Untracked, PRs: 1, Delta: 100, Contributors: "alice"},
+		{Key: "PROJ-1", PRs: 2, Delta: 15, Contributors: "alice,bob"},
+		{Key: "PROJ-2", PRs: 1, Delta: 5, Contributors: "bob"},
+		{Key: "PROJ-3", PRs: 1, Delta: 1, Contributors: "carol"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("TicketSummaries() = %+v, want %+v", got, want)
+	}
+	if untracked != 0.25 {
+		t.Errorf("untracked share = %v, want 0.25", untracked)
+	}
+
+	if ts, untracked := TicketSummaries(nil); len(ts) != 0 || untracked != 0 {
+		t.Errorf("TicketSummaries(nil) = %v, %v, want none", ts, untracked)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/ghcache"
+)
+
+// toggleWindow is how quickly a label must be removed after being added to be considered a toggle
+const toggleWindow = time.Minute
+
+// triageEvents are the timeline events which count as triage
+var triageEvents = map[string]bool{
+	"labeled":      true,
+	"unlabeled":    true,
+	"milestoned":   true,
+	"demilestoned": true,
+}
+
+// TriageSummary is a summary of a single triage action on an issue
+type TriageSummary struct {
+	URL          string `json:"url" desc:"Issue URL"`
+	Date         string `json:"date" desc:"Date of the action (YYYY-MM-DD)"`
+	Actor        string `json:"actor" desc:"Login of the user who acted"`
+	Action       string `json:"action" desc:"Timeline event: labeled, unlabeled, milestoned, or demilestoned"`
+	Label        string `json:"label" desc:"Label or milestone name"`
+	Project      string `json:"project" desc:"Repository name, without the organization"`
+	MemberAtTime string `json:"member_at_time" desc:"true or false for whether Actor was an org member at Date, empty if unknown" when:"--membership-history"`
+}
+
+// IssueTriage returns a list of labeling and milestone actions on issues within a project
+func IssueTriage(ctx context.Context, c *client.Client, opts *Options, org string, project string, since time.Time, until time.Time, users []string, includeSelf bool, collapseToggles bool) ([]*TriageSummary, error) {
+	is, err := issues(ctx, c, opts, org, project, since, until, nil, "all")
+	if err != nil {
+		return nil, fmt.Errorf("issues: %w", err)
+	}
+
+	logrus.Infof("found %d issues to check triage events on", len(is))
+
+	matchUser := map[string]bool{}
+	for _, u := range users {
+		matchUser[strings.ToLower(u)] = true
+	}
+
+	result := []*TriageSummary{}
+	for _, i := range is {
+		es, err := ghcache.IssuesListTimeline(ctx, c.Cache, c.GitHubClient, i.GetUpdatedAt(), org, project, i.GetNumber())
+		if err != nil {
+			return nil, err
+		}
+
+		if collapseToggles {
+			es = collapseLabelToggles(es)
+		}
+
+		for _, e := range es {
+			if !triageEvents[e.GetEvent()] {
+				continue
+			}
+
+			// Events are filtered by their own timestamps, as issues may be triaged long after creation
+			if e.GetCreatedAt().Before(since) || e.GetCreatedAt().After(until) {
+				continue
+			}
+
+			actor := opts.Canonical(e.GetActor().GetLogin())
+			if len(matchUser) > 0 && !matchUser[strings.ToLower(actor)] {
+				continue
+			}
+
+			if opts.ignored(e.GetActor()) {
+				continue
+			}
+
+			if !includeSelf && strings.EqualFold(actor, opts.Canonical(i.GetUser().GetLogin())) {
+				continue
+			}
+
+			label := e.GetLabel().GetName()
+			if strings.HasSuffix(e.GetEvent(), "milestoned") {
+				label = e.GetMilestone().GetTitle()
+			}
+
+			result = append(result, &TriageSummary{
+				URL:          i.GetHTMLURL(),
+				Date:         e.GetCreatedAt().Format(dateForm),
+				Actor:        actor,
+				Action:       e.GetEvent(),
+				Label:        label,
+				Project:      project,
+				MemberAtTime: opts.memberAtTime(actor, e.GetCreatedAt().Format(dateForm)),
+			})
+		}
+	}
+
+	logrus.Infof("Returning %d triage events", len(result))
+	return result, nil
+}
+
+// collapseLabelToggles removes pairs of events where a label was added and then removed by the same actor within a minute
+func collapseLabelToggles(es []*github.Timeline) []*github.Timeline {
+	drop := map[int]bool{}
+
+	for i, e := range es {
+		if e.GetEvent() != "labeled" || drop[i] {
+			continue
+		}
+
+		for j := i + 1; j < len(es); j++ {
+			o := es[j]
+			if o.GetCreatedAt().Sub(e.GetCreatedAt()) > toggleWindow {
+				break
+			}
+			if drop[j] || o.GetEvent() != "unlabeled" {
+				continue
+			}
+			if o.GetLabel().GetName() == e.GetLabel().GetName() && o.GetActor().GetLogin() == e.GetActor().GetLogin() {
+				logrus.Debugf("collapsing %q label toggle by %s", e.GetLabel().GetName(), e.GetActor().GetLogin())
+				drop[i] = true
+				drop[j] = true
+				break
+			}
+		}
+	}
+
+	result := []*github.Timeline{}
+	for i, e := range es {
+		if !drop[i] {
+			result = append(result, e)
+		}
+	}
+	return result
+}
@@ -0,0 +1,107 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package repo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+)
+
+// event returns a label event by actor, seconds after a fixed time
+func event(kind string, label string, actor string, secs int) *github.Timeline {
+	at := time.Date(2021, 3, 1, 0, 0, secs, 0, time.UTC)
+	return &github.Timeline{
+		Event:     github.String(kind),
+		Label:     &github.Label{Name: github.String(label)},
+		Actor:     &github.User{Login: github.String(actor)},
+		CreatedAt: &at,
+	}
+}
+
+func TestCollapseLabelToggles(t *testing.T) {
+	tests := []struct {
+		name string
+		es   []*github.Timeline
+		want []int
+	}{
+		{
+			name: "toggle",
+			es:   []*github.Timeline{event("labeled", "bug", "alice", 0), event("unlabeled", "bug", "alice", 30)},
+			want: []int{},
+		},
+		{
+			name: "after the window",
+			es:   []*github.Timeline{event("labeled", "bug", "alice", 0), event("unlabeled", "bug", "alice", 61)},
+			want: []int{0, 1},
+		},
+		{
+			name: "other actor",
+			es:   []*github.Timeline{event("labeled", "bug", "alice", 0), event("unlabeled", "bug", "bob", 10)},
+			want: []int{0, 1},
+		},
+		{
+			name: "other label",
+			es:   []*github.Timeline{event("labeled", "bug", "alice", 0), event("unlabeled", "feature", "alice", 10)},
+			want: []int{0, 1},
+		},
+		{
+			name: "events between",
+			es: []*github.Timeline{
+				event("labeled", "bug", "alice", 0),
+				event("labeled", "feature", "alice", 5),
+				event("commented", "", "bob", 10),
+				event("unlabeled", "bug", "alice", 20),
+			},
+			want: []int{1, 2},
+		},
+		{
+			// Each removal pairs with one addition
+			name: "twice",
+			es: []*github.Timeline{
+				event("labeled", "bug", "alice", 0),
+				event("unlabeled", "bug", "alice", 5),
+				event("labeled", "bug", "alice", 10),
+				event("unlabeled", "bug", "alice", 15),
+				event("labeled", "bug", "alice", 20),
+			},
+			want: []int{4},
+		},
+		{
+			name: "removed first",
+			es:   []*github.Timeline{event("unlabeled", "bug", "alice", 0), event("labeled", "bug", "alice", 10)},
+			want: []int{0, 1},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			index := map[*github.Timeline]int{}
+			for i, e := range tc.es {
+				index[e] = i
+			}
+
+			got := []int{}
+			for _, e := range collapseLabelToggles(tc.es) {
+				got = append(got, index[e])
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("collapseLabelToggles() kept events %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
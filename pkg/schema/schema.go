@@ -0,0 +1,146 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package schema describes the columns of each output type, from the desc and
+// when struct tags of the summary types.
+package schema
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// Version is bumped whenever a column is removed, renamed, or changes meaning
+const Version = 1
+
+// Column describes a single output column
+type Column struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description"`
+	// Populated is "always", or the condition under which the column is filled in
+	Populated string `json:"populated"`
+}
+
+// Table describes an output type
+type Table struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Command string   `json:"command"`
+	Columns []Column `json:"columns"`
+}
+
+// Document is the schema of every output type
+type Document struct {
+	Version int     `json:"version"`
+	Tables  []Table `json:"tables"`
+}
+
+// outputs are the summary types written by each command
+var outputs = []struct {
+	name    string
+	command string
+	v       interface{}
+}{
+	{"prs", "pullsheet prs", repo.PRSummary{}},
+	{"reviews", "pullsheet reviews", repo.ReviewSummary{}},
+	{"issues", "pullsheet issues", repo.IssueSummary{}},
+	{"issue-comments", "pullsheet issue-comments", repo.CommentSummary{}},
+	{"triage", "pullsheet triage", repo.TriageSummary{}},
+	{"codeowners", "pullsheet codeowners", repo.OwnershipSummary{}},
+	{"tickets", "pullsheet tickets", repo.TicketSummary{}},
+	{"warnings", "pullsheet export", digest.Entry{}},
+}
+
+// Describe returns the schema of every output type
+func Describe() Document {
+	d := Document{Version: Version}
+	for _, o := range outputs {
+		d.Tables = append(d.Tables, describe(o.name, o.command, o.v))
+	}
+	return d
+}
+
+// describe returns the schema of a single output type
+func describe(name string, command string, v interface{}) Table {
+	t := reflect.TypeOf(v)
+	tbl := Table{Name: name, Type: t.Name(), Command: command}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		populated := f.Tag.Get("when")
+		if populated == "" {
+			populated = "always"
+		}
+
+		tbl.Columns = append(tbl.Columns, Column{
+			Name:        columnName(f),
+			Type:        f.Type.String(),
+			Description: f.Tag.Get("desc"),
+			Populated:   populated,
+		})
+	}
+
+	return tbl
+}
+
+// columnName returns the header gocsv writes for a field
+func columnName(f reflect.StructField) string {
+	if n := strings.Split(f.Tag.Get("csv"), ",")[0]; n != "" {
+		return n
+	}
+	return f.Name
+}
+
+// Validate returns an error if any exported field of an output type lacks a description
+func Validate() error {
+	missing := []string{}
+	for _, t := range Describe().Tables {
+		for _, c := range t.Columns {
+			if c.Description == "" {
+				missing = append(missing, t.Type+"."+c.Name)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("output fields missing a desc tag: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// Markdown renders the schema as Markdown tables
+func Markdown(d Document) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# pullsheet output schema (version %d)\n", d.Version)
+
+	for _, t := range d.Tables {
+		fmt.Fprintf(&sb, "\n## %s\n\nWritten by `%s`.\n\n", t.Name, t.Command)
+		sb.WriteString("| Column | Type | Populated | Description |\n")
+		sb.WriteString("|---|---|---|---|\n")
+		for _, c := range t.Columns {
+			fmt.Fprintf(&sb, "| %s | %s | %s | %s |\n", c.Name, c.Type, strings.ReplaceAll(c.Populated, "|", `\|`), strings.ReplaceAll(c.Description, "|", `\|`))
+		}
+	}
+
+	return sb.String()
+}
@@ -0,0 +1,69 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package schema
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestValidate fails when an output field is added without a description, before a command refuses to start
+func TestValidate(t *testing.T) {
+	if err := Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	type summary struct {
+		URL     string `csv:"url,omitempty" desc:"Link to the item"`
+		Delta   int    `desc:"Lines changed" when:"--full-files"`
+		private string
+	}
+
+	got := describe("things", "pullsheet things", summary{})
+	want := Table{
+		Name:    "things",
+		Type:    "summary",
+		Command: "pullsheet things",
+		Columns: []Column{
+			{Name: "url", Type: "string", Description: "Link to the item", Populated: "always"},
+			{Name: "Delta", Type: "int", Description: "Lines changed", Populated: "--full-files"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("describe() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	d := Document{Version: 3, Tables: []Table{{
+		Name:    "things",
+		Command: "pullsheet things",
+		Columns: []Column{{Name: "Kind", Type: "string", Description: "bug|feature", Populated: "a|b"}},
+	}}}
+
+	got := Markdown(d)
+	for _, want := range []string{
+		"# pullsheet output schema (version 3)",
+		"Written by `pullsheet things`.",
+		`| Kind | string | a\|b | bug\|feature |`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Markdown() = %q, want it to contain %q", got, want)
+		}
+	}
+}
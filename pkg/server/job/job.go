@@ -38,6 +38,33 @@ type Opts struct {
 	Since    time.Time
 	Until    time.Time
 	Title    string
+
+	// ExcludeRepos are org/name glob patterns of repositories to leave out of Repos
+	ExcludeRepos []string
+
+	// Labels restricts PRs to those with at least one of these labels, and ExcludeLabels leaves out those with any of these
+	Labels        []string
+	ExcludeLabels []string
+
+	// Leaderboard are the options the job renders pages with, and its Repo those its data is collected with
+	Leaderboard *leaderboard.Options
+
+	// MaxCommentsPerIssue caps how many comments are fetched per issue, or 0 for unlimited
+	MaxCommentsPerIssue int
+
+	// IssueEvents enables fetching of issue triage events
+	IssueEvents       bool
+	IncludeSelfTriage bool
+	CollapseToggles   bool
+
+	// Codeowners enables reporting of CODEOWNERS review coverage
+	Codeowners bool
+
+	// FullFiles fetches the changed files of every PR, rather than only when needed
+	FullFiles bool
+
+	// NewContributors looks for authors with no PRs merged before Since
+	NewContributors bool
 }
 
 func New(opts *Opts) *Job {
@@ -51,19 +78,37 @@ func New(opts *Opts) *Job {
 }
 
 func (j *Job) Render() (string, error) {
-	d := data{
-		prs:      j.u.getPRs(),
-		reviews:  j.u.getReviews(),
-		issues:   j.u.getIssues(),
-		comments: j.u.getComments(),
-	}
+	return j.opts.Leaderboard.Render(j.opts.Title, j.opts.Since, j.opts.Until, j.opts.Users, j.data())
+}
 
-	result, err := leaderboard.Render(j.opts.Title, j.opts.Since, j.opts.Until, j.opts.Users, d.prs, d.reviews, d.issues, d.comments)
-	if err != nil {
-		return "", err
+// RenderUser returns the page of a user's activity within the job's data
+func (j *Job) RenderUser(login string) (string, error) {
+	return j.opts.Leaderboard.RenderUser(j.opts.Title, j.opts.Since, j.opts.Until, login, j.data())
+}
+
+// JSON returns the job's leaderboard charts and tables as JSON
+func (j *Job) JSON() ([]byte, error) {
+	return j.opts.Leaderboard.RenderJSON(j.opts.Title, j.opts.Since, j.opts.Until, j.opts.Users, j.data())
+}
+
+// data returns the job's latest data
+func (j *Job) data() leaderboard.Data {
+	return leaderboard.Data{
+		PRs:       j.u.getPRs(),
+		Reviews:   j.u.getReviews(),
+		Issues:    j.u.getIssues(),
+		Comments:  j.u.getComments(),
+		Triage:    j.u.getTriage(),
+		Ownership: j.u.getOwnership(),
+		Warnings:  j.u.getWarnings(),
+
+		NewContributors: j.u.getNewContributors(),
 	}
+}
 
-	return result, nil
+// Status describes the progress of the job's updates, such as "waiting for rate limit until 15:04"
+func (j *Job) Status(cl *client.Client) string {
+	return j.u.status(cl)
 }
 
 func (j *Job) Update(ctx context.Context, cl *client.Client) {
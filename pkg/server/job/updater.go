@@ -16,9 +16,12 @@ package job
 
 import (
 	"context"
+	"fmt"
 	"sync"
+	"time"
 
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/digest"
 	"github.com/google/pullsheet/pkg/repo"
 	"github.com/google/pullsheet/pkg/summary"
 )
@@ -26,13 +29,22 @@ import (
 type updater struct {
 	mu   *sync.Mutex
 	data data
+
+	// updating is whether an update is running, and updated and err when the last one finished, and how
+	updating bool
+	updated  time.Time
+	err      error
 }
 
 type data struct {
-	prs      []*repo.PRSummary
-	reviews  []*repo.ReviewSummary
-	issues   []*repo.IssueSummary
-	comments []*repo.CommentSummary
+	prs       []*repo.PRSummary
+	reviews   []*repo.ReviewSummary
+	issues    []*repo.IssueSummary
+	comments  []*repo.CommentSummary
+	triage    []*repo.TriageSummary
+	ownership []*repo.OwnershipSummary
+	warnings  []digest.Group
+	newcomers []*repo.NewContributorSummary
 }
 
 func (u *updater) getPRs() []*repo.PRSummary {
@@ -63,37 +75,156 @@ func (u *updater) getComments() []*repo.CommentSummary {
 	return u.data.comments
 }
 
+func (u *updater) getTriage() []*repo.TriageSummary {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.data.triage
+}
+
+func (u *updater) getOwnership() []*repo.OwnershipSummary {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.data.ownership
+}
+
+func (u *updater) getNewContributors() []*repo.NewContributorSummary {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.data.newcomers
+}
+
+func (u *updater) getWarnings() []digest.Group {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return u.data.warnings
+}
+
+// status describes the updater's progress, noting when it is waiting for the rate limit to reset
+func (u *updater) status(cl *client.Client) string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	switch {
+	case u.updating:
+		if w := cl.Budget.Status().WaitingUntil; !w.IsZero() {
+			return fmt.Sprintf("waiting for rate limit until %s", w.Format("15:04"))
+		}
+		if p, ok := repo.RunProgress.Percent(); ok {
+			return fmt.Sprintf("updating, %.0f%% complete: %s", p, repo.RunProgress.String())
+		}
+		return "updating"
+	case u.err != nil:
+		return fmt.Sprintf("update failed at %s: %v", u.updated.Format("15:04"), u.err)
+	case u.updated.IsZero():
+		return "not started"
+	default:
+		return fmt.Sprintf("updated at %s", u.updated.Format("15:04"))
+	}
+}
+
 func (u *updater) updateData(ctx context.Context, cl *client.Client, opts *Opts) error {
+	u.mu.Lock()
+	u.updating = true
+	u.mu.Unlock()
+	repo.RunProgress.Reset()
+
+	err := u.fetch(ctx, cl, opts)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.updating = false
+	u.updated = time.Now()
+	u.err = err
+	return err
+}
+
+// fetch queries the job's data, replacing what the updater holds
+func (u *updater) fetch(ctx context.Context, cl *client.Client, opts *Opts) error {
+	// Each update reports only its own warnings
+	digest.Default.Reset()
+
+	if len(opts.ExcludeRepos) > 0 {
+		filtered := *opts
+		filtered.Repos = []string{}
+		for _, r := range opts.Repos {
+			if !repo.RepoExcluded(r, opts.ExcludeRepos) {
+				filtered.Repos = append(filtered.Repos, r)
+			}
+		}
+		opts = &filtered
+	}
+
 	// Query data
-	prs, err := summary.Pulls(ctx, cl, opts.Repos, opts.Users, opts.Branches, opts.Since, opts.Until)
+	ro := opts.Leaderboard.Repo
+	plan := summary.FetchPlan{Files: opts.FullFiles || opts.Codeowners || opts.Leaderboard.NeedsFiles()}
+	prs, err := summary.PullsWithPlan(ctx, cl, ro, opts.Repos, opts.Users, opts.Branches, opts.Labels, opts.ExcludeLabels, opts.Since, opts.Until, plan)
 	if err != nil {
 		return err
 	}
 
-	reviews, err := summary.Reviews(ctx, cl, opts.Repos, opts.Users, opts.Since, opts.Until)
+	var newcomers []*repo.NewContributorSummary
+	if opts.NewContributors {
+		newcomers, err = repo.NewContributors(ctx, cl, ro, prs, opts.Since)
+		if err != nil {
+			return err
+		}
+	}
+
+	reviews, err := summary.Reviews(ctx, cl, ro, opts.Repos, opts.Users, opts.Since, opts.Until)
 	if err != nil {
 		return err
 	}
 
-	issues, err := summary.Issues(ctx, cl, opts.Repos, opts.Users, opts.Since, opts.Until)
+	issues, err := summary.Issues(ctx, cl, ro, opts.Repos, opts.Users, opts.Since, opts.Until, summary.IssuesClosed)
 	if err != nil {
 		return err
 	}
 
-	comments, err := summary.Comments(ctx, cl, opts.Repos, opts.Users, opts.Since, opts.Until)
+	comments, err := summary.Comments(ctx, cl, ro, opts.Repos, opts.Users, opts.Since, opts.Until, opts.MaxCommentsPerIssue)
 	if err != nil {
 		return err
 	}
 
+	if ro.OwnedBy != "" {
+		reviews, comments, err = summary.InheritOwnership(ctx, cl, ro, opts.Repos, opts.Users, opts.Branches, opts.Since, opts.Until, prs, reviews, comments)
+		if err != nil {
+			return err
+		}
+	}
+
+	var triage []*repo.TriageSummary
+	if opts.IssueEvents {
+		triage, err = summary.Triage(ctx, cl, ro, opts.Repos, opts.Users, opts.Since, opts.Until, opts.IncludeSelfTriage, opts.CollapseToggles)
+		if err != nil {
+			return err
+		}
+	}
+
+	var ownership []*repo.OwnershipSummary
+	if opts.Codeowners {
+		ownership, err = summary.Ownership(ctx, cl, opts.Repos, opts.Since, prs, reviews)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Update data in Job
 	u.mu.Lock()
 	defer u.mu.Unlock()
 
 	u.data = data{
-		prs:      prs,
-		reviews:  reviews,
-		issues:   issues,
-		comments: comments,
+		prs:       prs,
+		reviews:   reviews,
+		issues:    issues,
+		comments:  comments,
+		triage:    triage,
+		ownership: ownership,
+		warnings:  digest.Default.Groups(),
+		newcomers: newcomers,
 	}
 	return nil
 }
@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"net/http"
 	"runtime"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
@@ -54,6 +57,86 @@ func (s *Server) Root() http.HandlerFunc {
 	}
 }
 
+// JobData serves /job/<id>/data.json: the charts and tables of a job's leaderboard as JSON, and
+// /job/<id>/user/<login>: the page of a user's activity, where id is the job's index, starting from 0
+func (s *Server) JobData() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+		if len(parts) < 3 || parts[0] != "job" {
+			http.NotFound(w, r)
+			return
+		}
+		id, err := strconv.Atoi(parts[1])
+		if err != nil || id < 0 || id >= len(s.jobs) {
+			http.NotFound(w, r)
+			return
+		}
+
+		switch {
+		case len(parts) == 3 && parts[2] == "data.json":
+			js, err := s.jobs[id].JSON()
+			if err != nil {
+				logrus.Errorf("rendering job data: %s", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Write(js)
+		case len(parts) == 4 && parts[2] == "user" && parts[3] != "":
+			res, err := s.jobs[id].RenderUser(parts[3])
+			if err != nil {
+				logrus.Errorf("rendering user page: %s", err)
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			fmt.Fprint(w, res)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+}
+
+// Status returns a page describing the progress of the job, such as whether it is waiting for the rate limit to reset
+func (s *Server) Status() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		b := s.cl.Budget.Status()
+		fmt.Fprintf(w, "job: %s\n", s.jobs[0].Status(s.cl))
+		fmt.Fprintf(w, "rate limit: %d of %d remaining, resets at %s\n", b.Remaining, b.Limit, b.Reset.Format("15:04"))
+	}
+}
+
+// Statusz returns a page describing the credentials the server uses, as GitHub sees them, and the rate limit
+func (s *Server) Statusz() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.cl.Auth
+		if a == nil {
+			var err error
+			a, err = s.cl.VerifyAuth(r.Context(), nil)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprintf(w, "credentials: %v\n", err)
+				return
+			}
+		}
+
+		scopes := "not reported, as it isn't a classic token"
+		if a.Classic {
+			scopes = strings.Join(a.Scopes, ", ")
+		}
+
+		// The budget is as of the latest response, if there has been one since the check
+		b := s.cl.Budget.Status()
+		if b.Limit == 0 {
+			b = client.BudgetStatus{Limit: a.Rate.Limit, Remaining: a.Rate.Remaining, Reset: a.Rate.Reset.Time}
+		}
+
+		fmt.Fprintf(w, "login: %s\n", a.Login)
+		fmt.Fprintf(w, "scopes: %s\n", scopes)
+		fmt.Fprintf(w, "checked: %s\n", a.Checked.Format(time.RFC3339))
+		fmt.Fprintf(w, "rate limit: %d of %d remaining, resets at %s\n", b.Remaining, b.Limit, b.Reset.Format("15:04"))
+	}
+}
+
 // Healthz returns a dummy healthz page - it's always happy here!
 func (s *Server) Healthz() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,272 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sheet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/oauth2/jwt"
+)
+
+const (
+	sheetsAPI   = "https://sheets.googleapis.com/v4/spreadsheets/"
+	sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+	// MaxCells is the most cells a Google spreadsheet may hold, across all of its tabs
+	MaxCells = 10000000
+
+	// appendChunk is how many rows are sent per request, to stay under request size limits
+	appendChunk = 5000
+	// maxRetries is how many times a rate limited or failed request is retried
+	maxRetries = 5
+)
+
+// Google writes to Google Sheets as a service account
+type Google struct {
+	hc *http.Client
+	// backoff is the delay before the first retry, doubled for each one after
+	backoff time.Duration
+}
+
+// serviceAccount is the subset of a service account key file needed to authenticate
+type serviceAccount struct {
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+}
+
+// NewGoogle returns a Google Sheets writer authenticated by a service account JSON key file
+func NewGoogle(ctx context.Context, credentialsPath string) (*Google, error) {
+	b, err := ioutil.ReadFile(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	sa := serviceAccount{}
+	if err := json.Unmarshal(b, &sa); err != nil {
+		return nil, fmt.Errorf("%s: %v", credentialsPath, err)
+	}
+	if sa.ClientEmail == "" || sa.PrivateKey == "" {
+		return nil, fmt.Errorf("%s is not a service account key file", credentialsPath)
+	}
+	if sa.TokenURI == "" {
+		sa.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+
+	conf := &jwt.Config{
+		Email:        sa.ClientEmail,
+		PrivateKey:   []byte(sa.PrivateKey),
+		PrivateKeyID: sa.PrivateKeyID,
+		Scopes:       []string{sheetsScope},
+		TokenURL:     sa.TokenURI,
+	}
+
+	return &Google{hc: conf.Client(ctx), backoff: time.Second}, nil
+}
+
+// tab is the subset of a spreadsheet tab's properties that matter for writing
+type tab struct {
+	Properties struct {
+		Title          string `json:"title"`
+		GridProperties struct {
+			RowCount    int `json:"rowCount"`
+			ColumnCount int `json:"columnCount"`
+		} `json:"gridProperties"`
+	} `json:"properties"`
+}
+
+// Write writes rows, a slice of structs or struct pointers, to a tab of a spreadsheet, creating the tab if necessary.
+// The tab is cleared first, unless appending, in which case rows are added below any existing ones.
+func (g *Google) Write(ctx context.Context, spreadsheet string, name string, rows interface{}, appending bool) error {
+	header, values, err := Values(rows)
+	if err != nil {
+		return err
+	}
+
+	tabs, err := g.tabs(ctx, spreadsheet)
+	if err != nil {
+		return err
+	}
+
+	var target *tab
+	cells := 0
+	for i, t := range tabs {
+		if t.Properties.Title == name {
+			target = &tabs[i]
+			continue
+		}
+		cells += t.Properties.GridProperties.RowCount * t.Properties.GridProperties.ColumnCount
+	}
+
+	if target == nil {
+		logrus.Infof("creating tab %q in spreadsheet %s", name, spreadsheet)
+		req := map[string]interface{}{
+			"requests": []interface{}{
+				map[string]interface{}{"addSheet": map[string]interface{}{"properties": map[string]string{"title": name}}},
+			},
+		}
+		if err := g.call(ctx, "POST", sheetsAPI+url.PathEscape(spreadsheet)+":batchUpdate", req, nil); err != nil {
+			return fmt.Errorf("add tab %q: %v", name, err)
+		}
+	}
+
+	existing := 0
+	if appending && target != nil {
+		existing, err = g.usedRows(ctx, spreadsheet, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	if existing == 0 {
+		values = append([][]interface{}{header}, values...)
+	}
+
+	// Clearing a tab doesn't shrink its grid, so the grid only grows
+	rowCount, colCount := existing+len(values), len(header)
+	if target != nil {
+		if target.Properties.GridProperties.RowCount > rowCount {
+			rowCount = target.Properties.GridProperties.RowCount
+		}
+		if target.Properties.GridProperties.ColumnCount > colCount {
+			colCount = target.Properties.GridProperties.ColumnCount
+		}
+	}
+	if total := cells + rowCount*colCount; total > MaxCells {
+		return fmt.Errorf("writing %d rows to tab %q would grow the spreadsheet to %d cells, over the Google Sheets limit of %d: use a new spreadsheet or a shorter date range", len(values), name, total, MaxCells)
+	}
+
+	rng := url.PathEscape(quoteTab(name))
+	if !appending {
+		if err := g.call(ctx, "POST", sheetsAPI+url.PathEscape(spreadsheet)+"/values/"+rng+":clear", map[string]string{}, nil); err != nil {
+			return fmt.Errorf("clear tab %q: %v", name, err)
+		}
+	}
+
+	for start := 0; start < len(values); start += appendChunk {
+		end := start + appendChunk
+		if end > len(values) {
+			end = len(values)
+		}
+
+		u := sheetsAPI + url.PathEscape(spreadsheet) + "/values/" + rng + ":append?valueInputOption=RAW&insertDataOption=INSERT_ROWS"
+		if err := g.call(ctx, "POST", u, map[string]interface{}{"values": values[start:end]}, nil); err != nil {
+			return fmt.Errorf("append to tab %q: %v", name, err)
+		}
+	}
+
+	logrus.Infof("wrote %d rows to tab %q of spreadsheet %s", len(values), name, spreadsheet)
+	return nil
+}
+
+// tabs returns the tabs of a spreadsheet
+func (g *Google) tabs(ctx context.Context, spreadsheet string) ([]tab, error) {
+	resp := struct {
+		Sheets []tab `json:"sheets"`
+	}{}
+
+	if err := g.call(ctx, "GET", sheetsAPI+url.PathEscape(spreadsheet)+"?fields=sheets.properties", nil, &resp); err != nil {
+		return nil, fmt.Errorf("get spreadsheet: %v", err)
+	}
+	return resp.Sheets, nil
+}
+
+// usedRows returns how many rows of a tab hold data
+func (g *Google) usedRows(ctx context.Context, spreadsheet string, name string) (int, error) {
+	resp := struct {
+		Values [][]interface{} `json:"values"`
+	}{}
+
+	u := sheetsAPI + url.PathEscape(spreadsheet) + "/values/" + url.PathEscape(quoteTab(name)+"!A:A")
+	if err := g.call(ctx, "GET", u, nil, &resp); err != nil {
+		return 0, fmt.Errorf("get tab %q: %v", name, err)
+	}
+	return len(resp.Values), nil
+}
+
+// quoteTab quotes a tab name for use in A1 notation
+func quoteTab(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
+
+// call makes a Sheets API request, retrying when rate limited or when the server fails
+func (g *Google) call(ctx context.Context, method string, u string, body interface{}, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return err
+		}
+	}
+
+	wait := g.backoff
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(payload))
+		if err != nil {
+			return err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		resp, err := g.hc.Do(req)
+		if err != nil {
+			return err
+		}
+
+		b, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		if retryable && attempt < maxRetries {
+			if s, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && s > 0 {
+				wait = time.Duration(s) * time.Second
+			}
+
+			logrus.Warningf("%s %s: %s, retrying in %s", method, u, resp.Status, wait)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(wait):
+			}
+			wait *= 2
+			continue
+		}
+
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(b)))
+		}
+
+		if out == nil {
+			return nil
+		}
+		return json.Unmarshal(b, out)
+	}
+}
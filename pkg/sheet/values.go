@@ -0,0 +1,62 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sheet
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Values returns the header and rows of a slice of structs or struct pointers, as plain values.
+// Numbers and bools keep their type, so that spreadsheets treat them as such.
+func Values(rows interface{}) ([]interface{}, [][]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(rows))
+	if rv.Kind() != reflect.Slice {
+		return nil, nil, fmt.Errorf("rows must be a slice, not %s", rv.Kind())
+	}
+
+	t := rv.Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("rows must be structs, not %s", t.Kind())
+	}
+
+	header := []interface{}{}
+	fields := []int{}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			header = append(header, t.Field(i).Name)
+			fields = append(fields, i)
+		}
+	}
+
+	values := [][]interface{}{}
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		if !row.IsValid() {
+			continue
+		}
+
+		vs := []interface{}{}
+		for _, f := range fields {
+			vs = append(vs, row.Field(f).Interface())
+		}
+		values = append(values, vs)
+	}
+
+	return header, values, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sheet encodes slices of summaries as spreadsheets, with a column per
+// exported field and typed cells, so that numbers and dates can be summed and
+// sorted without conversion.
+package sheet
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const dateForm = "2006-01-02"
+
+const (
+	// maxNameLen is the longest worksheet name Excel accepts
+	maxNameLen = 31
+	// maxCellLen is the most characters Excel accepts in a cell
+	maxCellLen = 32767
+)
+
+// Sheet is a named worksheet of rows
+type Sheet struct {
+	Name string
+	// Rows is a slice, or pointer to a slice, of structs or struct pointers
+	Rows interface{}
+}
+
+// excelEpoch is day 0 of Excel's date serial numbers, accounting for its 1900 leap year bug
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// WriteXLSX writes an XLSX workbook with a worksheet per sheet. Integer and float fields are written as numbers,
+// bools as booleans, and fields named Date holding a YYYY-MM-DD date as date cells.
+func WriteXLSX(w io.Writer, sheets []Sheet) error {
+	z := zip.NewWriter(w)
+
+	names := map[string]bool{}
+	for i, s := range sheets {
+		name := sheetName(s.Name)
+		if names[name] {
+			return fmt.Errorf("duplicate sheet name %q", name)
+		}
+		names[name] = true
+		sheets[i].Name = name
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", contentTypes(len(sheets))},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", workbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", workbookRels(len(sheets))},
+		{"xl/styles.xml", styles},
+	}
+
+	for _, f := range files {
+		if err := writeZipFile(z, f.name, f.content); err != nil {
+			return err
+		}
+	}
+
+	for i, s := range sheets {
+		ws, err := worksheet(s.Rows)
+		if err != nil {
+			return fmt.Errorf("%s: %v", s.Name, err)
+		}
+		if err := writeZipFile(z, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), ws); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+func writeZipFile(z *zip.Writer, name string, content string) error {
+	f, err := z.Create(name)
+	if err != nil {
+		return fmt.Errorf("create %s: %v", name, err)
+	}
+	_, err = io.WriteString(f, content)
+	return err
+}
+
+// sheetName returns a name Excel will accept for a worksheet
+func sheetName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+
+	if r := []rune(name); len(r) > maxNameLen {
+		name = string(r[:maxNameLen])
+	}
+	if name == "" {
+		name = "Sheet"
+	}
+	return name
+}
+
+// worksheet renders the XML for a worksheet of rows, with a header row of field names
+func worksheet(rows interface{}) (string, error) {
+	rv := reflect.Indirect(reflect.ValueOf(rows))
+	if rv.Kind() != reflect.Slice {
+		return "", fmt.Errorf("rows must be a slice, not %s", rv.Kind())
+	}
+
+	t := rv.Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("rows must be structs, not %s", t.Kind())
+	}
+
+	fields := []int{}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).PkgPath == "" {
+			fields = append(fields, i)
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	sb.WriteString(`<row r="1">`)
+	for c, f := range fields {
+		sb.WriteString(stringCell(cellRef(c, 1), t.Field(f).Name))
+	}
+	sb.WriteString(`</row>`)
+
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		if !row.IsValid() {
+			continue
+		}
+
+		fmt.Fprintf(&sb, `<row r="%d">`, i+2)
+		for c, f := range fields {
+			sb.WriteString(cell(cellRef(c, i+2), t.Field(f).Name, row.Field(f)))
+		}
+		sb.WriteString(`</row>`)
+	}
+
+	sb.WriteString(`</sheetData></worksheet>`)
+	return sb.String(), nil
+}
+
+// cell renders a single typed cell
+func cell(ref string, name string, v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Ptr:
+		// A nil value is a blank cell
+		if v.IsNil() {
+			return ""
+		}
+		return cell(ref, name, v.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return fmt.Sprintf(`<c r="%s"><v>%d</v></c>`, ref, v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return fmt.Sprintf(`<c r="%s"><v>%d</v></c>`, ref, v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return fmt.Sprintf(`<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(v.Float(), 'g', -1, 64))
+	case reflect.Bool:
+		b := 0
+		if v.Bool() {
+			b = 1
+		}
+		return fmt.Sprintf(`<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+	case reflect.String:
+		if name == "Date" {
+			if t, err := time.Parse(dateForm, v.String()); err == nil {
+				// Style 1 is the date format in styles.xml
+				return fmt.Sprintf(`<c r="%s" s="1"><v>%d</v></c>`, ref, int(t.Sub(excelEpoch).Hours()/24))
+			}
+		}
+		return stringCell(ref, v.String())
+	default:
+		return stringCell(ref, fmt.Sprint(v.Interface()))
+	}
+}
+
+func stringCell(ref string, s string) string {
+	if r := []rune(s); len(r) > maxCellLen {
+		s = string(r[:maxCellLen])
+	}
+
+	var sb strings.Builder
+	if err := xml.EscapeText(&sb, []byte(s)); err != nil {
+		// EscapeText only fails if the writer does
+		panic(err)
+	}
+	return fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, sb.String())
+}
+
+// cellRef returns the A1-style reference for a zero-indexed column and one-indexed row
+func cellRef(col int, row int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return fmt.Sprintf("%s%d", name, row)
+}
+
+func contentTypes(n int) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	sb.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	sb.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	sb.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	sb.WriteString(`</Types>`)
+	return sb.String()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+func workbook(sheets []Sheet) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		var name strings.Builder
+		if err := xml.EscapeText(&name, []byte(s.Name)); err != nil {
+			panic(err)
+		}
+		fmt.Fprintf(&sb, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, name.String(), i+1, i+1)
+	}
+	sb.WriteString(`</sheets></workbook>`)
+	return sb.String()
+}
+
+func workbookRels(n int) string {
+	var sb strings.Builder
+	sb.WriteString(xml.Header)
+	sb.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= n; i++ {
+		fmt.Fprintf(&sb, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&sb, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, n+1)
+	sb.WriteString(`</Relationships>`)
+	return sb.String()
+}
+
+// styles defines the default cell style, and a yyyy-mm-dd date style
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd"/></numFmts>` +
+	`<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/></cellXfs>` +
+	`</styleSheet>`
@@ -0,0 +1,148 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package site renders the pages of a static export, which must work from
+// file:// or any static host without a pullsheet server.
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// IndexName is the entry page of a static export
+const IndexName = "index.html"
+
+// Link is a single entry on the index page
+type Link struct {
+	Href        string
+	Description string
+}
+
+const indexTmpl = `<html>
+<head>
+    <title>{{ .Title }}</title>
+    <style>
+    body {
+       font-family: sans-serif;
+       background-color: #f7f7fa;
+       padding: 1em;
+    }
+
+    h1 {
+      color: rgba(66,133,244);
+      margin-bottom: 0em;
+    }
+
+    .subtitle {
+      color: rgba(23,90,201);
+      font-size: small;
+    }
+    </style>
+</head>
+<body>
+    <h1>{{ .Title }}</h1>
+    <div class="subtitle">{{ .From }} &mdash; {{ .Until }}</div>
+    <ul>
+    {{ range .Links }}<li><a href="{{ .Href }}">{{ .Href }}</a> &mdash; {{ .Description }}</li>
+    {{ end }}
+    </ul>
+</body>
+</html>
+`
+
+// Index returns the HTML index page linking to every other page and file in the export
+func Index(title string, from string, until string, links []Link) (string, error) {
+	tmpl, err := template.New("Index").Parse(indexTmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse: %v", err)
+	}
+
+	sort.Slice(links, func(i, j int) bool { return links[i].Href < links[j].Href })
+
+	data := struct {
+		Title string
+		From  string
+		Until string
+		Links []Link
+	}{
+		Title: title,
+		From:  from,
+		Until: until,
+		Links: links,
+	}
+
+	var tpl bytes.Buffer
+	if err := tmpl.Execute(&tpl, data); err != nil {
+		return "", fmt.Errorf("execute: %w", err)
+	}
+	return tpl.String(), nil
+}
+
+var refRe = regexp.MustCompile(`(?i)(?:href|src)="([^"]*)"`)
+
+// BrokenLinks returns every relative href or src in the HTML files beneath dir which does not point at an existing file
+func BrokenLinks(dir string) ([]string, error) {
+	broken := []string{}
+
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() || !strings.HasSuffix(p, ".html") {
+			return nil
+		}
+
+		b, err := ioutil.ReadFile(p)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range refRe.FindAllStringSubmatch(string(b), -1) {
+			ref := m[1]
+			u, err := url.Parse(ref)
+			if err != nil {
+				broken = append(broken, fmt.Sprintf("%s: unparseable reference %q", p, ref))
+				continue
+			}
+
+			// Absolute and in-page references are not part of the export
+			if u.Scheme != "" || u.Host != "" || u.Path == "" {
+				continue
+			}
+
+			if path.IsAbs(u.Path) {
+				broken = append(broken, fmt.Sprintf("%s: %q is not relative", p, ref))
+				continue
+			}
+
+			target := filepath.Join(filepath.Dir(p), filepath.FromSlash(u.Path))
+			if _, err := os.Stat(target); err != nil {
+				broken = append(broken, fmt.Sprintf("%s: %q does not exist", p, ref))
+			}
+		}
+		return nil
+	})
+
+	return broken, err
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package site
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	out, err := Index("<b>Board</b>", "2021-03-01", "2021-04-01", []Link{
+		{Href: "prs.csv", Description: "Merged PRs"},
+		{Href: "leaderboard.html", Description: "Leaderboard"},
+	})
+	if err != nil {
+		t.Fatalf("Index() returned error: %v", err)
+	}
+
+	if strings.Contains(out, "<b>Board</b>") || !strings.Contains(out, "&lt;b&gt;Board&lt;/b&gt;") {
+		t.Errorf("Index() output doesn't escape the title")
+	}
+	// Links are listed in order
+	if l, p := strings.Index(out, `href="leaderboard.html"`), strings.Index(out, `href="prs.csv"`); l < 0 || p < 0 || l > p {
+		t.Errorf("Index() output lists links out of order:\n%s", out)
+	}
+}
+
+func TestBrokenLinks(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"prs.csv": "",
+		"index.html": `<a href="prs.csv">prs</a> <a href="leaderboard.html#top">board</a> <a href="issues.csv">issues</a>
+			<a href="https://github.com/google/pullsheet">source</a> <a href="#top">top</a> <img src="/logo.png">`,
+		"leaderboard.html": `<a href="index.html">home</a>`,
+		"users/alice.html": `<a href="../index.html">home</a> <a href="bob.html?tab=prs">bob</a>`,
+		// Only HTML files are checked
+		"data.json": `{"href": "missing.html"}`,
+	}
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	broken, err := BrokenLinks(dir)
+	if err != nil {
+		t.Fatalf("BrokenLinks() returned error: %v", err)
+	}
+
+	got := []string{}
+	for _, b := range broken {
+		got = append(got, strings.TrimPrefix(b, dir+string(filepath.Separator)))
+	}
+	sort.Strings(got)
+	want := []string{
+		`index.html: "/logo.png" is not relative`,
+		`index.html: "issues.csv" does not exist`,
+		filepath.FromSlash("users/alice.html") + `: "bob.html?tab=prs" does not exist`,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("BrokenLinks() = %q, want %q", got, want)
+	}
+}
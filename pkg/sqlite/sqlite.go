@@ -0,0 +1,207 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlite writes summaries to a SQLite database, with a table per
+// summary type and a column per field, named by its json tag. Rows are upserted
+// on their key, so overlapping runs against the same database don't duplicate
+// rows, and each command adds to the tables written by the others.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	// Registers the sqlite3 driver
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/sirupsen/logrus"
+)
+
+// Table describes how a summary type is stored
+type Table struct {
+	Name string
+	// Key are the columns which identify a row
+	Key []string
+	// User is the column naming the user the row is credited to
+	User string
+}
+
+// Tables are the known tables, by name
+var Tables = map[string]Table{
+	"prs":      {Name: "prs", Key: []string{"url"}, User: "user"},
+	"issues":   {Name: "issues", Key: []string{"url"}, User: "closer"},
+	"reviews":  {Name: "reviews", Key: []string{"url", "reviewer"}, User: "reviewer"},
+	"comments": {Name: "comments", Key: []string{"url", "commenter"}, User: "commenter"},
+}
+
+// column is a single field of a summary type
+type column struct {
+	name  string
+	kind  string
+	field int
+}
+
+// Write upserts rows, a slice of summary struct pointers, into the named table of the database at path, creating both as necessary
+func Write(path string, table string, rows interface{}) error {
+	t, ok := Tables[table]
+	if !ok {
+		return fmt.Errorf("unknown table %q", table)
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(rows))
+	st := rv.Type().Elem()
+	if st.Kind() == reflect.Ptr {
+		st = st.Elem()
+	}
+	cols := columns(st)
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("open: %v", err)
+	}
+	defer db.Close()
+
+	if err := ensureTable(db, t, cols); err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin: %v", err)
+	}
+
+	names := []string{}
+	marks := []string{}
+	for _, c := range cols {
+		names = append(names, c.name)
+		marks = append(marks, "?")
+	}
+
+	stmt, err := tx.Prepare(fmt.Sprintf("INSERT OR REPLACE INTO %s (%s) VALUES (%s)", t.Name, strings.Join(names, ", "), strings.Join(marks, ", ")))
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("prepare: %v", err)
+	}
+	defer stmt.Close()
+
+	for i := 0; i < rv.Len(); i++ {
+		row := reflect.Indirect(rv.Index(i))
+		args := []interface{}{}
+		for _, c := range cols {
+			args = append(args, row.Field(c.field).Interface())
+		}
+
+		if _, err := stmt.Exec(args...); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("insert: %v", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %v", err)
+	}
+
+	logrus.Infof("wrote %d rows to %s in %s", rv.Len(), t.Name, path)
+	return nil
+}
+
+// columns returns the stored columns of a summary type
+func columns(t reflect.Type) []column {
+	cols := []column{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if f.PkgPath != "" || name == "" || name == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+
+		kind := "TEXT"
+		switch ft.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Bool:
+			kind = "INTEGER"
+		case reflect.Float32, reflect.Float64:
+			kind = "REAL"
+		}
+
+		cols = append(cols, column{name: name, kind: kind, field: i})
+	}
+	return cols
+}
+
+// ensureTable creates a table and its indexes if missing, and adds any columns added to the summary type since
+func ensureTable(db *sql.DB, t Table, cols []column) error {
+	defs := []string{}
+	for _, c := range cols {
+		defs = append(defs, c.name+" "+c.kind)
+	}
+	defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(t.Key, ", ")))
+
+	if _, err := db.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (%s)", t.Name, strings.Join(defs, ", "))); err != nil {
+		return fmt.Errorf("create %s: %v", t.Name, err)
+	}
+
+	existing, err := tableColumns(db, t.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range cols {
+		if existing[c.name] {
+			continue
+		}
+		logrus.Infof("adding column %s to %s", c.name, t.Name)
+		if _, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", t.Name, c.name, c.kind)); err != nil {
+			return fmt.Errorf("add column %s.%s: %v", t.Name, c.name, err)
+		}
+	}
+
+	indexes := map[string]string{
+		t.Name + "_user_date":    t.User + ", date",
+		t.Name + "_project_date": "project, date",
+	}
+	for name, on := range indexes {
+		if _, err := db.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS %s ON %s (%s)", name, t.Name, on)); err != nil {
+			return fmt.Errorf("create index %s: %v", name, err)
+		}
+	}
+
+	return nil
+}
+
+// tableColumns returns the columns a table currently has
+func tableColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return nil, fmt.Errorf("table info: %v", err)
+	}
+	defer rows.Close()
+
+	names := map[string]bool{}
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, kind string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &kind, &notNull, &dflt, &pk); err != nil {
+			return nil, fmt.Errorf("table info: %v", err)
+		}
+		names[name] = true
+	}
+	return names, rows.Err()
+}
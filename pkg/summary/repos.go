@@ -0,0 +1,185 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+// RepoConcurrency is how many repositories are fetched at once
+var RepoConcurrency = 1
+
+// FailFast stops every repository as soon as one fails. Otherwise the rest are finished, and every failure returned.
+var FailFast = false
+
+// ordered passes on the results of repositories fetched concurrently in the order the repositories were given.
+// The first unfinished repository's results are passed on at once, and the others' are held until it finishes.
+type ordered struct {
+	mu      sync.Mutex
+	pending [][]func() error
+	done    []bool
+	next    int
+	// err is the first error passing on a result, which stops everything, as the output is broken
+	err error
+}
+
+func newOrdered(n int) *ordered {
+	return &ordered{pending: make([][]func() error, n), done: make([]bool, n)}
+}
+
+// deliver passes on a result of the i'th repository, by calling f, now or once the repositories before it finish
+func (o *ordered) deliver(i int, f func() error) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.err != nil {
+		return o.err
+	}
+	if i == o.next {
+		o.err = f()
+		return o.err
+	}
+	o.pending[i] = append(o.pending[i], f)
+	return nil
+}
+
+// finish records that the i'th repository has no more results, passing on those held for the repositories after it
+func (o *ordered) finish(i int) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.done[i] = true
+	for o.next < len(o.done) && o.done[o.next] {
+		o.next++
+		if o.next == len(o.done) {
+			break
+		}
+		for _, f := range o.pending[o.next] {
+			if o.err == nil {
+				o.err = f()
+			}
+		}
+		o.pending[o.next] = nil
+	}
+	return o.err
+}
+
+// eachRepo calls fn for each org/project repository, RepoConcurrency at a time. fn passes its results on through
+// deliver, which keeps them in the order repos were given. Failed repositories are returned together, with their
+// names, once the rest have finished, or at once if FailFast is set.
+func eachRepo(ctx context.Context, repos []string, fn func(ctx context.Context, org string, project string, deliver func(func() error) error) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := RepoConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	o := newOrdered(len(repos))
+	var finished int64
+	errs := make([]error, len(repos))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+start:
+	for i, r := range repos {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			break start
+		}
+
+		wg.Add(1)
+		go func(i int, r string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			log := logrus.WithField("repo", r)
+			if workers > 1 {
+				log.Infof("fetching")
+			}
+
+			repo.Report(repo.ProgressEvent{Phase: "repo", Repo: r, Done: int(atomic.LoadInt64(&finished)), Total: len(repos), Items: i + 1})
+			defer func() {
+				repo.Report(repo.ProgressEvent{Phase: "repo", Repo: r, Done: int(atomic.AddInt64(&finished, 1)), Total: len(repos)})
+			}()
+
+			org, project := repo.ParseURL(r)
+			err := fn(ctx, org, project, func(f func() error) error { return o.deliver(i, f) })
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", r, err)
+				if FailFast {
+					cancel()
+				} else if workers > 1 {
+					log.Errorf("failed, continuing with the other repositories: %v", err)
+				}
+			} else if workers > 1 {
+				log.Infof("done")
+			}
+
+			if err := o.finish(i); err != nil {
+				cancel()
+			}
+		}(i, r)
+	}
+	wg.Wait()
+
+	if o.err != nil {
+		return o.err
+	}
+	return repoErrors(errs)
+}
+
+// repoErrors combines the errors of failed repositories, leaving out those cancelled because another failed. If none
+// failed, but some were cancelled, the run was interrupted.
+func repoErrors(errs []error) error {
+	failed := []error{}
+	cancelled := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+		case errors.Is(err, context.Canceled):
+			cancelled++
+		default:
+			failed = append(failed, err)
+		}
+	}
+
+	switch len(failed) {
+	case 0:
+		if cancelled > 0 {
+			return repo.ErrInterrupted
+		}
+		return nil
+	case 1:
+		return failed[0]
+	}
+
+	msgs := []string{}
+	for _, err := range failed[1:] {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Errorf("%d repositories failed: %w; %s", len(failed), failed[0], strings.Join(msgs, "; "))
+}
@@ -16,89 +16,404 @@ package summary
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/go-github/v33/github"
 	"github.com/sirupsen/logrus"
 
 	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/codeowners"
+	"github.com/google/pullsheet/pkg/digest"
+	"github.com/google/pullsheet/pkg/gitattributes"
+	"github.com/google/pullsheet/pkg/mailmap"
 	"github.com/google/pullsheet/pkg/repo"
 )
 
-func Pulls(ctx context.Context, c *client.Client, repos []string, users []string, branches []string, since time.Time, until time.Time) ([]*repo.PRSummary, error) {
-	prFiles := map[*github.PullRequest][]github.CommitFile{}
+// FetchPlan describes which optional data must be fetched for the requested outputs
+type FetchPlan struct {
+	// Files fetches the changed files of each PR, needed for the Files and Type columns and for exact deltas.
+	// Otherwise the delta is taken from the PR's own addition and deletion counts, and Files and Type are left empty.
+	Files bool
+	// Reviews fetches the reviews of each PR, needed for the Reviewers and Approvers columns
+	Reviews bool
+}
 
-	for _, r := range repos {
-		org, project := repo.ParseURL(r)
+// FullPlan fetches everything
+var FullPlan = FetchPlan{Files: true, Reviews: true}
 
-		prs, err := repo.MergedPulls(ctx, c, org, project, since, until, users, branches)
-		if err != nil {
-			return nil, fmt.Errorf("list: %v", err)
+// Sources describes where each derived PR field comes from under the plan
+func (p FetchPlan) Sources() map[string]string {
+	src := map[string]string{
+		"Delta":     "pull request additions and deletions",
+		"Files":     "not fetched",
+		"Type":      "not fetched",
+		"Reviewers": "not fetched",
+		"Approvers": "not fetched",
+	}
+
+	if p.Files {
+		src["Delta"] = "changed files, excluding generated paths"
+		src["Files"] = "changed files"
+		src["Type"] = "changed files"
+	}
+
+	if p.Reviews {
+		src["Reviewers"] = "pull request reviews"
+		src["Approvers"] = "pull request reviews"
+	}
+
+	return src
+}
+
+// Pulls returns summaries of merged PRs, fetching everything
+func Pulls(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, branches []string, labels []string, excludeLabels []string, since time.Time, until time.Time) ([]*repo.PRSummary, error) {
+	return PullsWithPlan(ctx, c, opts, repos, users, branches, labels, excludeLabels, since, until, FullPlan)
+}
+
+// PullsWithPlan returns summaries of merged PRs, fetching only the data required by the plan
+func PullsWithPlan(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, branches []string, labels []string, excludeLabels []string, since time.Time, until time.Time, plan FetchPlan) ([]*repo.PRSummary, error) {
+	sum := []*repo.PRSummary{}
+	err := PullsTo(ctx, c, opts, repos, users, branches, labels, excludeLabels, since, until, plan, func(s *repo.PRSummary) error {
+		sum = append(sum, s)
+		return nil
+	})
+	// What was summarized before an interruption is still returned
+	if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+		return nil, err
+	}
+	return sum, err
+}
+
+// PullsTo is PullsWithPlan, passing each summary to emit as soon as its PR has been fetched rather than collecting them
+func PullsTo(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, branches []string, labels []string, excludeLabels []string, since time.Time, until time.Time, plan FetchPlan, emit func(*repo.PRSummary) error) error {
+	// Ownership, generated files, and counted extensions are determined by file paths
+	if opts.OwnedBy != "" || opts.RespectGitattributes || len(opts.CountExtensions) > 0 {
+		plan.Files = true
+	}
+
+	return eachRepo(ctx, repos, func(ctx context.Context, org string, project string, deliver func(func() error) error) error {
+		// Summaries are passed on in repository order, however the repositories' fetches interleave
+		emit := func(s *repo.PRSummary) error {
+			return deliver(func() error { return emit(s) })
 		}
+		seen := map[string]bool{}
 
-		for _, pr := range prs {
-			files, err := repo.FilteredFiles(ctx, c, pr.GetMergedAt(), org, project, pr.GetNumber())
+		var owners *codeowners.Ruleset
+		if opts.OwnedBy != "" {
+			var err error
+			owners, err = repo.Codeowners(ctx, c, since, org, project)
 			if err != nil {
-				return nil, fmt.Errorf("filtered files: %v", err)
+				return fmt.Errorf("codeowners: %w", err)
+			}
+
+			if owners == nil {
+				logrus.Infof("%s/%s has no CODEOWNERS, so nothing is owned by %s", org, project, opts.OwnedBy)
+				return nil
+			}
+		}
+
+		var attrs *gitattributes.Attributes
+		if opts.RespectGitattributes {
+			var err error
+			attrs, err = repo.Gitattributes(ctx, c, since, org, project)
+			if err != nil {
+				return fmt.Errorf("gitattributes: %w", err)
+			}
+		}
+
+		prs, err := repo.MergedPulls(ctx, c, opts, org, project, since, until, users, branches, labels, excludeLabels)
+		if skipMissing(org, project, err) {
+			return nil
+		}
+		// Once interrupted, the PRs found so far are summarized as far as their files and reviews are cached
+		interrupted := errors.Is(err, repo.ErrInterrupted)
+		if err != nil && !interrupted {
+			return fmt.Errorf("list: %w", err)
+		}
+
+		fresh := []*github.PullRequest{}
+		for _, pr := range prs {
+			// Pages may overlap if PRs are updated while listing
+			if seen[pr.GetHTMLURL()] {
+				continue
+			}
+			seen[pr.GetHTMLURL()] = true
+			fresh = append(fresh, pr)
+		}
+
+		var prFiles [][]*github.CommitFile
+		if plan.Files && !interrupted {
+			prFiles, err = repo.FilteredFilesOf(ctx, c, opts, org, project, fresh)
+			interrupted = err != nil && ctx.Err() != nil
+			if err != nil && !interrupted {
+				return fmt.Errorf("filtered files: %w", err)
 			}
+		}
+
+		for i, pr := range fresh {
+			emit := emit
+			if plan.Reviews {
+				reviewers, approvers, err := repo.PullReviewers(ctx, c, opts, org, project, pr)
+				if err != nil {
+					if ctx.Err() != nil {
+						return repo.ErrInterrupted
+					}
+					return fmt.Errorf("reviewers: %w", err)
+				}
+
+				next := emit
+				emit = func(s *repo.PRSummary) error {
+					s.Reviewers = strings.Join(reviewers, ",")
+					s.Approvers = strings.Join(approvers, ",")
+					repo.CreditBotMerge(opts, s, approvers)
+					return next(s)
+				}
+			}
+
+			if !plan.Files {
+				repo.RunStats.FileListSkipped()
+				// A nil file list tells PullSummary to use the PR's own counts
+				if err := repo.PullSummaryTo(opts, map[*github.PullRequest][]github.CommitFile{pr: nil}, since, until, emit); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var files []*github.CommitFile
+			if prFiles != nil {
+				files = prFiles[i]
+			}
+			if files == nil && interrupted {
+				files, err = repo.FilteredFiles(ctx, c, opts, pr.GetUpdatedAt(), org, project, pr.GetNumber())
+				if err != nil {
+					return repo.ErrInterrupted
+				}
+			}
+			repo.RunStats.FileListFetched()
 			logrus.Errorf("%s files: %v", pr, files)
 
-			prFiles[pr] = []github.CommitFile{}
+			generated := 0
+			if attrs != nil {
+				files, generated = repo.ExcludeGenerated(attrs, files)
+			}
+
+			if owners != nil {
+				owned, fraction := repo.OwnedFiles(owners, opts.OwnedBy, files)
+				if !opts.IncludeOwned(owned, fraction) {
+					logrus.Infof("%s is %.0f%% owned by %s, skipping", pr.GetHTMLURL(), fraction*100, opts.OwnedBy)
+					continue
+				}
+				files = owned
+			}
 
+			cfs := []github.CommitFile{}
 			for _, f := range files {
-				prFiles[pr] = append(prFiles[pr], *f)
+				cfs = append(cfs, *f)
+			}
+
+			err = repo.PullSummaryTo(opts, map[*github.PullRequest][]github.CommitFile{pr: cfs}, since, until, func(s *repo.PRSummary) error {
+				s.GeneratedLinesExcluded = generated
+				return emit(s)
+			})
+			if err != nil {
+				return err
 			}
 		}
-	}
 
-	sum, err := repo.PullSummary(prFiles, since, until)
-	if err != nil {
-		return nil, fmt.Errorf("pull summary failed: %v", err)
+		if interrupted {
+			return repo.ErrInterrupted
+		}
+		return nil
+	})
+}
+
+// skipMissing returns whether err means a repository doesn't exist, such as after it was deleted, warning that it is
+// skipped if so, so that one missing repository doesn't fail a run across many
+func skipMissing(org string, project string, err error) bool {
+	if !client.IsNotFound(err) {
+		return false
 	}
 
-	return sum, nil
+	logrus.Warningf("%s/%s was not found, skipping it: %v", org, project, err)
+	digest.Add(digest.RepoSkipped, org+"/"+project, repo.WebURL+"/"+org+"/"+project, "%v", err)
+	return true
 }
 
-func Reviews(ctx context.Context, c *client.Client, repos []string, users []string, since time.Time, until time.Time) ([]*repo.ReviewSummary, error) {
+func Reviews(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, since time.Time, until time.Time) ([]*repo.ReviewSummary, error) {
 	rs := []*repo.ReviewSummary{}
-	for _, r := range repos {
-		org, project := repo.ParseURL(r)
-		rrs, err := repo.MergedReviews(ctx, c, org, project, since, until, users)
+	err := eachRepo(ctx, repos, func(ctx context.Context, org string, project string, deliver func(func() error) error) error {
+		rrs, err := repo.MergedReviews(ctx, c, opts, org, project, since, until, users)
+		if skipMissing(org, project, err) {
+			return nil
+		}
 		if err != nil {
-			return nil, fmt.Errorf("merged pulls: %v", err)
+			return fmt.Errorf("merged pulls: %w", err)
 		}
-		rs = append(rs, rrs...)
+		return deliver(func() error {
+			rs = append(rs, rrs...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return rs, nil
 }
 
-func Issues(ctx context.Context, c *client.Client, repos []string, users []string, since time.Time, until time.Time) ([]*repo.IssueSummary, error) {
+// Issue states, choosing whether Issues counts issues opened or closed within the window, or both
+const (
+	IssuesOpened = "opened"
+	IssuesClosed = "closed"
+	IssuesBoth   = "both"
+)
+
+// IssueStates are the valid states for Issues
+var IssueStates = []string{IssuesOpened, IssuesClosed, IssuesBoth}
+
+func Issues(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, since time.Time, until time.Time, state string) ([]*repo.IssueSummary, error) {
 	rs := []*repo.IssueSummary{}
+	err := IssuesTo(ctx, c, opts, repos, users, since, until, state, func(s *repo.IssueSummary) error {
+		rs = append(rs, s)
+		return nil
+	})
+	// What was summarized before an interruption is still returned
+	if err != nil && !errors.Is(err, repo.ErrInterrupted) {
+		return nil, err
+	}
+
+	return rs, err
+}
+
+// IssuesTo is Issues, passing each summary to emit as soon as its issue has been fetched rather than collecting them
+func IssuesTo(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, since time.Time, until time.Time, state string, emit func(*repo.IssueSummary) error) error {
+	return eachRepo(ctx, repos, func(ctx context.Context, org string, project string, deliver func(func() error) error) error {
+		// Summaries are passed on in repository order, however the repositories' fetches interleave
+		emit := func(s *repo.IssueSummary) error {
+			return deliver(func() error { return emit(s) })
+		}
+
+		if state == IssuesOpened || state == IssuesBoth {
+			err := repo.OpenIssuesTo(ctx, c, opts, org, project, since, until, users, emit)
+			if skipMissing(org, project, err) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("opened issues: %w", err)
+			}
+		}
+		if state == IssuesClosed || state == IssuesBoth {
+			err := repo.ClosedIssuesTo(ctx, c, opts, org, project, since, until, users, emit)
+			if skipMissing(org, project, err) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("closed issues: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func Comments(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, since time.Time, until time.Time, maxComments int) ([]*repo.CommentSummary, error) {
+	rs := []*repo.CommentSummary{}
+	err := eachRepo(ctx, repos, func(ctx context.Context, org string, project string, deliver func(func() error) error) error {
+		rrs, err := repo.IssueComments(ctx, c, opts, org, project, since, until, users, maxComments)
+		if skipMissing(org, project, err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("merged pulls: %w", err)
+		}
+		return deliver(func() error {
+			rs = append(rs, rrs...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+// Mailmap returns the merged .mailmap identity mappings for a list of repositories
+func Mailmap(ctx context.Context, c *client.Client, repos []string, since time.Time) (*mailmap.Mailmap, error) {
+	m := mailmap.New()
 	for _, r := range repos {
 		org, project := repo.ParseURL(r)
-		rrs, err := repo.ClosedIssues(ctx, c, org, project, since, until, users)
+		rm, err := repo.Mailmap(ctx, c, since, org, project)
 		if err != nil {
-			return nil, fmt.Errorf("merged pulls: %v", err)
+			return nil, fmt.Errorf("mailmap: %w", err)
 		}
-		rs = append(rs, rrs...)
+		m.Merge(rm)
+	}
+
+	return m, nil
+}
+
+func Triage(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, since time.Time, until time.Time, includeSelf bool, collapseToggles bool) ([]*repo.TriageSummary, error) {
+	rs := []*repo.TriageSummary{}
+	err := eachRepo(ctx, repos, func(ctx context.Context, org string, project string, deliver func(func() error) error) error {
+		rrs, err := repo.IssueTriage(ctx, c, opts, org, project, since, until, users, includeSelf, collapseToggles)
+		if skipMissing(org, project, err) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("issue triage: %w", err)
+		}
+		return deliver(func() error {
+			rs = append(rs, rrs...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return rs, nil
 }
 
-func Comments(ctx context.Context, c *client.Client, repos []string, users []string, since time.Time, until time.Time) ([]*repo.CommentSummary, error) {
-	rs := []*repo.CommentSummary{}
+// Ownership returns CODEOWNERS review coverage for each repository that has a CODEOWNERS file
+func Ownership(ctx context.Context, c *client.Client, repos []string, since time.Time, prs []*repo.PRSummary, reviews []*repo.ReviewSummary) ([]*repo.OwnershipSummary, error) {
+	rs := []*repo.OwnershipSummary{}
 	for _, r := range repos {
 		org, project := repo.ParseURL(r)
-		rrs, err := repo.IssueComments(ctx, c, org, project, since, until, users)
+		owners, err := repo.Codeowners(ctx, c, since, org, project)
 		if err != nil {
-			return nil, fmt.Errorf("merged pulls: %v", err)
+			return nil, fmt.Errorf("codeowners: %w", err)
 		}
 
-		rs = append(rs, rrs...)
+		if owners == nil {
+			continue
+		}
+
+		rs = append(rs, repo.OwnershipCoverage(owners, org, project, prs, reviews)...)
 	}
 
 	return rs, nil
 }
+
+// InheritOwnership restricts reviews and comments to PRs which touch files owned by opts.OwnedBy.
+// prs may be passed to avoid refetching them, if they were collected for all users.
+func InheritOwnership(ctx context.Context, c *client.Client, opts *repo.Options, repos []string, users []string, branches []string, since time.Time, until time.Time, prs []*repo.PRSummary, reviews []*repo.ReviewSummary, comments []*repo.CommentSummary) ([]*repo.ReviewSummary, []*repo.CommentSummary, error) {
+	// Reviews and comments on PRs by other users count too, so the PR list must not be filtered by user
+	if prs == nil || len(users) > 0 {
+		var err error
+		prs, err = PullsWithPlan(ctx, c, opts, repos, nil, branches, nil, nil, since, until, FetchPlan{Files: true})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	urls := map[string]bool{}
+	for _, pr := range prs {
+		urls[pr.URL] = true
+	}
+
+	reviews, comments = repo.InheritOwnership(urls, reviews, comments)
+	return reviews, comments, nil
+}
@@ -0,0 +1,165 @@
+// Copyright 2021 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package summary
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/go-github/v33/github"
+
+	"github.com/google/pullsheet/pkg/cache"
+	"github.com/google/pullsheet/pkg/client"
+	"github.com/google/pullsheet/pkg/repo"
+)
+
+var (
+	since  = time.Date(2021, 3, 1, 0, 0, 0, 0, time.UTC)
+	until  = time.Date(2021, 4, 1, 0, 0, 0, 0, time.UTC)
+	merged = time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)
+)
+
+// testServer serves a repository with a single merged PR, counting requests for its files
+func testServer(t *testing.T) (*client.Client, *int64) {
+	t.Helper()
+	var fileLists int64
+
+	pr := &github.PullRequest{
+		Number:         github.Int(1),
+		Title:          github.String("Add a feature"),
+		HTMLURL:        github.String("https://github.com/org/project/pull/1"),
+		User:           &github.User{Login: github.String("alice")},
+		State:          github.String("closed"),
+		CreatedAt:      &merged,
+		UpdatedAt:      &merged,
+		ClosedAt:       &merged,
+		MergedAt:       &merged,
+		Merged:         github.Bool(true),
+		MergeCommitSHA: github.String("abc123"),
+		Additions:      github.Int(70),
+		Deletions:      github.Int(30),
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/org/project/pulls":
+			json.NewEncoder(w).Encode([]*github.PullRequest{pr})
+		case "/repos/org/project/pulls/1":
+			json.NewEncoder(w).Encode(pr)
+		case "/repos/org/project/pulls/1/files":
+			atomic.AddInt64(&fileLists, 1)
+			json.NewEncoder(w).Encode([]*github.CommitFile{
+				{Filename: github.String("pkg/feature.go"), Additions: github.Int(5), Deletions: github.Int(1)},
+				{Filename: github.String("go.sum"), Additions: github.Int(65), Deletions: github.Int(29)},
+			})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	p, err := cache.New(cache.Config{Backend: "memory"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gc := github.NewClient(srv.Client())
+	gc.BaseURL, _ = url.Parse(srv.URL + "/")
+	return &client.Client{Cache: p, GitHubClient: gc}, &fileLists
+}
+
+func TestPullsWithPlan(t *testing.T) {
+	tests := []struct {
+		name          string
+		plan          FetchPlan
+		opts          func(*repo.Options)
+		wantFileLists int64
+		wantDelta     int
+		wantFiles     bool
+	}{
+		{
+			// The delta comes from the PR itself, generated files and all
+			name:          "deltas only",
+			plan:          FetchPlan{},
+			wantFileLists: 0,
+			wantDelta:     100,
+		},
+		{
+			name:          "files",
+			plan:          FetchPlan{Files: true},
+			wantFileLists: 1,
+			wantDelta:     6,
+			wantFiles:     true,
+		},
+		{
+			// Counted extensions are determined by file paths, whatever the plan
+			name:          "counted extensions",
+			plan:          FetchPlan{},
+			opts:          func(o *repo.Options) { o.CountExtensions = map[string]bool{".go": true} },
+			wantFileLists: 1,
+			wantDelta:     6,
+			wantFiles:     true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			c, fileLists := testServer(t)
+			opts := repo.DefaultOptions()
+			if tc.opts != nil {
+				tc.opts(opts)
+			}
+
+			prs, err := PullsWithPlan(context.Background(), c, opts, []string{"org/project"}, nil, nil, nil, nil, since, until, tc.plan)
+			if err != nil {
+				t.Fatalf("PullsWithPlan() returned error: %v", err)
+			}
+			if len(prs) != 1 {
+				t.Fatalf("PullsWithPlan() returned %d summaries, want 1", len(prs))
+			}
+
+			if got := atomic.LoadInt64(fileLists); got != tc.wantFileLists {
+				t.Errorf("file lists fetched = %d, want %d", got, tc.wantFileLists)
+			}
+			if prs[0].Delta != tc.wantDelta {
+				t.Errorf("Delta = %d, want %d", prs[0].Delta, tc.wantDelta)
+			}
+			if got := prs[0].Files != "" && prs[0].Type != ""; got != tc.wantFiles {
+				t.Errorf("Files = %q and Type = %q, want them set: %v", prs[0].Files, prs[0].Type, tc.wantFiles)
+			}
+		})
+	}
+}
+
+func TestFetchPlanSources(t *testing.T) {
+	src := FetchPlan{}.Sources()
+	for _, field := range []string{"Files", "Type", "Reviewers", "Approvers"} {
+		if src[field] != "not fetched" {
+			t.Errorf("Sources()[%q] = %q without fetching, want %q", field, src[field], "not fetched")
+		}
+	}
+
+	src = FullPlan.Sources()
+	for field, s := range src {
+		if s == "not fetched" {
+			t.Errorf("Sources()[%q] = %q with everything fetched", field, s)
+		}
+	}
+}